@@ -77,7 +77,8 @@ func (h *Handler) testNotifier(ctx context.Context, actionName string, action *t
 		}
 	}
 	notifierMessage := &notifiers.Message{
-		Content: msg,
+		Content:     msg,
+		ClusterName: clientNotifier.ClusterID,
 	}
 	if notifier.Spec.SMTPConfig != nil {
 		notifierMessage.Title = testSMTPTitle
@@ -87,7 +88,7 @@ func (h *Handler) testNotifier(ctx context.Context, actionName string, action *t
 	if err != nil {
 		return errors.Wrap(err, "error getting dialer")
 	}
-	return notifiers.SendMessage(ctx, notifier, "", notifierMessage, dialer)
+	return notifiers.SendTestMessage(ctx, notifier, "", notifierMessage, dialer)
 }
 
 func canCreateNotifier(apiContext *types.APIContext, resource *types.RawResource, clusterID string) bool {
@@ -47,6 +47,7 @@ func SetPasswordStore(schemas *types.Schemas, secretStore v1.SecretInterface, ns
 	modifyProjectTypes := map[string]bool{
 		"githubPipelineConfig": true,
 		"gitlabPipelineConfig": true,
+		"giteaPipelineConfig":  true,
 	}
 
 	pwdStore := &PasswordStore{
@@ -10,6 +10,7 @@
 	"github.com/rancher/rancher/pkg/api/steve/health"
 	"github.com/rancher/rancher/pkg/api/steve/projects"
 	"github.com/rancher/rancher/pkg/api/steve/proxy"
+	"github.com/rancher/rancher/pkg/catalogv2/webhook"
 	"github.com/rancher/rancher/pkg/features"
 	"github.com/rancher/rancher/pkg/provisioningv2/rke2/configserver"
 	"github.com/rancher/rancher/pkg/provisioningv2/rke2/installer"
@@ -57,6 +58,7 @@ func AdditionalAPIs(ctx context.Context, config *wrangler.Context, steve *steve.
 	mux.UseEncodedPath()
 	mux.Handle("/v1/github{path:.*}", githubHandler)
 	mux.Handle("/v3/connect", Tunnel(config))
+	mux.Handle(webhook.Path, webhook.New(config.Catalog.ClusterRepo(), config.Core.Secret().Cache()))
 	health.Register(mux)
 
 	return func(next http.Handler) http.Handler {
@@ -0,0 +1,83 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	catalogtypes "github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
+	"github.com/rancher/rancher/pkg/catalogv2/content"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+)
+
+type bundles struct {
+	contentManager *content.Manager
+	importer       *bundle.Importer
+}
+
+func (b *bundles) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	switch apiContext.Action {
+	case "exportBundle":
+		if err := b.export(apiContext, rw, req); err != nil {
+			apiContext.WriteError(err)
+		}
+	case "importBundle":
+		if err := b.importBundle(apiContext, req); err != nil {
+			apiContext.WriteError(err)
+		}
+	}
+}
+
+func (b *bundles) export(apiContext *types.APIRequest, rw http.ResponseWriter, req *http.Request) error {
+	exportArgs := &catalogtypes.BundleExportAction{}
+	if err := json.NewDecoder(req.Body).Decode(exportArgs); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if len(exportArgs.Repos) == 0 {
+		return apierror.NewAPIError(validation.MissingRequired, "repos is required")
+	}
+	for _, name := range exportArgs.Repos {
+		if err := apiContext.AccessControl.CanDo(apiContext, apiContext.Type, "get", "", name); err != nil {
+			return err
+		}
+		// fail before the response is started if a repo does not exist
+		if _, err := b.contentManager.Index("", name); err != nil {
+			return err
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/gzip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"catalog-bundle-%s.tar.gz\"", time.Now().Format("20060102150405")))
+	if err := bundle.Export(rw, b.contentManager, exportArgs.Repos); err != nil {
+		// the archive is truncated, which fails its import
+		logrus.Errorf("Failed to export catalog bundle: %v", err)
+	}
+	return nil
+}
+
+func (b *bundles) importBundle(apiContext *types.APIRequest, req *http.Request) error {
+	for _, verb := range []string{"create", "update"} {
+		if err := apiContext.AccessControl.CanDo(apiContext, apiContext.Type, verb, "", ""); err != nil {
+			return err
+		}
+	}
+
+	repos, err := b.importer.Import(req.Body)
+	if err != nil {
+		return err
+	}
+
+	apiContext.WriteResponse(http.StatusOK, types.APIObject{
+		Type: "bundleImportOutput",
+		Object: &catalogtypes.BundleImportOutput{
+			Repos: repos,
+		},
+	})
+	return nil
+}
@@ -9,6 +9,7 @@
 	"github.com/rancher/apiserver/pkg/types"
 	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	"github.com/rancher/rancher/pkg/apis/catalog.cattle.io"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/content"
 	"github.com/rancher/rancher/pkg/catalogv2/helmop"
 	schema2 "github.com/rancher/steve/pkg/schema"
@@ -19,7 +20,8 @@
 
 func Register(ctx context.Context, server *steve.Server,
 	helmop *helmop.Operations,
-	contentManager *content.Manager) error {
+	contentManager *content.Manager,
+	importer *bundle.Importer) error {
 	ops := newOperation(helmop)
 	server.ClusterCache.OnAdd(ctx, ops.OnAdd)
 	server.ClusterCache.OnChange(ctx, ops.OnChange)
@@ -28,17 +30,26 @@ func Register(ctx context.Context, server *steve.Server,
 		contentManager: contentManager,
 	}
 
-	addSchemas(server, ops, index)
+	bundles := &bundles{
+		contentManager: contentManager,
+		importer:       importer,
+	}
+
+	addSchemas(server, ops, index, bundles)
 	return nil
 }
 
-func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
+func addSchemas(server *steve.Server, ops *operation, index, bundles http.Handler) {
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUninstallAction{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ChartRollbackAction{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ChartHistoryAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgradeAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgrade{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartInstallAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartInstall{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartActionOutput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.BundleExportAction{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.BundleImportOutput{}, nil)
 
 	operationTemplate := schema2.Template{
 		Group: catalog.GroupName,
@@ -60,12 +71,22 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 		Customize: func(apiSchema *types.APISchema) {
 			apiSchema.ActionHandlers = map[string]http.Handler{
 				"uninstall": ops,
+				"rollback":  ops,
+				"history":   ops,
 			}
 			apiSchema.ResourceActions = map[string]schemas3.Action{
 				"uninstall": {
 					Input:  "chartUninstallAction",
 					Output: "chartActionOutput",
 				},
+				"rollback": {
+					Input:  "chartRollbackAction",
+					Output: "chartActionOutput",
+				},
+				"history": {
+					Input:  "chartHistoryAction",
+					Output: "chartActionOutput",
+				},
 			}
 		},
 	}
@@ -107,6 +128,19 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 	}
 	chartRepoTemplate := repoTemplate
 	chartRepoTemplate.Kind = "ClusterRepo"
+	chartRepoTemplate.Customize = func(apiSchema *types.APISchema) {
+		repoTemplate.Customize(apiSchema)
+		apiSchema.ActionHandlers["exportBundle"] = bundles
+		apiSchema.ActionHandlers["importBundle"] = bundles
+		apiSchema.CollectionActions = map[string]schemas3.Action{
+			"exportBundle": {
+				Input: "bundleExportAction",
+			},
+			"importBundle": {
+				Output: "bundleImportOutput",
+			},
+		}
+	}
 
 	server.SchemaFactory.AddTemplate(
 		operationTemplate,
@@ -1,6 +1,10 @@
 package catalog
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/rancher/apiserver/pkg/types"
@@ -43,9 +47,25 @@ func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	case "install":
 		op, err = o.ops.Install(apiRequest.Context(), user, ns, name, req.Body)
 	case "upgrade":
-		op, err = o.ops.Upgrade(apiRequest.Context(), user, ns, name, req.Body)
+		body, readErr := ioutil.ReadAll(req.Body)
+		if readErr != nil {
+			apiRequest.WriteError(readErr)
+			return
+		}
+		upgradeArgs := &catalogtypes.ChartUpgradeAction{}
+		if json.Unmarshal(body, upgradeArgs) == nil && upgradeArgs.DryRun {
+			o.diffUpgrade(apiRequest, ns, name, body)
+			return
+		}
+		op, err = o.ops.Upgrade(apiRequest.Context(), user, ns, name, bytes.NewReader(body))
 	case "uninstall":
 		op, err = o.ops.Uninstall(apiRequest.Context(), user, ns, name, req.Body)
+	case "rollback":
+		op, err = o.ops.Rollback(apiRequest.Context(), user, ns, name, req.Body)
+	case "history":
+		// history only reads the release, see Operations.History for why it is not an operation
+		o.history(apiRequest, ns, name, req.Body)
+		return
 	}
 
 	switch apiRequest.Link {
@@ -72,6 +92,36 @@ func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	})
 }
 
+func (o *operation) diffUpgrade(apiRequest *types.APIRequest, ns, name string, body []byte) {
+	diffs, err := o.ops.DiffUpgrade(apiRequest.Context(), ns, name, bytes.NewReader(body))
+	if err != nil {
+		apiRequest.WriteError(err)
+		return
+	}
+
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+		Type: "chartActionOutput",
+		Object: &catalogtypes.ChartActionOutput{
+			Diffs: diffs,
+		},
+	})
+}
+
+func (o *operation) history(apiRequest *types.APIRequest, ns, name string, body io.Reader) {
+	revisions, err := o.ops.History(apiRequest.Context(), ns, name, body)
+	if err != nil {
+		apiRequest.WriteError(err)
+		return
+	}
+
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+		Type: "chartActionOutput",
+		Object: &catalogtypes.ChartActionOutput{
+			Revisions: revisions,
+		},
+	})
+}
+
 func (o *operation) OnAdd(gvk schema2.GroupVersionKind, key string, obj runtime.Object) error {
 	return o.ops.Impersonator.PurgeOldRoles(gvk, key, obj)
 }
@@ -41,6 +41,22 @@ type ChartUninstallAction struct {
 	Description  string           `json:"description,omitempty"`
 }
 
+type ChartRollbackAction struct {
+	Revision      int              `json:"revision,omitempty"`
+	DisableHooks  bool             `json:"noHooks,omitempty"`
+	DryRun        bool             `json:"dryRun,omitempty"`
+	Force         bool             `json:"force,omitempty"`
+	RecreatePods  bool             `json:"recreatePods,omitempty"`
+	Wait          bool             `json:"wait,omitempty"`
+	Timeout       *metav1.Duration `json:"timeout,omitempty"`
+	CleanupOnFail bool             `json:"cleanupOnFail,omitempty"`
+	MaxHistory    int              `json:"historyMax,omitempty"`
+}
+
+type ChartHistoryAction struct {
+	Max int `json:"max,omitempty"`
+}
+
 type ChartUpgradeAction struct {
 	Timeout                  *metav1.Duration `json:"timeout,omitempty"`
 	Wait                     bool             `json:"wait,omitempty"`
@@ -52,6 +68,7 @@ type ChartUpgradeAction struct {
 	Install                  bool             `json:"install,omitempty"`
 	Namespace                string           `json:"namespace,omitempty"`
 	CleanupOnFail            bool             `json:"cleanupOnFail,omitempty"`
+	DryRun                   bool             `json:"dryRun,omitempty"`
 	Charts                   []ChartUpgrade   `json:"charts,omitempty"`
 }
 
@@ -67,6 +84,41 @@ type ChartUpgrade struct {
 }
 
 type ChartActionOutput struct {
-	OperationName      string `json:"operationName,omitempty"`
-	OperationNamespace string `json:"operationNamespace,omitempty"`
+	OperationName      string         `json:"operationName,omitempty"`
+	OperationNamespace string         `json:"operationNamespace,omitempty"`
+	Diffs              []ResourceDiff `json:"diffs,omitempty"`
+	// Revisions is the output of the history action
+	Revisions []ReleaseRevision `json:"revisions,omitempty"`
+}
+
+// ReleaseRevision is a revision of a release, as listed by helm history.
+type ReleaseRevision struct {
+	Revision    int    `json:"revision,omitempty"`
+	Updated     string `json:"updated,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Chart       string `json:"chart,omitempty"`
+	AppVersion  string `json:"appVersion,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ResourceDiff is the change a dry run upgrade would make to a resource of a release.
+type ResourceDiff struct {
+	ReleaseName string `json:"releaseName,omitempty"`
+	APIVersion  string `json:"apiVersion,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	// Change is "added", "removed" or "changed"
+	Change string `json:"change,omitempty"`
+	// Diff is the unified diff of the resource manifest
+	Diff string `json:"diff,omitempty"`
+}
+
+// BundleExportAction selects the ClusterRepos exported into an air-gapped catalog bundle.
+type BundleExportAction struct {
+	Repos []string `json:"repos,omitempty"`
+}
+
+type BundleImportOutput struct {
+	Repos []string `json:"repos,omitempty"`
 }
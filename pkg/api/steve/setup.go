@@ -10,6 +10,7 @@
 	"github.com/rancher/rancher/pkg/api/steve/navlinks"
 	"github.com/rancher/rancher/pkg/api/steve/settings"
 	"github.com/rancher/rancher/pkg/api/steve/userpreferences"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/wrangler"
 	steve "github.com/rancher/steve/pkg/server"
 )
@@ -26,5 +27,6 @@ func Setup(ctx context.Context, server *steve.Server, config *wrangler.Context)
 	return catalog.Register(ctx,
 		server,
 		config.HelmOperations,
-		config.CatalogContentManager)
+		config.CatalogContentManager,
+		bundle.NewImporter(config.Catalog.ClusterRepo(), config.Core.ConfigMap()))
 }
@@ -23,7 +23,8 @@ type SecretReference struct {
 }
 
 type RepoSpec struct {
-	// URL A http URL of the repo to connect to
+	// URL A http URL of the repo to connect to, or an oci:// URL of a registry namespace or
+	// repository to build the repo index from the charts pushed to it
 	URL string `json:"url,omitempty"`
 
 	// GitRepo a git repo to clone and index as the helm repo
@@ -41,7 +42,8 @@ type RepoSpec struct {
 
 	// ClientSecretName is the client secret to be used to connect to the repo
 	// It is expected the secret be of type "kubernetes.io/basic-auth" or "kubernetes.io/tls" for Helm repos
-	// and "kubernetes.io/basic-auth" or "kubernetes.io/ssh-auth" for git repos.
+	// and "kubernetes.io/basic-auth" or "kubernetes.io/ssh-auth" for git repos. OCI repos also accept
+	// "kubernetes.io/dockerconfigjson" secrets.
 	// For a repo the Namespace file will be ignored
 	ClientSecret *SecretReference `json:"clientSecret,omitempty"`
 
@@ -61,6 +63,37 @@ type RepoSpec struct {
 
 	// If disabled the repo clone will not be updated or allowed to be installed from
 	Enabled *bool `json:"enabled,omitempty"`
+
+	// Verification configures checking the signatures of charts before they are installed or upgraded
+	Verification *ChartVerification `json:"verification,omitempty"`
+
+	// RefreshInterval is the interval in seconds at which the repo index is downloaded again.
+	// Defaults to 300 seconds, intervals below 60 seconds are raised to 60.
+	RefreshInterval int `json:"refreshInterval,omitempty"`
+
+	// WebhookSecret is the secret whose "token" key authenticates webhook requests that refresh the
+	// repo immediately, such as GitHub and GitLab push events.
+	// Only ClusterRepos can be refreshed by webhooks, the secret is read from the Namespace field.
+	WebhookSecret *SecretReference `json:"webhookSecret,omitempty"`
+}
+
+type VerificationPolicy string
+
+const (
+	VerificationOff     VerificationPolicy = "off"
+	VerificationWarn    VerificationPolicy = "warn"
+	VerificationEnforce VerificationPolicy = "enforce"
+)
+
+type ChartVerification struct {
+	// Policy "off" skips verification, "warn" records failed verifications on the operation and
+	// "enforce" refuses to install charts that fail verification. Defaults to "off".
+	Policy VerificationPolicy `json:"policy,omitempty"`
+
+	// KeyringSecret is the secret holding the trusted keys. The "keyring" key holds a GPG keyring
+	// used to check Helm provenance files and the "cosign.pub" key a PEM encoded cosign public key.
+	// For a repo the Namespace field will be ignored
+	KeyringSecret *SecretReference `json:"keyringSecret,omitempty"`
 }
 
 type RepoCondition string
@@ -115,5 +148,17 @@ type OperationStatus struct {
 	PodName            string                              `json:"podName,omitempty"`
 	PodNamespace       string                              `json:"podNamespace,omitempty"`
 	PodCreated         bool                                `json:"podCreated,omitempty"`
+	Verifications      []ChartVerificationStatus           `json:"verifications,omitempty"`
 	Conditions         []genericcondition.GenericCondition `json:"conditions,omitempty"`
 }
+
+// ChartVerificationStatus is the result of checking the signature of a chart.
+type ChartVerificationStatus struct {
+	Chart   string `json:"chart,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Method is "provenance" for Helm provenance files or "cosign" for cosign signatures
+	Method   string `json:"method,omitempty"`
+	Verified bool   `json:"verified"`
+	SignedBy string `json:"signedBy,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
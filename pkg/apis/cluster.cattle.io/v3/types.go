@@ -2,6 +2,7 @@
 
 import (
 	"github.com/rancher/norman/types"
+	managementv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,4 +35,7 @@ type ClusterAuthToken struct {
 	ExpiresAt     string `json:"expiresAt,omitempty"`
 	SecretKeyHash string `json:"hash"`
 	Enabled       bool   `json:"enabled"`
+	// Scope is copied from the token. The cluster only sees the token during authentication, so
+	// it refuses scopes it cannot enforce there.
+	Scope *managementv3.TokenScope `json:"scope,omitempty"`
 }
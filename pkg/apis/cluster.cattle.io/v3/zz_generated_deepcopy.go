@@ -21,6 +21,7 @@
 package v3
 
 import (
+	managementcattleiov3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -30,6 +31,11 @@ func (in *ClusterAuthToken) DeepCopyInto(out *ClusterAuthToken) {
 	out.Namespaced = in.Namespaced
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Scope != nil {
+		in, out := &in.Scope, &out.Scope
+		*out = new(managementcattleiov3.TokenScope)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -89,7 +89,7 @@ func (p *ProjectAlertSpec) ObjClusterName() string {
 type Recipient struct {
 	Recipient    string `json:"recipient,omitempty"`
 	NotifierName string `json:"notifierName,omitempty" norman:"required,type=reference[notifier]"`
-	NotifierType string `json:"notifierType,omitempty" norman:"required,options=slack|email|pagerduty|webhook|wechat|dingtalk|msteams"`
+	NotifierType string `json:"notifierType,omitempty" norman:"required,options=slack|email|pagerduty|webhook|wechat|dingtalk|msteams|opsgenie|googlechat|mattermost|telegram"`
 }
 
 type TargetNode struct {
@@ -350,16 +350,23 @@ func (n *Notifier) ObjClusterName() string {
 type NotifierSpec struct {
 	ClusterName string `json:"clusterName" norman:"type=reference[cluster]"`
 
-	DisplayName     string           `json:"displayName,omitempty" norman:"required"`
-	Description     string           `json:"description,omitempty"`
-	SendResolved    bool             `json:"sendResolved,omitempty"`
-	SMTPConfig      *SMTPConfig      `json:"smtpConfig,omitempty"`
-	SlackConfig     *SlackConfig     `json:"slackConfig,omitempty"`
-	PagerdutyConfig *PagerdutyConfig `json:"pagerdutyConfig,omitempty"`
-	WebhookConfig   *WebhookConfig   `json:"webhookConfig,omitempty"`
-	WechatConfig    *WechatConfig    `json:"wechatConfig,omitempty"`
-	DingtalkConfig  *DingtalkConfig  `json:"dingtalkConfig,omitempty"`
-	MSTeamsConfig   *MSTeamsConfig   `json:"msteamsConfig,omitempty"`
+	DisplayName      string            `json:"displayName,omitempty" norman:"required"`
+	Description      string            `json:"description,omitempty"`
+	SendResolved     bool              `json:"sendResolved,omitempty"`
+	SMTPConfig       *SMTPConfig       `json:"smtpConfig,omitempty"`
+	SlackConfig      *SlackConfig      `json:"slackConfig,omitempty"`
+	PagerdutyConfig  *PagerdutyConfig  `json:"pagerdutyConfig,omitempty"`
+	WebhookConfig    *WebhookConfig    `json:"webhookConfig,omitempty"`
+	WechatConfig     *WechatConfig     `json:"wechatConfig,omitempty"`
+	DingtalkConfig   *DingtalkConfig   `json:"dingtalkConfig,omitempty"`
+	MSTeamsConfig    *MSTeamsConfig    `json:"msteamsConfig,omitempty"`
+	OpsgenieConfig   *OpsgenieConfig   `json:"opsgenieConfig,omitempty"`
+	GoogleChatConfig *GoogleChatConfig `json:"googleChatConfig,omitempty"`
+	MattermostConfig *MattermostConfig `json:"mattermostConfig,omitempty"`
+	TelegramConfig   *TelegramConfig   `json:"telegramConfig,omitempty"`
+
+	MessageTemplate *NotifierMessageTemplate `json:"messageTemplate,omitempty"`
+	RateLimit       *NotifierRateLimit       `json:"rateLimit,omitempty"`
 }
 
 func (n *NotifierSpec) ObjClusterName() string {
@@ -367,14 +374,18 @@ func (n *NotifierSpec) ObjClusterName() string {
 }
 
 type Notification struct {
-	Message         string           `json:"message,omitempty"`
-	SMTPConfig      *SMTPConfig      `json:"smtpConfig,omitempty"`
-	SlackConfig     *SlackConfig     `json:"slackConfig,omitempty"`
-	PagerdutyConfig *PagerdutyConfig `json:"pagerdutyConfig,omitempty"`
-	WebhookConfig   *WebhookConfig   `json:"webhookConfig,omitempty"`
-	WechatConfig    *WechatConfig    `json:"wechatConfig,omitempty"`
-	DingtalkConfig  *DingtalkConfig  `json:"dingtalkConfig,omitempty"`
-	MSTeamsConfig   *MSTeamsConfig   `json:"msteamsConfig,omitempty"`
+	Message          string            `json:"message,omitempty"`
+	SMTPConfig       *SMTPConfig       `json:"smtpConfig,omitempty"`
+	SlackConfig      *SlackConfig      `json:"slackConfig,omitempty"`
+	PagerdutyConfig  *PagerdutyConfig  `json:"pagerdutyConfig,omitempty"`
+	WebhookConfig    *WebhookConfig    `json:"webhookConfig,omitempty"`
+	WechatConfig     *WechatConfig     `json:"wechatConfig,omitempty"`
+	DingtalkConfig   *DingtalkConfig   `json:"dingtalkConfig,omitempty"`
+	MSTeamsConfig    *MSTeamsConfig    `json:"msteamsConfig,omitempty"`
+	OpsgenieConfig   *OpsgenieConfig   `json:"opsgenieConfig,omitempty"`
+	GoogleChatConfig *GoogleChatConfig `json:"googleChatConfig,omitempty"`
+	MattermostConfig *MattermostConfig `json:"mattermostConfig,omitempty"`
+	TelegramConfig   *TelegramConfig   `json:"telegramConfig,omitempty"`
 }
 
 type SMTPConfig struct {
@@ -424,6 +435,55 @@ type MSTeamsConfig struct {
 	*HTTPClientConfig
 }
 
+type OpsgenieConfig struct {
+	APIKey string `json:"apiKey,omitempty" norman:"type=password,required"`
+	APIURL string `json:"apiUrl,omitempty" norman:"default=https://api.opsgenie.com"`
+	// Priority of the alerts created in Opsgenie.
+	Priority string `json:"priority,omitempty" norman:"options=P1|P2|P3|P4|P5,default=P3"`
+	// DefaultRecipient is the name of the Opsgenie team the alerts are assigned to.
+	DefaultRecipient string `json:"defaultRecipient,omitempty"`
+	*HTTPClientConfig
+}
+
+type GoogleChatConfig struct {
+	URL string `json:"url,omitempty" norman:"required"`
+	*HTTPClientConfig
+}
+
+type MattermostConfig struct {
+	URL              string `json:"url,omitempty" norman:"required"`
+	DefaultRecipient string `json:"defaultRecipient,omitempty"`
+	*HTTPClientConfig
+}
+
+type TelegramConfig struct {
+	Token string `json:"token,omitempty" norman:"type=password,required"`
+	// DefaultRecipient is the ID of the chat the bot sends messages to.
+	DefaultRecipient string `json:"defaultRecipient,omitempty" norman:"required"`
+	APIURL           string `json:"apiUrl,omitempty" norman:"default=https://api.telegram.org"`
+	*HTTPClientConfig
+}
+
+// NotifierMessageTemplate holds Go templates for the messages sent by a notifier. The templates can
+// reference .Title, .Content, .Labels, .ClusterName and .ProjectName of the original message. For alerts,
+// the templates are executed by alertmanager and .Labels holds the labels common to the alerts of a
+// notification, so .Title and .Content can only be output on their own. Alerts to DingTalk, Microsoft Teams
+// and webhook notifiers with a template are rendered and sent by rancher instead.
+type NotifierMessageTemplate struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// NotifierRateLimit caps how many messages a notifier sends per interval, and drops messages
+// identical to one already sent within the dedup window. Messages are counted by each rancher replica
+// separately. Alerts are instead spread evenly over the interval, and repeated no sooner than the dedup
+// window, by the alertmanager routes of the notifier.
+type NotifierRateLimit struct {
+	MaxMessages        int `json:"maxMessages,omitempty" norman:"min=0"`
+	IntervalSeconds    int `json:"intervalSeconds,omitempty" norman:"min=1,default=60"`
+	DedupWindowSeconds int `json:"dedupWindowSeconds,omitempty" norman:"min=0"`
+}
+
 type NotifierStatus struct {
 }
 
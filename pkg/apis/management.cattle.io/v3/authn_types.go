@@ -32,6 +32,31 @@ type Token struct {
 	Current         bool              `json:"current"`
 	ClusterName     string            `json:"clusterName,omitempty" norman:"noupdate,type=reference[cluster]"`
 	Enabled         *bool             `json:"enabled,omitempty" norman:"default=true"`
+	Scope           *TokenScope       `json:"scope,omitempty" norman:"noupdate"`
+	LastUsedAt      string            `json:"lastUsedAt,omitempty" norman:"nocreate,noupdate"`
+	LastUsedFrom    string            `json:"lastUsedFrom,omitempty" norman:"nocreate,noupdate"`
+}
+
+// TokenScope narrows what requests authenticated with a token may do. It never grants more than
+// the user's own permissions. Empty fields do not restrict. Scoped tokens cannot generate
+// kubeconfigs or open a kubectl shell, since those hand out credentials outside of the scope.
+type TokenScope struct {
+	// Clusters the token can be used with, "local" for requests not addressed to a downstream cluster.
+	Clusters []string `json:"clusters,omitempty"`
+	// Projects the token can be used with, as <cluster>:<project>. Only requests to the Rancher
+	// API of these projects are allowed.
+	Projects []string `json:"projects,omitempty"`
+	// Rules allow requests matching any of them.
+	Rules []TokenScopeRule `json:"rules,omitempty"`
+}
+
+type TokenScopeRule struct {
+	// Resources are plural resource names such as "pods", or steve types such as
+	// "management.cattle.io.clusters". A "*" matches every resource.
+	Resources []string `json:"resources,omitempty"`
+	// Verbs are kubernetes style verbs: get, list, watch, create, update, patch, delete and
+	// deletecollection. A "*" matches every verb.
+	Verbs []string `json:"verbs,omitempty"`
 }
 
 func (t *Token) ObjClusterName() string {
@@ -92,6 +117,11 @@ type UserAttribute struct {
 	GroupPrincipals map[string]Principals // the value is a []Principal, but code generator cannot handle slice as a value
 	LastRefresh     string
 	NeedsRefresh    bool
+
+	// The following fields are only maintained for users of the local auth provider.
+	FailedLoginAttempts int    // consecutive failed logins since the last successful one
+	LockedUntil         string // RFC3339 time until which logins are refused, empty if not locked
+	PasswordChangedAt   string // RFC3339 time the password was last set
 }
 
 type Principals struct {
@@ -154,6 +184,19 @@ type SetPasswordInput struct {
 	NewPassword string `json:"newPassword" norman:"type=string,required"`
 }
 
+type TOTPEnrollOutput struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
+
+type TOTPCodeInput struct {
+	Code string `json:"code" norman:"type=string,required"`
+}
+
+type TOTPRecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -373,6 +416,11 @@ type SamlConfig struct {
 	UIDField           string `json:"uidField"           norman:"required"`
 	RancherAPIHost     string `json:"rancherApiHost"     norman:"required"`
 	EntityID           string `json:"entityID"`
+	// SingleLogoutEnabled logs users out of the identity provider when they log out of Rancher
+	// and accepts logout requests sent by the identity provider.
+	SingleLogoutEnabled bool `json:"singleLogoutEnabled,omitempty"`
+	// SignAuthnRequests signs the authentication requests sent to the identity provider with the SP key.
+	SignAuthnRequests bool `json:"signAuthnRequests,omitempty"`
 }
 
 type SamlConfigTestInput struct {
@@ -422,6 +470,24 @@ type OIDCConfig struct {
 	PrivateKey         string `json:"privateKey" norman:"type=password"`
 	RancherURL         string `json:"rancherUrl" norman:"required,notnullable"`
 	GroupSearchEnabled *bool  `json:"groupSearchEnabled"`
+
+	// GroupsClaim is the path of the claim holding the user's groups, with nested claims
+	// separated by dots, e.g. "resource_access.rancher.roles". Defaults to the groups and
+	// full_group_path claims.
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+	// GroupsInclude are regular expressions; if set, only groups matching one of them are kept.
+	GroupsInclude []string `json:"groupsInclude,omitempty"`
+	// GroupsExclude are regular expressions; groups matching any of them are dropped.
+	GroupsExclude []string `json:"groupsExclude,omitempty"`
+	// GroupRenameRules rename the groups that are kept. The first rule matching a group applies.
+	GroupRenameRules []GroupRenameRule `json:"groupRenameRules,omitempty"`
+}
+
+type GroupRenameRule struct {
+	// Pattern is a regular expression matched against the group name.
+	Pattern string `json:"pattern"`
+	// Replacement replaces the match and can reference submatches as $1 or ${name}.
+	Replacement string `json:"replacement"`
 }
 
 type OIDCTestOutput struct {
@@ -37,6 +37,7 @@ type BasicLogin struct {
 	GenericLogin `json:",inline"`
 	Username     string `json:"username" norman:"type=string,required"`
 	Password     string `json:"password" norman:"type=string,required"`
+	MFACode      string `json:"mfaCode,omitempty"`
 }
 
 // +genclient
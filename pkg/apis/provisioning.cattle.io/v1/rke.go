@@ -3,6 +3,7 @@
 import (
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -20,6 +21,28 @@ type RKEMachinePool struct {
 	RollingUpdate                *RKEMachinePoolRollingUpdate `json:"rollingUpdate,omitempty"`
 	MachineDeploymentLabels      map[string]string            `json:"machineDeploymentLabels,omitempty"`
 	MachineDeploymentAnnotations map[string]string            `json:"machineDeploymentAnnotations,omitempty"`
+
+	// UnhealthyNodeTimeout is how long a node may report NotReady or Unknown before its
+	// machine is replaced. Setting it enables automatic remediation of the pool.
+	UnhealthyNodeTimeout *metav1.Duration `json:"unhealthyNodeTimeout,omitempty"`
+	// NodeStartupTimeout is how long a machine may take to register its node before it
+	// is replaced. Defaults to 15m.
+	NodeStartupTimeout *metav1.Duration `json:"nodeStartupTimeout,omitempty"`
+	// PlanFailureTimeout is how long a machine may fail to apply its plan before it is
+	// replaced, with 5m more when its probes are unhealthy. Only used when
+	// UnhealthyNodeTimeout is set.
+	PlanFailureTimeout *metav1.Duration `json:"planFailureTimeout,omitempty"`
+	// MaxUnhealthy stops remediation of the pool when more than this number or percentage
+	// of its machines are unhealthy. Pools with the etcd role never remediate more than
+	// one machine at a time.
+	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
+
+	// AutoscalingMinSize and AutoscalingMaxSize hand the size of the pool over to the
+	// cluster autoscaler, which keeps it between the two. Quantity is then only the
+	// initial size. Both must be set to enable autoscaling, and AutoscalingMinSize must
+	// be at least 1.
+	AutoscalingMinSize *int32 `json:"autoscalingMinSize,omitempty"`
+	AutoscalingMaxSize *int32 `json:"autoscalingMaxSize,omitempty"`
 }
 
 type RKEMachinePoolRollingUpdate struct {
@@ -58,8 +81,13 @@ type RKEMachinePoolRollingUpdate struct {
 type RKEConfig struct {
 	rkev1.RKEClusterSpecCommon
 
-	ETCDSnapshotCreate  *rkev1.ETCDSnapshotCreate  `json:"etcdSnapshotCreate,omitempty"`
-	ETCDSnapshotRestore *rkev1.ETCDSnapshotRestore `json:"etcdSnapshotRestore,omitempty"`
-	MachinePools        []RKEMachinePool           `json:"machinePools,omitempty"`
-	InfrastructureRef   *corev1.ObjectReference    `json:"infrastructureRef,omitempty"`
+	ETCDSnapshotCreate   *rkev1.ETCDSnapshotCreate   `json:"etcdSnapshotCreate,omitempty"`
+	ETCDSnapshotRestore  *rkev1.ETCDSnapshotRestore  `json:"etcdSnapshotRestore,omitempty"`
+	RotateCertificates   *rkev1.RotateCertificates   `json:"rotateCertificates,omitempty"`
+	RotateEncryptionKeys *rkev1.RotateEncryptionKeys `json:"rotateEncryptionKeys,omitempty"`
+	// DryRun pauses the rollout of changes to the nodes of the cluster and reports the
+	// changes in the status of its RKEControlPlane instead, see RKEControlPlaneSpec.DryRun.
+	DryRun            bool                    `json:"dryRun,omitempty"`
+	MachinePools      []RKEMachinePool        `json:"machinePools,omitempty"`
+	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
 }
@@ -24,6 +24,7 @@
 	rkecattleiov1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	genericcondition "github.com/rancher/wrangler/pkg/genericcondition"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -201,6 +202,16 @@ func (in *RKEConfig) DeepCopyInto(out *RKEConfig) {
 		*out = new(rkecattleiov1.ETCDSnapshotRestore)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RotateCertificates != nil {
+		in, out := &in.RotateCertificates, &out.RotateCertificates
+		*out = new(rkecattleiov1.RotateCertificates)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RotateEncryptionKeys != nil {
+		in, out := &in.RotateEncryptionKeys, &out.RotateEncryptionKeys
+		*out = new(rkecattleiov1.RotateEncryptionKeys)
+		**out = **in
+	}
 	if in.MachinePools != nil {
 		in, out := &in.MachinePools, &out.MachinePools
 		*out = make([]RKEMachinePool, len(*in))
@@ -259,6 +270,36 @@ func (in *RKEMachinePool) DeepCopyInto(out *RKEMachinePool) {
 			(*out)[key] = val
 		}
 	}
+	if in.UnhealthyNodeTimeout != nil {
+		in, out := &in.UnhealthyNodeTimeout, &out.UnhealthyNodeTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NodeStartupTimeout != nil {
+		in, out := &in.NodeStartupTimeout, &out.NodeStartupTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.PlanFailureTimeout != nil {
+		in, out := &in.PlanFailureTimeout, &out.PlanFailureTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxUnhealthy != nil {
+		in, out := &in.MaxUnhealthy, &out.MaxUnhealthy
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.AutoscalingMinSize != nil {
+		in, out := &in.AutoscalingMinSize, &out.AutoscalingMinSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AutoscalingMaxSize != nil {
+		in, out := &in.AutoscalingMaxSize, &out.AutoscalingMaxSize
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
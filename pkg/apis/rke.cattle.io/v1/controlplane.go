@@ -19,13 +19,77 @@ type RKEControlPlane struct {
 type RKEControlPlaneSpec struct {
 	RKEClusterSpecCommon
 
-	AgentEnvVars          []corev1.EnvVar      `json:"agentEnvVars,omitempty"`
-	ETCDSnapshotCreate    *ETCDSnapshotCreate  `json:"etcdSnapshotCreate,omitempty"`
-	ETCDSnapshotRestore   *ETCDSnapshotRestore `json:"etcdSnapshotRestore,omitempty"`
-	KubernetesVersion     string               `json:"kubernetesVersion,omitempty"`
-	ClusterName           string               `json:"clusterName,omitempty" wrangler:"required"`
-	ManagementClusterName string               `json:"managementClusterName,omitempty" wrangler:"required"`
-	UnmanagedConfig       bool                 `json:"unmanagedConfig,omitempty"`
+	AgentEnvVars          []corev1.EnvVar       `json:"agentEnvVars,omitempty"`
+	ETCDSnapshotCreate    *ETCDSnapshotCreate   `json:"etcdSnapshotCreate,omitempty"`
+	ETCDSnapshotRestore   *ETCDSnapshotRestore  `json:"etcdSnapshotRestore,omitempty"`
+	KubernetesVersion     string                `json:"kubernetesVersion,omitempty"`
+	ClusterName           string                `json:"clusterName,omitempty" wrangler:"required"`
+	ManagementClusterName string                `json:"managementClusterName,omitempty" wrangler:"required"`
+	UnmanagedConfig       bool                  `json:"unmanagedConfig,omitempty"`
+	RotateCertificates    *RotateCertificates   `json:"rotateCertificates,omitempty"`
+	RotateEncryptionKeys  *RotateEncryptionKeys `json:"rotateEncryptionKeys,omitempty"`
+	// DryRun pauses the planner: no plan is assigned, no node is drained and no etcd snapshot, restore or
+	// certificate or encryption key rotation is started, so new machines are not provisioned either. The plan
+	// changes it would roll out are reported in the status instead, refreshed whenever a machine or its plan
+	// changes, and are applied once DryRun is unset.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type RotateCertificates struct {
+	// Services is the list of services to rotate certificates for, all certificates are rotated if empty.
+	Services []string `json:"services,omitempty"`
+	// Changing the Generation is the only thing required to initiate a certificate rotation.
+	Generation int64 `json:"generation,omitempty"`
+}
+
+type RotateCertificatesPhase string
+
+var (
+	RotateCertificatesPhaseStarted      RotateCertificatesPhase = "Started"
+	RotateCertificatesPhaseControlPlane RotateCertificatesPhase = "ControlPlane"
+	RotateCertificatesPhaseWorker       RotateCertificatesPhase = "Worker"
+	RotateCertificatesPhaseFinished     RotateCertificatesPhase = "Finished"
+)
+
+type RotateEncryptionKeys struct {
+	// Changing the Generation is the only thing required to initiate a secrets encryption key rotation. If the
+	// previous rotation failed, changing the Generation resumes it from the phase it failed in.
+	Generation int64 `json:"generation,omitempty"`
+}
+
+type RotateEncryptionKeysPhase string
+
+var (
+	RotateEncryptionKeysPhaseStarted              RotateEncryptionKeysPhase = "Started"
+	RotateEncryptionKeysPhasePrepare              RotateEncryptionKeysPhase = "Prepare"
+	RotateEncryptionKeysPhasePostPrepareRestart   RotateEncryptionKeysPhase = "PostPrepareRestart"
+	RotateEncryptionKeysPhaseRotate               RotateEncryptionKeysPhase = "Rotate"
+	RotateEncryptionKeysPhasePostRotateRestart    RotateEncryptionKeysPhase = "PostRotateRestart"
+	RotateEncryptionKeysPhaseReencrypt            RotateEncryptionKeysPhase = "Reencrypt"
+	RotateEncryptionKeysPhasePostReencryptRestart RotateEncryptionKeysPhase = "PostReencryptRestart"
+	RotateEncryptionKeysPhaseFinished             RotateEncryptionKeysPhase = "Finished"
+)
+
+type DryRunReport struct {
+	// ObservedGeneration is the generation of the RKEControlPlane the report was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Error is set when the changes fail validation and could not be rolled out at all.
+	Error string `json:"error,omitempty"`
+	// Nodes lists every node whose plan would change.
+	Nodes []DryRunNode `json:"nodes,omitempty"`
+}
+
+type DryRunNode struct {
+	MachineName string `json:"machineName,omitempty"`
+	// Tier is the stage of the rollout the node is updated in: bootstrap, etcd, control plane or worker.
+	Tier string `json:"tier,omitempty"`
+	// Restart is true if the new plan restarts the Kubernetes distribution on the node.
+	Restart bool `json:"restart,omitempty"`
+	// Drain is true if the node is drained before the new plan is applied.
+	Drain   bool     `json:"drain,omitempty"`
+	Reasons []string `json:"reasons,omitempty"`
+	// Error is set when no plan could be computed for the node.
+	Error string `json:"error,omitempty"`
 }
 
 type ETCDSnapshotPhase string
@@ -38,12 +102,19 @@ type RKEControlPlaneSpec struct {
 )
 
 type RKEControlPlaneStatus struct {
-	Conditions               []genericcondition.GenericCondition `json:"conditions,omitempty"`
-	Ready                    bool                                `json:"ready,omitempty"`
-	ObservedGeneration       int64                               `json:"observedGeneration"`
-	ETCDSnapshotRestore      *ETCDSnapshotRestore                `json:"etcdSnapshotRestore,omitempty"`
-	ETCDSnapshotRestorePhase ETCDSnapshotPhase                   `json:"etcdSnapshotRestorePhase,omitempty"`
-	ETCDSnapshotCreate       *ETCDSnapshotCreate                 `json:"etcdSnapshotCreate,omitempty"`
-	ETCDSnapshotCreatePhase  ETCDSnapshotPhase                   `json:"etcdSnapshotCreatePhase,omitempty"`
-	ConfigGeneration         int64                               `json:"configGeneration,omitempty"`
+	Conditions                  []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	Ready                       bool                                `json:"ready,omitempty"`
+	ObservedGeneration          int64                               `json:"observedGeneration"`
+	ETCDSnapshotRestore         *ETCDSnapshotRestore                `json:"etcdSnapshotRestore,omitempty"`
+	ETCDSnapshotRestorePhase    ETCDSnapshotPhase                   `json:"etcdSnapshotRestorePhase,omitempty"`
+	ETCDSnapshotCreate          *ETCDSnapshotCreate                 `json:"etcdSnapshotCreate,omitempty"`
+	ETCDSnapshotCreatePhase     ETCDSnapshotPhase                   `json:"etcdSnapshotCreatePhase,omitempty"`
+	ConfigGeneration            int64                               `json:"configGeneration,omitempty"`
+	RotateCertificates          *RotateCertificates                 `json:"rotateCertificates,omitempty"`
+	RotateCertificatesPhase     RotateCertificatesPhase             `json:"rotateCertificatesPhase,omitempty"`
+	RotateEncryptionKeys        *RotateEncryptionKeys               `json:"rotateEncryptionKeys,omitempty"`
+	RotateEncryptionKeysPhase   RotateEncryptionKeysPhase           `json:"rotateEncryptionKeysPhase,omitempty"`
+	RotateEncryptionKeysLeader  string                              `json:"rotateEncryptionKeysLeader,omitempty"`
+	RotateEncryptionKeysFailure string                              `json:"rotateEncryptionKeysFailure,omitempty"`
+	DryRun                      *DryRunReport                       `json:"dryRun,omitempty"`
 }
@@ -1,6 +1,8 @@
 package plan
 
 import (
+	"time"
+
 	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
@@ -23,6 +25,7 @@ type Node struct {
 	InSync      bool                   `json:"inSync,omitempty"`
 	Healthy     bool                   `json:"healthy,omitempty"`
 	ProbeStatus map[string]ProbeStatus `json:"probeStatus,omitempty"`
+	PlanUpdated time.Time              `json:"-"`
 }
 
 type Secret struct {
@@ -12,6 +12,7 @@
 	"github.com/rancher/rancher/pkg/auth/principals"
 	"github.com/rancher/rancher/pkg/auth/providerrefresh"
 	"github.com/rancher/rancher/pkg/auth/providers"
+	"github.com/rancher/rancher/pkg/auth/providers/local"
 	"github.com/rancher/rancher/pkg/auth/requests"
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	managementschema "github.com/rancher/rancher/pkg/schemas/management.cattle.io/v3"
@@ -29,8 +30,11 @@ func User(ctx context.Context, schemas *types.Schemas, management *config.Scaled
 	schema := schemas.Schema(&managementschema.Version, client.UserType)
 	handler := &user.Handler{
 		UserClient:               management.Management.Users(""),
+		UserAttributeClient:      management.Management.UserAttributes(""),
 		GlobalRoleBindingsClient: management.Management.GlobalRoleBindings(""),
 		UserAuthRefresher:        providerrefresh.NewUserAuthRefresher(ctx, management),
+		MFA:                      local.NewMFA(management.Core.Secrets(""), management.Core.Secrets("").Controller().Lister()),
+		PasswordHistory:          local.NewPasswordHistory(management.Core.Secrets(""), management.Core.Secrets("").Controller().Lister()),
 	}
 
 	schema.Formatter = handler.UserFormatter
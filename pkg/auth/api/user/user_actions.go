@@ -8,7 +8,10 @@
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/parse"
 	"github.com/rancher/norman/types"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/auth/providerrefresh"
+	"github.com/rancher/rancher/pkg/auth/providers"
+	"github.com/rancher/rancher/pkg/auth/providers/local"
 	"github.com/rancher/rancher/pkg/auth/settings"
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
@@ -18,6 +21,10 @@
 
 func (h *Handler) UserFormatter(apiContext *types.APIContext, resource *types.RawResource) {
 	resource.AddAction(apiContext, "setpassword")
+	if h.userCanUpdate(apiContext) {
+		resource.AddAction(apiContext, "unlock")
+		resource.AddAction(apiContext, "resettotp")
+	}
 
 	if canRefresh := h.userCanRefresh(apiContext); canRefresh {
 		resource.AddAction(apiContext, "refreshauthprovideraccess")
@@ -26,6 +33,9 @@ func (h *Handler) UserFormatter(apiContext *types.APIContext, resource *types.Ra
 
 func (h *Handler) CollectionFormatter(apiContext *types.APIContext, collection *types.GenericCollection) {
 	collection.AddAction(apiContext, "changepassword")
+	collection.AddAction(apiContext, "enrolltotp")
+	collection.AddAction(apiContext, "activatetotp")
+	collection.AddAction(apiContext, "disabletotp")
 	if canRefresh := h.userCanRefresh(apiContext); canRefresh {
 		collection.AddAction(apiContext, "refreshauthprovideraccess")
 	}
@@ -33,8 +43,11 @@ func (h *Handler) CollectionFormatter(apiContext *types.APIContext, collection *
 
 type Handler struct {
 	UserClient               v3.UserInterface
+	UserAttributeClient      v3.UserAttributeInterface
 	GlobalRoleBindingsClient v3.GlobalRoleBindingInterface
 	UserAuthRefresher        providerrefresh.UserAuthRefresher
+	MFA                      *local.MFA
+	PasswordHistory          *local.PasswordHistory
 }
 
 func (h *Handler) Actions(actionName string, action *types.Action, apiContext *types.APIContext) error {
@@ -51,6 +64,16 @@ func (h *Handler) Actions(actionName string, action *types.Action, apiContext *t
 		if err := h.refreshAttributes(actionName, action, apiContext); err != nil {
 			return err
 		}
+	case "unlock":
+		return h.unlock(actionName, action, apiContext)
+	case "enrolltotp":
+		return h.enrollTOTP(actionName, action, apiContext)
+	case "activatetotp":
+		return h.activateTOTP(actionName, action, apiContext)
+	case "disabletotp":
+		return h.disableTOTP(actionName, action, apiContext)
+	case "resettotp":
+		return h.resetTOTP(actionName, action, apiContext)
 	default:
 		return errors.Errorf("bad action %v", actionName)
 	}
@@ -98,11 +121,17 @@ func (h *Handler) changePassword(actionName string, action *types.Action, reques
 		return httperror.NewAPIError(httperror.InvalidBodyContent, "invalid current password")
 	}
 
+	policy := local.GetPasswordPolicy()
+	if err := h.validateNewPassword(policy, user, newPass); err != nil {
+		return err
+	}
+
 	newPassHash, err := HashPasswordString(newPass)
 	if err != nil {
 		return err
 	}
 
+	oldPassHash := user.Password
 	user.Password = newPassHash
 	user.MustChangePassword = false
 	user, err = h.UserClient.Update(user)
@@ -110,7 +139,7 @@ func (h *Handler) changePassword(actionName string, action *types.Action, reques
 		return err
 	}
 
-	return nil
+	return h.recordPasswordChange(policy, user.Name, oldPassHash)
 }
 
 func (h *Handler) setPassword(actionName string, action *types.Action, request *types.APIContext) error {
@@ -134,6 +163,16 @@ func (h *Handler) setPassword(actionName string, action *types.Action, request *
 		return errors.New("Invalid password")
 	}
 
+	user, err := h.UserClient.Get(request.ID, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	policy := local.GetPasswordPolicy()
+	if err := h.validateNewPassword(policy, user, newPass); err != nil {
+		return err
+	}
+
 	userData[client.UserFieldPassword] = newPass
 	if err := hashPassword(userData); err != nil {
 		return err
@@ -146,10 +185,184 @@ func (h *Handler) setPassword(actionName string, action *types.Action, request *
 		return err
 	}
 
+	if err := h.recordPasswordChange(policy, user.Name, user.Password); err != nil {
+		return err
+	}
+
 	request.WriteResponse(http.StatusOK, userData)
 	return nil
 }
 
+// unlock clears the failed login attempts and lockout of a local user.
+func (h *Handler) unlock(actionName string, action *types.Action, request *types.APIContext) error {
+	if !h.userCanUpdate(request) {
+		return httperror.NewAPIError(httperror.PermissionDenied, "not allowed to unlock users")
+	}
+
+	err := h.updateUserAttribute(request.ID, func(attribs *v32.UserAttribute) {
+		attribs.FailedLoginAttempts = 0
+		attribs.LockedUntil = ""
+	})
+	if err != nil {
+		return err
+	}
+
+	request.WriteResponse(http.StatusOK, nil)
+	return nil
+}
+
+// enrollTOTP starts a TOTP enrollment for the requesting user and returns the key to load into an
+// authenticator app.
+func (h *Handler) enrollTOTP(actionName string, action *types.Action, request *types.APIContext) error {
+	user, err := h.localRequestUser(request)
+	if err != nil {
+		return err
+	}
+
+	secret, url, err := h.MFA.Enroll(user.Name, user.Username)
+	if err != nil {
+		return err
+	}
+
+	request.WriteResponse(http.StatusOK, map[string]interface{}{
+		"type":                             client.TOTPEnrollOutputType,
+		client.TOTPEnrollOutputFieldSecret: secret,
+		client.TOTPEnrollOutputFieldURL:    url,
+	})
+	return nil
+}
+
+// activateTOTP completes the enrollment of the requesting user and returns their recovery codes.
+func (h *Handler) activateTOTP(actionName string, action *types.Action, request *types.APIContext) error {
+	user, err := h.localRequestUser(request)
+	if err != nil {
+		return err
+	}
+	code, err := readTOTPCode(request)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.MFA.Activate(user.Name, code)
+	if err != nil {
+		return err
+	}
+
+	request.WriteResponse(http.StatusOK, map[string]interface{}{
+		"type": client.TOTPRecoveryCodesOutputType,
+		client.TOTPRecoveryCodesOutputFieldRecoveryCodes: codes,
+	})
+	return nil
+}
+
+// disableTOTP removes the enrollment of the requesting user, who has to prove possession of the
+// authenticator or a recovery code.
+func (h *Handler) disableTOTP(actionName string, action *types.Action, request *types.APIContext) error {
+	user, err := h.localRequestUser(request)
+	if err != nil {
+		return err
+	}
+	code, err := readTOTPCode(request)
+	if err != nil {
+		return err
+	}
+
+	ok, err := h.MFA.Verify(user.Name, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, "invalid code")
+	}
+	if err := h.MFA.Disable(user.Name); err != nil {
+		return err
+	}
+
+	request.WriteResponse(http.StatusOK, nil)
+	return nil
+}
+
+// resetTOTP removes the enrollment of another user, for example after they lost their
+// authenticator and recovery codes.
+func (h *Handler) resetTOTP(actionName string, action *types.Action, request *types.APIContext) error {
+	if !h.userCanUpdate(request) {
+		return httperror.NewAPIError(httperror.PermissionDenied, "not allowed to reset multi-factor authentication of users")
+	}
+
+	if err := h.MFA.Disable(request.ID); err != nil {
+		return err
+	}
+
+	request.WriteResponse(http.StatusOK, nil)
+	return nil
+}
+
+func (h *Handler) localRequestUser(request *types.APIContext) (*v3.User, error) {
+	userID := request.Request.Header.Get("Impersonate-User")
+	if userID == "" {
+		return nil, errors.New("can't find user")
+	}
+
+	user, err := h.UserClient.Get(userID, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if user.Password == "" {
+		return nil, httperror.NewAPIError(httperror.InvalidAction, "multi-factor authentication is only available to local users")
+	}
+	return user, nil
+}
+
+func readTOTPCode(request *types.APIContext) (string, error) {
+	actionInput, err := parse.ReadBody(request.Request)
+	if err != nil {
+		return "", err
+	}
+
+	code, ok := actionInput[client.TOTPCodeInputFieldCode].(string)
+	if !ok || len(code) == 0 {
+		return "", httperror.NewAPIError(httperror.InvalidBodyContent, "must specify code")
+	}
+	return code, nil
+}
+
+func (h *Handler) validateNewPassword(policy local.PasswordPolicy, user *v3.User, newPass string) error {
+	if err := policy.Validate(user.Username, newPass); err != nil {
+		return err
+	}
+
+	history, err := h.PasswordHistory.Get(user.Name)
+	if err != nil {
+		return err
+	}
+	return policy.ValidateReuse(newPass, user.Password, history)
+}
+
+func (h *Handler) recordPasswordChange(policy local.PasswordPolicy, userID, oldPassHash string) error {
+	if err := h.updateUserAttribute(userID, policy.RecordPasswordChange); err != nil {
+		return err
+	}
+	history, err := h.PasswordHistory.Get(userID)
+	if err != nil {
+		return err
+	}
+	return h.PasswordHistory.Set(userID, policy.RememberPassword(history, oldPassHash))
+}
+
+// updateUserAttribute goes through the local auth provider so that the token manager it was configured with is
+// reused instead of starting another one.
+func (h *Handler) updateUserAttribute(userID string, update func(attribs *v32.UserAttribute)) error {
+	provider, err := providers.GetProvider(local.Name)
+	if err != nil {
+		return err
+	}
+	localProvider, ok := provider.(*local.Provider)
+	if !ok {
+		return errors.New("local auth provider is not configured")
+	}
+	return localProvider.UpdateUserAttribute(userID, update)
+}
+
 func (h *Handler) refreshAttributes(actionName string, action *types.Action, request *types.APIContext) error {
 	canRefresh := h.userCanRefresh(request)
 
@@ -170,3 +383,7 @@ func (h *Handler) refreshAttributes(actionName string, action *types.Action, req
 func (h *Handler) userCanRefresh(request *types.APIContext) bool {
 	return request.AccessControl.CanDo(v3.UserGroupVersionKind.Group, v3.UserResource.Name, "create", request, nil, request.Schema) == nil
 }
+
+func (h *Handler) userCanUpdate(request *types.APIContext) bool {
+	return request.AccessControl.CanDo(v3.UserGroupVersionKind.Group, v3.UserResource.Name, "update", request, nil, request.Schema) == nil
+}
@@ -9,6 +9,7 @@
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/store/transform"
 	"github.com/rancher/norman/types"
+	"github.com/rancher/rancher/pkg/auth/providers/local"
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/types/config"
@@ -107,6 +108,12 @@ func HashPasswordString(password string) (string, error) {
 }
 
 func (s *userStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	username, _ := data[client.UserFieldUsername].(string)
+	password, _ := data[client.UserFieldPassword].(string)
+	if err := local.GetPasswordPolicy().Validate(username, password); err != nil {
+		return nil, err
+	}
+
 	if err := hashPassword(data); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/pkg/namespace"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SigningKeySecretName is the name of the Secret in the cattle-system namespace holding the
+	// ed25519 key used to sign audit log checkpoints.
+	SigningKeySecretName = "audit-log-signing-key"
+	signingKeyPrivate    = "privateKey"
+	signingKeyPublic     = "publicKey"
+
+	checkpointType = "checkpoint"
+	hashField      = `,"hash":"`
+)
+
+// chain links every audit record to its predecessor. Each record gets a sequence number and the
+// hash of the previous record appended, and is then sealed with the SHA-256 hash of everything
+// before the hash field. Every checkpointInterval records a checkpoint record is added to the
+// chain carrying an ed25519 signature over its own hash. A chain is not safe for concurrent use.
+type chain struct {
+	sequence           uint64
+	lastHash           string
+	signer             ed25519.PrivateKey
+	checkpointInterval uint64
+	// unsigned counts the records sealed since the last checkpoint.
+	unsigned uint64
+}
+
+type chainFields struct {
+	Type         string `json:"type,omitempty"`
+	Timestamp    string `json:"timestamp,omitempty"`
+	Sequence     uint64 `json:"sequence"`
+	PreviousHash string `json:"previousHash"`
+	Hash         string `json:"hash"`
+	Signature    string `json:"signature,omitempty"`
+}
+
+// seal appends the chain fields to entry and returns the resulting records, which is entry
+// followed by a checkpoint record if one is due.
+func (c *chain) seal(entry []byte) [][]byte {
+	records := [][]byte{c.next(bytes.TrimSuffix(bytes.TrimSuffix(entry, []byte("\n")), []byte("}")), false)}
+	if c.checkpointInterval > 0 && c.sequence%c.checkpointInterval == 0 {
+		records = append(records, c.checkpoint()...)
+	}
+	return records
+}
+
+// checkpoint returns a signed checkpoint record covering the records sealed since the last one. It
+// returns nothing if there are none or the chain has no signing key.
+func (c *chain) checkpoint() [][]byte {
+	if c.signer == nil || c.unsigned == 0 {
+		return nil
+	}
+	checkpoint := fmt.Sprintf(`{"type":%q,"timestamp":%q`, checkpointType, time.Now().Format(time.RFC3339))
+	return [][]byte{c.next([]byte(checkpoint), true)}
+}
+
+// next seals the next record of the chain. open is a JSON object without its closing brace.
+func (c *chain) next(open []byte, sign bool) []byte {
+	c.sequence++
+
+	var buf bytes.Buffer
+	buf.Write(open)
+	if len(open) > 1 {
+		buf.WriteByte(',')
+	}
+	fmt.Fprintf(&buf, `"sequence":%d,"previousHash":%q`, c.sequence, c.lastHash)
+
+	sum := sha256.Sum256(append(buf.Bytes(), '}'))
+	c.lastHash = hex.EncodeToString(sum[:])
+
+	fmt.Fprintf(&buf, `%s%s"`, hashField, c.lastHash)
+	if sign {
+		fmt.Fprintf(&buf, `,"signature":%q`, base64.StdEncoding.EncodeToString(ed25519.Sign(c.signer, sum[:])))
+		c.unsigned = 0
+	} else {
+		c.unsigned++
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// resume continues the chain from the last complete record of an existing audit log file. A
+// record cut short by a crash is left in place for the verifier to report; it is terminated with
+// a newline so the records that follow start on a line of their own.
+func (c *chain) resume(path string) error {
+	line, truncated, err := lastRecord(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if truncated {
+		logrus.Warnf("audit: last record of %s is incomplete, resuming the hash chain from the record before it", path)
+		if err := terminateLine(path); err != nil {
+			return err
+		}
+	}
+	if len(line) == 0 {
+		return nil
+	}
+
+	fields := chainFields{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return errors.Wrapf(err, "failed to parse last audit record of %s", path)
+	}
+	c.sequence = fields.Sequence
+	c.lastHash = fields.Hash
+	if fields.Type != checkpointType {
+		c.unsigned = 1
+	}
+	return nil
+}
+
+// lastRecord returns the last non-empty, newline terminated line of a file, reading it backwards
+// from the end. truncated reports whether the file ends in a partial line, which is skipped.
+func lastRecord(path string) (line []byte, truncated bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	const chunkSize = 64 * 1024
+	var (
+		offset = stat.Size()
+		tail   []byte
+	)
+	for offset > 0 {
+		size := int64(chunkSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		if tail == nil {
+			truncated = chunk[len(chunk)-1] != '\n'
+		}
+		tail = append(chunk, tail...)
+
+		complete := completeLines(tail, truncated)
+		if complete == nil {
+			continue
+		}
+		trimmed := bytes.TrimRight(complete, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], truncated, nil
+		}
+	}
+	return bytes.TrimRight(completeLines(tail, truncated), "\n"), truncated, nil
+}
+
+// completeLines strips the partial line from the end of data when truncated is set. It returns
+// nil if data holds nothing but the partial line.
+func completeLines(data []byte, truncated bool) []byte {
+	if !truncated {
+		return data
+	}
+	i := bytes.LastIndexByte(data, '\n')
+	if i < 0 {
+		return nil
+	}
+	return data[:i+1]
+}
+
+// terminateLine appends a newline to a file.
+func terminateLine(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte("\n")); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ensureSigningKey returns the checkpoint signing key, generating and storing a new one in a
+// Secret if it does not exist yet.
+func ensureSigningKey(secrets corecontrollers.SecretClient) (ed25519.PrivateKey, error) {
+	secret, err := secrets.Get(namespace.System, SigningKeySecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		public, private, genErr := ed25519.GenerateKey(rand.Reader)
+		if genErr != nil {
+			return nil, genErr
+		}
+		secret, err = secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SigningKeySecretName,
+				Namespace: namespace.System,
+			},
+			Data: map[string][]byte{
+				signingKeyPrivate: private,
+				signingKeyPublic:  public,
+			},
+		})
+		if apierrors.IsAlreadyExists(err) {
+			secret, err = secrets.Get(namespace.System, SigningKeySecretName, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := secret.Data[signingKeyPrivate]
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("secret %s/%s does not contain a valid ed25519 %s", namespace.System, SigningKeySecretName, signingKeyPrivate)
+	}
+	return ed25519.PrivateKey(key), nil
+}
@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeChain(t *testing.T, c *chain, count int) []byte {
+	var out bytes.Buffer
+	for i := 0; i < count; i++ {
+		entry := fmt.Sprintf(`{"auditID":"%d","requestURI":"/v3/tokens","requestBody":{"hash":"not-the-hash"}}`+"\n", i)
+		for _, record := range c.seal([]byte(entry)) {
+			out.Write(record)
+		}
+	}
+	return out.Bytes()
+}
+
+func TestChainVerifies(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := writeChain(t, &chain{signer: private, checkpointInterval: 4}, 10)
+
+	result, err := Verify(bytes.NewReader(log), public, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.Equal(t, 13, result.Records)
+	assert.Equal(t, 3, result.Checkpoints)
+	assert.Equal(t, uint64(1), result.FirstSequence)
+	assert.Equal(t, uint64(13), result.LastSequence)
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = Verify(bytes.NewReader(log), otherPublic, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.BrokenLine)
+	assert.Equal(t, "checkpoint 5 has an invalid signature", result.Reason)
+}
+
+func TestChainRequiresCheckpoints(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records after the last checkpoint are not covered by a signature.
+	c := &chain{signer: private, checkpointInterval: 4}
+	log := writeChain(t, c, 11)
+	result, err := Verify(bytes.NewReader(log), public, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, result.BrokenLine)
+	assert.Equal(t, "log ends with 1 records after the last signed checkpoint", result.Reason)
+
+	// Until the next checkpoint is written.
+	for _, record := range c.checkpoint() {
+		log = append(log, record...)
+	}
+	assert.Nil(t, c.checkpoint(), "checkpoint without records to cover")
+	result, err = Verify(bytes.NewReader(log), public, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+
+	// A log with fewer checkpoints than the interval requires fails, one without any too.
+	result, err = Verify(bytes.NewReader(log), public, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.BrokenLine)
+	assert.Equal(t, "record 3 follows 2 records without a signed checkpoint", result.Reason)
+
+	result, err = Verify(bytes.NewReader(writeChain(t, &chain{}, 3)), public, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.BrokenLine)
+
+	// Without a key only the chain is checked.
+	result, err = Verify(bytes.NewReader(writeChain(t, &chain{}, 3)), nil, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+}
+
+func TestVerifierRotatedFiles(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(writeChain(t, &chain{signer: private, checkpointInterval: 4}, 10), []byte("\n"))
+
+	// The end of the first file is covered by the first checkpoint of the second one.
+	v := &Verifier{PublicKey: public, CheckpointInterval: 4}
+	result, err := v.Verify(bytes.NewReader(bytes.Join(lines[:6], nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.NotEmpty(t, v.Finish())
+	result, err = v.Verify(bytes.NewReader(bytes.Join(lines[6:], nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.Equal(t, uint64(7), result.FirstSequence)
+	assert.Empty(t, v.Finish())
+
+	// A missing file breaks the chain.
+	v = &Verifier{PublicKey: public, CheckpointInterval: 4}
+	_, err = v.Verify(bytes.NewReader(bytes.Join(lines[:4], nil)))
+	assert.NoError(t, err)
+	result, err = v.Verify(bytes.NewReader(bytes.Join(lines[6:], nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.BrokenLine)
+	assert.Equal(t, "expected sequence 5, found 7", result.Reason)
+}
+
+func TestChainDetectsTampering(t *testing.T) {
+	log := writeChain(t, &chain{}, 5)
+	lines := bytes.SplitAfter(log, []byte("\n"))
+
+	edited := bytes.Join(lines, nil)
+	edited = bytes.Replace(edited, []byte(`"auditID":"2"`), []byte(`"auditID":"X"`), 1)
+	result, err := Verify(bytes.NewReader(edited), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.BrokenLine)
+	assert.Equal(t, "record 3 does not match its hash", result.Reason)
+
+	removed := bytes.Join(append(append([][]byte{}, lines[:1]...), lines[2:]...), nil)
+	result, err = Verify(bytes.NewReader(removed), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.BrokenLine)
+	assert.Equal(t, "expected sequence 2, found 3", result.Reason)
+
+	// A rotated file starts in the middle of the chain and still verifies on its own.
+	result, err = Verify(bytes.NewReader(bytes.Join(lines[2:], nil)), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.Equal(t, uint64(3), result.FirstSequence)
+}
+
+func TestChainResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	first := &chain{}
+	if err := ioutil.WriteFile(path, writeChain(t, first, 3), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &chain{}
+	assert.NoError(t, second.resume(path))
+	assert.Equal(t, first.sequence, second.sequence)
+	assert.Equal(t, first.lastHash, second.lastHash)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(writeChain(t, second, 2))
+	f.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Verify(bytes.NewReader(data), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.Equal(t, 5, result.Records)
+}
+
+func TestChainResumeAfterTruncatedRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	first := &chain{}
+	records := writeChain(t, first, 3)
+	partial := writeChain(t, &chain{sequence: first.sequence, lastHash: first.lastHash}, 1)
+	if err := ioutil.WriteFile(path, append(records, partial[:len(partial)/2]...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &chain{}
+	assert.NoError(t, second.resume(path))
+	assert.Equal(t, first.sequence, second.sequence)
+	assert.Equal(t, first.lastHash, second.lastHash)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(writeChain(t, second, 2))
+	f.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	// The truncated record is kept and reported, and the records after it still chain on.
+	result, err := Verify(bytes.NewReader(data), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.BrokenLine)
+	assert.Equal(t, 3, result.Records)
+
+	result, err = Verify(bytes.NewReader(bytes.Join(lines[4:], nil)), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.Equal(t, uint64(4), result.FirstSequence)
+	assert.Equal(t, 2, result.Records)
+}
@@ -23,6 +23,7 @@ func NewAuditLogMiddleware(auditWriter *LogWriter) (func(http.Handler) http.Hand
 			next:            next,
 			auditWriter:     auditWriter,
 			sanitizingRegex: sensitiveRegex,
+			policy:          &policyCache{},
 		}
 	}, err
 }
@@ -50,10 +51,11 @@ type auditHandler struct {
 	next            http.Handler
 	auditWriter     *LogWriter
 	sanitizingRegex *regexp.Regexp
+	policy          *policyCache
 }
 
 func (h auditHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	if h.auditWriter == nil {
+	if h.auditWriter == nil || !h.auditWriter.enabled() {
 		h.next.ServeHTTP(rw, req)
 		return
 	}
@@ -63,7 +65,13 @@ func (h auditHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	context := context.WithValue(req.Context(), userKey, user)
 	req = req.WithContext(context)
 
-	auditLog, err := newAuditLog(h.auditWriter, req, h.sanitizingRegex)
+	level := h.policy.get().LevelFor(GetRequestAttributes(req), user, h.auditWriter.Level)
+	if level == levelNull {
+		h.next.ServeHTTP(rw, req)
+		return
+	}
+
+	auditLog, err := newAuditLog(h.auditWriter, level, req, h.sanitizingRegex)
 	if err != nil {
 		util.ReturnHTTPError(rw, req, 500, err.Error())
 		return
@@ -2,37 +2,189 @@
 
 import (
 	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/rancher/rancher/pkg/settings"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 )
 
+// checkpointPeriod is how often a checkpoint is added to the hash chain when records were written since
+// the last one, so the end of the log is signed even when fewer than the checkpoint interval records come in.
+const checkpointPeriod = time.Minute
+
 type LogWriter struct {
-	Level  int
-	Output *lumberjack.Logger
+	Level int
+
+	file  *lumberjack.Logger
+	lock  sync.Mutex
+	sinks []*queuedSink
+	chain *chain
 }
 
-func (l *LogWriter) Start(ctx context.Context) {
+// Start builds the sinks configured in settings and begins delivering entries to them. Entries
+// written before Start or after the context is done are discarded.
+func (l *LogWriter) Start(ctx context.Context, secrets corecontrollers.SecretClient) {
 	if l == nil {
 		return
 	}
+
+	sinks, err := newSinksFromSettings(l.file)
+	if err != nil {
+		logrus.Errorf("audit: failed to configure audit log sinks, audit logging is disabled: %v", err)
+		return
+	}
+
+	var chain *chain
+	if settings.AuditLogHashChain.Get() == "true" {
+		chain, err = l.newChain(secrets)
+		if err != nil {
+			logrus.Errorf("audit: failed to set up audit log hash chain, audit logging is disabled: %v", err)
+			return
+		}
+	}
+
+	for _, sink := range sinks {
+		go sink.run()
+	}
+
+	l.lock.Lock()
+	l.sinks = sinks
+	l.chain = chain
+	l.lock.Unlock()
+
 	go func() {
-		<-ctx.Done()
-		l.Output.Close()
+		ticker := time.NewTicker(checkpointPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.checkpoint()
+			case <-ctx.Done():
+				l.checkpoint()
+				l.lock.Lock()
+				sinks := l.sinks
+				l.sinks = nil
+				l.lock.Unlock()
+				for _, sink := range sinks {
+					sink.stop()
+				}
+				return
+			}
+		}
 	}()
 }
 
+// enabled reports whether any sink is running, so that requests are not buffered for nothing.
+func (l *LogWriter) enabled() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.sinks) > 0
+}
+
+// Write queues a serialized audit entry for every configured sink without blocking. When hash
+// chaining is enabled the entry is sealed first; the lock keeps the records in chain order.
+func (l *LogWriter) Write(entry []byte) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.chain == nil {
+		l.enqueue([][]byte{entry})
+		return
+	}
+	// A sealed record dropped by a full sink would leave a gap in the chain that reads as tampering,
+	// so the entry is dropped before it gets a sequence number. It may be followed by a checkpoint.
+	for _, sink := range l.sinks {
+		if !sink.hasRoom(2) {
+			for _, sink := range l.sinks {
+				sink.drop()
+			}
+			return
+		}
+	}
+	if len(l.sinks) > 0 {
+		l.enqueue(l.chain.seal(entry))
+	}
+}
+
+// checkpoint adds a checkpoint to the hash chain if records were sealed since the last one.
+func (l *LogWriter) checkpoint() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.chain == nil {
+		return
+	}
+	for _, sink := range l.sinks {
+		if !sink.hasRoom(1) {
+			return
+		}
+	}
+	l.enqueue(l.chain.checkpoint())
+}
+
+func (l *LogWriter) enqueue(records [][]byte) {
+	for _, sink := range l.sinks {
+		for _, record := range records {
+			sink.enqueue(record)
+		}
+	}
+}
+
+func (l *LogWriter) newChain(secrets corecontrollers.SecretClient) (*chain, error) {
+	c := &chain{}
+	if l.file != nil {
+		if err := c.resume(l.file.Filename); err != nil {
+			return nil, err
+		}
+	}
+
+	interval := settings.AuditLogCheckpointInterval.GetInt()
+	if interval <= 0 {
+		return c, nil
+	}
+	signer, err := ensureSigningKey(secrets)
+	if err != nil {
+		return nil, err
+	}
+	c.signer = signer
+	c.checkpointInterval = uint64(interval)
+	return c, nil
+}
+
+// NewLogWriter returns nil when auditing is off or no sink can be configured. Settings are not
+// loaded yet when the writer is created, so without an audit log path the other sinks have to be
+// enabled through the environment for the writer to exist.
 func NewLogWriter(path string, level, maxAge, maxBackup, maxSize int) *LogWriter {
-	if path == "" || level == levelNull {
+	if level == levelNull || (path == "" && !hasPathlessSink(os.Getenv(settings.GetEnvKey(settings.AuditLogSinks.Name)))) {
 		return nil
 	}
 
-	return &LogWriter{
+	writer := &LogWriter{
 		Level: level,
-		Output: &lumberjack.Logger{
+	}
+	if path != "" {
+		writer.file = &lumberjack.Logger{
 			Filename:   path,
 			MaxAge:     maxAge,
 			MaxBackups: maxBackup,
 			MaxSize:    maxSize,
-		},
+		}
+	}
+	return writer
+}
+
+// hasPathlessSink reports whether a comma separated sink list enables a sink that does not write
+// to the audit log path.
+func hasPathlessSink(sinks string) bool {
+	for _, name := range strings.Split(sinks, ",") {
+		if name = strings.TrimSpace(name); name != "" && name != sinkFile {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,293 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+var levelNames = map[string]int{
+	"None":            levelNull,
+	"Metadata":        levelMetadata,
+	"Request":         levelRequest,
+	"RequestResponse": levelRequestResponse,
+}
+
+// Policy selects the audit level of a request. Rules are evaluated in order and the first rule
+// matching the request decides its level. Requests that match no rule use the level the audit
+// log was started with.
+type Policy struct {
+	Rules []PolicyRule `json:"rules,omitempty"`
+}
+
+// PolicyRule matches a request on all of its non-empty fields. Within a field any of the listed
+// values may match. Resources and URIPrefixes are alternatives: a rule listing both matches a
+// request if either of them does.
+type PolicyRule struct {
+	// Level is one of None, Metadata, Request or RequestResponse.
+	Level string `json:"level"`
+	// Users are matched against the authenticated user name.
+	Users []string `json:"users,omitempty"`
+	// UserGroups are matched against the groups of the authenticated user.
+	UserGroups []string `json:"userGroups,omitempty"`
+	// Verbs are kubernetes style verbs: get, list, watch, create, update, patch, delete and deletecollection.
+	Verbs []string `json:"verbs,omitempty"`
+	// Resources are plural resource names such as "tokens" or "secrets", or steve types such as
+	// "management.cattle.io.tokens". A "*" matches every resource.
+	Resources []string `json:"resources,omitempty"`
+	// URIPrefixes are matched against the request path.
+	URIPrefixes []string `json:"uriPrefixes,omitempty"`
+	// ClusterIDs are matched against the downstream cluster the request targets, "local" when the
+	// request is not addressed to a downstream cluster.
+	ClusterIDs []string `json:"clusterIDs,omitempty"`
+
+	level int
+}
+
+// ParsePolicy parses and validates a JSON audit policy.
+func ParsePolicy(data []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	for i := range policy.Rules {
+		level, ok := levelNames[policy.Rules[i].Level]
+		if !ok {
+			return nil, fmt.Errorf("rule %d: invalid audit level %q", i, policy.Rules[i].Level)
+		}
+		policy.Rules[i].level = level
+	}
+	return policy, nil
+}
+
+// LevelFor returns the audit level for a request, or defaultLevel if no rule matches.
+func (p *Policy) LevelFor(attrs *RequestAttributes, user *User, defaultLevel int) int {
+	if p == nil {
+		return defaultLevel
+	}
+	for _, rule := range p.Rules {
+		if rule.matches(attrs, user) {
+			return rule.level
+		}
+	}
+	return defaultLevel
+}
+
+func (r *PolicyRule) matches(attrs *RequestAttributes, user *User) bool {
+	if len(r.Users) > 0 && (user == nil || !isExist(r.Users, user.Name)) {
+		return false
+	}
+	if len(r.UserGroups) > 0 && (user == nil || !anyExist(r.UserGroups, user.Group)) {
+		return false
+	}
+	if len(r.Verbs) > 0 && !isExist(r.Verbs, attrs.Verb) {
+		return false
+	}
+	if len(r.ClusterIDs) > 0 && !isExist(r.ClusterIDs, attrs.ClusterID) {
+		return false
+	}
+	if len(r.Resources) == 0 && len(r.URIPrefixes) == 0 {
+		return true
+	}
+	return r.matchesResource(attrs) || r.matchesURI(attrs)
+}
+
+func (r *PolicyRule) matchesResource(attrs *RequestAttributes) bool {
+	if attrs.Resource == "" {
+		return false
+	}
+	for _, resource := range r.Resources {
+		if resource == "*" || resource == attrs.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PolicyRule) matchesURI(attrs *RequestAttributes) bool {
+	for _, prefix := range r.URIPrefixes {
+		if strings.HasPrefix(attrs.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyExist(values, candidates []string) bool {
+	for _, candidate := range candidates {
+		if isExist(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestAttributes are the properties of a request audit policy rules are matched against.
+type RequestAttributes struct {
+	Path      string
+	Verb      string
+	ClusterID string
+	// ProjectID is the <cluster>:<project> the request targets in the norman API, if any.
+	ProjectID string
+	Resource  string
+	Name      string
+}
+
+// GetRequestAttributes derives the verb, target cluster and resource of a request from its URL.
+// It understands the kubernetes API paths (optionally proxied through /k8s/clusters/<id>), the
+// norman /v3 API and the steve /v1 API.
+func GetRequestAttributes(req *http.Request) *RequestAttributes {
+	attrs := &RequestAttributes{
+		Path:      req.URL.Path,
+		ClusterID: "local",
+	}
+
+	parts := splitPath(req.URL.Path)
+	if len(parts) >= 3 && parts[0] == "k8s" && parts[1] == "clusters" {
+		attrs.ClusterID = parts[2]
+		parts = parts[3:]
+	}
+
+	if len(parts) > 0 {
+		switch parts[0] {
+		case "api":
+			// /api/<version>/...
+			parseKubernetesResource(attrs, parts[min(2, len(parts)):])
+		case "apis":
+			// /apis/<group>/<version>/...
+			parseKubernetesResource(attrs, parts[min(3, len(parts)):])
+		case "v3":
+			parseNormanResource(attrs, parts[1:])
+		case "v1":
+			parseSteveResource(attrs, parts[1:])
+		}
+	}
+
+	attrs.Verb = getVerb(req, attrs.Name != "")
+	return attrs
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func parseKubernetesResource(attrs *RequestAttributes, parts []string) {
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	if len(parts) > 0 {
+		attrs.Resource = parts[0]
+	}
+	if len(parts) > 1 {
+		attrs.Name = parts[1]
+	}
+}
+
+func parseNormanResource(attrs *RequestAttributes, parts []string) {
+	// Resources nested below a cluster or project, e.g. /v3/cluster/<id>/namespaces or
+	// /v3/project/<cluster>:<project>/apps.
+	if len(parts) >= 2 {
+		nested := true
+		switch parts[0] {
+		case "clusters", "cluster":
+			attrs.ClusterID = parts[1]
+		case "projects", "project":
+			attrs.ClusterID = strings.SplitN(parts[1], ":", 2)[0]
+			attrs.ProjectID = parts[1]
+		default:
+			nested = false
+		}
+		if nested && len(parts) >= 3 {
+			parts = parts[2:]
+		}
+	}
+	if len(parts) > 0 {
+		attrs.Resource = parts[0]
+	}
+	if len(parts) > 1 {
+		attrs.Name = parts[1]
+	}
+}
+
+func parseSteveResource(attrs *RequestAttributes, parts []string) {
+	if len(parts) > 0 {
+		attrs.Resource = parts[0]
+	}
+	if len(parts) > 1 {
+		attrs.Name = parts[len(parts)-1]
+	}
+}
+
+func getVerb(req *http.Request, named bool) string {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if req.URL.Query().Get("watch") == "true" || strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+			return "watch"
+		}
+		if named {
+			return "get"
+		}
+		return "list"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if named {
+			return "delete"
+		}
+		return "deletecollection"
+	}
+	return strings.ToLower(req.Method)
+}
+
+// policyCache holds the policy parsed from the audit-policy setting, reparsing it only when the
+// setting changes.
+type policyCache struct {
+	lock   sync.Mutex
+	raw    string
+	policy *Policy
+}
+
+func (c *policyCache) get() *Policy {
+	raw := strings.TrimSpace(settings.AuditPolicy.Get())
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if raw == c.raw {
+		return c.policy
+	}
+
+	c.raw = raw
+	c.policy = nil
+	if raw == "" {
+		return nil
+	}
+	policy, err := ParsePolicy([]byte(raw))
+	if err != nil {
+		logrus.Errorf("audit: invalid %s setting, using the default audit level for all requests: %v", settings.AuditPolicy.Name, err)
+		return nil
+	}
+	c.policy = policy
+	return policy
+}
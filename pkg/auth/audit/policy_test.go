@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRequestAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		uri    string
+		want   RequestAttributes
+	}{
+		{
+			name:   "downstream namespaced list",
+			method: "GET",
+			uri:    "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods",
+			want:   RequestAttributes{Path: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods", Verb: "list", ClusterID: "c-abcde", Resource: "pods"},
+		},
+		{
+			name:   "downstream watch",
+			method: "GET",
+			uri:    "/k8s/clusters/c-abcde/apis/apps/v1/deployments?watch=true",
+			want:   RequestAttributes{Path: "/k8s/clusters/c-abcde/apis/apps/v1/deployments", Verb: "watch", ClusterID: "c-abcde", Resource: "deployments"},
+		},
+		{
+			name:   "local rbac update",
+			method: "PUT",
+			uri:    "/apis/rbac.authorization.k8s.io/v1/clusterroles/admin",
+			want:   RequestAttributes{Path: "/apis/rbac.authorization.k8s.io/v1/clusterroles/admin", Verb: "update", ClusterID: "local", Resource: "clusterroles", Name: "admin"},
+		},
+		{
+			name:   "namespace get",
+			method: "GET",
+			uri:    "/api/v1/namespaces/default",
+			want:   RequestAttributes{Path: "/api/v1/namespaces/default", Verb: "get", ClusterID: "local", Resource: "namespaces", Name: "default"},
+		},
+		{
+			name:   "norman token delete",
+			method: "DELETE",
+			uri:    "/v3/tokens/token-xyz",
+			want:   RequestAttributes{Path: "/v3/tokens/token-xyz", Verb: "delete", ClusterID: "local", Resource: "tokens", Name: "token-xyz"},
+		},
+		{
+			name:   "norman project scoped create",
+			method: "POST",
+			uri:    "/v3/project/c-abcde:p-xyz/apps",
+			want:   RequestAttributes{Path: "/v3/project/c-abcde:p-xyz/apps", Verb: "create", ClusterID: "c-abcde", ProjectID: "c-abcde:p-xyz", Resource: "apps"},
+		},
+		{
+			name:   "norman cluster get",
+			method: "GET",
+			uri:    "/v3/clusters/c-abcde",
+			want:   RequestAttributes{Path: "/v3/clusters/c-abcde", Verb: "get", ClusterID: "c-abcde", Resource: "clusters", Name: "c-abcde"},
+		},
+		{
+			name:   "steve list",
+			method: "GET",
+			uri:    "/v1/management.cattle.io.tokens",
+			want:   RequestAttributes{Path: "/v1/management.cattle.io.tokens", Verb: "list", ClusterID: "local", Resource: "management.cattle.io.tokens"},
+		},
+		{
+			name:   "unknown path",
+			method: "GET",
+			uri:    "/healthz",
+			want:   RequestAttributes{Path: "/healthz", Verb: "list", ClusterID: "local"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.uri, nil)
+			assert.Equal(t, &tt.want, GetRequestAttributes(req))
+		})
+	}
+}
+
+func TestPolicyLevelFor(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{"rules": [
+		{"level": "None", "users": ["system:serviceaccount:cattle-system:noisy"]},
+		{"level": "RequestResponse", "resources": ["tokens", "clusterroles", "clusterroletemplatebindings"]},
+		{"level": "RequestResponse", "uriPrefixes": ["/v3/users"], "verbs": ["create", "update"]},
+		{"level": "Metadata", "verbs": ["list", "watch"]},
+		{"level": "Request", "userGroups": ["okta_group://admins"], "clusterIDs": ["c-prod"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &User{Name: "u-abc", Group: []string{"system:authenticated", "okta_group://admins"}}
+	tests := []struct {
+		name   string
+		method string
+		uri    string
+		user   *User
+		want   int
+	}{
+		{
+			name:   "ignored user",
+			method: "GET",
+			uri:    "/v3/tokens/token-xyz",
+			user:   &User{Name: "system:serviceaccount:cattle-system:noisy"},
+			want:   levelNull,
+		},
+		{
+			name:   "token read",
+			method: "GET",
+			uri:    "/v3/tokens/token-xyz",
+			user:   user,
+			want:   levelRequestResponse,
+		},
+		{
+			name:   "user creation by prefix",
+			method: "POST",
+			uri:    "/v3/users",
+			user:   user,
+			want:   levelRequestResponse,
+		},
+		{
+			name:   "user list does not match verbs",
+			method: "GET",
+			uri:    "/v3/users",
+			user:   user,
+			want:   levelMetadata,
+		},
+		{
+			name:   "group and cluster match",
+			method: "POST",
+			uri:    "/k8s/clusters/c-prod/api/v1/namespaces/default/configmaps",
+			user:   user,
+			want:   levelRequest,
+		},
+		{
+			name:   "no rule matches",
+			method: "POST",
+			uri:    "/k8s/clusters/c-dev/api/v1/namespaces/default/configmaps",
+			user:   user,
+			want:   levelRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.uri, nil)
+			assert.Equal(t, tt.want, policy.LevelFor(GetRequestAttributes(req), tt.user, levelRequest))
+		})
+	}
+}
+
+func TestParsePolicyInvalidLevel(t *testing.T) {
+	_, err := ParsePolicy([]byte(`{"rules": [{"level": "Everything"}]}`))
+	assert.EqualError(t, err, `rule 0: invalid audit level "Everything"`)
+}
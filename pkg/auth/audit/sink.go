@@ -0,0 +1,362 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	sinkFile    = "file"
+	sinkStdout  = "stdout"
+	sinkWebhook = "webhook"
+	sinkSyslog  = "syslog"
+
+	defaultQueueSize = 10000
+	maxSinkBatch     = 500
+
+	// syslogPriority is facility local0 (16) with severity informational (6).
+	syslogPriority = 16*8 + 6
+	syslogAppName  = "rancher-audit"
+)
+
+// AuditSink is a destination for audit log entries. Every entry is a single compact JSON
+// document terminated by a newline. Write is only ever called from one goroutine per sink.
+type AuditSink interface {
+	Write(entries [][]byte) error
+	Close() error
+}
+
+// queuedSink decouples an AuditSink from the request path. Entries are buffered in a bounded
+// channel and dropped once it is full, so a slow or unreachable sink never blocks a request.
+type queuedSink struct {
+	name      string
+	sink      AuditSink
+	batchSize int
+	queue     chan []byte
+	done      chan struct{}
+
+	dropLock sync.Mutex
+	dropped  int
+}
+
+func newQueuedSink(name string, sink AuditSink, queueSize, batchSize int) *queuedSink {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	return &queuedSink{
+		name:      name,
+		sink:      sink,
+		batchSize: batchSize,
+		queue:     make(chan []byte, queueSize),
+		done:      make(chan struct{}),
+	}
+}
+
+func (q *queuedSink) enqueue(entry []byte) {
+	select {
+	case q.queue <- entry:
+	default:
+		q.drop()
+	}
+}
+
+// hasRoom reports whether count entries can be queued without dropping any. Only the writer adds
+// entries, so the answer holds until it enqueues them.
+func (q *queuedSink) hasRoom(count int) bool {
+	return cap(q.queue)-len(q.queue) >= count
+}
+
+func (q *queuedSink) drop() {
+	q.dropLock.Lock()
+	q.dropped++
+	q.dropLock.Unlock()
+}
+
+func (q *queuedSink) run() {
+	defer close(q.done)
+	for entry := range q.queue {
+		batch := [][]byte{entry}
+	drain:
+		for len(batch) < q.batchSize {
+			select {
+			case next, ok := <-q.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		if err := q.sink.Write(batch); err != nil {
+			logrus.Errorf("audit: failed to write %d entries to %s sink: %v", len(batch), q.name, err)
+		}
+		q.reportDropped()
+	}
+	if err := q.sink.Close(); err != nil {
+		logrus.Errorf("audit: failed to close %s sink: %v", q.name, err)
+	}
+}
+
+func (q *queuedSink) reportDropped() {
+	q.dropLock.Lock()
+	dropped := q.dropped
+	q.dropped = 0
+	q.dropLock.Unlock()
+	if dropped > 0 {
+		logrus.Warnf("audit: %s sink queue is full, dropped %d entries", q.name, dropped)
+	}
+}
+
+// stop closes the queue and waits for the remaining entries to be flushed.
+func (q *queuedSink) stop() {
+	close(q.queue)
+	<-q.done
+}
+
+// newSinksFromSettings builds the sinks listed in the audit-log-sinks setting.
+func newSinksFromSettings(file *lumberjack.Logger) ([]*queuedSink, error) {
+	queueSize := settings.AuditLogQueueSize.GetInt()
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	var result []*queuedSink
+	for _, name := range strings.Split(settings.AuditLogSinks.Get(), ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case sinkFile:
+			if file == nil {
+				logrus.Warnf("audit: %s sink is enabled but no audit log path is set, skipping", sinkFile)
+				continue
+			}
+			result = append(result, newQueuedSink(name, &writerSink{out: file}, queueSize, maxSinkBatch))
+		case sinkStdout:
+			result = append(result, newQueuedSink(name, &writerSink{out: nopCloser{os.Stdout}}, queueSize, maxSinkBatch))
+		case sinkWebhook:
+			sink, err := newWebhookSink(settings.AuditLogWebhookURL.Get(), settings.AuditLogWebhookMaxRetries.GetInt())
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, newQueuedSink(name, sink, queueSize, settings.AuditLogWebhookBatchSize.GetInt()))
+		case sinkSyslog:
+			sink, err := newSyslogSink(settings.AuditLogSyslogAddress.Get(), settings.AuditLogSyslogTLS.Get() == "true", settings.AuditLogSyslogCACerts.Get())
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, newQueuedSink(name, sink, queueSize, maxSinkBatch))
+		default:
+			return nil, fmt.Errorf("unknown audit log sink %q", name)
+		}
+	}
+	return result, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// writerSink writes entries to a local io.WriteCloser such as the rotating audit log file or stdout.
+type writerSink struct {
+	out io.WriteCloser
+}
+
+func (w *writerSink) Write(entries [][]byte) error {
+	for _, entry := range entries {
+		if _, err := w.out.Write(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writerSink) Close() error {
+	return w.out.Close()
+}
+
+// webhookSink posts batches of entries to an HTTP endpoint as a JSON array, retrying
+// failed requests with exponential backoff.
+type webhookSink struct {
+	url     string
+	client  *http.Client
+	backoff wait.Backoff
+}
+
+func newWebhookSink(url string, maxRetries int) (*webhookSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("audit log %s sink requires %s to be set", sinkWebhook, settings.AuditLogWebhookURL.Name)
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return &webhookSink{
+		url: url,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		backoff: wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Jitter:   0.1,
+			Steps:    maxRetries + 1,
+			Cap:      time.Minute,
+		},
+	}, nil
+}
+
+func (w *webhookSink) Write(entries [][]byte) error {
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i, entry := range entries {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(bytes.TrimSuffix(entry, []byte("\n")))
+	}
+	body.WriteByte(']')
+
+	var lastErr error
+	err := wait.ExponentialBackoff(w.backoff, func() (bool, error) {
+		lastErr = w.post(body.Bytes())
+		return lastErr == nil, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	return err
+}
+
+func (w *webhookSink) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *webhookSink) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// syslogSink sends entries as RFC 5424 messages over TCP, optionally wrapped in TLS, using
+// the octet-counting framing from RFC 6587. The connection is re-established on failure.
+type syslogSink struct {
+	address   string
+	tlsConfig *tls.Config
+	hostname  string
+	conn      net.Conn
+}
+
+func newSyslogSink(address string, useTLS bool, caCerts string) (*syslogSink, error) {
+	if address == "" {
+		return nil, fmt.Errorf("audit log %s sink requires %s to be set", sinkSyslog, settings.AuditLogSyslogAddress.Name)
+	}
+
+	s := &syslogSink{
+		address: address,
+	}
+	s.hostname, _ = os.Hostname()
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+
+	if useTLS {
+		s.tlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		if caCerts != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(caCerts)) {
+				return nil, fmt.Errorf("failed to parse %s", settings.AuditLogSyslogCACerts.Name)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	}
+	return s, nil
+}
+
+func (s *syslogSink) connect() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if s.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	}
+	return dialer.Dial("tcp", s.address)
+}
+
+func (s *syslogSink) Write(entries [][]byte) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		msg := formatSyslogMessage(time.Now(), s.hostname, entry)
+		fmt.Fprintf(&buf, "%d %s", len(msg), msg)
+	}
+
+	// Retry once on a fresh connection in case the previous one was closed by the server.
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			conn, err := s.connect()
+			if err != nil {
+				return errors.Wrapf(err, "failed to connect to syslog server %s", s.address)
+			}
+			s.conn = conn
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		if _, err := s.conn.Write(buf.Bytes()); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			if attempt == 1 {
+				return err
+			}
+			continue
+		}
+		return nil
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// formatSyslogMessage renders an RFC 5424 message with no structured data.
+func formatSyslogMessage(ts time.Time, hostname string, entry []byte) []byte {
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d - - %s", syslogPriority, ts.UTC().Format(time.RFC3339Nano),
+		hostname, syslogAppName, os.Getpid(), bytes.TrimSuffix(entry, []byte("\n"))))
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+type recordingSink struct {
+	lock    sync.Mutex
+	block   chan struct{}
+	entries []string
+}
+
+func (r *recordingSink) Write(entries [][]byte) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, entry := range entries {
+		r.entries = append(r.entries, string(entry))
+	}
+	return nil
+}
+
+func (r *recordingSink) Close() error {
+	return nil
+}
+
+func TestNewLogWriterWithoutSink(t *testing.T) {
+	if sinks, ok := os.LookupEnv("CATTLE_AUDIT_LOG_SINKS"); ok {
+		defer os.Setenv("CATTLE_AUDIT_LOG_SINKS", sinks)
+	} else {
+		defer os.Unsetenv("CATTLE_AUDIT_LOG_SINKS")
+	}
+
+	os.Setenv("CATTLE_AUDIT_LOG_SINKS", "")
+	assert.Nil(t, NewLogWriter("", levelMetadata, 10, 10, 100))
+	assert.NotNil(t, NewLogWriter("/var/log/auditlog/rancher-api-audit.log", levelMetadata, 10, 10, 100))
+
+	os.Setenv("CATTLE_AUDIT_LOG_SINKS", "file, webhook")
+	assert.NotNil(t, NewLogWriter("", levelMetadata, 10, 10, 100))
+}
+
+func TestQueuedSinkDropsWhenFull(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	q := newQueuedSink("test", sink, 2, 10)
+	go q.run()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			q.enqueue([]byte("entry\n"))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("enqueue blocked on a slow sink")
+	}
+
+	close(sink.block)
+	q.stop()
+
+	assert.True(t, len(sink.entries) > 0)
+	assert.True(t, len(sink.entries) < 10, "expected entries to be dropped, got %d", len(sink.entries))
+}
+
+func TestLogWriterDropsBeforeSealing(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	q := newQueuedSink("test", sink, 3, 10)
+	go q.run()
+	l := &LogWriter{sinks: []*queuedSink{q}, chain: &chain{}}
+
+	for i := 0; i < 10; i++ {
+		l.Write([]byte(`{"auditID":"1"}` + "\n"))
+	}
+	close(sink.block)
+	q.stop()
+
+	// The entries dropped never got a sequence number, so the chain has no gaps.
+	assert.True(t, len(sink.entries) < 10, "expected entries to be dropped, got %d", len(sink.entries))
+	result, err := Verify(strings.NewReader(strings.Join(sink.entries, "")), nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.BrokenLine, result.Reason)
+	assert.Equal(t, len(sink.entries), result.Records)
+}
+
+func TestWebhookSinkRetriesAndBatches(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		attempts int
+		received []map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("webhook body is not a JSON array: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := newWebhookSink(server.URL, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 4}
+
+	err = sink.Write([][]byte{[]byte(`{"auditID":"1"}` + "\n"), []byte(`{"auditID":"2"}` + "\n")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, received, 2)
+	assert.Equal(t, "2", received[1]["auditID"])
+}
+
+func TestWebhookSinkGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink, err := newWebhookSink(server.URL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 2}
+
+	err = sink.Write([][]byte{[]byte(`{}`)})
+	assert.EqualError(t, err, "webhook returned status 500")
+}
+
+func TestFormatSyslogMessage(t *testing.T) {
+	ts := time.Date(2021, 8, 16, 10, 0, 0, 0, time.UTC)
+	msg := string(formatSyslogMessage(ts, "rancher-0", []byte(`{"auditID":"1"}`+"\n")))
+
+	assert.True(t, strings.HasPrefix(msg, "<134>1 2021-08-16T10:00:00Z rancher-0 rancher-audit "), msg)
+	assert.True(t, strings.HasSuffix(msg, ` - - {"auditID":"1"}`), msg)
+}
@@ -0,0 +1,292 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/urfave/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// VerifyResult describes the outcome of walking a hash-chained audit log.
+type VerifyResult struct {
+	Records     int
+	Checkpoints int
+	// UnverifiedCheckpoints counts checkpoints whose signature was not checked because no public
+	// key was given.
+	UnverifiedCheckpoints int
+	FirstSequence         uint64
+	LastSequence          uint64
+	LastHash              string
+	// BrokenLine is the 1-based line number of the first record that fails verification, or 0
+	// if the whole log verified.
+	BrokenLine int
+	Reason     string
+
+	lines int
+}
+
+func (v *VerifyResult) broken(line int, format string, args ...interface{}) *VerifyResult {
+	v.BrokenLine = line
+	v.Reason = fmt.Sprintf(format, args...)
+	return v
+}
+
+// Verifier walks audit logs written with hash chaining. The files of a rotated log are verified
+// one after the other, in order, with the same Verifier, so the chain carries over from one file
+// to the next. The first record verified anchors the chain; to detect truncation at the start,
+// verify consecutive files in order.
+type Verifier struct {
+	// PublicKey verifies the checkpoint signatures. Without it signatures are not checked and no
+	// checkpoints are required.
+	PublicKey ed25519.PublicKey
+	// CheckpointInterval is the most records the log may hold without a signed checkpoint.
+	CheckpointInterval uint64
+
+	started      bool
+	lastSequence uint64
+	lastHash     string
+	unsigned     uint64
+}
+
+// Verify checks the records read from r and reports the first one whose hash, sequence number,
+// link to the previous record or checkpoint signature does not check out, or that follows more
+// than CheckpointInterval records without a signed checkpoint.
+func (v *Verifier) Verify(r io.Reader) (*VerifyResult, error) {
+	result := &VerifyResult{}
+	reader := bufio.NewReader(r)
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\n")
+		if len(line) == 0 {
+			if err == io.EOF {
+				return result, nil
+			}
+			continue
+		}
+		result.lines = lineNumber
+
+		fields := chainFields{}
+		if jsonErr := json.Unmarshal(line, &fields); jsonErr != nil {
+			return result.broken(lineNumber, "record is not valid JSON: %v", jsonErr), nil
+		}
+
+		i := bytes.LastIndex(line, []byte(hashField))
+		if i < 0 || fields.Hash == "" {
+			return result.broken(lineNumber, "record has no hash"), nil
+		}
+		sum := sha256.Sum256(append(append([]byte{}, line[:i]...), '}'))
+		if hex.EncodeToString(sum[:]) != fields.Hash {
+			return result.broken(lineNumber, "record %d does not match its hash", fields.Sequence), nil
+		}
+
+		if !v.started {
+			if fields.Sequence == 1 && fields.PreviousHash != "" {
+				return result.broken(lineNumber, "first record of the chain links to a previous record"), nil
+			}
+			v.started = true
+		} else {
+			if fields.Sequence != v.lastSequence+1 {
+				return result.broken(lineNumber, "expected sequence %d, found %d", v.lastSequence+1, fields.Sequence), nil
+			}
+			if fields.PreviousHash != v.lastHash {
+				return result.broken(lineNumber, "record %d does not link to record %d", fields.Sequence, v.lastSequence), nil
+			}
+		}
+		if result.Records == 0 {
+			result.FirstSequence = fields.Sequence
+		}
+
+		if fields.Type == checkpointType {
+			result.Checkpoints++
+			if v.PublicKey == nil {
+				result.UnverifiedCheckpoints++
+			} else {
+				signature, decodeErr := base64.StdEncoding.DecodeString(fields.Signature)
+				if decodeErr != nil || !ed25519.Verify(v.PublicKey, sum[:], signature) {
+					return result.broken(lineNumber, "checkpoint %d has an invalid signature", fields.Sequence), nil
+				}
+			}
+			v.unsigned = 0
+		} else {
+			v.unsigned++
+			if v.PublicKey != nil && v.unsigned > v.CheckpointInterval {
+				return result.broken(lineNumber, "record %d follows %d records without a signed checkpoint", fields.Sequence, v.CheckpointInterval), nil
+			}
+		}
+
+		result.Records++
+		result.LastSequence = fields.Sequence
+		result.LastHash = fields.Hash
+		v.lastSequence = fields.Sequence
+		v.lastHash = fields.Hash
+
+		if err == io.EOF {
+			return result, nil
+		}
+	}
+}
+
+// Finish reports the records at the end of the log that no signed checkpoint covers. Anyone able to
+// write the log could have replaced them along with their hashes.
+func (v *Verifier) Finish() string {
+	if v.PublicKey == nil || v.unsigned == 0 {
+		return ""
+	}
+	return fmt.Sprintf("log ends with %d records after the last signed checkpoint", v.unsigned)
+}
+
+// Verify walks a complete audit log written with hash chaining, see Verifier.
+func Verify(r io.Reader, publicKey ed25519.PublicKey, checkpointInterval uint64) (*VerifyResult, error) {
+	v := &Verifier{PublicKey: publicKey, CheckpointInterval: checkpointInterval}
+	result, err := v.Verify(r)
+	if err != nil || result.BrokenLine > 0 {
+		return result, err
+	}
+	if reason := v.Finish(); reason != "" {
+		return result.broken(result.lines+1, "%s", reason), nil
+	}
+	return result, nil
+}
+
+func RegisterVerifyCommand() {
+	reexec.Register("/usr/bin/verify-audit-log", verifyAuditLog)
+	reexec.Register("verify-audit-log", verifyAuditLog)
+}
+
+func verifyAuditLog() {
+	app := cli.NewApp()
+	app.Usage = "Verify the hash chain and checkpoint signatures of Rancher API audit log files"
+	app.ArgsUsage = "FILE..."
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "public-key",
+			Usage: "Base64 encoded ed25519 public key used to verify checkpoints. Read from the " + SigningKeySecretName + " secret if not set",
+		},
+		cli.BoolFlag{
+			Name:  "skip-signatures",
+			Usage: "Only verify the hash chain, not the checkpoint signatures",
+		},
+		cli.IntFlag{
+			Name:  "checkpoint-interval",
+			Usage: "Most records allowed without a signed checkpoint, the audit-log-checkpoint-interval setting the log was written with",
+			Value: 1000,
+		},
+	}
+
+	app.Action = func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return fmt.Errorf("at least one audit log file is required")
+		}
+
+		var publicKey ed25519.PublicKey
+		if !c.Bool("skip-signatures") {
+			var err error
+			publicKey, err = getVerifyPublicKey(c.String("public-key"))
+			if err != nil {
+				return err
+			}
+		}
+
+		interval := c.Int("checkpoint-interval")
+		if interval <= 0 && publicKey != nil {
+			return fmt.Errorf("checkpoint-interval must be positive unless signatures are skipped")
+		}
+		newVerifier := func() *Verifier {
+			return &Verifier{PublicKey: publicKey, CheckpointInterval: uint64(interval)}
+		}
+
+		// The files are one chain, the records at the end of a rotated file are covered by the first
+		// checkpoint of the next one.
+		failed := false
+		verifier := newVerifier()
+		for i, path := range c.Args() {
+			result, err := verifyFile(verifier, path)
+			if err != nil {
+				return err
+			}
+			if result.BrokenLine == 0 && i == c.NArg()-1 {
+				if reason := verifier.Finish(); reason != "" {
+					result.broken(result.lines+1, "%s", reason)
+				}
+			}
+			if result.BrokenLine > 0 {
+				failed = true
+				fmt.Fprintf(os.Stdout, "%s: FAILED at line %d: %s\n", path, result.BrokenLine, result.Reason)
+				verifier = newVerifier()
+				continue
+			}
+			fmt.Fprintf(os.Stdout, "%s: OK, %d records (sequence %d-%d), %d checkpoints\n", path, result.Records,
+				result.FirstSequence, result.LastSequence, result.Checkpoints)
+		}
+		if failed {
+			return fmt.Errorf("audit log verification failed")
+		}
+		return nil
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func verifyFile(verifier *Verifier, path string) (*VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return verifier.Verify(f)
+}
+
+func getVerifyPublicKey(encoded string) (ed25519.PublicKey, error) {
+	var key []byte
+	if encoded != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %v", err)
+		}
+		key = decoded
+	} else {
+		kubeConfigPath := os.ExpandEnv("$HOME/.kube/config")
+		if _, err := os.Stat(kubeConfigPath); err != nil {
+			kubeConfigPath = ""
+		}
+		conf, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't get kubeconfig. %v", err)
+		}
+		client, err := kubernetes.NewForConfig(conf)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't get kubernetes client. %v", err)
+		}
+		secret, err := client.CoreV1().Secrets(namespace.System).Get(context.Background(), SigningKeySecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't get audit log signing key. %v", err)
+		}
+		key = secret.Data[signingKeyPublic]
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
@@ -11,6 +11,7 @@
 	"github.com/pkg/errors"
 	"github.com/rancher/norman/httperror"
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/oidc"
 	"github.com/sirupsen/logrus"
 )
 
@@ -102,6 +103,50 @@ func (k *KeyCloakClient) groupSearch(searchTerm string, sURL string) ([]account,
 	return accounts, nil
 }
 
+// groupByMappedName returns the group the group filters and rename rules map to name. Without
+// rename rules that is the group of that name, otherwise all groups are searched for the one that
+// is renamed to it. An empty account is returned if there is none.
+func (k *KeyCloakClient) groupByMappedName(name string, mapping *oidc.GroupMapping, config *v32.OIDCConfig) (account, error) {
+	sURL, err := getSearchURL(config.Issuer)
+	if err != nil {
+		return account{}, err
+	}
+	searchTerm := name
+	if mapping.Renames() {
+		searchTerm = ""
+	}
+	accounts, err := k.groupSearch(searchTerm, sURL)
+	if err != nil {
+		return account{}, err
+	}
+	for _, acct := range accounts {
+		if mapped, ok := mapping.Map(acct.Name); ok && mapped == name {
+			acct.Name = mapped
+			return acct, nil
+		}
+	}
+	return account{}, nil
+}
+
+// mapGroupAccounts applies the group filters and rename rules to the groups among accounts, so
+// they match the group principals users get at login.
+func mapGroupAccounts(accounts []account, mapping *oidc.GroupMapping) []account {
+	var result []account
+	seen := map[string]bool{}
+	for _, acct := range accounts {
+		if acct.Type == GroupType {
+			name, ok := mapping.Map(acct.Name)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			acct.Name = name
+		}
+		result = append(result, acct)
+	}
+	return result
+}
+
 func filterByGroupName(name string, accounts []account) account {
 	for _, group := range accounts {
 		if group.Name == name {
@@ -0,0 +1,52 @@
+package keycloakoidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/oidc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupMappingInSearch(t *testing.T) {
+	mapping, err := oidc.NewGroupMapping(&v32.OIDCConfig{
+		GroupsExclude:    []string{"-test$"},
+		GroupRenameRules: []v32.GroupRenameRule{{Pattern: "^rancher-(.*)$", Replacement: "$1"}},
+	})
+	assert.NoError(t, err)
+
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/auth/admin/realms/test/groups", req.URL.Path)
+		searches = append(searches, req.URL.Query().Get("search"))
+		rw.Write([]byte(`[
+			{"id": "1", "name": "rancher-admins", "subGroups": [{"id": "2", "name": "rancher-dev-test"}]},
+			{"id": "3", "name": "hr"}
+		]`))
+	}))
+	defer server.Close()
+	config := &v32.OIDCConfig{Issuer: server.URL + "/auth/realms/test"}
+	client := &KeyCloakClient{httpClient: server.Client()}
+
+	// Searching yields the same group names users get at login.
+	accounts, err := client.groupSearch("a", server.URL+"/auth/admin/realms/test")
+	assert.NoError(t, err)
+	accounts = mapGroupAccounts(append(accounts, account{ID: "u1", Name: "rancher-user", Type: UserType}), mapping)
+	var names []string
+	for _, acct := range accounts {
+		names = append(names, acct.Name)
+	}
+	assert.Equal(t, []string{"admins", "hr", "rancher-user"}, names)
+
+	// Looking up a group principal resolves the renamed name to the keycloak group.
+	acct, err := client.groupByMappedName("admins", mapping, config)
+	assert.NoError(t, err)
+	assert.Equal(t, account{ID: "1", Name: "admins", Type: GroupType}, acct)
+
+	acct, err = client.groupByMappedName("dev-test", mapping, config)
+	assert.NoError(t, err)
+	assert.Equal(t, account{}, acct)
+	assert.Equal(t, []string{"a", "", ""}, searches)
+}
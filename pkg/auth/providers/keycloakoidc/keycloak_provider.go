@@ -80,6 +80,10 @@ func (k *keyCloakOIDCProvider) SearchPrincipals(searchValue, principalType strin
 	if err != nil {
 		return principals, err
 	}
+	mapping, err := oidc.NewGroupMapping(config)
+	if err != nil {
+		return principals, err
+	}
 	storedOauthToken, err := k.TokenMGR.GetSecret(token.UserID, token.AuthProvider, []*v3.Token{&token})
 	if err := json.Unmarshal([]byte(storedOauthToken), &oauthToken); err != nil {
 		return principals, err
@@ -100,7 +104,7 @@ func (k *keyCloakOIDCProvider) SearchPrincipals(searchValue, principalType strin
 		logrus.Errorf("[keycloak oidc] SearchPrincipals: problem searching keycloak: %v", err)
 		return principals, err
 	}
-	for _, acct := range accts {
+	for _, acct := range mapGroupAccounts(accts, mapping) {
 		p := k.toPrincipal(acct.Type, acct, &token)
 		principals = append(principals, p)
 	}
@@ -142,6 +146,10 @@ func (k *keyCloakOIDCProvider) GetPrincipal(principalID string, token v3.Token)
 	if err != nil {
 		return v3.Principal{}, err
 	}
+	mapping, err := oidc.NewGroupMapping(config)
+	if err != nil {
+		return v3.Principal{}, err
+	}
 	storedOauthToken, err := k.TokenMGR.GetSecret(token.UserID, token.AuthProvider, []*v3.Token{&token})
 	if err := json.Unmarshal([]byte(storedOauthToken), &oauthToken); err != nil {
 		return v3.Principal{}, err
@@ -168,7 +176,13 @@ func (k *keyCloakOIDCProvider) GetPrincipal(principalID string, token v3.Token)
 		logrus.Errorf("[keycloak oidc] GetPrincipal: error creating new http client: %v", err)
 		return v3.Principal{}, err
 	}
-	acct, err := keyCloakClient.getFromKeyCloakByID(externalID, principalType, config)
+	var acct account
+	if principalType == GroupType {
+		// group principals carry the name the group filters and rename rules give the group
+		acct, err = keyCloakClient.groupByMappedName(externalID, mapping, config)
+	} else {
+		acct, err = keyCloakClient.getFromKeyCloakByID(externalID, principalType, config)
+	}
 	if err != nil {
 		return v3.Principal{}, err
 	}
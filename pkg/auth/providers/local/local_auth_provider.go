@@ -4,6 +4,7 @@
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 
@@ -13,9 +14,12 @@
 	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/rbac"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
@@ -28,16 +32,25 @@
 	userSearchIndex       = "authn.management.cattle.io/user-search-index"
 	groupSearchIndex      = "authn.management.cattle.io/group-search-index"
 	searchIndexDefaultLen = 6
+
+	// ExternalGroupLabel marks Group objects that hold a group of an external provider, as
+	// provisioned through SCIM, rather than a local group.
+	ExternalGroupLabel = "authn.management.cattle.io/external-group"
 )
 
 type Provider struct {
-	userLister   v3.UserLister
-	groupLister  v3.GroupLister
-	userIndexer  cache.Indexer
-	gmIndexer    cache.Indexer
-	groupIndexer cache.Indexer
-	tokenMGR     *tokens.Manager
-	invalidHash  []byte
+	users               v3.UserInterface
+	userLister          v3.UserLister
+	userAttributes      v3.UserAttributeInterface
+	userAttributeLister v3.UserAttributeLister
+	groupLister         v3.GroupLister
+	grbLister           v3.GlobalRoleBindingLister
+	userIndexer         cache.Indexer
+	gmIndexer           cache.Indexer
+	groupIndexer        cache.Indexer
+	tokenMGR            *tokens.Manager
+	mfa                 *MFA
+	invalidHash         []byte
 }
 
 func Configure(ctx context.Context, mgmtCtx *config.ScaledContext, tokenMGR *tokens.Manager) common.AuthProvider {
@@ -56,13 +69,18 @@ func Configure(ctx context.Context, mgmtCtx *config.ScaledContext, tokenMGR *tok
 	invalidHash, _ := bcrypt.GenerateFromPassword([]byte("invalid"), bcrypt.DefaultCost)
 
 	l := &Provider{
-		userIndexer:  informer.GetIndexer(),
-		gmIndexer:    gmInformer.GetIndexer(),
-		groupLister:  mgmtCtx.Management.Groups("").Controller().Lister(),
-		groupIndexer: gInformer.GetIndexer(),
-		userLister:   mgmtCtx.Management.Users("").Controller().Lister(),
-		tokenMGR:     tokenMGR,
-		invalidHash:  invalidHash,
+		userIndexer:         informer.GetIndexer(),
+		gmIndexer:           gmInformer.GetIndexer(),
+		groupLister:         mgmtCtx.Management.Groups("").Controller().Lister(),
+		groupIndexer:        gInformer.GetIndexer(),
+		grbLister:           mgmtCtx.Management.GlobalRoleBindings("").Controller().Lister(),
+		users:               mgmtCtx.Management.Users(""),
+		userLister:          mgmtCtx.Management.Users("").Controller().Lister(),
+		userAttributes:      mgmtCtx.Management.UserAttributes(""),
+		userAttributeLister: mgmtCtx.Management.UserAttributes("").Controller().Lister(),
+		tokenMGR:            tokenMGR,
+		mfa:                 NewMFA(mgmtCtx.Core.Secrets(""), mgmtCtx.Core.Secrets("").Controller().Lister()),
+		invalidHash:         invalidHash,
 	}
 	return l
 }
@@ -119,10 +137,43 @@ func (l *Provider) AuthenticateUser(ctx context.Context, input interface{}) (v3.
 		return v3.Principal{}, nil, "", authFailedError
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(pwd)); err != nil {
-		logrus.Debugf("Authentication failed for User [%s]: %v", username, err)
+	policy := GetPasswordPolicy()
+	attribs, err := l.userAttributeLister.Get("", user.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return v3.Principal{}, nil, "", err
+	}
+
+	// The password is compared even for locked users so a lockout cannot be told apart from a
+	// wrong password by timing.
+	passwordErr := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(pwd))
+	if Locked(attribs) {
+		logrus.Infof("Authentication refused for User [%s]: account is locked until %s", username, attribs.LockedUntil)
 		return v3.Principal{}, nil, "", authFailedError
 	}
+	if passwordErr != nil {
+		logrus.Debugf("Authentication failed for User [%s]: %v", username, passwordErr)
+		l.recordFailedLogin(user, policy)
+		return v3.Principal{}, nil, "", authFailedError
+	}
+	if err := l.checkMFA(user, localInput.MFACode, policy); err != nil {
+		return v3.Principal{}, nil, "", err
+	}
+	if attribs != nil && (attribs.FailedLoginAttempts > 0 || attribs.LockedUntil != "") {
+		if err := UpdateUserAttribute(l.tokenMGR, l.userAttributes, user.Name, func(attribs *v32.UserAttribute) {
+			attribs.FailedLoginAttempts = 0
+			attribs.LockedUntil = ""
+		}); err != nil {
+			logrus.Errorf("Failed to reset failed login attempts of User [%s]: %v", username, err)
+		}
+	}
+
+	if policy.Expired(user, attribs) && !user.MustChangePassword {
+		user = user.DeepCopy()
+		user.MustChangePassword = true
+		if _, err := l.users.Update(user); err != nil {
+			return v3.Principal{}, nil, "", errors.Wrapf(err, "failed to require a password change for expired password of %v", user.Name)
+		}
+	}
 
 	principalID := getLocalPrincipalID(user)
 	userPrincipal := l.toPrincipal("user", user.DisplayName, user.Username, principalID, nil)
@@ -136,6 +187,76 @@ func (l *Provider) AuthenticateUser(ctx context.Context, input interface{}) (v3.
 	return userPrincipal, groupPrincipals, "", nil
 }
 
+// recordFailedLogin counts a failed login and locks the user once the policy's limit is reached.
+func (l *Provider) recordFailedLogin(user *v3.User, policy PasswordPolicy) {
+	if policy.MaxFailedAttempts <= 0 {
+		return
+	}
+	err := UpdateUserAttribute(l.tokenMGR, l.userAttributes, user.Name, func(attribs *v32.UserAttribute) {
+		attribs.FailedLoginAttempts++
+		if attribs.FailedLoginAttempts >= policy.MaxFailedAttempts {
+			attribs.FailedLoginAttempts = 0
+			attribs.LockedUntil = time.Now().Add(policy.LockoutDuration).UTC().Format(time.RFC3339)
+			logrus.Infof("Locking User [%s] until %s after %d failed login attempts", user.Username, attribs.LockedUntil, policy.MaxFailedAttempts)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("Failed to record failed login attempt of User [%s]: %v", user.Username, err)
+	}
+}
+
+// checkMFA is the second step of a local login. Users enrolled in TOTP must supply a valid code
+// or recovery code. Admins that still have to enroll are let in, the login handler restricts
+// their session to the enrollment, see MFAEnrollmentRequired.
+func (l *Provider) checkMFA(user *v3.User, code string, policy PasswordPolicy) error {
+	enrolled, err := l.mfa.Enrolled(user.Name)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return nil
+	}
+
+	if code == "" {
+		return httperror.NewAPIError(MFARequired, "multi-factor authentication code required")
+	}
+	ok, err := l.mfa.Verify(user.Name, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		logrus.Debugf("Authentication failed for User [%s]: invalid multi-factor authentication code", user.Username)
+		l.recordFailedLogin(user, policy.mfaLockoutPolicy())
+		return httperror.NewAPIError(httperror.Unauthorized, "authentication failed")
+	}
+	return nil
+}
+
+// MFAEnrollmentRequired reports whether the user is an admin that has to enroll in multi-factor
+// authentication before getting a regular session.
+func (l *Provider) MFAEnrollmentRequired(user *v3.User) (bool, error) {
+	if settings.MFARequiredForAdmins.Get() != "true" || !l.isAdmin(user) {
+		return false, nil
+	}
+	enrolled, err := l.mfa.Enrolled(user.Name)
+	return !enrolled, err
+}
+
+func (l *Provider) isAdmin(user *v3.User) bool {
+	grbs, err := l.grbLister.List("", labels.Everything())
+	if err != nil {
+		logrus.Errorf("Failed to list global role bindings of User [%s]: %v", user.Username, err)
+		// Err on the side of requiring MFA.
+		return true
+	}
+	for _, grb := range grbs {
+		if grb.UserName == user.Name && (grb.GlobalRoleName == rbac.GlobalAdmin || grb.GlobalRoleName == rbac.GlobalRestrictedAdmin) {
+			return true
+		}
+	}
+	return false
+}
+
 func getLocalPrincipalID(user *v3.User) string {
 	// TODO error condition handling: no principal, more than one that would match
 	var principalID string
@@ -312,6 +433,9 @@ func (l *Provider) listAllUsersAndGroups(searchKey string) ([]*v3.User, []*v3.Gr
 		return localUsers, localGroups, err
 	}
 	for _, group := range allGroups {
+		if group.Labels[ExternalGroupLabel] == "true" {
+			continue
+		}
 		if !(strings.HasPrefix(group.ObjectMeta.Name, searchKey) || strings.HasPrefix(group.DisplayName, searchKey)) {
 			continue
 		}
@@ -402,7 +526,7 @@ func userSearchIndexer(obj interface{}) ([]string, error) {
 
 func groupSearchIndexer(obj interface{}) ([]string, error) {
 	group, ok := obj.(*v3.Group)
-	if !ok {
+	if !ok || group.Labels[ExternalGroupLabel] == "true" {
 		return []string{}, nil
 	}
 	var fieldIndexes []string
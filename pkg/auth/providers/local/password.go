@@ -0,0 +1,254 @@
+package local
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rancher/norman/httperror"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	passwordHistorySecretNameEnding = "-password-history"
+	passwordHistoryKey              = "hashes"
+)
+
+// PasswordPolicy is the set of rules local user passwords are held to. It is read from settings.
+type PasswordPolicy struct {
+	MinLength         int
+	RequireComplexity bool
+	DisallowUsername  bool
+	HistoryCount      int
+	MaxAge            time.Duration
+	MaxFailedAttempts int
+	LockoutDuration   time.Duration
+}
+
+func GetPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:         settings.PasswordMinLength.GetInt(),
+		RequireComplexity: settings.PasswordRequireComplexity.Get() == "true",
+		DisallowUsername:  settings.PasswordDisallowUsername.Get() == "true",
+		HistoryCount:      settings.PasswordHistoryCount.GetInt(),
+		MaxAge:            time.Duration(settings.PasswordMaxAgeDays.GetInt()) * 24 * time.Hour,
+		MaxFailedAttempts: settings.PasswordMaxFailedAttempts.GetInt(),
+		LockoutDuration:   time.Duration(settings.PasswordLockoutMinutes.GetInt()) * time.Minute,
+	}
+}
+
+// Validate checks a new password against the length, username and complexity rules of the policy.
+func (p PasswordPolicy) Validate(username, password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, fmt.Sprintf("password must be at least %d characters", p.MinLength))
+	}
+	if p.DisallowUsername && username != "" && strings.EqualFold(username, password) {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, "password cannot be the same as the username")
+	}
+	if !p.RequireComplexity {
+		return nil
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if !upper || !lower || !digit || !symbol {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, "password must contain upper and lower case letters, digits and symbols")
+	}
+	return nil
+}
+
+// ValidateReuse rejects a new password that matches the current password or one of the
+// passwords remembered in the user's history.
+func (p PasswordPolicy) ValidateReuse(password, currentHash string, history []string) error {
+	if p.HistoryCount <= 0 {
+		return nil
+	}
+
+	hashes := append([]string{currentHash}, history...)
+	if len(hashes) > p.HistoryCount {
+		hashes = hashes[:p.HistoryCount]
+	}
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return httperror.NewAPIError(httperror.InvalidBodyContent, fmt.Sprintf("password cannot be one of the last %d passwords", p.HistoryCount))
+		}
+	}
+	return nil
+}
+
+// mfaLockoutPolicy returns the policy failed multi-factor codes are counted with. A six digit code
+// can be guessed, so these failures lock the user out even when the policy does not.
+func (p PasswordPolicy) mfaLockoutPolicy() PasswordPolicy {
+	if p.MaxFailedAttempts <= 0 || p.MaxFailedAttempts > mfaMaxFailedAttempts {
+		p.MaxFailedAttempts = mfaMaxFailedAttempts
+	}
+	if p.LockoutDuration <= 0 {
+		p.LockoutDuration = mfaLockoutDuration
+	}
+	return p
+}
+
+// RecordPasswordChange resets the password age and any lockout of the user.
+func (p PasswordPolicy) RecordPasswordChange(attribs *v32.UserAttribute) {
+	attribs.PasswordChangedAt = time.Now().UTC().Format(time.RFC3339)
+	attribs.FailedLoginAttempts = 0
+	attribs.LockedUntil = ""
+}
+
+// RememberPassword returns history with the replaced password hash added, trimmed to the number of
+// passwords the policy remembers.
+func (p PasswordPolicy) RememberPassword(history []string, oldHash string) []string {
+	// The current password is checked separately, so the history only needs the ones before it.
+	if oldHash != "" {
+		history = append([]string{oldHash}, history...)
+	}
+	if max := p.HistoryCount - 1; len(history) > max {
+		if max < 0 {
+			max = 0
+		}
+		history = history[:max]
+	}
+	return history
+}
+
+// PasswordHistory keeps the bcrypt hashes of the previous passwords of local users, newest first, in a
+// secret named after the user in the cattle-system namespace rather than on the UserAttribute, which
+// anyone allowed to read user attributes can see.
+type PasswordHistory struct {
+	secrets      v1.SecretInterface
+	secretLister v1.SecretLister
+}
+
+func NewPasswordHistory(secrets v1.SecretInterface, secretLister v1.SecretLister) *PasswordHistory {
+	return &PasswordHistory{
+		secrets:      secrets,
+		secretLister: secretLister,
+	}
+}
+
+// Get returns the password history of the user.
+func (h *PasswordHistory) Get(userID string) ([]string, error) {
+	secret, err := h.secretLister.Get(totpSecretNamespace, userID+passwordHistorySecretNameEnding)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	for _, hash := range strings.Split(string(secret.Data[passwordHistoryKey]), "\n") {
+		if hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes, nil
+}
+
+// Set replaces the password history of the user, an empty history removes its secret.
+func (h *PasswordHistory) Set(userID string, hashes []string) error {
+	name := userID + passwordHistorySecretNameEnding
+	if len(hashes) == 0 {
+		err := h.secrets.DeleteNamespaced(totpSecretNamespace, name, &metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	data := map[string][]byte{
+		passwordHistoryKey: []byte(strings.Join(hashes, "\n")),
+	}
+	existing, err := h.secretLister.Get(totpSecretNamespace, name)
+	if apierrors.IsNotFound(err) {
+		_, err = h.secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: totpSecretNamespace,
+			},
+			Data: data,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	existing = existing.DeepCopy()
+	existing.Data = data
+	_, err = h.secrets.Update(existing)
+	return err
+}
+
+// Expired reports whether the password of user has outlived the maximum password age.
+func (p PasswordPolicy) Expired(user *v3.User, attribs *v32.UserAttribute) bool {
+	if p.MaxAge <= 0 {
+		return false
+	}
+	changed := user.CreationTimestamp.Time
+	if attribs != nil && attribs.PasswordChangedAt != "" {
+		if t, err := time.Parse(time.RFC3339, attribs.PasswordChangedAt); err == nil {
+			changed = t
+		}
+	}
+	return time.Since(changed) > p.MaxAge
+}
+
+// Locked reports whether logins for the user are currently refused.
+func Locked(attribs *v32.UserAttribute) bool {
+	if attribs == nil || attribs.LockedUntil == "" {
+		return false
+	}
+	until, err := time.Parse(time.RFC3339, attribs.LockedUntil)
+	return err == nil && time.Now().Before(until)
+}
+
+// UpdateUserAttribute applies update to the UserAttribute of userID with the token manager of the provider.
+func (l *Provider) UpdateUserAttribute(userID string, update func(attribs *v32.UserAttribute)) error {
+	return UpdateUserAttribute(l.tokenMGR, l.userAttributes, userID, update)
+}
+
+// UpdateUserAttribute applies update to the UserAttribute of userID, creating it if it does not
+// exist yet, and retries on conflicts.
+func UpdateUserAttribute(tokenMGR *tokens.Manager, userAttributes v3.UserAttributeInterface, userID string, update func(attribs *v32.UserAttribute)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// Read from the API rather than the cache so retries see the latest version.
+		needCreate := false
+		attribs, err := userAttributes.Get(userID, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			attribs, needCreate, err = tokenMGR.EnsureAndGetUserAttribute(userID)
+		}
+		if err != nil {
+			return err
+		}
+		update(attribs)
+		if needCreate {
+			_, err = userAttributes.Create(attribs)
+			return err
+		}
+		_, err = userAttributes.Update(attribs)
+		return err
+	})
+}
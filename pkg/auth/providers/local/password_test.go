@@ -0,0 +1,118 @@
+package local
+
+import (
+	"testing"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	corefakes "github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func hash(t *testing.T, password string) string {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(h)
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8}
+	assert.Error(t, policy.Validate("admin", "short"))
+	assert.NoError(t, policy.Validate("administrator", "Administrator"))
+	assert.NoError(t, policy.Validate("admin", "longenough"))
+
+	policy.DisallowUsername = true
+	assert.Error(t, policy.Validate("administrator", "Administrator"))
+
+	policy.RequireComplexity = true
+	assert.Error(t, policy.Validate("admin", "longenough"))
+	assert.Error(t, policy.Validate("admin", "LongEnough1"))
+	assert.NoError(t, policy.Validate("admin", "LongEnough1!"))
+}
+
+func TestPasswordPolicyHistory(t *testing.T) {
+	policy := PasswordPolicy{HistoryCount: 3}
+	var history []string
+
+	// Rotate through four passwords; the current one plus the two before it are remembered.
+	current := hash(t, "first")
+	for _, next := range []string{"second", "third", "fourth"} {
+		assert.NoError(t, policy.ValidateReuse(next, current, history))
+		history = policy.RememberPassword(history, current)
+		current = hash(t, next)
+	}
+
+	assert.Len(t, history, 2)
+	assert.Error(t, policy.ValidateReuse("fourth", current, history))
+	assert.Error(t, policy.ValidateReuse("third", current, history))
+	assert.Error(t, policy.ValidateReuse("second", current, history))
+	assert.NoError(t, policy.ValidateReuse("first", current, history))
+
+	assert.NoError(t, PasswordPolicy{}.ValidateReuse("fourth", current, history))
+	assert.Empty(t, PasswordPolicy{}.RememberPassword(history, current))
+}
+
+func TestPasswordHistorySecret(t *testing.T) {
+	secrets := map[string]*corev1.Secret{}
+	store := func(secret *corev1.Secret) (*corev1.Secret, error) {
+		secrets[secret.Name] = secret
+		return secret, nil
+	}
+	history := NewPasswordHistory(&corefakes.SecretInterfaceMock{
+		CreateFunc: store,
+		UpdateFunc: store,
+		DeleteNamespacedFunc: func(namespace string, name string, options *metav1.DeleteOptions) error {
+			if _, ok := secrets[name]; !ok {
+				return apierrors.NewNotFound(corev1.Resource("secrets"), name)
+			}
+			delete(secrets, name)
+			return nil
+		},
+	}, &corefakes.SecretListerMock{
+		GetFunc: func(namespace string, name string) (*corev1.Secret, error) {
+			if secret, ok := secrets[name]; ok {
+				return secret, nil
+			}
+			return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+		},
+	})
+
+	hashes, err := history.Get("u-abcde")
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	require.NoError(t, history.Set("u-abcde", []string{"second", "first"}))
+	require.NoError(t, history.Set("u-abcde", []string{"third", "second"}))
+	assert.Equal(t, "cattle-system", secrets["u-abcde-password-history"].Namespace)
+	hashes, err = history.Get("u-abcde")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"third", "second"}, hashes)
+
+	require.NoError(t, history.Set("u-abcde", nil))
+	assert.Empty(t, secrets)
+	require.NoError(t, history.Set("u-abcde", nil))
+}
+
+func TestPasswordPolicyExpired(t *testing.T) {
+	user := &v3.User{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().Add(-48 * time.Hour))}}
+	policy := PasswordPolicy{MaxAge: 24 * time.Hour}
+
+	assert.False(t, PasswordPolicy{}.Expired(user, nil))
+	assert.True(t, policy.Expired(user, nil))
+	assert.False(t, policy.Expired(user, &v32.UserAttribute{PasswordChangedAt: time.Now().Format(time.RFC3339)}))
+}
+
+func TestLocked(t *testing.T) {
+	assert.False(t, Locked(nil))
+	assert.False(t, Locked(&v32.UserAttribute{}))
+	assert.False(t, Locked(&v32.UserAttribute{LockedUntil: time.Now().Add(-time.Minute).Format(time.RFC3339)}))
+	assert.True(t, Locked(&v32.UserAttribute{LockedUntil: time.Now().Add(time.Minute).Format(time.RFC3339)}))
+}
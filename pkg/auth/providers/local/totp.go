@@ -0,0 +1,276 @@
+package local
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	totpSecretNamespace  = "cattle-system"
+	totpSecretNameEnding = "-totp"
+	totpIssuer           = "Rancher"
+	totpPeriod           = 30
+	totpDigits           = 6
+	// totpSkew is the number of periods before and after the current one that are accepted to
+	// allow for clock drift between the server and the authenticator.
+	totpSkew          = 1
+	recoveryCodeCount = 10
+	// mfaMaxFailedAttempts and mfaLockoutDuration bound the guessing of codes once the password is
+	// known, whether or not the password policy locks users out.
+	mfaMaxFailedAttempts = 5
+	mfaLockoutDuration   = 15 * time.Minute
+
+	totpSecretKey        = "secret"
+	totpEnabledKey       = "enabled"
+	totpLastStepKey      = "lastStep"
+	totpRecoveryCodesKey = "recoveryCodes"
+)
+
+var (
+	MFARequired           = httperror.ErrorCode{Code: "MFARequired", Status: 401}
+	MFAEnrollmentRequired = httperror.ErrorCode{Code: "MFAEnrollmentRequired", Status: 401}
+
+	base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// GenerateTOTPSecret returns a random 160 bit key in the base32 form authenticator apps expect.
+func GenerateTOTPSecret() (string, error) {
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(key), nil
+}
+
+// TOTPURL returns the otpauth:// URL that authenticator apps read from a QR code.
+func TOTPURL(account, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", totpIssuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", strconv.Itoa(totpDigits))
+	values.Set("period", strconv.Itoa(totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(totpIssuer), url.PathEscape(account), values.Encode())
+}
+
+// totpCode computes the RFC 6238 code of key for the given time step.
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// validateTOTP checks code against secret at now. Steps at or before lastStep are rejected so a
+// code can only be used once. It returns the step the code matched.
+func validateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// generateRecoveryCodes returns single use recovery codes together with the bcrypt hashes that
+// are stored in place of them.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
+
+// MFA manages the TOTP enrollment of local users. Each user's key, state and hashed recovery
+// codes are kept in a secret named after the user in the cattle-system namespace.
+type MFA struct {
+	secrets      v1.SecretInterface
+	secretLister v1.SecretLister
+}
+
+func NewMFA(secrets v1.SecretInterface, secretLister v1.SecretLister) *MFA {
+	return &MFA{
+		secrets:      secrets,
+		secretLister: secretLister,
+	}
+}
+
+func (m *MFA) get(userID string) (*corev1.Secret, error) {
+	secret, err := m.secretLister.Get(totpSecretNamespace, userID+totpSecretNameEnding)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return secret, err
+}
+
+// Enrolled reports whether the user has a verified TOTP enrollment.
+func (m *MFA) Enrolled(userID string) (bool, error) {
+	secret, err := m.get(userID)
+	if err != nil || secret == nil {
+		return false, err
+	}
+	return string(secret.Data[totpEnabledKey]) == "true", nil
+}
+
+// Enroll generates a new key for the user. The enrollment only takes effect once a code generated
+// from the key is confirmed with Activate.
+func (m *MFA) Enroll(userID, account string) (string, string, error) {
+	existing, err := m.get(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if existing != nil && string(existing.Data[totpEnabledKey]) == "true" {
+		return "", "", httperror.NewAPIError(httperror.InvalidState, "multi-factor authentication is already enabled")
+	}
+
+	key, err := GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	data := map[string][]byte{
+		totpSecretKey:  []byte(key),
+		totpEnabledKey: []byte("false"),
+	}
+
+	if existing == nil {
+		_, err = m.secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      userID + totpSecretNameEnding,
+				Namespace: totpSecretNamespace,
+			},
+			Data: data,
+		})
+	} else {
+		existing = existing.DeepCopy()
+		existing.Data = data
+		_, err = m.secrets.Update(existing)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return key, TOTPURL(account, key), nil
+}
+
+// Activate confirms a pending enrollment with a code from the authenticator and returns the
+// user's recovery codes. They are only ever shown here.
+func (m *MFA) Activate(userID, code string) ([]string, error) {
+	secret, err := m.get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, httperror.NewAPIError(httperror.InvalidState, "multi-factor authentication enrollment has not been started")
+	}
+	if string(secret.Data[totpEnabledKey]) == "true" {
+		return nil, httperror.NewAPIError(httperror.InvalidState, "multi-factor authentication is already enabled")
+	}
+
+	step, ok := validateTOTP(string(secret.Data[totpSecretKey]), code, time.Now(), 0)
+	if !ok {
+		return nil, httperror.NewAPIError(httperror.InvalidBodyContent, "invalid code")
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data[totpEnabledKey] = []byte("true")
+	secret.Data[totpLastStepKey] = []byte(strconv.FormatInt(step, 10))
+	secret.Data[totpRecoveryCodesKey] = []byte(strings.Join(hashes, "\n"))
+	if _, err := m.secrets.Update(secret); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code or one of the user's recovery codes. Used codes are recorded so they
+// cannot be replayed.
+func (m *MFA) Verify(userID, code string) (bool, error) {
+	secret, err := m.get(userID)
+	if err != nil || secret == nil || string(secret.Data[totpEnabledKey]) != "true" {
+		return false, err
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, nil
+	}
+
+	lastStep, _ := strconv.ParseInt(string(secret.Data[totpLastStepKey]), 10, 64)
+	if step, ok := validateTOTP(string(secret.Data[totpSecretKey]), code, time.Now(), lastStep); ok {
+		secret = secret.DeepCopy()
+		secret.Data[totpLastStepKey] = []byte(strconv.FormatInt(step, 10))
+		_, err := m.secrets.Update(secret)
+		return err == nil, err
+	}
+
+	hashes := strings.Split(string(secret.Data[totpRecoveryCodesKey]), "\n")
+	for i, hash := range hashes {
+		if hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(strings.ToLower(code))) != nil {
+			continue
+		}
+		secret = secret.DeepCopy()
+		secret.Data[totpRecoveryCodesKey] = []byte(strings.Join(append(hashes[:i:i], hashes[i+1:]...), "\n"))
+		_, err := m.secrets.Update(secret)
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// Disable removes the user's enrollment, pending or not.
+func (m *MFA) Disable(userID string) error {
+	err := m.secrets.DeleteNamespaced(totpSecretNamespace, userID+totpSecretNameEnding, &metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
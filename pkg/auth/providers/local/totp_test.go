@@ -0,0 +1,186 @@
+package local
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	corefakes "github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
+	mgmtfakes "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/rbac"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B, truncated to six digits.
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, totpCode(key, tt.time/totpPeriod), "time %d", tt.time)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	step, ok := validateTOTP(secret, "050471", now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1111111111/totpPeriod), step)
+
+	// The previous period is accepted for clock drift, older ones are not.
+	_, ok = validateTOTP(secret, "081804", now, 0)
+	assert.True(t, ok)
+	_, ok = validateTOTP(secret, "081804", now.Add(totpPeriod*time.Second), 0)
+	assert.False(t, ok)
+
+	// A code cannot be used twice.
+	_, ok = validateTOTP(secret, "050471", now, step)
+	assert.False(t, ok)
+
+	_, ok = validateTOTP(secret, "000000", now, 0)
+	assert.False(t, ok)
+	_, ok = validateTOTP("not base32!", "050471", now, 0)
+	assert.False(t, ok)
+}
+
+func TestTOTPURL(t *testing.T) {
+	url := TOTPURL("admin", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(url, "otpauth://totp/Rancher:admin?"))
+	assert.Contains(t, url, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, url, "issuer=Rancher")
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashes[0]), []byte(codes[0])))
+	assert.NotEqual(t, codes[0], codes[1])
+}
+
+func TestMFAEnrollmentFromRefusedState(t *testing.T) {
+	secrets := map[string]*corev1.Secret{}
+	store := func(secret *corev1.Secret) (*corev1.Secret, error) {
+		secrets[secret.Name] = secret
+		return secret, nil
+	}
+	provider := &Provider{
+		grbLister: &mgmtfakes.GlobalRoleBindingListerMock{
+			ListFunc: func(namespace string, selector labels.Selector) ([]*v32.GlobalRoleBinding, error) {
+				return []*v32.GlobalRoleBinding{{UserName: "u-admin", GlobalRoleName: rbac.GlobalAdmin}}, nil
+			},
+		},
+		mfa: NewMFA(&corefakes.SecretInterfaceMock{
+			CreateFunc: store,
+			UpdateFunc: store,
+		}, &corefakes.SecretListerMock{
+			GetFunc: func(namespace string, name string) (*corev1.Secret, error) {
+				if secret, ok := secrets[name]; ok {
+					return secret, nil
+				}
+				return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+			},
+		}),
+	}
+	user := &v32.User{ObjectMeta: metav1.ObjectMeta{Name: "u-admin"}, Username: "admin"}
+
+	require.NoError(t, settings.MFARequiredForAdmins.Set("true"))
+	defer settings.MFARequiredForAdmins.Set("false")
+
+	// The unenrolled admin passes the login, but only gets a session for the enrollment.
+	required, err := provider.MFAEnrollmentRequired(user)
+	require.NoError(t, err)
+	assert.True(t, required)
+	assert.NoError(t, provider.checkMFA(user, "", PasswordPolicy{}))
+
+	secret, _, err := provider.mfa.Enroll(user.Name, user.Username)
+	require.NoError(t, err)
+	required, err = provider.MFAEnrollmentRequired(user)
+	require.NoError(t, err)
+	assert.True(t, required, "a pending enrollment is not enough")
+
+	key, err := base32NoPadding.DecodeString(secret)
+	require.NoError(t, err)
+	codes, err := provider.mfa.Activate(user.Name, totpCode(key, time.Now().Unix()/totpPeriod))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	required, err = provider.MFAEnrollmentRequired(user)
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	// From now on logins need a code.
+	err = provider.checkMFA(user, "", PasswordPolicy{})
+	assert.True(t, httperror.IsAPIError(err))
+	assert.Equal(t, MFARequired, err.(*httperror.APIError).Code)
+	assert.NoError(t, provider.checkMFA(user, codes[0], PasswordPolicy{}))
+}
+
+func TestMFAFailuresLockOut(t *testing.T) {
+	secrets := map[string]*corev1.Secret{}
+	store := func(secret *corev1.Secret) (*corev1.Secret, error) {
+		secrets[secret.Name] = secret
+		return secret, nil
+	}
+	attribs := &v32.UserAttribute{ObjectMeta: metav1.ObjectMeta{Name: "u-abcde"}}
+	provider := &Provider{
+		userAttributes: &mgmtfakes.UserAttributeInterfaceMock{
+			GetFunc: func(name string, opts metav1.GetOptions) (*v32.UserAttribute, error) {
+				return attribs.DeepCopy(), nil
+			},
+			UpdateFunc: func(in *v32.UserAttribute) (*v32.UserAttribute, error) {
+				attribs = in
+				return in, nil
+			},
+		},
+		mfa: NewMFA(&corefakes.SecretInterfaceMock{
+			CreateFunc: store,
+			UpdateFunc: store,
+		}, &corefakes.SecretListerMock{
+			GetFunc: func(namespace string, name string) (*corev1.Secret, error) {
+				if secret, ok := secrets[name]; ok {
+					return secret, nil
+				}
+				return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+			},
+		}),
+	}
+	user := &v32.User{ObjectMeta: metav1.ObjectMeta{Name: "u-abcde"}, Username: "user"}
+
+	secret, _, err := provider.mfa.Enroll(user.Name, user.Username)
+	require.NoError(t, err)
+	key, err := base32NoPadding.DecodeString(secret)
+	require.NoError(t, err)
+	_, err = provider.mfa.Activate(user.Name, totpCode(key, time.Now().Unix()/totpPeriod))
+	require.NoError(t, err)
+
+	// Wrong codes lock the user out even though the password policy has no lockout.
+	for i := 0; i < mfaMaxFailedAttempts-1; i++ {
+		assert.Error(t, provider.checkMFA(user, "000000", PasswordPolicy{}))
+	}
+	assert.Equal(t, mfaMaxFailedAttempts-1, attribs.FailedLoginAttempts)
+	assert.Empty(t, attribs.LockedUntil)
+
+	assert.Error(t, provider.checkMFA(user, "000000", PasswordPolicy{}))
+	assert.True(t, Locked(attribs))
+}
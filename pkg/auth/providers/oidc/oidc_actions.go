@@ -81,6 +81,10 @@ func (o *OpenIDCProvider) TestAndApply(actionName string, action *types.Action,
 	}
 	oidcConfig.Issuer = issuerURL.String()
 
+	if _, err := NewGroupMapping(&oidcConfig); err != nil {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, fmt.Sprintf("[generic oidc] testAndApply: %v", err))
+	}
+
 	//call provider
 	userPrincipal, groupPrincipals, providerToken, claimInfo, err := o.LoginUser(request.Request.Context(), oidcLogin, &oidcConfig)
 	if err != nil {
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+)
+
+// groupsFromClaims returns the groups held by the claim at path. The path is first looked up as
+// a claim name, as namespaced claims such as "https://example.com/groups" contain dots, and
+// otherwise as dot separated nested claims.
+func groupsFromClaims(claims map[string]interface{}, path string) []string {
+	value, ok := claims[path]
+	if !ok {
+		var current interface{} = claims
+		for _, key := range strings.Split(path, ".") {
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			current = m[key]
+		}
+		value = current
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, item := range v {
+			if group, ok := item.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// GroupMapping filters and renames the groups of a user as configured on an OIDC auth config.
+// Group principals found by searching the identity provider have to go through the same mapping
+// to match the group principals users get at login.
+type GroupMapping struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	rename  []renameRule
+}
+
+type renameRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func NewGroupMapping(config *v32.OIDCConfig) (*GroupMapping, error) {
+	var err error
+	m := &GroupMapping{}
+	if m.include, err = compileAll(config.GroupsInclude); err != nil {
+		return nil, fmt.Errorf("invalid groupsInclude: %v", err)
+	}
+	if m.exclude, err = compileAll(config.GroupsExclude); err != nil {
+		return nil, fmt.Errorf("invalid groupsExclude: %v", err)
+	}
+	for _, rule := range config.GroupRenameRules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid groupRenameRules: %v", err)
+		}
+		m.rename = append(m.rename, renameRule{pattern: pattern, replacement: rule.Replacement})
+	}
+	return m, nil
+}
+
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// Apply returns the groups that pass the include and exclude filters, renamed by the first
+// matching rename rule. Filters match the group names as sent by the identity provider.
+func (m *GroupMapping) Apply(groups []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, group := range groups {
+		group, ok := m.Map(group)
+		if ok && !seen[group] {
+			seen[group] = true
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// Map returns the name of a single group after the filters and rename rules, and false if the
+// group is filtered out.
+func (m *GroupMapping) Map(group string) (string, bool) {
+	if len(m.include) > 0 && !matchesAny(m.include, group) {
+		return "", false
+	}
+	if matchesAny(m.exclude, group) {
+		return "", false
+	}
+	for _, rule := range m.rename {
+		if rule.pattern.MatchString(group) {
+			group = rule.pattern.ReplaceAllString(group, rule.replacement)
+			break
+		}
+	}
+	return group, group != ""
+}
+
+// Renames reports whether any rename rules are configured, in which case a group may be known
+// by a different name than the identity provider's.
+func (m *GroupMapping) Renames() bool {
+	return len(m.rename) > 0
+}
+
+func matchesAny(res []*regexp.Regexp, value string) bool {
+	for _, re := range res {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
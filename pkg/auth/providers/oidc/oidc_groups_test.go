@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"encoding/json"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupsFromClaims(t *testing.T) {
+	var claims map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"groups": ["a", "b"],
+		"https://example.com/groups": "c",
+		"resource_access": {"rancher": {"roles": ["admin", 1, "viewer"]}}
+	}`), &claims)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, groupsFromClaims(claims, "groups"))
+	assert.Equal(t, []string{"c"}, groupsFromClaims(claims, "https://example.com/groups"))
+	assert.Equal(t, []string{"admin", "viewer"}, groupsFromClaims(claims, "resource_access.rancher.roles"))
+	assert.Nil(t, groupsFromClaims(claims, "resource_access.other.roles"))
+	assert.Nil(t, groupsFromClaims(claims, "groups.nested"))
+}
+
+func TestGroupMapping(t *testing.T) {
+	mapping, err := NewGroupMapping(&v32.OIDCConfig{
+		GroupsInclude: []string{"^rancher-", "^ops$"},
+		GroupsExclude: []string{"-test$"},
+		GroupRenameRules: []v32.GroupRenameRule{
+			{Pattern: "^rancher-(.*)$", Replacement: "$1"},
+			{Pattern: "^ops$", Replacement: "operations"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]string{"admins", "operations"},
+		mapping.Apply([]string{"rancher-admins", "rancher-dev-test", "hr", "ops", "rancher-admins"}))
+
+	mapping, err = NewGroupMapping(&v32.OIDCConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, mapping.Apply([]string{"a", "b"}))
+
+	_, err = NewGroupMapping(&v32.OIDCConfig{GroupsExclude: []string{"("}})
+	assert.Error(t, err)
+}
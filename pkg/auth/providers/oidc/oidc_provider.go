@@ -103,7 +103,10 @@ func (o *OpenIDCProvider) LoginUser(ctx context.Context, oauthLoginInfo *v32.OID
 	}
 	userPrincipal = o.userToPrincipal(userInfo, userClaimInfo)
 	userPrincipal.Me = true
-	groupPrincipals = o.getGroupsFromClaimInfo(userClaimInfo)
+	groupPrincipals, err = o.getGroupsFromClaimInfo(userClaimInfo, config)
+	if err != nil {
+		return userPrincipal, groupPrincipals, "", userClaimInfo, err
+	}
 
 	logrus.Debugf("[generic oidc] loginuser: checking user's access to rancher")
 	allowed, err := o.UserMGR.CheckAccess(config.AccessMode, config.AllowedPrincipalIDs, userPrincipal.Name, groupPrincipals)
@@ -207,7 +210,7 @@ func (o *OpenIDCProvider) RefetchGroupPrincipals(principalID string, secret stri
 	if err != nil {
 		return groupPrincipals, err
 	}
-	return o.getGroupsFromClaimInfo(claimInfo), nil
+	return o.getGroupsFromClaimInfo(claimInfo, config)
 }
 
 func (o *OpenIDCProvider) CanAccessWithGroupProviders(userPrincipalID string, groupPrincipals []v3.Principal) (bool, error) {
@@ -395,6 +398,14 @@ func (o *OpenIDCProvider) getUserInfo(ctx *context.Context, config *v32.OIDCConf
 	if err := userInfo.Claims(&claimInfo); err != nil {
 		return userInfo, oauth2Token, err
 	}
+	if config.GroupsClaim != "" {
+		var claims map[string]interface{}
+		if err := userInfo.Claims(&claims); err != nil {
+			return userInfo, oauth2Token, err
+		}
+		claimInfo.Groups = groupsFromClaims(claims, config.GroupsClaim)
+		claimInfo.FullGroupPath = nil
+	}
 	return userInfo, oauth2Token, nil
 }
 
@@ -420,26 +431,31 @@ func ConfigToOauthConfig(endpoint oauth2.Endpoint, config *v32.OIDCConfig) oauth
 	}
 }
 
-func (o *OpenIDCProvider) getGroupsFromClaimInfo(claimInfo ClaimInfo) []v3.Principal {
+func (o *OpenIDCProvider) getGroupsFromClaimInfo(claimInfo ClaimInfo, config *v32.OIDCConfig) ([]v3.Principal, error) {
 	var groupPrincipals []v3.Principal
 
+	mapping, err := NewGroupMapping(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []string
 	if claimInfo.FullGroupPath != nil {
 		for _, groupPath := range claimInfo.FullGroupPath {
 			groupsFromPath := strings.Split(groupPath, "/")
 			for _, group := range groupsFromPath {
 				if group != "" {
-					groupPrincipal := o.groupToPrincipal(group)
-					groupPrincipal.MemberOf = true
-					groupPrincipals = append(groupPrincipals, groupPrincipal)
+					groups = append(groups, group)
 				}
 			}
 		}
 	} else {
-		for _, group := range claimInfo.Groups {
-			groupPrincipal := o.groupToPrincipal(group)
-			groupPrincipal.MemberOf = true
-			groupPrincipals = append(groupPrincipals, groupPrincipal)
-		}
+		groups = claimInfo.Groups
+	}
+	for _, group := range mapping.Apply(groups) {
+		groupPrincipal := o.groupToPrincipal(group)
+		groupPrincipal.MemberOf = true
+		groupPrincipals = append(groupPrincipals, groupPrincipal)
 	}
-	return groupPrincipals
+	return groupPrincipals, nil
 }
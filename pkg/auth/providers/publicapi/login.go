@@ -211,6 +211,18 @@ func (h *loginHandler) createLoginToken(request *types.APIContext) (v3.Token, st
 		return v3.Token{}, "", "", httperror.NewAPIError(httperror.PermissionDenied, "Permission Denied")
 	}
 
+	enrollmentOnly, err := mfaEnrollmentRequired(providerName, currUser)
+	if err != nil {
+		return v3.Token{}, "", "", err
+	}
+	if enrollmentOnly {
+		if strings.HasPrefix(responseType, tokens.KubeconfigResponseType) {
+			return v3.Token{}, "", "", httperror.NewAPIError(local.MFAEnrollmentRequired, "multi-factor authentication enrollment is required")
+		}
+		rToken, unhashedTokenKey, err := h.tokenMGR.NewMFAEnrollmentToken(currUser.Name, userPrincipal, groupPrincipals, ttl, description)
+		return rToken, unhashedTokenKey, responseType, err
+	}
+
 	if strings.HasPrefix(responseType, tokens.KubeconfigResponseType) {
 		token, tokenValue, err := tokens.GetKubeConfigToken(currUser.Name, responseType, h.userMGR)
 		if err != nil {
@@ -228,6 +240,23 @@ func (h *loginHandler) createLoginToken(request *types.APIContext) (v3.Token, st
 	return rToken, unhashedTokenKey, responseType, err
 }
 
+// mfaEnrollmentRequired reports whether a local admin has to enroll in multi-factor authentication, in which case
+// the login only yields a session for the enrollment.
+func mfaEnrollmentRequired(providerName string, user *v3.User) (bool, error) {
+	if providerName != local.Name {
+		return false, nil
+	}
+	provider, err := providers.GetProvider(local.Name)
+	if err != nil {
+		return false, err
+	}
+	localProvider, ok := provider.(*local.Provider)
+	if !ok {
+		return false, nil
+	}
+	return localProvider.MFAEnrollmentRequired(user)
+}
+
 // createClusterAuthTokenIfNeeded checks if local cluster auth endpoint is enabled. If it is, a cluster auth token
 // is created.
 func (h *loginHandler) createClusterAuthTokenIfNeeded(token *v3.Token, tokenValue string) error {
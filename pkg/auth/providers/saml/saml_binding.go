@@ -0,0 +1,183 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// The HTTP-Redirect binding signs messages in the query string rather than in the XML, see
+// section 3.4.4.1 of the SAML 2.0 bindings specification.
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// redirectURL returns destination with the deflated and base64 encoded message added as the
+// param query parameter. The query string is signed with key if it is not nil.
+func redirectURL(destination string, param, message, relayState string, key *rsa.PrivateKey) (*url.URL, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	query := param + "=" + url.QueryEscape(message)
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	if key != nil {
+		query += "&SigAlg=" + url.QueryEscape(dsig.RSASHA256SignatureMethod)
+		digest := sha256.Sum256([]byte(query))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery += "&" + query
+	} else {
+		u.RawQuery = query
+	}
+	return u, nil
+}
+
+// deflateMessage encodes an XML message for the HTTP-Redirect binding.
+func deflateMessage(el *etree.Element) (string, error) {
+	doc := etree.NewDocument()
+	doc.SetRoot(el)
+
+	buf := &bytes.Buffer{}
+	w, err := flate.NewWriter(buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := doc.WriteTo(w); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// inflateMessage decodes a message received with the HTTP-Redirect binding.
+func inflateMessage(message string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode base64: %v", err)
+	}
+	return ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+}
+
+// verifyRedirectSignature verifies the query string signature of a message received with the
+// HTTP-Redirect binding. The signature covers the raw, still URL encoded, query parameters.
+func verifyRedirectSignature(rawQuery, param string, certs []*x509.Certificate) error {
+	raw := map[string]string{}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			raw[parts[0]] = parts[1]
+		}
+	}
+	if raw["Signature"] == "" || raw["SigAlg"] == "" {
+		return fmt.Errorf("message is not signed")
+	}
+
+	signed := param + "=" + raw[param]
+	if relayState, ok := raw["RelayState"]; ok {
+		signed += "&RelayState=" + relayState
+	}
+	signed += "&SigAlg=" + raw["SigAlg"]
+
+	sigAlg, err := url.QueryUnescape(raw["SigAlg"])
+	if err != nil {
+		return err
+	}
+	encodedSignature, err := url.QueryUnescape(raw["Signature"])
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("unable to decode signature: %v", err)
+	}
+
+	var hash crypto.Hash
+	var digest []byte
+	switch sigAlg {
+	case dsig.RSASHA256SignatureMethod:
+		sum := sha256.Sum256([]byte(signed))
+		hash, digest = crypto.SHA256, sum[:]
+	case dsig.RSASHA1SignatureMethod:
+		sum := sha1.Sum([]byte(signed))
+		hash, digest = crypto.SHA1, sum[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm %s", sigAlg)
+	}
+
+	for _, cert := range certs {
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature does not match any signing certificate of the identity provider")
+}
+
+// verifyPostSignature verifies the signature embedded in a message received with the HTTP-POST binding
+// and returns the signed element. Callers must parse the returned element rather than the original
+// message, anything outside of the signed element may have been added or changed by an attacker.
+func verifyPostSignature(message []byte, certs []*x509.Certificate) ([]byte, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(message); err != nil {
+		return nil, err
+	}
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	ctx.IdAttribute = "ID"
+	validated, err := ctx.Validate(doc.Root())
+	if err != nil {
+		return nil, err
+	}
+	signed := etree.NewDocument()
+	signed.SetRoot(validated)
+	return signed.WriteToBytes()
+}
+
+// idpSigningCertificates returns the certificates the identity provider signs messages with.
+func idpSigningCertificates(sp *saml.ServiceProvider) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, idp := range sp.IDPMetadata.IDPSSODescriptors {
+		for _, kd := range idp.KeyDescriptors {
+			if kd.Use != "" && kd.Use != "signing" {
+				continue
+			}
+			der, err := base64.StdEncoding.DecodeString(whitespace.ReplaceAllString(kd.KeyInfo.Certificate, ""))
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode identity provider certificate: %v", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse identity provider certificate: %v", err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("identity provider metadata has no signing certificate")
+	}
+	return certs, nil
+}
@@ -0,0 +1,100 @@
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/xml"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectSignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	el := etree.NewElement("samlp:LogoutRequest")
+	el.CreateAttr("ID", "id-1")
+	message, err := deflateMessage(el)
+	require.NoError(t, err)
+
+	u, err := redirectURL("https://idp.example.com/slo?tenant=a", "SAMLRequest", message, "state", key)
+	require.NoError(t, err)
+	assert.Equal(t, "a", u.Query().Get("tenant"))
+	assert.NoError(t, verifyRedirectSignature(u.RawQuery, "SAMLRequest", []*x509.Certificate{cert}))
+
+	inflated, err := inflateMessage(u.Query().Get("SAMLRequest"))
+	require.NoError(t, err)
+	assert.Contains(t, string(inflated), `ID="id-1"`)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tampered, err := redirectURL("https://idp.example.com/slo", "SAMLRequest", message, "other", otherKey)
+	require.NoError(t, err)
+	assert.Error(t, verifyRedirectSignature(tampered.RawQuery, "SAMLRequest", []*x509.Certificate{cert}))
+
+	unsigned, err := redirectURL("https://idp.example.com/slo", "SAMLRequest", message, "", nil)
+	require.NoError(t, err)
+	assert.Error(t, verifyRedirectSignature(unsigned.RawQuery, "SAMLRequest", []*x509.Certificate{cert}))
+}
+
+func TestPostSignature(t *testing.T) {
+	keyStore := dsig.RandomKeyStoreForTest()
+	_, der, err := keyStore.GetKeyPair()
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	el := etree.NewElement("samlp:LogoutRequest")
+	el.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	el.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	el.CreateAttr("ID", "id-1")
+	el.CreateAttr("Version", "2.0")
+	el.CreateElement("saml:NameID").SetText("alice")
+	signed, err := dsig.NewDefaultSigningContext(keyStore).SignEnveloped(el)
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	doc.SetRoot(signed)
+	message, err := doc.WriteToBytes()
+	require.NoError(t, err)
+
+	raw, err := verifyPostSignature(message, []*x509.Certificate{cert})
+	require.NoError(t, err)
+	req := &saml.LogoutRequest{}
+	require.NoError(t, xml.Unmarshal(raw, req))
+	assert.Equal(t, "id-1", req.ID)
+	assert.Equal(t, "alice", req.NameID.Value)
+
+	signed.FindElement("./NameID").SetText("admin")
+	tampered, err := doc.WriteToBytes()
+	require.NoError(t, err)
+	_, err = verifyPostSignature(tampered, []*x509.Certificate{cert})
+	assert.Error(t, err)
+
+	otherStore := dsig.RandomKeyStoreForTest()
+	_, otherDER, err := otherStore.GetKeyPair()
+	require.NoError(t, err)
+	otherCert, err := x509.ParseCertificate(otherDER)
+	require.NoError(t, err)
+	_, err = verifyPostSignature(message, []*x509.Certificate{otherCert})
+	assert.Error(t, err)
+}
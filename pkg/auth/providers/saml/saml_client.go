@@ -25,6 +25,7 @@
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/namespace"
+	dsig "github.com/russellhaering/goxmldsig"
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -135,6 +136,10 @@ func InitializeSamlServiceProvider(configToSet *v32.SamlConfig, name string) err
 	metadataURL.Path = metadataURL.Path + "/saml/metadata"
 	acsURL := *actURL
 	acsURL.Path = acsURL.Path + "/saml/acs"
+	sloURL := *actURL
+	sloURL.Path = sloURL.Path + "/saml/slo"
+	logoutURL := *actURL
+	logoutURL.Path = logoutURL.Path + "/saml/logout"
 
 	sp := saml.ServiceProvider{
 		Key:         privKey,
@@ -143,6 +148,12 @@ func InitializeSamlServiceProvider(configToSet *v32.SamlConfig, name string) err
 		AcsURL:      acsURL,
 		EntityID:    configToSet.EntityID,
 	}
+	if configToSet.SingleLogoutEnabled {
+		sp.SloURL = sloURL
+	}
+	if configToSet.SignAuthnRequests {
+		sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+	}
 
 	// XML unmarshal throws an error for IdP Metadata cacheDuration field, as it's of type xml Duration. Using a separate struct for unmarshaling for now
 	idm := &IDPMetadata{}
@@ -163,6 +174,11 @@ func InitializeSamlServiceProvider(configToSet *v32.SamlConfig, name string) err
 	}
 
 	provider.serviceProvider = &sp
+	provider.sloURL = sloURL
+	provider.logoutURL = logoutURL
+	provider.rancherAPIHost = rancherAPIHost
+	provider.signRequests = configToSet.SignAuthnRequests
+	provider.singleLogout = configToSet.SingleLogoutEnabled
 
 	cookieStore := ClientCookies{
 		ServiceProvider: &sp,
@@ -180,18 +196,28 @@ func InitializeSamlServiceProvider(configToSet *v32.SamlConfig, name string) err
 	case PingName:
 		root.Get("PingACS").HandlerFunc(provider.ServeHTTP)
 		root.Get("PingMetadata").HandlerFunc(provider.ServeHTTP)
+		root.Get("PingSLO").HandlerFunc(provider.ServeHTTP)
+		root.Get("PingLogout").HandlerFunc(provider.ServeHTTP)
 	case ADFSName:
 		root.Get("AdfsACS").HandlerFunc(provider.ServeHTTP)
 		root.Get("AdfsMetadata").HandlerFunc(provider.ServeHTTP)
+		root.Get("AdfsSLO").HandlerFunc(provider.ServeHTTP)
+		root.Get("AdfsLogout").HandlerFunc(provider.ServeHTTP)
 	case KeyCloakName:
 		root.Get("KeyCloakACS").HandlerFunc(provider.ServeHTTP)
 		root.Get("KeyCloakMetadata").HandlerFunc(provider.ServeHTTP)
+		root.Get("KeyCloakSLO").HandlerFunc(provider.ServeHTTP)
+		root.Get("KeyCloakLogout").HandlerFunc(provider.ServeHTTP)
 	case OKTAName:
 		root.Get("OktaACS").HandlerFunc(provider.ServeHTTP)
 		root.Get("OktaMetadata").HandlerFunc(provider.ServeHTTP)
+		root.Get("OktaSLO").HandlerFunc(provider.ServeHTTP)
+		root.Get("OktaLogout").HandlerFunc(provider.ServeHTTP)
 	case ShibbolethName:
 		root.Get("ShibbolethACS").HandlerFunc(provider.ServeHTTP)
 		root.Get("ShibbolethMetadata").HandlerFunc(provider.ServeHTTP)
+		root.Get("ShibbolethSLO").HandlerFunc(provider.ServeHTTP)
+		root.Get("ShibbolethLogout").HandlerFunc(provider.ServeHTTP)
 	}
 
 	appliedVersion = configToSet.ResourceVersion
@@ -204,18 +230,28 @@ func AuthHandler() http.Handler {
 
 	root.Methods("POST").Path("/v1-saml/ping/saml/acs").Name("PingACS")
 	root.Methods("GET").Path("/v1-saml/ping/saml/metadata").Name("PingMetadata")
+	root.Methods("GET", "POST").Path("/v1-saml/ping/saml/slo").Name("PingSLO")
+	root.Methods("GET").Path("/v1-saml/ping/saml/logout").Name("PingLogout")
 
 	root.Methods("POST").Path("/v1-saml/adfs/saml/acs").Name("AdfsACS")
 	root.Methods("GET").Path("/v1-saml/adfs/saml/metadata").Name("AdfsMetadata")
+	root.Methods("GET", "POST").Path("/v1-saml/adfs/saml/slo").Name("AdfsSLO")
+	root.Methods("GET").Path("/v1-saml/adfs/saml/logout").Name("AdfsLogout")
 
 	root.Methods("POST").Path("/v1-saml/keycloak/saml/acs").Name("KeyCloakACS")
 	root.Methods("GET").Path("/v1-saml/keycloak/saml/metadata").Name("KeyCloakMetadata")
+	root.Methods("GET", "POST").Path("/v1-saml/keycloak/saml/slo").Name("KeyCloakSLO")
+	root.Methods("GET").Path("/v1-saml/keycloak/saml/logout").Name("KeyCloakLogout")
 
 	root.Methods("POST").Path("/v1-saml/okta/saml/acs").Name("OktaACS")
 	root.Methods("GET").Path("/v1-saml/okta/saml/metadata").Name("OktaMetadata")
+	root.Methods("GET", "POST").Path("/v1-saml/okta/saml/slo").Name("OktaSLO")
+	root.Methods("GET").Path("/v1-saml/okta/saml/logout").Name("OktaLogout")
 
 	root.Methods("POST").Path("/v1-saml/shibboleth/saml/acs").Name("ShibbolethACS")
 	root.Methods("GET").Path("/v1-saml/shibboleth/saml/metadata").Name("ShibbolethMetadata")
+	root.Methods("GET", "POST").Path("/v1-saml/shibboleth/saml/slo").Name("ShibbolethSLO")
+	root.Methods("GET").Path("/v1-saml/shibboleth/saml/logout").Name("ShibbolethLogout")
 
 	return root
 }
@@ -309,6 +345,7 @@ func (s *Provider) HandleSamlAssertion(w http.ResponseWriter, r *http.Request, a
 		http.Redirect(w, r, redirectURL+"errorCode=422&errorMsg="+UITranslationKeyForErrorMessage, http.StatusFound)
 		return
 	}
+	addSessionInfo(&userPrincipal, assertion)
 	allowedPrincipals := config.AllowedPrincipalIDs
 
 	allowed, err := s.userMGR.CheckAccess(config.AccessMode, allowedPrincipals, userPrincipal.Name, groupPrincipals)
@@ -17,7 +17,16 @@
 func (s *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	serviceProvider := s.serviceProvider
 	if r.URL.Path == serviceProvider.MetadataURL.Path {
-		buf, _ := xml.MarshalIndent(serviceProvider.Metadata(), "", "  ")
+		metadata := serviceProvider.Metadata()
+		if s.singleLogout {
+			for i := range metadata.SPSSODescriptors {
+				metadata.SPSSODescriptors[i].SingleLogoutServices = []saml.Endpoint{
+					{Binding: saml.HTTPRedirectBinding, Location: s.sloURL.String()},
+					{Binding: saml.HTTPPostBinding, Location: s.sloURL.String()},
+				}
+			}
+		}
+		buf, _ := xml.MarshalIndent(metadata, "", "  ")
 		w.Header().Set("Content-Type", "application/samlmetadata+xml")
 		w.Write(buf)
 		return
@@ -39,6 +48,16 @@ func (s *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Path == s.sloURL.Path {
+		s.handleSLO(w, r)
+		return
+	}
+
+	if r.URL.Path == s.logoutURL.Path {
+		s.handleLogout(w, r)
+		return
+	}
+
 	http.NotFoundHandler().ServeHTTP(w, r)
 }
 
@@ -101,6 +120,15 @@ func (s *Provider) HandleSamlLogin(w http.ResponseWriter, r *http.Request) (stri
 	s.clientState.SetState(w, r, relayState, signedState)
 
 	if binding == saml.HTTPRedirectBinding {
+		if s.signRequests {
+			// the redirect binding carries the signature in the query string, not in the request
+			req.Signature = nil
+			redirectURL, err := s.redirectMessage(bindingLocation, "SAMLRequest", req.Element(), relayState)
+			if err != nil {
+				return "", err
+			}
+			return redirectURL.String(), nil
+		}
 		redirectURL := req.Redirect(relayState)
 		return redirectURL.String(), nil
 	}
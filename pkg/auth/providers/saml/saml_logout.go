@@ -0,0 +1,335 @@
+package saml
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	"github.com/golang-jwt/jwt"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// The NameID and session index of the assertion a user logged in with are kept in the extra info
+// of the user principal of the login token, as the identity provider needs them to end the session.
+const (
+	nameIDInfoKey       = "samlNameId"
+	nameIDFormatInfoKey = "samlNameIdFormat"
+	sessionIndexInfoKey = "samlSessionIndex"
+)
+
+// addSessionInfo records the NameID and session index of assertion on the user principal.
+func addSessionInfo(userPrincipal *v3.Principal, assertion *saml.Assertion) {
+	if assertion.Subject == nil || assertion.Subject.NameID == nil {
+		return
+	}
+	if userPrincipal.ExtraInfo == nil {
+		userPrincipal.ExtraInfo = map[string]string{}
+	}
+	userPrincipal.ExtraInfo[nameIDInfoKey] = assertion.Subject.NameID.Value
+	userPrincipal.ExtraInfo[nameIDFormatInfoKey] = assertion.Subject.NameID.Format
+	for _, statement := range assertion.AuthnStatements {
+		if statement.SessionIndex != "" {
+			userPrincipal.ExtraInfo[sessionIndexInfoKey] = statement.SessionIndex
+			break
+		}
+	}
+}
+
+// sloLocation returns the single logout endpoint of the identity provider and its binding,
+// preferring the HTTP-Redirect binding.
+func (s *Provider) sloLocation() (string, string) {
+	for _, binding := range []string{saml.HTTPRedirectBinding, saml.HTTPPostBinding} {
+		if location := s.serviceProvider.GetSLOBindingLocation(binding); location != "" {
+			return location, binding
+		}
+	}
+	return "", ""
+}
+
+// logout is called when a login token of the provider is logged out. If single logout is enabled
+// it returns the Rancher URL that sends the user agent to the identity provider to end its session.
+func (s *Provider) logout(token *v3.Token) (string, error) {
+	if s.serviceProvider == nil || !s.singleLogout {
+		return "", nil
+	}
+	nameID := token.UserPrincipal.ExtraInfo[nameIDInfoKey]
+	if location, _ := s.sloLocation(); location == "" || nameID == "" {
+		return "", nil
+	}
+
+	// The token is deleted before the user agent gets to the logout endpoint, so the session is
+	// handed over in a signed state.
+	state := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"nameId":       nameID,
+		"nameIdFormat": token.UserPrincipal.ExtraInfo[nameIDFormatInfoKey],
+		"sessionIndex": token.UserPrincipal.ExtraInfo[sessionIndexInfoKey],
+		"exp":          time.Now().Add(saml.MaxIssueDelay).Unix(),
+	})
+	signedState, err := state.SignedString(x509.MarshalPKCS1PrivateKey(s.serviceProvider.Key))
+	if err != nil {
+		return "", err
+	}
+
+	logoutURL := s.logoutURL
+	logoutURL.RawQuery = url.Values{"state": []string{signedState}}.Encode()
+	return logoutURL.String(), nil
+}
+
+// handleLogout starts SP-initiated single logout by sending a LogoutRequest to the identity provider.
+func (s *Provider) handleLogout(w http.ResponseWriter, r *http.Request) {
+	sp := s.serviceProvider
+
+	jwtParser := jwt.Parser{
+		ValidMethods: []string{jwt.SigningMethodHS256.Name},
+	}
+	state, err := jwtParser.Parse(r.URL.Query().Get("state"), func(t *jwt.Token) (interface{}, error) {
+		return x509.MarshalPKCS1PrivateKey(sp.Key), nil
+	})
+	if err != nil || !state.Valid {
+		log.Debugf("SAML [handleLogout]: invalid logout state: %v", err)
+		http.Redirect(w, r, s.rancherAPIHost, http.StatusFound)
+		return
+	}
+	claims := state.Claims.(jwt.MapClaims)
+	nameID, _ := claims["nameId"].(string)
+	nameIDFormat, _ := claims["nameIdFormat"].(string)
+	sessionIndex, _ := claims["sessionIndex"].(string)
+
+	location, binding := s.sloLocation()
+	req, err := sp.MakeLogoutRequest(location, nameID)
+	if err != nil {
+		log.Errorf("SAML [handleLogout]: failed to create logout request: %v", err)
+		http.Redirect(w, r, s.rancherAPIHost, http.StatusFound)
+		return
+	}
+	req.NameID.Format = nameIDFormat
+	if sessionIndex != "" {
+		req.SessionIndex = &saml.SessionIndex{Value: sessionIndex}
+	}
+	// MakeLogoutRequest signed the request before it was complete.
+	req.Signature = nil
+
+	if binding == saml.HTTPPostBinding {
+		if s.signRequests {
+			if err := sp.SignLogoutRequest(req); err != nil {
+				log.Errorf("SAML [handleLogout]: failed to sign logout request: %v", err)
+				http.Redirect(w, r, s.rancherAPIHost, http.StatusFound)
+				return
+			}
+		}
+		writePostForm(w, req.Post(""))
+		return
+	}
+
+	redirect, err := s.redirectMessage(location, "SAMLRequest", req.Element(), "")
+	if err != nil {
+		log.Errorf("SAML [handleLogout]: failed to encode logout request: %v", err)
+		http.Redirect(w, r, s.rancherAPIHost, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// handleSLO is the single logout service. It receives the LogoutResponse of SP-initiated logout
+// and the LogoutRequest of IdP-initiated logout, with either binding.
+func (s *Provider) handleSLO(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.singleLogout {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Form.Get("SAMLResponse") != "" {
+		// The Rancher session ended before the user was sent to the identity provider, so a
+		// failed logout at the identity provider is only logged.
+		if err := s.validateLogoutResponse(r); err != nil {
+			log.Warnf("SAML [handleSLO]: invalid logout response from identity provider: %v", err)
+		}
+		http.Redirect(w, r, s.rancherAPIHost, http.StatusFound)
+		return
+	}
+
+	if r.Form.Get("SAMLRequest") == "" {
+		http.Error(w, "missing SAMLRequest or SAMLResponse", http.StatusBadRequest)
+		return
+	}
+
+	req, err := s.parseLogoutRequest(r)
+	if err != nil {
+		log.Warnf("SAML [handleSLO]: invalid logout request from identity provider: %v", err)
+		http.Error(w, "invalid logout request", http.StatusForbidden)
+		return
+	}
+
+	if err := s.deleteSessions(req); err != nil {
+		log.Errorf("SAML [handleSLO]: failed to end Rancher sessions: %v", err)
+		http.Error(w, "failed to end sessions", http.StatusInternalServerError)
+		return
+	}
+	tokens.DeleteAuthCookies(w, r)
+
+	s.writeLogoutResponse(w, r, req.ID)
+}
+
+// writeLogoutResponse answers a LogoutRequest of the identity provider, with the binding the
+// request came in with if the identity provider supports it.
+func (s *Provider) writeLogoutResponse(w http.ResponseWriter, r *http.Request, requestID string) {
+	binding := saml.HTTPRedirectBinding
+	if r.Method == http.MethodPost {
+		binding = saml.HTTPPostBinding
+	}
+	location := s.serviceProvider.GetSLOBindingLocation(binding)
+	if location == "" {
+		location, binding = s.sloLocation()
+	}
+
+	resp, err := s.serviceProvider.MakeLogoutResponse(location, requestID)
+	if err != nil {
+		log.Errorf("SAML [handleSLO]: failed to create logout response: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if binding == saml.HTTPPostBinding {
+		writePostForm(w, resp.Post(r.Form.Get("RelayState")))
+		return
+	}
+
+	resp.Signature = nil
+	redirect, err := s.redirectMessage(location, "SAMLResponse", resp.Element(), r.Form.Get("RelayState"))
+	if err != nil {
+		log.Errorf("SAML [handleSLO]: failed to encode logout response: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// redirectMessage returns the URL sending the message to location with the HTTP-Redirect binding.
+func (s *Provider) redirectMessage(location, param string, el *etree.Element, relayState string) (*url.URL, error) {
+	message, err := deflateMessage(el)
+	if err != nil {
+		return nil, err
+	}
+	var key *rsa.PrivateKey
+	if s.signRequests {
+		key = s.serviceProvider.Key
+	}
+	return redirectURL(location, param, message, relayState, key)
+}
+
+func (s *Provider) parseLogoutRequest(r *http.Request) (*saml.LogoutRequest, error) {
+	certs, err := idpSigningCertificates(s.serviceProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	if r.Method == http.MethodPost {
+		message, err := base64.StdEncoding.DecodeString(r.PostForm.Get("SAMLRequest"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode base64: %v", err)
+		}
+		if raw, err = verifyPostSignature(message, certs); err != nil {
+			return nil, err
+		}
+	} else {
+		if raw, err = inflateMessage(r.URL.Query().Get("SAMLRequest")); err != nil {
+			return nil, err
+		}
+		if err := verifyRedirectSignature(r.URL.RawQuery, "SAMLRequest", certs); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &saml.LogoutRequest{}
+	if err := xml.Unmarshal(raw, req); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal logout request: %v", err)
+	}
+	if req.Issuer == nil || req.Issuer.Value != s.serviceProvider.IDPMetadata.EntityID {
+		return nil, fmt.Errorf("issuer does not match the IDP metadata (expected %q)", s.serviceProvider.IDPMetadata.EntityID)
+	}
+	if req.Destination != "" && req.Destination != s.serviceProvider.SloURL.String() {
+		return nil, fmt.Errorf("destination does not match SloURL (expected %q)", s.serviceProvider.SloURL.String())
+	}
+	if req.NameID == nil || req.NameID.Value == "" {
+		return nil, fmt.Errorf("logout request has no NameID")
+	}
+	if req.IssueInstant.Add(saml.MaxIssueDelay).Before(time.Now()) {
+		return nil, fmt.Errorf("issueInstant expired at %s", req.IssueInstant.Add(saml.MaxIssueDelay))
+	}
+	return req, nil
+}
+
+func (s *Provider) validateLogoutResponse(r *http.Request) error {
+	if r.Method == http.MethodPost {
+		return s.serviceProvider.ValidateLogoutResponseForm(r.PostForm.Get("SAMLResponse"))
+	}
+
+	certs, err := idpSigningCertificates(s.serviceProvider)
+	if err != nil {
+		return err
+	}
+	raw, err := inflateMessage(r.URL.Query().Get("SAMLResponse"))
+	if err != nil {
+		return err
+	}
+	if err := verifyRedirectSignature(r.URL.RawQuery, "SAMLResponse", certs); err != nil {
+		return err
+	}
+	resp := &saml.LogoutResponse{}
+	if err := xml.Unmarshal(raw, resp); err != nil {
+		return fmt.Errorf("cannot unmarshal logout response: %v", err)
+	}
+	if resp.Issuer == nil || resp.Issuer.Value != s.serviceProvider.IDPMetadata.EntityID {
+		return fmt.Errorf("issuer does not match the IDP metadata (expected %q)", s.serviceProvider.IDPMetadata.EntityID)
+	}
+	if resp.Status.StatusCode.Value != saml.StatusSuccess {
+		return fmt.Errorf("status code was %s", resp.Status.StatusCode.Value)
+	}
+	return nil
+}
+
+// deleteSessions deletes the login tokens of the session the identity provider ended. Without a
+// session index in the request all sessions of the NameID end.
+func (s *Provider) deleteSessions(req *saml.LogoutRequest) error {
+	sessions, err := s.tokenLister.List("", labels.SelectorFromSet(labels.Set{tokens.TokenKindLabel: "session"}))
+	if err != nil {
+		return err
+	}
+	for _, token := range sessions {
+		info := token.UserPrincipal.ExtraInfo
+		if token.AuthProvider != s.name || info[nameIDInfoKey] != req.NameID.Value {
+			continue
+		}
+		if req.SessionIndex != nil && req.SessionIndex.Value != "" && info[sessionIndexInfoKey] != req.SessionIndex.Value {
+			continue
+		}
+		log.Debugf("SAML [deleteSessions]: deleting token %s of user %s", token.Name, token.UserID)
+		if err := s.tokens.Delete(token.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePostForm(w http.ResponseWriter, form []byte) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte("<!DOCTYPE html><html><body>"))
+	w.Write(form)
+	w.Write([]byte("</body></html>"))
+}
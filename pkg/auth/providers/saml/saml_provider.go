@@ -4,6 +4,7 @@
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
@@ -50,6 +51,13 @@ type Provider struct {
 	groupType       string
 	clientState     ClientState
 	ldapProvider    common.AuthProvider
+	tokens          v3.TokenInterface
+	tokenLister     v3.TokenLister
+	sloURL          url.URL
+	logoutURL       url.URL
+	rancherAPIHost  string
+	signRequests    bool
+	singleLogout    bool
 }
 
 var SamlProviders = make(map[string]*Provider)
@@ -60,6 +68,8 @@ func Configure(ctx context.Context, mgmtCtx *config.ScaledContext, userMGR user.
 		authConfigs: mgmtCtx.Management.AuthConfigs(""),
 		secrets:     mgmtCtx.Core.Secrets(""),
 		samlTokens:  mgmtCtx.Management.SamlTokens(""),
+		tokens:      mgmtCtx.Management.Tokens(""),
+		tokenLister: mgmtCtx.Management.Tokens("").Controller().Lister(),
 		userMGR:     userMGR,
 		tokenMGR:    tokenMGR,
 		name:        name,
@@ -72,6 +82,7 @@ func Configure(ctx context.Context, mgmtCtx *config.ScaledContext, userMGR user.
 	}
 
 	SamlProviders[name] = samlp
+	tokens.RegisterProviderLogout(name, samlp.logout)
 	return samlp
 }
 
@@ -66,8 +66,10 @@ func NewAuthenticator(ctx context.Context, clusterRouter ClusterRouter, mgmtCtx
 		userAttributeLister: mgmtCtx.Management.UserAttributes("").Controller().Lister(),
 		userAttributes:      mgmtCtx.Management.UserAttributes(""),
 		userLister:          mgmtCtx.Management.Users("").Controller().Lister(),
+		clusterLister:       mgmtCtx.Management.Clusters("").Controller().Lister(),
 		clusterRouter:       clusterRouter,
 		userAuthRefresher:   providerrefresh.NewUserAuthRefresher(ctx, mgmtCtx),
+		tokenUsage:          newTokenUsageRecorder(mgmtCtx.Management.Tokens("")),
 	}
 }
 
@@ -78,8 +80,10 @@ type tokenAuthenticator struct {
 	userAttributes      v3.UserAttributeInterface
 	userAttributeLister v3.UserAttributeLister
 	userLister          v3.UserLister
+	clusterLister       v3.ClusterLister
 	clusterRouter       ClusterRouter
 	userAuthRefresher   providerrefresh.UserAuthRefresher
+	tokenUsage          *tokenUsageRecorder
 }
 
 const (
@@ -110,6 +114,12 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 	if token.ClusterName != "" && token.ClusterName != a.clusterRouter(req) {
 		return nil, errors.Wrapf(ErrMustAuthenticate, "clusterID does not match")
 	}
+	if err := checkTokenScope(token.Scope, req); err != nil {
+		return nil, err
+	}
+	if err := checkMFAEnrollment(token, req); err != nil {
+		return nil, err
+	}
 
 	attribs, err := a.userAttributeLister.Get("", token.UserID)
 	if err != nil && !apierrors.IsNotFound(err) {
@@ -154,6 +164,8 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 		go a.userAuthRefresher.TriggerUserRefresh(token.UserID, false)
 	}
 
+	a.tokenUsage.record(token, req)
+
 	authResp.IsAuthed = true
 	authResp.User = token.UserID
 	authResp.UserPrincipal = token.UserPrincipal.Name
@@ -203,7 +215,7 @@ func (a *tokenAuthenticator) TokenFromRequest(req *http.Request) (*v3.Token, err
 		storedToken = objs[0].(*v3.Token)
 	}
 
-	if _, err := tokens.VerifyToken(storedToken, tokenName, tokenKey); err != nil {
+	if _, err := tokens.VerifyToken(storedToken, tokenName, tokenKey, a.clusterLister); err != nil {
 		return nil, errors.Wrapf(ErrMustAuthenticate, "failed to verify token: %v", err)
 	}
 
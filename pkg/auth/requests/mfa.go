@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/rancher/pkg/auth/providers/local"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+)
+
+var errMFAEnrollmentOnly = httperror.NewAPIError(local.MFAEnrollmentRequired, "multi-factor authentication enrollment is required")
+
+// checkMFAEnrollment restricts the sessions of admins that still have to enroll in multi-factor
+// authentication to the enrollment itself.
+func checkMFAEnrollment(token *v3.Token, req *http.Request) error {
+	if token.Labels[tokens.MFAEnrollmentLabel] != "true" || mfaEnrollmentRequest(req) {
+		return nil
+	}
+	return errMFAEnrollmentOnly
+}
+
+func mfaEnrollmentRequest(req *http.Request) bool {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	action := req.URL.Query().Get("action")
+	switch {
+	case req.Method == http.MethodGet && path == "/v3/users":
+		return req.URL.Query().Get("me") == "true"
+	case req.Method == http.MethodPost && path == "/v3/users":
+		return action == "enrolltotp" || action == "activatetotp"
+	case req.Method == http.MethodPost && path == "/v3/tokens":
+		return action == "logout"
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckMFAEnrollment(t *testing.T) {
+	enrollment := &v3.Token{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{tokens.MFAEnrollmentLabel: "true"}}}
+	session := &v3.Token{}
+
+	tests := []struct {
+		name    string
+		token   *v3.Token
+		method  string
+		path    string
+		allowed bool
+	}{
+		{name: "regular session", token: session, method: "GET", path: "/v3/clusters", allowed: true},
+		{name: "enroll", token: enrollment, method: "POST", path: "/v3/users?action=enrolltotp", allowed: true},
+		{name: "activate", token: enrollment, method: "POST", path: "/v3/users?action=activatetotp", allowed: true},
+		{name: "current user", token: enrollment, method: "GET", path: "/v3/users?me=true", allowed: true},
+		{name: "logout", token: enrollment, method: "POST", path: "/v3/tokens?action=logout", allowed: true},
+		{name: "disable", token: enrollment, method: "POST", path: "/v3/users?action=disabletotp"},
+		{name: "list users", token: enrollment, method: "GET", path: "/v3/users"},
+		{name: "create user", token: enrollment, method: "POST", path: "/v3/users"},
+		{name: "clusters", token: enrollment, method: "GET", path: "/v3/clusters"},
+		{name: "downstream", token: enrollment, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/pods"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMFAEnrollment(tt.token, httptest.NewRequest(tt.method, tt.path, nil))
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package requests
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/httperror"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit"
+)
+
+var ErrOutOfScope = httperror.NewAPIError(httperror.PermissionDenied, "request is outside of the token's scope")
+
+// checkTokenScope returns an error if the request is not allowed by the scope of the token it is
+// authenticated with. Unscoped tokens allow every request.
+func checkTokenScope(scope *v32.TokenScope, req *http.Request) error {
+	if scope == nil {
+		return nil
+	}
+
+	attrs := audit.GetRequestAttributes(req)
+	if len(scope.Clusters) > 0 && !scopeContains(scope.Clusters, attrs.ClusterID) {
+		return errors.Wrapf(ErrOutOfScope, "cluster %s", attrs.ClusterID)
+	}
+	if len(scope.Projects) > 0 && !scopeContains(scope.Projects, attrs.ProjectID) {
+		return errors.Wrapf(ErrOutOfScope, "project %s", attrs.ProjectID)
+	}
+	// These hand out credentials that are not bound to the scope: a kubeconfig token or a shell
+	// running with the user's full permissions.
+	if createsCredentials(req, attrs) {
+		return errors.Wrapf(ErrOutOfScope, "%s %s", attrs.Verb, attrs.Resource)
+	}
+	// Impersonation is allowed if the scope allows the impersonate verb on what is impersonated,
+	// the same way kubernetes authorizes it.
+	for _, resource := range impersonatedResources(req) {
+		if !scopeAllows(scope, resource, "impersonate") {
+			return errors.Wrapf(ErrOutOfScope, "impersonate %s", resource)
+		}
+	}
+	if !scopeAllows(scope, attrs.Resource, attrs.Verb) {
+		return errors.Wrapf(ErrOutOfScope, "%s %s", attrs.Verb, attrs.Resource)
+	}
+	return nil
+}
+
+func scopeAllows(scope *v32.TokenScope, resource, verb string) bool {
+	if len(scope.Rules) == 0 {
+		return true
+	}
+	for _, rule := range scope.Rules {
+		if scopeContains(rule.Resources, resource) && scopeContains(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func createsCredentials(req *http.Request, attrs *audit.RequestAttributes) bool {
+	if attrs.Resource != "clusters" && attrs.Resource != "management.cattle.io.clusters" {
+		return false
+	}
+	query := req.URL.Query()
+	return query.Get("action") == "generateKubeconfig" || query.Get("shell") == "true" || query.Get("link") == "shell"
+}
+
+func impersonatedResources(req *http.Request) []string {
+	var resources []string
+	if user := req.Header.Get("Impersonate-User"); strings.HasPrefix(user, "system:serviceaccount:") {
+		resources = append(resources, "serviceaccounts")
+	} else if user != "" {
+		resources = append(resources, "users")
+	}
+	if len(req.Header.Values("Impersonate-Group")) > 0 {
+		resources = append(resources, "groups")
+	}
+	for header := range req.Header {
+		if strings.HasPrefix(header, "Impersonate-Extra-") {
+			resources = append(resources, "userextras")
+			break
+		}
+	}
+	return resources
+}
+
+func scopeContains(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package requests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTokenScope(t *testing.T) {
+	scope := &v32.TokenScope{
+		Clusters: []string{"c-abcde"},
+		Rules: []v32.TokenScopeRule{
+			{Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+			{Resources: []string{"*"}, Verbs: []string{"watch"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		scope   *v32.TokenScope
+		method  string
+		path    string
+		header  string
+		allowed bool
+	}{
+		{name: "unscoped", method: "DELETE", path: "/k8s/clusters/c-other/api/v1/namespaces/default/pods/p", allowed: true},
+		{name: "allowed verb and resource", scope: scope, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/p", allowed: true},
+		{name: "wildcard resource", scope: scope, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/secrets?watch=true", allowed: true},
+		{name: "verb not allowed", scope: scope, method: "DELETE", path: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/p"},
+		{name: "resource not allowed", scope: scope, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/secrets"},
+		{name: "other cluster", scope: scope, method: "GET", path: "/k8s/clusters/c-other/api/v1/pods"},
+		{name: "local cluster", scope: scope, method: "GET", path: "/v1/pods"},
+		{name: "impersonation", scope: scope, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/pods", header: "Impersonate-User"},
+		{name: "group impersonation", scope: scope, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/pods", header: "Impersonate-Group"},
+		{name: "impersonation allowed by scope", scope: &v32.TokenScope{Rules: []v32.TokenScopeRule{
+			{Resources: []string{"pods"}, Verbs: []string{"get"}},
+			{Resources: []string{"users"}, Verbs: []string{"impersonate"}},
+		}}, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/p", header: "Impersonate-User", allowed: true},
+		{name: "unrestricted impersonation", scope: &v32.TokenScope{Clusters: []string{"c-abcde"}}, method: "GET", path: "/k8s/clusters/c-abcde/api/v1/pods", header: "Impersonate-User", allowed: true},
+		{name: "generate kubeconfig", scope: &v32.TokenScope{Clusters: []string{"c-abcde"}}, method: "POST", path: "/v3/clusters/c-abcde?action=generateKubeconfig"},
+		{name: "steve generate kubeconfig", scope: &v32.TokenScope{Clusters: []string{"local"}}, method: "POST", path: "/v1/management.cattle.io.clusters/c-abcde?action=generateKubeconfig"},
+		{name: "kubectl shell", scope: &v32.TokenScope{Clusters: []string{"c-abcde"}}, method: "GET", path: "/v3/clusters/c-abcde?shell=true"},
+		{name: "cluster", scope: &v32.TokenScope{Clusters: []string{"c-abcde"}}, method: "GET", path: "/v3/clusters/c-abcde", allowed: true},
+		{name: "project", scope: &v32.TokenScope{Projects: []string{"c-abcde:p-xyz"}}, method: "GET", path: "/v3/project/c-abcde:p-xyz/apps", allowed: true},
+		{name: "other project", scope: &v32.TokenScope{Projects: []string{"c-abcde:p-xyz"}}, method: "GET", path: "/v3/project/c-abcde:p-other/apps"},
+		{name: "outside any project", scope: &v32.TokenScope{Projects: []string{"c-abcde:p-xyz"}}, method: "GET", path: "/v3/clusters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, "admin")
+			}
+			err := checkTokenScope(tt.scope, req)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package requests
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tokenUsageInterval is how often the last use of a token is written at most. Writing on every
+// request would turn each API call into a write to the token.
+const tokenUsageInterval = time.Minute
+
+// tokenUsageRecorder records when and from where tokens were last used.
+type tokenUsageRecorder struct {
+	sync.Mutex
+	tokenClient v3.TokenInterface
+	// recorded holds the last write per token name, covering the time until the write reaches
+	// the token cache.
+	recorded map[string]time.Time
+	now      func() time.Time
+}
+
+func newTokenUsageRecorder(tokenClient v3.TokenInterface) *tokenUsageRecorder {
+	return &tokenUsageRecorder{
+		tokenClient: tokenClient,
+		recorded:    map[string]time.Time{},
+		now:         time.Now,
+	}
+}
+
+// record updates the last used timestamp and source IP of token in the background, unless they
+// were updated less than tokenUsageInterval ago.
+func (r *tokenUsageRecorder) record(token *v3.Token, req *http.Request) {
+	now := r.now()
+	from := sourceIP(req)
+	if !r.due(token, now) {
+		return
+	}
+
+	go func() {
+		patch, err := json.Marshal(map[string]interface{}{
+			"lastUsedAt":   now.UTC().Format(time.RFC3339),
+			"lastUsedFrom": from,
+		})
+		if err != nil {
+			logrus.Errorf("Failed to create last used patch for token %s: %v", token.Name, err)
+			return
+		}
+		if _, err := r.tokenClient.ObjectClient().Patch(token.Name, token, types.MergePatchType, patch); err != nil {
+			logrus.Debugf("Failed to record last use of token %s: %v", token.Name, err)
+		}
+	}()
+}
+
+func (r *tokenUsageRecorder) due(token *v3.Token, now time.Time) bool {
+	if lastUsed, err := time.Parse(time.RFC3339, token.LastUsedAt); err == nil && now.Sub(lastUsed) < tokenUsageInterval {
+		return false
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if now.Sub(r.recorded[token.Name]) < tokenUsageInterval {
+		return false
+	}
+	for name, recorded := range r.recorded {
+		if now.Sub(recorded) >= tokenUsageInterval {
+			delete(r.recorded, name)
+		}
+	}
+	r.recorded[token.Name] = now
+	return true
+}
+
+func sourceIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
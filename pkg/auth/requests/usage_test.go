@@ -0,0 +1,29 @@
+package requests
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTokenUsageDue(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := newTokenUsageRecorder(nil)
+
+	token := &v3.Token{ObjectMeta: metav1.ObjectMeta{Name: "token-abcde"}}
+	assert.True(t, r.due(token, now))
+	// Written moments ago, but the token cache has not caught up yet.
+	assert.False(t, r.due(token, now.Add(10*time.Second)))
+	assert.True(t, r.due(token, now.Add(tokenUsageInterval)))
+
+	token.LastUsedAt = now.Add(tokenUsageInterval).Format(time.RFC3339)
+	r.recorded = map[string]time.Time{}
+	assert.False(t, r.due(token, now.Add(tokenUsageInterval+30*time.Second)))
+	assert.True(t, r.due(token, now.Add(2*tokenUsageInterval)))
+
+	other := &v3.Token{ObjectMeta: metav1.ObjectMeta{Name: "token-fghij"}}
+	assert.True(t, r.due(other, now.Add(2*tokenUsageInterval)))
+}
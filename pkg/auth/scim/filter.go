@@ -0,0 +1,76 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// filter is the only form of SCIM filter identity providers send when looking up resources:
+// a single attribute compared for equality, e.g. userName eq "jdoe".
+type filter struct {
+	attribute string
+	value     string
+}
+
+func parseFilter(expr string) (*filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(expr, " ", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[1], "eq") {
+		return nil, newError(http.StatusBadRequest, "invalidFilter", fmt.Sprintf("unsupported filter %q, only 'attribute eq \"value\"' is supported", expr))
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(parts[2]))
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidFilter", fmt.Sprintf("filter value of %q must be a quoted string", expr))
+	}
+	return &filter{attribute: strings.ToLower(parts[0]), value: value}, nil
+}
+
+// matches reports whether the filter accepts a resource with the given attribute values. The
+// attributes are keyed by their lower cased name.
+func (f *filter) matches(attributes map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	// Attribute values are compared case insensitively, as userName and displayName are caseExact=false.
+	return strings.EqualFold(attributes[f.attribute], f.value)
+}
+
+// page applies the 1-based startIndex and count query parameters to a list of resources.
+func page(req *http.Request, resources []interface{}) *ListResponse {
+	start, err := strconv.Atoi(req.URL.Query().Get("startIndex"))
+	if err != nil || start < 1 {
+		start = 1
+	}
+	count, err := strconv.Atoi(req.URL.Query().Get("count"))
+	if err != nil || count < 0 {
+		count = len(resources)
+	}
+
+	total := len(resources)
+	if start > total {
+		resources = nil
+	} else {
+		resources = resources[start-1:]
+	}
+	if len(resources) > count {
+		resources = resources[:count]
+	}
+	if resources == nil {
+		resources = []interface{}{}
+	}
+
+	return &ListResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
@@ -0,0 +1,311 @@
+package scim
+
+import (
+	"net/http"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/local"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	groupPrincipalAnnotation = "scim.cattle.io/principal-id"
+
+	// groupPrincipalsKey is the entry of UserAttribute.GroupPrincipals holding the memberships
+	// pushed by SCIM. The entry of the provider itself is replaced on every login and refresh.
+	groupPrincipalsKey = "scim"
+)
+
+func (h *handler) groupByPrincipal(principalID string) (*v3.Group, error) {
+	groups, err := h.groupLister.List("", labels.SelectorFromSet(labels.Set{local.ExternalGroupLabel: "true"}))
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Annotations[groupPrincipalAnnotation] == principalID {
+			return group, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *handler) getProviderGroup(req *request, id string) (*v3.Group, error) {
+	group, err := h.groupLister.Get("", id)
+	if apierrors.IsNotFound(err) || (err == nil && group.Labels[local.ExternalGroupLabel] != "true") {
+		return nil, newError(http.StatusNotFound, "", "group "+id+" not found")
+	}
+	return group, err
+}
+
+// members returns the users holding the principal of group. Memberships are kept in the group
+// principals of the users' UserAttributes, which is what authorization reads them from, under
+// their own groupPrincipalsKey entry.
+func (h *handler) members(req *request, group *v3.Group) ([]Reference, error) {
+	attribs, err := h.userAttributeLister.List("", labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	principalID := group.Annotations[groupPrincipalAnnotation]
+	var members []Reference
+	for _, attrib := range attribs {
+		for _, principal := range attrib.GroupPrincipals[groupPrincipalsKey].Items {
+			if principal.Name == principalID {
+				members = append(members, Reference{
+					Value: attrib.Name,
+					Ref:   location("Users", attrib.Name),
+				})
+				break
+			}
+		}
+	}
+	return members, nil
+}
+
+func groupResource(req *request, group *v3.Group, members []Reference) *Group {
+	return &Group{
+		Schemas:     []string{groupSchema},
+		ID:          group.Name,
+		ExternalID:  group.Annotations[externalIDAnnotation],
+		DisplayName: group.DisplayName,
+		Members:     members,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      group.CreationTimestamp.UTC().Format(time.RFC3339),
+			Location:     location("Groups", group.Name),
+		},
+	}
+}
+
+func (h *handler) toSCIMGroup(req *request, group *v3.Group) (*Group, error) {
+	members, err := h.members(req, group)
+	if err != nil {
+		return nil, err
+	}
+	return groupResource(req, group, members), nil
+}
+
+func (h *handler) listGroups(req *request) (int, interface{}, error) {
+	f, err := parseFilter(req.URL.Query().Get("filter"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	groups, err := h.groupLister.List("", labels.SelectorFromSet(labels.Set{local.ExternalGroupLabel: "true"}))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Identity providers list groups to look them up by name; members are only included when
+	// asked for, as collecting them means going through every user.
+	withMembers := req.URL.Query().Get("excludedAttributes") != "members"
+	var resources []interface{}
+	for _, group := range groups {
+		if !f.matches(map[string]string{"id": group.Name, "displayname": group.DisplayName, "externalid": group.Annotations[externalIDAnnotation]}) {
+			continue
+		}
+		g := groupResource(req, group, nil)
+		if withMembers {
+			if g, err = h.toSCIMGroup(req, group); err != nil {
+				return 0, nil, err
+			}
+		}
+		resources = append(resources, g)
+	}
+	return http.StatusOK, page(req.Request, resources), nil
+}
+
+func (h *handler) getGroup(req *request) (int, interface{}, error) {
+	group, err := h.getProviderGroup(req, req.id)
+	if err != nil {
+		return 0, nil, err
+	}
+	g, err := h.toSCIMGroup(req, group)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, g, nil
+}
+
+func (h *handler) createGroup(req *request) (int, interface{}, error) {
+	input := &Group{}
+	if err := readBody(req, input); err != nil {
+		return 0, nil, err
+	}
+	if input.DisplayName == "" {
+		return 0, nil, newError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	principalID, err := groupPrincipalID(req.provider, input)
+	if err != nil {
+		return 0, nil, err
+	}
+	existing, err := h.groupByPrincipal(principalID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		return 0, nil, newError(http.StatusConflict, "uniqueness", "group "+input.DisplayName+" already exists")
+	}
+
+	group := &v3.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "g-",
+			Labels:       map[string]string{local.ExternalGroupLabel: "true"},
+			Annotations:  map[string]string{groupPrincipalAnnotation: principalID},
+		},
+		DisplayName: input.DisplayName,
+	}
+	if input.ExternalID != "" {
+		group.Annotations[externalIDAnnotation] = input.ExternalID
+	}
+	group, err = h.groups.Create(group)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := h.setMembers(req, group, nil, input.Members, false); err != nil {
+		return 0, nil, err
+	}
+	logrus.Infof("scim: provisioned group %s for principal %s", group.Name, principalID)
+
+	// The new memberships may not have reached the cache yet, so respond with what was requested.
+	return http.StatusCreated, groupResource(req, group, input.Members), nil
+}
+
+func (h *handler) replaceGroup(req *request) (int, interface{}, error) {
+	input := &Group{}
+	if err := readBody(req, input); err != nil {
+		return 0, nil, err
+	}
+	group, err := h.getProviderGroup(req, req.id)
+	if err != nil {
+		return 0, nil, err
+	}
+	return h.saveGroup(req, group, input)
+}
+
+func (h *handler) patchGroup(req *request) (int, interface{}, error) {
+	patch := &PatchRequest{}
+	if err := readBody(req, patch); err != nil {
+		return 0, nil, err
+	}
+	group, err := h.getProviderGroup(req, req.id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	g, err := h.toSCIMGroup(req, group)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := applyGroupPatch(g, patch.Operations); err != nil {
+		return 0, nil, err
+	}
+	return h.saveGroup(req, group, g)
+}
+
+func (h *handler) saveGroup(req *request, group *v3.Group, input *Group) (int, interface{}, error) {
+	current, err := h.members(req, group)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	renamed := input.DisplayName != "" && input.DisplayName != group.DisplayName
+	if renamed {
+		group = group.DeepCopy()
+		group.DisplayName = input.DisplayName
+		if group, err = h.groups.Update(group); err != nil {
+			return 0, nil, err
+		}
+	}
+	if err := h.setMembers(req, group, current, input.Members, renamed); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, groupResource(req, group, input.Members), nil
+}
+
+// deleteGroup removes the group and its principal from all of its members.
+func (h *handler) deleteGroup(req *request) (int, interface{}, error) {
+	group, err := h.getProviderGroup(req, req.id)
+	if err != nil {
+		return 0, nil, err
+	}
+	current, err := h.members(req, group)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := h.setMembers(req, group, current, nil, false); err != nil {
+		return 0, nil, err
+	}
+	if err := h.groups.Delete(group.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return 0, nil, err
+	}
+	logrus.Infof("scim: deprovisioned group %s", group.Name)
+	return http.StatusNoContent, nil, nil
+}
+
+// setMembers adds the group principal to the users that joined the group and removes it from
+// the ones that left. Existing members are only updated when the group was renamed.
+func (h *handler) setMembers(req *request, group *v3.Group, current, desired []Reference, renamed bool) error {
+	principal := v32.Principal{
+		ObjectMeta:    metav1.ObjectMeta{Name: group.Annotations[groupPrincipalAnnotation]},
+		DisplayName:   group.DisplayName,
+		PrincipalType: "group",
+		Provider:      req.provider,
+	}
+
+	want := map[string]bool{}
+	for _, member := range desired {
+		want[member.Value] = true
+	}
+	have := map[string]bool{}
+	for _, member := range current {
+		have[member.Value] = true
+	}
+
+	for userID := range want {
+		if have[userID] && !renamed {
+			continue
+		}
+		if _, err := h.getProviderUser(req, userID); err != nil {
+			return err
+		}
+		err := h.setUserGroups(req, userID, func(groups []v32.Principal) []v32.Principal {
+			var result []v32.Principal
+			for _, g := range groups {
+				if g.Name != principal.Name {
+					result = append(result, g)
+				}
+			}
+			return append(result, principal)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for userID := range have {
+		if want[userID] {
+			continue
+		}
+		err := h.setUserGroups(req, userID, func(groups []v32.Principal) []v32.Principal {
+			var result []v32.Principal
+			for _, g := range groups {
+				if g.Name != principal.Name {
+					result = append(result, g)
+				}
+			}
+			return result
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
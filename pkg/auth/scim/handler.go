@@ -0,0 +1,198 @@
+package scim
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/rancher/pkg/auth/providers"
+	"github.com/rancher/rancher/pkg/auth/providers/local"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/rancher/rancher/pkg/user"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// PathPrefix is where the SCIM 2.0 service provider is served.
+	PathPrefix = "/v1-scim/v2"
+
+	tokenSecretNamespace = "cattle-system"
+	tokenSecretName      = "scim-token"
+	tokenSecretKey       = "token"
+)
+
+type handler struct {
+	users               v3.UserInterface
+	userLister          v3.UserLister
+	userAttributes      v3.UserAttributeInterface
+	userAttributeLister v3.UserAttributeLister
+	groups              v3.GroupInterface
+	groupLister         v3.GroupLister
+	tokens              v3.TokenInterface
+	tokenLister         v3.TokenLister
+	secretLister        v1.SecretLister
+	userManager         user.Manager
+	tokenMGR            *tokens.Manager
+}
+
+// NewHandler returns the SCIM 2.0 endpoint identity providers push users, deactivations and
+// group memberships to. Users and groups are created as principals of the auth provider named
+// by the scim-auth-provider setting, and requests authenticate with the bearer token kept in
+// the cattle-system/scim-token secret. See userPrincipalValue and groupPrincipalID for the
+// externalId each provider expects for users and groups.
+func NewHandler(ctx context.Context, mgmt *config.ScaledContext) http.Handler {
+	h := &handler{
+		users:               mgmt.Management.Users(""),
+		userLister:          mgmt.Management.Users("").Controller().Lister(),
+		userAttributes:      mgmt.Management.UserAttributes(""),
+		userAttributeLister: mgmt.Management.UserAttributes("").Controller().Lister(),
+		groups:              mgmt.Management.Groups(""),
+		groupLister:         mgmt.Management.Groups("").Controller().Lister(),
+		tokens:              mgmt.Management.Tokens(""),
+		tokenLister:         mgmt.Management.Tokens("").Controller().Lister(),
+		secretLister:        mgmt.Core.Secrets("").Controller().Lister(),
+		userManager:         mgmt.UserManager,
+		tokenMGR:            tokens.NewManager(ctx, mgmt),
+	}
+
+	root := mux.NewRouter()
+	root.UseEncodedPath()
+	r := root.PathPrefix(PathPrefix).Subrouter()
+	r.Methods(http.MethodGet).Path("/ServiceProviderConfig").Handler(h.wrap(h.serviceProviderConfig))
+	r.Methods(http.MethodGet).Path("/Users").Handler(h.wrap(h.listUsers))
+	r.Methods(http.MethodPost).Path("/Users").Handler(h.wrap(h.createUser))
+	r.Methods(http.MethodGet).Path("/Users/{id}").Handler(h.wrap(h.getUser))
+	r.Methods(http.MethodPut).Path("/Users/{id}").Handler(h.wrap(h.replaceUser))
+	r.Methods(http.MethodPatch).Path("/Users/{id}").Handler(h.wrap(h.patchUser))
+	r.Methods(http.MethodDelete).Path("/Users/{id}").Handler(h.wrap(h.deleteUser))
+	r.Methods(http.MethodGet).Path("/Groups").Handler(h.wrap(h.listGroups))
+	r.Methods(http.MethodPost).Path("/Groups").Handler(h.wrap(h.createGroup))
+	r.Methods(http.MethodGet).Path("/Groups/{id}").Handler(h.wrap(h.getGroup))
+	r.Methods(http.MethodPut).Path("/Groups/{id}").Handler(h.wrap(h.replaceGroup))
+	r.Methods(http.MethodPatch).Path("/Groups/{id}").Handler(h.wrap(h.patchGroup))
+	r.Methods(http.MethodDelete).Path("/Groups/{id}").Handler(h.wrap(h.deleteGroup))
+	root.NotFoundHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		writeResponse(rw, http.StatusNotFound, newError(http.StatusNotFound, "", "not found"))
+	})
+	return root
+}
+
+// request carries what every SCIM operation needs.
+type request struct {
+	*http.Request
+	provider string
+	id       string
+}
+
+type handlerFunc func(req *request) (int, interface{}, error)
+
+func (h *handler) wrap(fn handlerFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		provider := settings.ScimAuthProvider.Get()
+		if provider == "" || provider == local.Name || !providers.ProviderNames[provider] {
+			writeResponse(rw, http.StatusNotFound, newError(http.StatusNotFound, "", "SCIM provisioning is not enabled"))
+			return
+		}
+		if err := h.authenticate(req); err != nil {
+			writeResponse(rw, http.StatusUnauthorized, err)
+			return
+		}
+
+		status, body, err := fn(&request{Request: req, provider: provider, id: mux.Vars(req)["id"]})
+		if err != nil {
+			scimErr, ok := err.(*Error)
+			if !ok {
+				if apierrors.IsNotFound(err) {
+					scimErr = newError(http.StatusNotFound, "", "resource not found")
+				} else {
+					logrus.Errorf("scim: %s %s failed: %v", req.Method, req.URL.Path, err)
+					scimErr = newError(http.StatusInternalServerError, "", "internal error")
+				}
+			}
+			writeResponse(rw, scimErr.Status, scimErr)
+			return
+		}
+		writeResponse(rw, status, body)
+	})
+}
+
+func (h *handler) authenticate(req *http.Request) *Error {
+	unauthorized := newError(http.StatusUnauthorized, "", "unauthorized")
+
+	auth := req.Header.Get("Authorization")
+	if len(auth) < len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return unauthorized
+	}
+	secret, err := h.secretLister.Get(tokenSecretNamespace, tokenSecretName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logrus.Errorf("scim: failed to get bearer token secret: %v", err)
+		}
+		return unauthorized
+	}
+	expected := secret.Data[tokenSecretKey]
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(auth[len("Bearer "):])) != 1 {
+		return unauthorized
+	}
+	return nil
+}
+
+func writeResponse(rw http.ResponseWriter, status int, body interface{}) {
+	rw.Header().Set("Content-Type", contentType)
+	rw.WriteHeader(status)
+	if body == nil {
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(body); err != nil {
+		logrus.Errorf("scim: failed to write response: %v", err)
+	}
+}
+
+func readBody(req *request, into interface{}) error {
+	if err := json.NewDecoder(req.Body).Decode(into); err != nil {
+		return newError(http.StatusBadRequest, "invalidSyntax", fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+func (h *handler) serviceProviderConfig(req *request) (int, interface{}, error) {
+	supported := func(supported bool) map[string]interface{} {
+		return map[string]interface{}{"supported": supported}
+	}
+	return http.StatusOK, map[string]interface{}{
+		"schemas":        []string{serviceProviderSchema},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 0},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the token stored in the cattle-system/scim-token secret",
+		}},
+	}, nil
+}
+
+func location(resourceType, id string) string {
+	return fmt.Sprintf("%s%s/%s/%s", settings.ServerURL.Get(), PathPrefix, resourceType, id)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,216 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// applyUserPatch applies the operations of a PATCH request to user.
+func applyUserPatch(user *User, ops []PatchOperation) error {
+	for _, op := range ops {
+		values, err := patchValues(op)
+		if err != nil {
+			return err
+		}
+		for path, value := range values {
+			remove := strings.EqualFold(op.Op, "remove")
+			switch path {
+			case "active":
+				active := false
+				if !remove {
+					if active, err = boolValue(value); err != nil {
+						return err
+					}
+				}
+				user.Active = &active
+			case "username":
+				if remove {
+					return newError(http.StatusBadRequest, "mutability", "userName cannot be removed")
+				}
+				user.UserName = stringValue(value)
+			case "displayname":
+				user.DisplayName = stringValueUnless(remove, value)
+			case "externalid":
+				user.ExternalID = stringValueUnless(remove, value)
+			case "name.formatted":
+				if user.Name == nil {
+					user.Name = &Name{}
+				}
+				user.Name.Formatted = stringValueUnless(remove, value)
+			default:
+				// Attributes Rancher does not keep, such as emails, are ignored rather than
+				// failing the whole request.
+			}
+		}
+	}
+	return nil
+}
+
+// applyGroupPatch applies the operations of a PATCH request to group.
+func applyGroupPatch(group *Group, ops []PatchOperation) error {
+	for _, op := range ops {
+		opName := strings.ToLower(op.Op)
+
+		// Member removal by filter, e.g. members[value eq "u-abcde"].
+		if strings.HasPrefix(strings.ToLower(op.Path), "members[") && strings.HasSuffix(op.Path, "]") {
+			if opName != "remove" {
+				return newError(http.StatusBadRequest, "invalidPath", fmt.Sprintf("unsupported operation %s on %s", op.Op, op.Path))
+			}
+			f, err := parseFilter(op.Path[len("members[") : len(op.Path)-1])
+			if err != nil {
+				return err
+			}
+			var members []Reference
+			for _, member := range group.Members {
+				if !f.matches(map[string]string{"value": member.Value}) {
+					members = append(members, member)
+				}
+			}
+			group.Members = members
+			continue
+		}
+
+		values, err := patchValues(op)
+		if err != nil {
+			return err
+		}
+		for path, value := range values {
+			switch path {
+			case "displayname":
+				if opName == "remove" {
+					return newError(http.StatusBadRequest, "mutability", "displayName cannot be removed")
+				}
+				group.DisplayName = stringValue(value)
+			case "externalid":
+				group.ExternalID = stringValueUnless(opName == "remove", value)
+			case "members":
+				refs, err := references(value)
+				if err != nil {
+					return err
+				}
+				switch opName {
+				case "add":
+					group.Members = addReferences(group.Members, refs)
+				case "replace":
+					group.Members = addReferences(nil, refs)
+				case "remove":
+					if value == nil {
+						group.Members = nil
+					} else {
+						group.Members = removeReferences(group.Members, refs)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// patchValues returns the values an operation sets, keyed by lower cased attribute path. An
+// operation without a path carries a map of attributes as its value.
+func patchValues(op PatchOperation) (map[string]interface{}, error) {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace", "remove":
+	default:
+		return nil, newError(http.StatusBadRequest, "invalidSyntax", fmt.Sprintf("unsupported patch operation %q", op.Op))
+	}
+
+	if op.Path != "" {
+		return map[string]interface{}{strings.ToLower(op.Path): op.Value}, nil
+	}
+
+	attributes, ok := op.Value.(map[string]interface{})
+	if !ok {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "operations without a path must have an object value")
+	}
+	values := map[string]interface{}{}
+	for k, v := range attributes {
+		if name, ok := v.(map[string]interface{}); ok && strings.EqualFold(k, "name") {
+			for subKey, subValue := range name {
+				values["name."+strings.ToLower(subKey)] = subValue
+			}
+			continue
+		}
+		values[strings.ToLower(k)] = v
+	}
+	return values, nil
+}
+
+func boolValue(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		// Some identity providers send booleans as "True" and "False".
+		b, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			return false, newError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("%q is not a boolean", v))
+		}
+		return b, nil
+	}
+	return false, newError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("%v is not a boolean", value))
+}
+
+func stringValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
+
+func stringValueUnless(remove bool, value interface{}) string {
+	if remove {
+		return ""
+	}
+	return stringValue(value)
+}
+
+func references(value interface{}) ([]Reference, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+
+	var refs []Reference
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok || stringValue(m["value"]) == "" {
+			return nil, newError(http.StatusBadRequest, "invalidValue", "members must be objects with a value")
+		}
+		refs = append(refs, Reference{Value: stringValue(m["value"]), Display: stringValue(m["display"])})
+	}
+	return refs, nil
+}
+
+func addReferences(existing, refs []Reference) []Reference {
+	seen := map[string]bool{}
+	for _, ref := range existing {
+		seen[ref.Value] = true
+	}
+	for _, ref := range refs {
+		if !seen[ref.Value] {
+			seen[ref.Value] = true
+			existing = append(existing, ref)
+		}
+	}
+	return existing
+}
+
+func removeReferences(existing, refs []Reference) []Reference {
+	remove := map[string]bool{}
+	for _, ref := range refs {
+		remove[ref.Value] = true
+	}
+	var result []Reference
+	for _, ref := range existing {
+		if !remove[ref.Value] {
+			result = append(result, ref)
+		}
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := parseFilter(`userName eq "jdoe@example.com"`)
+	assert.NoError(t, err)
+	assert.Equal(t, &filter{attribute: "username", value: "jdoe@example.com"}, f)
+	assert.True(t, f.matches(map[string]string{"username": "JDoe@example.com"}))
+	assert.False(t, f.matches(map[string]string{"username": "other"}))
+
+	f, err = parseFilter("")
+	assert.NoError(t, err)
+	assert.True(t, f.matches(nil))
+
+	_, err = parseFilter(`userName sw "j"`)
+	assert.Error(t, err)
+	_, err = parseFilter(`userName eq jdoe`)
+	assert.Error(t, err)
+}
+
+func TestPage(t *testing.T) {
+	resources := []interface{}{1, 2, 3, 4, 5}
+
+	list := page(httptest.NewRequest("GET", "/Users?startIndex=2&count=2", nil), resources)
+	assert.Equal(t, 5, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	assert.Equal(t, []interface{}{2, 3}, list.Resources)
+
+	list = page(httptest.NewRequest("GET", "/Users?startIndex=9", nil), resources)
+	assert.Equal(t, 0, list.ItemsPerPage)
+	assert.Equal(t, []interface{}{}, list.Resources)
+}
+
+func patchOps(t *testing.T, body string) []PatchOperation {
+	patch := &PatchRequest{}
+	if err := json.Unmarshal([]byte(body), patch); err != nil {
+		t.Fatal(err)
+	}
+	return patch.Operations
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	active := true
+	user := &User{UserName: "jdoe", DisplayName: "John", Active: &active}
+
+	// Azure AD style: capitalized op and string booleans.
+	err := applyUserPatch(user, patchOps(t, `{"Operations":[{"op":"Replace","path":"active","value":"False"}]}`))
+	assert.NoError(t, err)
+	assert.False(t, *user.Active)
+
+	// Okta style: no path, attributes in the value.
+	err = applyUserPatch(user, patchOps(t, `{"Operations":[{"op":"replace","value":{"active":true,"displayName":"John Doe","name":{"formatted":"J. Doe"}}}]}`))
+	assert.NoError(t, err)
+	assert.True(t, *user.Active)
+	assert.Equal(t, "John Doe", user.DisplayName)
+	assert.Equal(t, "J. Doe", user.Name.Formatted)
+
+	err = applyUserPatch(user, patchOps(t, `{"Operations":[{"op":"remove","path":"userName"}]}`))
+	assert.Error(t, err)
+	err = applyUserPatch(user, patchOps(t, `{"Operations":[{"op":"move","path":"active"}]}`))
+	assert.Error(t, err)
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	group := &Group{DisplayName: "devs", Members: []Reference{{Value: "u-a"}}}
+
+	err := applyGroupPatch(group, patchOps(t, `{"Operations":[{"op":"add","path":"members","value":[{"value":"u-b"},{"value":"u-a"}]}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Reference{{Value: "u-a"}, {Value: "u-b"}}, group.Members)
+
+	err = applyGroupPatch(group, patchOps(t, `{"Operations":[{"op":"remove","path":"members[value eq \"u-a\"]"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Reference{{Value: "u-b"}}, group.Members)
+
+	err = applyGroupPatch(group, patchOps(t, `{"Operations":[{"op":"replace","value":{"displayName":"developers","members":[{"value":"u-c"}]}}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "developers", group.DisplayName)
+	assert.Equal(t, []Reference{{Value: "u-c"}}, group.Members)
+
+	err = applyGroupPatch(group, patchOps(t, `{"Operations":[{"op":"remove","path":"members"}]}`))
+	assert.NoError(t, err)
+	assert.Empty(t, group.Members)
+}
+
+func TestUserPrincipalValue(t *testing.T) {
+	tests := []struct {
+		provider string
+		input    User
+		want     string
+		wantErr  bool
+	}{
+		{provider: "azuread", input: User{UserName: "jdoe@example.com", ExternalID: "0d1a8e0c-8a83-4c36-a6c2-1b2f3e4d5c6b"}, want: "0d1a8e0c-8a83-4c36-a6c2-1b2f3e4d5c6b"},
+		{provider: "azuread", input: User{UserName: "jdoe@example.com"}, wantErr: true},
+		{provider: "azuread", input: User{UserName: "jdoe@example.com", ExternalID: "jdoe"}, wantErr: true},
+		{provider: "github", input: User{UserName: "jdoe", ExternalID: "1234567"}, want: "1234567"},
+		{provider: "github", input: User{UserName: "jdoe", ExternalID: "jdoe"}, wantErr: true},
+		{provider: "openldap", input: User{UserName: "jdoe", ExternalID: "uid=jdoe,ou=users,dc=example,dc=com"}, want: "uid=jdoe,ou=users,dc=example,dc=com"},
+		{provider: "activedirectory", input: User{UserName: "jdoe", ExternalID: "jdoe"}, wantErr: true},
+		{provider: "keycloakoidc", input: User{UserName: "jdoe", ExternalID: "f3b1c2d4"}, want: "f3b1c2d4"},
+		{provider: "oidc", input: User{UserName: "jdoe"}, wantErr: true},
+		{provider: "okta", input: User{UserName: "jdoe@example.com"}, want: "jdoe@example.com"},
+	}
+	for _, tt := range tests {
+		got, err := userPrincipalValue(tt.provider, &tt.input)
+		if tt.wantErr {
+			assert.Error(t, err, tt.provider)
+			continue
+		}
+		assert.NoError(t, err, tt.provider)
+		assert.Equal(t, tt.want, got, tt.provider)
+	}
+}
@@ -0,0 +1,88 @@
+package scim
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/rancher/rancher/pkg/auth/providers/activedirectory"
+	"github.com/rancher/rancher/pkg/auth/providers/azure"
+	"github.com/rancher/rancher/pkg/auth/providers/github"
+	"github.com/rancher/rancher/pkg/auth/providers/googleoauth"
+	"github.com/rancher/rancher/pkg/auth/providers/keycloakoidc"
+	"github.com/rancher/rancher/pkg/auth/providers/ldap"
+	"github.com/rancher/rancher/pkg/auth/providers/oidc"
+	ldapv2 "gopkg.in/ldap.v2"
+)
+
+var (
+	guidRegexp    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	numericRegexp = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// userPrincipalValue returns the part of the user's principal ID after the provider prefix. It
+// has to be what the provider itself puts there at login, or the provisioned user is never linked
+// to the person logging in and a second user gets created. The externalId of the SCIM user
+// carries it:
+//
+//   - azuread: the object ID of the user
+//   - github: the numeric account ID
+//   - googleoauth: the numeric subject ID
+//   - activedirectory, openldap, freeipa: the distinguished name of the user
+//   - oidc, keycloakoidc: the subject (sub claim) of the user
+//   - SAML providers: the value of the UID attribute, defaulting to the userName
+func userPrincipalValue(provider string, input *User) (string, error) {
+	switch provider {
+	case azure.Name:
+		return requireExternalID(input.ExternalID, "the object ID of the user", guidRegexp.MatchString)
+	case github.Name, googleoauth.Name:
+		return requireExternalID(input.ExternalID, "the numeric ID of the user's account", numericRegexp.MatchString)
+	case activedirectory.Name, ldap.OpenLdapName, ldap.FreeIpaName:
+		return requireExternalID(input.ExternalID, "the distinguished name of the user", func(value string) bool {
+			_, err := ldapv2.ParseDN(value)
+			return err == nil
+		})
+	case oidc.Name, keycloakoidc.Name:
+		return requireExternalID(input.ExternalID, "the subject of the user", nil)
+	}
+	return firstNonEmpty(input.ExternalID, input.UserName), nil
+}
+
+// groupPrincipalID returns the principal ID of the group as the provider itself names it in the
+// group principals of users logging in, so that role bindings made for the provider's group also
+// apply to the members pushed by SCIM. The externalId of the SCIM group carries the value:
+//
+//   - azuread: the object ID of the group
+//   - github: the numeric ID of the team
+//   - googleoauth: the ID of the group
+//   - activedirectory, openldap, freeipa: the distinguished name of the group
+//   - oidc, keycloakoidc, SAML providers: the group name as sent at login, defaulting to the displayName
+func groupPrincipalID(provider string, input *Group) (string, error) {
+	var (
+		value string
+		err   error
+	)
+	switch provider {
+	case azure.Name:
+		value, err = requireExternalID(input.ExternalID, "the object ID of the group", guidRegexp.MatchString)
+	case github.Name:
+		value, err = requireExternalID(input.ExternalID, "the numeric ID of the team", numericRegexp.MatchString)
+		return github.Name + "_team://" + value, err
+	case googleoauth.Name:
+		value, err = requireExternalID(input.ExternalID, "the ID of the group", nil)
+	case activedirectory.Name, ldap.OpenLdapName, ldap.FreeIpaName:
+		value, err = requireExternalID(input.ExternalID, "the distinguished name of the group", func(value string) bool {
+			_, err := ldapv2.ParseDN(value)
+			return err == nil
+		})
+	default:
+		value = firstNonEmpty(input.ExternalID, input.DisplayName)
+	}
+	return provider + "_group://" + value, err
+}
+
+func requireExternalID(externalID, expected string, valid func(string) bool) (string, error) {
+	if externalID == "" || (valid != nil && !valid(externalID)) {
+		return "", newError(http.StatusBadRequest, "invalidValue", "externalId must be "+expected)
+	}
+	return externalID, nil
+}
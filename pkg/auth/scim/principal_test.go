@@ -0,0 +1,35 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupPrincipalID(t *testing.T) {
+	tests := []struct {
+		provider string
+		group    Group
+		want     string
+		wantErr  bool
+	}{
+		{provider: "azuread", group: Group{ExternalID: "8c2f1b5e-7a4d-4e0b-9f6a-3d1c2b4a5e6f", DisplayName: "admins"}, want: "azuread_group://8c2f1b5e-7a4d-4e0b-9f6a-3d1c2b4a5e6f"},
+		{provider: "azuread", group: Group{DisplayName: "admins"}, wantErr: true},
+		{provider: "github", group: Group{ExternalID: "1234", DisplayName: "admins"}, want: "github_team://1234"},
+		{provider: "github", group: Group{ExternalID: "admins"}, wantErr: true},
+		{provider: "googleoauth", group: Group{ExternalID: "01ci93xb3tmzyin", DisplayName: "admins"}, want: "googleoauth_group://01ci93xb3tmzyin"},
+		{provider: "activedirectory", group: Group{ExternalID: "CN=admins,DC=example,DC=com"}, want: "activedirectory_group://CN=admins,DC=example,DC=com"},
+		{provider: "openldap", group: Group{ExternalID: "admins"}, wantErr: true},
+		{provider: "keycloakoidc", group: Group{DisplayName: "admins"}, want: "keycloakoidc_group://admins"},
+		{provider: "okta", group: Group{ExternalID: "Everyone", DisplayName: "everyone"}, want: "okta_group://Everyone"},
+	}
+	for _, tt := range tests {
+		got, err := groupPrincipalID(tt.provider, &tt.group)
+		if tt.wantErr {
+			assert.Error(t, err, tt.provider)
+			continue
+		}
+		assert.NoError(t, err, tt.provider)
+		assert.Equal(t, tt.want, got)
+	}
+}
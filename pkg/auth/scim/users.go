@@ -0,0 +1,300 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	userNameAnnotation   = "scim.cattle.io/user-name"
+	externalIDAnnotation = "scim.cattle.io/external-id"
+)
+
+func userPrincipalPrefix(provider string) string {
+	return provider + "_user://"
+}
+
+// providerPrincipal returns the principal of the provider the user is known by, if any.
+func providerPrincipal(provider string, user *v3.User) string {
+	for _, id := range user.PrincipalIDs {
+		if strings.HasPrefix(id, userPrincipalPrefix(provider)) {
+			return id
+		}
+	}
+	return ""
+}
+
+func (h *handler) toSCIMUser(req *request, user *v3.User) *User {
+	principal := providerPrincipal(req.provider, user)
+	active := user.Enabled == nil || *user.Enabled
+	u := &User{
+		Schemas:     []string{userSchema},
+		ID:          user.Name,
+		ExternalID:  user.Annotations[externalIDAnnotation],
+		UserName:    firstNonEmpty(user.Annotations[userNameAnnotation], strings.TrimPrefix(principal, userPrincipalPrefix(req.provider))),
+		DisplayName: user.DisplayName,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      user.CreationTimestamp.UTC().Format(time.RFC3339),
+			Location:     location("Users", user.Name),
+		},
+	}
+
+	if attribs, err := h.userAttributeLister.Get("", user.Name); err == nil {
+		for _, principal := range attribs.GroupPrincipals[groupPrincipalsKey].Items {
+			ref := Reference{Value: principal.Name, Display: principal.DisplayName}
+			if group, err := h.groupByPrincipal(principal.Name); err == nil && group != nil {
+				ref.Value = group.Name
+				ref.Ref = location("Groups", group.Name)
+			}
+			u.Groups = append(u.Groups, ref)
+		}
+	}
+	return u
+}
+
+// getProviderUser returns the user with the given id if it belongs to the SCIM provider.
+func (h *handler) getProviderUser(req *request, id string) (*v3.User, error) {
+	user, err := h.userLister.Get("", id)
+	if apierrors.IsNotFound(err) || (err == nil && providerPrincipal(req.provider, user) == "") {
+		return nil, newError(http.StatusNotFound, "", "user "+id+" not found")
+	}
+	return user, err
+}
+
+func (h *handler) listUsers(req *request) (int, interface{}, error) {
+	f, err := parseFilter(req.URL.Query().Get("filter"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	users, err := h.userLister.List("", labels.Everything())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var resources []interface{}
+	for _, user := range users {
+		if providerPrincipal(req.provider, user) == "" {
+			continue
+		}
+		u := h.toSCIMUser(req, user)
+		if f.matches(map[string]string{"id": u.ID, "username": u.UserName, "externalid": u.ExternalID, "displayname": u.DisplayName}) {
+			resources = append(resources, u)
+		}
+	}
+	return http.StatusOK, page(req.Request, resources), nil
+}
+
+func (h *handler) getUser(req *request) (int, interface{}, error) {
+	user, err := h.getProviderUser(req, req.id)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, h.toSCIMUser(req, user), nil
+}
+
+func (h *handler) createUser(req *request) (int, interface{}, error) {
+	input := &User{}
+	if err := readBody(req, input); err != nil {
+		return 0, nil, err
+	}
+	if input.UserName == "" {
+		return 0, nil, newError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+
+	value, err := userPrincipalValue(req.provider, input)
+	if err != nil {
+		return 0, nil, err
+	}
+	principalID := userPrincipalPrefix(req.provider) + value
+	existing, err := h.userManager.GetUserByPrincipalID(principalID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		return 0, nil, newError(http.StatusConflict, "uniqueness", "user "+input.UserName+" already exists")
+	}
+
+	user, err := h.userManager.EnsureUser(principalID, displayName(input))
+	if err != nil {
+		return 0, nil, err
+	}
+	user, err = h.saveUser(req, user.Name, input)
+	if err != nil {
+		return 0, nil, err
+	}
+	logrus.Infof("scim: provisioned user %s for principal %s", user.Name, principalID)
+	return http.StatusCreated, h.toSCIMUser(req, user), nil
+}
+
+func (h *handler) replaceUser(req *request) (int, interface{}, error) {
+	input := &User{}
+	if err := readBody(req, input); err != nil {
+		return 0, nil, err
+	}
+	if _, err := h.getProviderUser(req, req.id); err != nil {
+		return 0, nil, err
+	}
+
+	user, err := h.saveUser(req, req.id, input)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, h.toSCIMUser(req, user), nil
+}
+
+func (h *handler) patchUser(req *request) (int, interface{}, error) {
+	patch := &PatchRequest{}
+	if err := readBody(req, patch); err != nil {
+		return 0, nil, err
+	}
+	user, err := h.getProviderUser(req, req.id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	u := h.toSCIMUser(req, user)
+	if err := applyUserPatch(u, patch.Operations); err != nil {
+		return 0, nil, err
+	}
+	user, err = h.saveUser(req, req.id, u)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, h.toSCIMUser(req, user), nil
+}
+
+// deleteUser deprovisions a user: their tokens are revoked and the user is removed.
+func (h *handler) deleteUser(req *request) (int, interface{}, error) {
+	if _, err := h.getProviderUser(req, req.id); err != nil {
+		return 0, nil, err
+	}
+	if err := h.revokeTokens(req.id); err != nil {
+		return 0, nil, err
+	}
+	if err := h.users.Delete(req.id, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return 0, nil, err
+	}
+	logrus.Infof("scim: deprovisioned user %s", req.id)
+	return http.StatusNoContent, nil, nil
+}
+
+// saveUser updates the Rancher user from its SCIM representation. Deactivating a user revokes
+// their tokens.
+func (h *handler) saveUser(req *request, id string, input *User) (*v3.User, error) {
+	var (
+		user        *v3.User
+		deactivated bool
+	)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		user, err = h.users.Get(id, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		principal := strings.TrimPrefix(providerPrincipal(req.provider, user), userPrincipalPrefix(req.provider))
+		if input.UserName == "" || (principal != input.ExternalID && principal != input.UserName) {
+			return newError(http.StatusBadRequest, "mutability", "userName and externalId identify the principal of the user and cannot be changed")
+		}
+
+		wasActive := user.Enabled == nil || *user.Enabled
+		active := input.Active == nil || *input.Active
+		deactivated = wasActive && !active
+
+		if user.Annotations == nil {
+			user.Annotations = map[string]string{}
+		}
+		user.Annotations[userNameAnnotation] = input.UserName
+		if input.ExternalID != "" {
+			user.Annotations[externalIDAnnotation] = input.ExternalID
+		} else {
+			delete(user.Annotations, externalIDAnnotation)
+		}
+		if name := displayName(input); name != "" {
+			user.DisplayName = name
+		}
+		user.Enabled = &active
+
+		user, err = h.users.Update(user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deactivated {
+		logrus.Infof("scim: deactivated user %s", user.Name)
+		if err := h.revokeTokens(user.Name); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// revokeTokens deletes all tokens of a user so deprovisioned users lose access immediately
+// rather than when their tokens expire.
+func (h *handler) revokeTokens(userID string) error {
+	userTokens, err := h.tokenLister.List("", labels.SelectorFromSet(labels.Set{tokens.UserIDLabel: userID}))
+	if err != nil {
+		return err
+	}
+	for _, token := range userTokens {
+		if err := h.tokens.Delete(token.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// setUserGroups updates the group principals SCIM keeps for the user.
+func (h *handler) setUserGroups(req *request, userID string, update func(groups []v32.Principal) []v32.Principal) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		attribs, needCreate, err := h.tokenMGR.EnsureAndGetUserAttribute(userID)
+		if err != nil {
+			return err
+		}
+		if !needCreate {
+			// Read from the API rather than the cache so retries see the latest version.
+			if attribs, err = h.userAttributes.Get(userID, metav1.GetOptions{}); err != nil {
+				return err
+			}
+		}
+		if attribs.GroupPrincipals == nil {
+			attribs.GroupPrincipals = map[string]v32.Principals{}
+		}
+		attribs.GroupPrincipals[groupPrincipalsKey] = v32.Principals{Items: update(attribs.GroupPrincipals[groupPrincipalsKey].Items)}
+
+		if needCreate {
+			_, err = h.userAttributes.Create(attribs)
+		} else {
+			_, err = h.userAttributes.Update(attribs)
+		}
+		return err
+	})
+}
+
+func displayName(u *User) string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+	return u.UserName
+}
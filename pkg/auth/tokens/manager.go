@@ -7,6 +7,7 @@
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"sort"
 	"time"
 
@@ -36,17 +37,31 @@
 	userPrincipalIndex     = "authn.management.cattle.io/user-principal-index"
 	UserIDLabel            = "authn.management.cattle.io/token-userId"
 	TokenKindLabel         = "authn.management.cattle.io/kind"
+	MFAEnrollmentLabel     = "authn.management.cattle.io/mfa-enrollment"
 	TokenHashed            = "authn.management.cattle.io/token-hashed"
 	tokenKeyIndex          = "authn.management.cattle.io/token-key-index"
 	secretNameEnding       = "-secret"
 	secretNamespace        = "cattle-system"
 	KubeconfigResponseType = "kubeconfig"
+
+	// MFAEnrollmentTTL caps the lifetime of sessions that can only be used to enroll in multi-factor authentication.
+	MFAEnrollmentTTL = 15 * time.Minute
 )
 
 var (
 	toDeleteCookies = []string{CookieName, CSRFCookie}
+	providerLogouts = map[string]ProviderLogout{}
 )
 
+// ProviderLogout ends the session of a login token at the auth provider that issued it. It
+// returns the URL the user agent has to visit to complete the logout, if any.
+type ProviderLogout func(token *v3.Token) (string, error)
+
+// RegisterProviderLogout makes logout call fn for tokens of the named auth provider.
+func RegisterProviderLogout(provider string, fn ProviderLogout) {
+	providerLogouts[provider] = fn
+}
+
 func RegisterIndexer(ctx context.Context, apiContext *config.ScaledContext) error {
 	informer := apiContext.Management.Users("").Controller().Informer()
 	return informer.AddIndexers(map[string]cache.IndexFunc{userPrincipalIndex: userPrincipalIndexer})
@@ -64,6 +79,7 @@ func NewManager(ctx context.Context, apiContext *config.ScaledContext) *Manager
 		userAttributes:      apiContext.Management.UserAttributes(""),
 		userAttributeLister: apiContext.Management.UserAttributes("").Controller().Lister(),
 		userLister:          apiContext.Management.Users("").Controller().Lister(),
+		clusterLister:       apiContext.Management.Clusters("").Controller().Lister(),
 		secrets:             apiContext.Core.Secrets(""),
 		secretLister:        apiContext.Core.Secrets("").Controller().Lister(),
 	}
@@ -77,6 +93,7 @@ type Manager struct {
 	userIndexer         cache.Indexer
 	tokenIndexer        cache.Indexer
 	userLister          v3.UserLister
+	clusterLister       v3.ClusterLister
 	secrets             v1.SecretInterface
 	secretLister        v1.SecretLister
 }
@@ -104,6 +121,11 @@ func (m *Manager) createDerivedToken(jsonInput clientv3.Token, tokenAuthValue st
 		return v3.Token{}, "", 500, fmt.Errorf("error validating max-ttl %v", err)
 	}
 
+	scope, err := derivedTokenScope(token, jsonInput.Scope)
+	if err != nil {
+		return v3.Token{}, "", 403, err
+	}
+
 	var unhashedTokenKey string
 	derivedToken := v3.Token{
 		UserPrincipal: token.UserPrincipal,
@@ -114,6 +136,7 @@ func (m *Manager) createDerivedToken(jsonInput clientv3.Token, tokenAuthValue st
 		ProviderInfo:  token.ProviderInfo,
 		Description:   jsonInput.Description,
 		ClusterName:   jsonInput.ClusterID,
+		Scope:         scope,
 	}
 	derivedToken, unhashedTokenKey, err = m.createToken(&derivedToken)
 
@@ -121,6 +144,25 @@ func (m *Manager) createDerivedToken(jsonInput clientv3.Token, tokenAuthValue st
 
 }
 
+// derivedTokenScope returns the scope of a token derived from token. Tokens derived from a scoped
+// token keep its scope, so that a scoped token cannot be used to escape it.
+func derivedTokenScope(token *v3.Token, input *clientv3.TokenScope) (*v32.TokenScope, error) {
+	var scope *v32.TokenScope
+	if input != nil {
+		scope = &v32.TokenScope{}
+		if err := convert.ToObj(input, scope); err != nil {
+			return nil, err
+		}
+	}
+	if token.Scope == nil {
+		return scope, nil
+	}
+	if scope != nil && !reflect.DeepEqual(scope, token.Scope) {
+		return nil, errors.New("a scoped token can only create tokens with the same scope")
+	}
+	return token.Scope.DeepCopy(), nil
+}
+
 // createToken returns the token object and it's unhashed token key, which is stored hashed
 func (m *Manager) createToken(k8sToken *v3.Token) (v3.Token, string, error) {
 	key, err := randomtoken.Generate()
@@ -180,7 +222,7 @@ func (m *Manager) getToken(tokenAuthValue string) (*v3.Token, int, error) {
 		storedToken = objs[0].(*v3.Token)
 	}
 
-	if code, err := VerifyToken(storedToken, tokenName, tokenKey); err != nil {
+	if code, err := VerifyToken(storedToken, tokenName, tokenKey, m.clusterLister); err != nil {
 		return nil, code, err
 	}
 
@@ -205,7 +247,7 @@ func (m *Manager) getTokens(tokenAuthValue string) ([]v3.Token, int, error) {
 	}
 
 	for _, t := range tokenList.Items {
-		if IsExpired(t) {
+		if IsExpired(t) || IsIdle(t, m.clusterLister) {
 			t.Expired = true
 		}
 		tokens = append(tokens, t)
@@ -357,6 +399,39 @@ func (m *Manager) logout(actionName string, action *types.Action, request *types
 		return httperror.NewAPIErrorLong(http.StatusUnauthorized, util.GetHTTPErrorCode(http.StatusUnauthorized), "No valid token cookie or auth header")
 	}
 
+	DeleteAuthCookies(w, r)
+	w.Header().Add("Content-type", "application/json")
+
+	var idpRedirectURL string
+	if token, _, err := m.getToken(tokenAuthValue); err == nil && providerLogouts[token.AuthProvider] != nil {
+		idpRedirectURL, err = providerLogouts[token.AuthProvider](token)
+		if err != nil {
+			// the Rancher session still ends, only the identity provider session is left
+			logrus.Errorf("Logout from auth provider %s failed with error: %v", token.AuthProvider, err)
+		}
+	}
+
+	//getToken
+	status, err := m.deleteToken(tokenAuthValue)
+	if err != nil {
+		logrus.Errorf("DeleteToken failed with error: %v", err)
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		return httperror.NewAPIErrorLong(status, util.GetHTTPErrorCode(status), fmt.Sprintf("%v", err))
+	}
+
+	if idpRedirectURL != "" {
+		return json.NewEncoder(w).Encode(map[string]string{
+			"type":           "logoutOutput",
+			"idpRedirectUrl": idpRedirectURL,
+		})
+	}
+	return nil
+}
+
+// DeleteAuthCookies clears the session and CSRF cookies of the user agent.
+func DeleteAuthCookies(w http.ResponseWriter, r *http.Request) {
 	isSecure := false
 	if r.URL.Scheme == "https" {
 		isSecure = true
@@ -374,18 +449,6 @@ func (m *Manager) logout(actionName string, action *types.Action, request *types
 		}
 		http.SetCookie(w, tokenCookie)
 	}
-	w.Header().Add("Content-type", "application/json")
-
-	//getToken
-	status, err := m.deleteToken(tokenAuthValue)
-	if err != nil {
-		logrus.Errorf("DeleteToken failed with error: %v", err)
-		if status == 0 {
-			status = http.StatusInternalServerError
-		}
-		return httperror.NewAPIErrorLong(status, util.GetHTTPErrorCode(status), fmt.Sprintf("%v", err))
-	}
-	return nil
 }
 
 func (m *Manager) getTokenFromRequest(request *types.APIContext) error {
@@ -629,6 +692,24 @@ func (m *Manager) UserAttributeChanged(attribs *v32.UserAttribute, provider stri
 }
 
 func (m *Manager) NewLoginToken(userID string, userPrincipal v3.Principal, groupPrincipals []v3.Principal, providerToken string, ttl int64, description string) (v3.Token, string, error) {
+	return m.newLoginToken(userID, userPrincipal, groupPrincipals, providerToken, ttl, description, map[string]string{
+		TokenKindLabel: "session",
+	})
+}
+
+// NewMFAEnrollmentToken creates a short-lived session that can only be used to enroll in multi-factor authentication.
+func (m *Manager) NewMFAEnrollmentToken(userID string, userPrincipal v3.Principal, groupPrincipals []v3.Principal, ttl int64, description string) (v3.Token, string, error) {
+	if maxTTL := MFAEnrollmentTTL.Milliseconds(); ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return m.newLoginToken(userID, userPrincipal, groupPrincipals, "", ttl, description, map[string]string{
+		TokenKindLabel:     "session",
+		MFAEnrollmentLabel: "true",
+	})
+}
+
+func (m *Manager) newLoginToken(userID string, userPrincipal v3.Principal, groupPrincipals []v3.Principal, providerToken string, ttl int64,
+	description string, labels map[string]string) (v3.Token, string, error) {
 	provider := userPrincipal.Provider
 	// Providers that use oauth need to create a secret for storing the access token.
 	if (provider == "github" || provider == "azuread" || provider == "googleoauth" || provider == "oidc" || provider == "keycloakoidc") && providerToken != "" {
@@ -658,9 +739,7 @@ func (m *Manager) NewLoginToken(userID string, userPrincipal v3.Principal, group
 		AuthProvider:  provider,
 		Description:   description,
 		ObjectMeta: metav1.ObjectMeta{
-			Labels: map[string]string{
-				TokenKindLabel: "session",
-			},
+			Labels: labels,
 		},
 	}
 	return m.createToken(token)
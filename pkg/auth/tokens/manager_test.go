@@ -8,10 +8,14 @@
 	"time"
 
 	"github.com/rancher/norman/types"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/features"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/wrangler/pkg/randomtoken"
 	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
@@ -195,3 +199,41 @@ func (d *DummyIndexer) AddIndexers(newIndexers cache.Indexers) error {
 func (d *DummyIndexer) SetTokenHashed(enabled bool) {
 	d.hashedEnabled = enabled
 }
+
+func TestIsIdle(t *testing.T) {
+	assert.NoError(t, settings.AuthTokenIdleTimeoutMinutes.Set("60"))
+	defer settings.AuthTokenIdleTimeoutMinutes.Set("0")
+
+	clusterLister := &fakes.ClusterListerMock{
+		GetFunc: func(namespace, name string) (*v32.Cluster, error) {
+			cluster := &v32.Cluster{ObjectMeta: v1.ObjectMeta{Name: name}}
+			switch name {
+			case "c-ace":
+				cluster.Spec.LocalClusterAuthEndpoint.Enabled = true
+			case "c-proxy":
+			default:
+				return nil, apierrors.NewNotFound(v32.Resource("clusters"), name)
+			}
+			return cluster, nil
+		},
+	}
+
+	created := v1.NewTime(time.Now().Add(-2 * time.Hour))
+	newToken := func(kind, clusterName, lastUsedAt string) v3.Token {
+		return v3.Token{
+			ObjectMeta:  v1.ObjectMeta{CreationTimestamp: created, Labels: map[string]string{TokenKindLabel: kind}},
+			ClusterName: clusterName,
+			LastUsedAt:  lastUsedAt,
+		}
+	}
+	recently := time.Now().Add(-time.Minute).Format(time.RFC3339)
+
+	assert.True(t, IsIdle(newToken("session", "", ""), clusterLister))
+	assert.False(t, IsIdle(newToken("session", "", recently), clusterLister))
+	assert.True(t, IsIdle(newToken(KubeconfigResponseType, "", ""), clusterLister))
+	assert.False(t, IsIdle(newToken(KubeconfigResponseType, "c-ace", ""), clusterLister), "tokens of clusters with the authorized cluster endpoint are used without Rancher")
+	assert.True(t, IsIdle(newToken(KubeconfigResponseType, "c-proxy", ""), clusterLister), "tokens of clusters used through the proxy record their use")
+	assert.False(t, IsIdle(newToken(KubeconfigResponseType, "c-proxy", recently), clusterLister))
+	assert.True(t, IsIdle(newToken(KubeconfigResponseType, "c-removed", ""), clusterLister))
+	assert.False(t, IsIdle(newToken("telemetry", "", ""), clusterLister))
+}
@@ -22,6 +22,7 @@ func StartPurgeDaemon(ctx context.Context, mgmt *config.ManagementContext) {
 		tokens:           mgmt.Management.Tokens(""),
 		samlTokensLister: mgmt.Management.SamlTokens("").Controller().Lister(),
 		samlTokens:       mgmt.Management.SamlTokens(""),
+		clusterLister:    mgmt.Management.Clusters("").Controller().Lister(),
 	}
 	go wait.JitterUntil(p.purge, time.Duration(intervalSeconds)*time.Second, .1, true, ctx.Done())
 }
@@ -31,6 +32,7 @@ type purger struct {
 	tokens           v3.TokenInterface
 	samlTokens       v3.SamlTokenInterface
 	samlTokensLister v3.SamlTokenLister
+	clusterLister    v3.ClusterLister
 }
 
 func (p *purger) purge() {
@@ -41,7 +43,7 @@ func (p *purger) purge() {
 
 	var count int
 	for _, token := range allTokens {
-		if IsExpired(*token) {
+		if IsExpired(*token) || IsIdle(*token, p.clusterLister) {
 			err = p.tokens.Delete(token.ObjectMeta.Name, &metav1.DeleteOptions{})
 			if err != nil && !clientbase.IsNotFound(err) {
 				logrus.Errorf("Error: while deleting expired token %v: %v", err, token.ObjectMeta.Name)
@@ -51,7 +53,7 @@ func (p *purger) purge() {
 		}
 	}
 	if count > 0 {
-		logrus.Infof("Purged %v expired tokens", count)
+		logrus.Infof("Purged %v expired or idle tokens", count)
 	}
 
 	// saml tokens store encrypted token for login request from rancher cli
@@ -12,8 +12,10 @@
 	"github.com/rancher/norman/types/convert"
 	"github.com/rancher/rancher/pkg/features"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/user"
 	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 func getAuthProviderName(principalID string) string {
@@ -58,6 +60,47 @@ func IsExpired(token v3.Token) bool {
 	return durationElapsed.Seconds() >= ttlDuration.Seconds()
 }
 
+// idleExpiringKinds are the token kinds that users create and can forget about: API keys, which
+// have no kind, login sessions and kubeconfig tokens.
+var idleExpiringKinds = map[string]bool{
+	"":                     true,
+	"session":              true,
+	KubeconfigResponseType: true,
+}
+
+// IsIdle returns true if the auth-token-idle-timeout-minutes setting is enabled and the token has
+// not been used for longer than it. Tokens that were never used are idle from their creation.
+// Tokens Rancher issues to itself, such as those of the telemetry client, never go idle. Neither
+// do tokens of clusters with the authorized cluster endpoint enabled: they are synced to the
+// cluster, which authenticates them without Rancher and so never records their use.
+func IsIdle(token v3.Token, clusterLister v3.ClusterLister) bool {
+	timeout := settings.AuthTokenIdleTimeoutMinutes.GetInt()
+	if timeout <= 0 || !idleExpiringKinds[token.Labels[TokenKindLabel]] {
+		return false
+	}
+
+	lastUsed := token.ObjectMeta.CreationTimestamp.Time
+	if t, err := time.Parse(time.RFC3339, token.LastUsedAt); err == nil && t.After(lastUsed) {
+		lastUsed = t
+	}
+	if time.Since(lastUsed) < time.Duration(timeout)*time.Minute {
+		return false
+	}
+
+	if token.ClusterName != "" {
+		cluster, err := clusterLister.Get("", token.ClusterName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			// keep the token rather than expiring it on a transient error
+			logrus.Errorf("failed to get cluster %s of token %s: %v", token.ClusterName, token.Name, err)
+			return false
+		}
+		if err == nil && cluster.Spec.LocalClusterAuthEndpoint.Enabled {
+			return false
+		}
+	}
+	return true
+}
+
 func GetTokenAuthFromRequest(req *http.Request) string {
 	var tokenAuthValue string
 	authHeader := req.Header.Get(AuthHeaderName)
@@ -130,7 +173,7 @@ func extractClusterIDFromResponseType(responseType string) string {
 }
 
 // Given a stored token with hashed key, check if the provided (unhashed) tokenKey matches and is valid
-func VerifyToken(storedToken *v3.Token, tokenName, tokenKey string) (int, error) {
+func VerifyToken(storedToken *v3.Token, tokenName, tokenKey string, clusterLister v3.ClusterLister) (int, error) {
 	invalidAuthTokenErr := errors.New("Invalid auth token value")
 	if storedToken.ObjectMeta.Name != tokenName {
 		return 422, invalidAuthTokenErr
@@ -145,7 +188,7 @@ func VerifyToken(storedToken *v3.Token, tokenName, tokenKey string) (int, error)
 			return 422, invalidAuthTokenErr
 		}
 	}
-	if IsExpired(*storedToken) {
+	if IsExpired(*storedToken) || IsIdle(*storedToken, clusterLister) {
 		return 410, errors.New("must authenticate")
 	}
 	return 200, nil
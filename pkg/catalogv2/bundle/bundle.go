@@ -0,0 +1,155 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	name2 "github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Scheme is the URL scheme of repos seeded from an imported bundle, as in bundle://<repo name>.
+	Scheme = "bundle"
+
+	bundleLabel    = "catalog.cattle.io/bundle"
+	fileAnnotation = "catalog.cattle.io/bundle-file"
+	nextAnnotation = "catalog.cattle.io/next"
+
+	reposDir             = "repos"
+	indexFile            = "index.yaml"
+	chartsDir            = "charts"
+	iconsDir             = "icons"
+	imagesFile           = "images.txt"
+	imagesAndSourcesFile = "images-sources.txt"
+	windowsImagesFile    = "windows-images.txt"
+)
+
+type configMapGetter interface {
+	Get(namespace, name string) (*corev1.ConfigMap, error)
+}
+
+func IsBundle(repoURL string) bool {
+	return strings.HasPrefix(repoURL, Scheme+"://")
+}
+
+// URL returns the URL of the repo seeded from the bundled repo name.
+func URL(name string) string {
+	return Scheme + "://" + name
+}
+
+func bundleName(repoURL string) (string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != Scheme || u.Host == "" {
+		return "", fmt.Errorf("invalid bundle URL %s", repoURL)
+	}
+	return u.Host, nil
+}
+
+// configMapName returns the name of the ConfigMap holding chunk of file of a bundled repo. The
+// name only depends on the repo and the file so that a file can be read without listing.
+func configMapName(repoName, file string, chunk int) string {
+	h := sha256.Sum256([]byte(file))
+	return name2.SafeConcatName(repoName, "bundle", hex.EncodeToString(h[:])[:16], fmt.Sprint(chunk))
+}
+
+func readFile(configMaps configMapGetter, repoName, file string) ([]byte, error) {
+	cm, err := configMaps.Get(namespaces.System, configMapName(repoName, file, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s in bundle %s: %w", file, repoName, validation.NotFound)
+	}
+	if cm.Labels[bundleLabel] != repoName || cm.Annotations[fileAnnotation] != file {
+		return nil, fmt.Errorf("failed to find %s in bundle %s: %w", file, repoName, validation.NotFound)
+	}
+
+	data := cm.BinaryData["content"]
+	for next := cm.Annotations[nextAnnotation]; next != ""; next = cm.Annotations[nextAnnotation] {
+		cm, err = configMaps.Get(namespaces.System, next)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, cm.BinaryData["content"]...)
+	}
+	return data, nil
+}
+
+// DownloadIndex returns the index of an imported repo.
+func DownloadIndex(configMaps configMapGetter, repoURL string) (*repo.IndexFile, error) {
+	name, err := bundleName(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readFile(configMaps, name, indexFile)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse index of bundle %s: %w", name, err)
+	}
+	index.SortEntries()
+	return index, nil
+}
+
+func Chart(configMaps configMapGetter, repoURL string, chart *repo.ChartVersion) (io.ReadCloser, error) {
+	if len(chart.URLs) == 0 {
+		return nil, fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+
+	name, err := bundleName(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readFile(configMaps, name, chart.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Signature returns the signature file stored next to the archive of chart with the extension ext,
+// such as its provenance file, if it was exported with the bundle.
+func Signature(configMaps configMapGetter, repoURL string, chart *repo.ChartVersion, ext string) ([]byte, error) {
+	if len(chart.URLs) == 0 {
+		return nil, fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+
+	name, err := bundleName(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(configMaps, name, chart.URLs[0]+ext)
+}
+
+// Icon returns the icon of chart if it was exported with the bundle. Icons that could not be exported
+// keep their original URL, which is not served as it is most likely unreachable.
+func Icon(configMaps configMapGetter, repoURL string, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
+	if !strings.HasPrefix(chart.Icon, iconsDir+"/") {
+		return nil, "", fmt.Errorf("failed to find icon of chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+
+	name, err := bundleName(repoURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := readFile(configMaps, name, chart.Icon)
+	if err != nil {
+		return nil, "", err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), path.Ext(chart.Icon), nil
+}
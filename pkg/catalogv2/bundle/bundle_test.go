@@ -0,0 +1,347 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestExportImport(t *testing.T) {
+	chartData := chartArchive(t, "nginx", "1.0.0", "image:\n  repository: nginx\n  tag: 1.21.0\n")
+	content := &fakeContent{
+		index: &repo.IndexFile{
+			APIVersion: "v1",
+			Entries: map[string]repo.ChartVersions{
+				"nginx": {{
+					Metadata: &chart.Metadata{Name: "nginx", Version: "1.0.0", Icon: "https://example.com/nginx.svg"},
+					URLs:     []string{"https://example.com/nginx-1.0.0.tgz"},
+				}},
+			},
+		},
+		chart:  chartData,
+		icon:   []byte("<svg/>"),
+		prov:   []byte("provenance"),
+		policy: catalog.VerificationEnforce,
+	}
+
+	archive := &bytes.Buffer{}
+	require.NoError(t, Export(archive, content, []string{"charts"}))
+
+	files := readArchive(t, archive.Bytes())
+	assert.Equal(t, chartData, files["repos/charts/charts/nginx-1.0.0.tgz"])
+	assert.Equal(t, []byte("provenance"), files["repos/charts/charts/nginx-1.0.0.tgz.prov"])
+	assert.NotContains(t, files, "repos/charts/charts/nginx-1.0.0.tgz.sig")
+	assert.Equal(t, []byte("<svg/>"), files["repos/charts/icons/nginx-1.0.0.svg"])
+	assert.Equal(t, "nginx:1.21.0\n", string(files["images.txt"]))
+	assert.Equal(t, "nginx:1.21.0 nginx:1.0.0\n", string(files["images-sources.txt"]))
+
+	configMaps := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+	clusterRepos := &fakeClusterRepos{clusterRepos: map[string]*catalog.ClusterRepo{}}
+	// a file of a previous import that is no longer part of the bundle
+	configMaps.configMaps["stale"] = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      "stale",
+		Namespace: "cattle-system",
+		Labels:    map[string]string{bundleLabel: "charts"},
+	}}
+
+	names, err := NewImporter(clusterRepos, configMaps).Import(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"charts"}, names)
+	assert.Equal(t, "bundle://charts", clusterRepos.clusterRepos["charts"].Spec.URL)
+	assert.NotNil(t, clusterRepos.clusterRepos["charts"].Spec.ForceUpdate)
+	assert.NotContains(t, configMaps.configMaps, "stale")
+
+	index, err := DownloadIndex(configMaps.cache(), "bundle://charts")
+	require.NoError(t, err)
+	chartVersion, err := index.Get("nginx", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"charts/nginx-1.0.0.tgz"}, chartVersion.URLs)
+
+	rc, err := Chart(configMaps.cache(), "bundle://charts", chartVersion)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, chartData, data)
+
+	rc, suffix, err := Icon(configMaps.cache(), "bundle://charts", chartVersion)
+	require.NoError(t, err)
+	assert.Equal(t, ".svg", suffix)
+	data, err = ioutil.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("<svg/>"), data)
+
+	prov, err := Signature(configMaps.cache(), "bundle://charts", chartVersion, ".prov")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("provenance"), prov)
+	_, err = Signature(configMaps.cache(), "bundle://charts", chartVersion, ".sig")
+	assert.True(t, errors.Is(err, validation.NotFound))
+
+	// repos not seeded from a bundle are not replaced
+	clusterRepos.clusterRepos["charts"].Spec.URL = "https://example.com"
+	_, err = NewImporter(clusterRepos, configMaps).Import(bytes.NewReader(archive.Bytes()))
+	assert.Error(t, err)
+
+	// repos enforcing verification are not exported without signatures
+	content.prov = nil
+	assert.Error(t, Export(&bytes.Buffer{}, content, []string{"charts"}))
+	content.policy = catalog.VerificationWarn
+	assert.NoError(t, Export(&bytes.Buffer{}, content, []string{"charts"}))
+}
+
+func TestImportFailureCleanup(t *testing.T) {
+	tooLarge := map[string][]byte{"repos/charts/index.yaml": []byte("apiVersion: v1\n")}
+	for i := 0; i <= maxBundleSize/maxFileSize; i++ {
+		tooLarge[fmt.Sprintf("repos/charts/charts/big-1.0.%d.tgz", i)] = bytes.Repeat([]byte("a"), maxFileSize)
+	}
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+	}{
+		{
+			name:  "missing index",
+			files: map[string][]byte{"repos/charts/charts/nginx-1.0.0.tgz": []byte("chart")},
+		},
+		{
+			name: "file too large",
+			files: map[string][]byte{
+				"repos/charts/charts/nginx-1.0.0.tgz": []byte("chart"),
+				"repos/charts/charts/big-1.0.0.tgz":   bytes.Repeat([]byte("a"), maxFileSize+1),
+				"repos/charts/index.yaml":             []byte("apiVersion: v1\n"),
+			},
+		},
+		{
+			name:  "bundle too large",
+			files: tooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMaps := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+			clusterRepos := &fakeClusterRepos{clusterRepos: map[string]*catalog.ClusterRepo{}}
+
+			_, err := NewImporter(clusterRepos, configMaps).Import(bytes.NewReader(bundleArchive(t, tt.files)))
+			assert.Error(t, err)
+			assert.Empty(t, clusterRepos.clusterRepos)
+			assert.Empty(t, configMaps.configMaps)
+		})
+	}
+}
+
+func TestStoreFileChunks(t *testing.T) {
+	configMaps := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+	importer := NewImporter(&fakeClusterRepos{}, configMaps)
+	imported := &importedRepo{
+		clusterRepo: &catalog.ClusterRepo{ObjectMeta: metav1.ObjectMeta{Name: "charts"}},
+		configMaps:  map[string]bool{},
+	}
+
+	data := bytes.Repeat([]byte("a"), 2*maxSize+1)
+	require.NoError(t, importer.storeFile(imported, "charts/big-1.0.0.tgz", data))
+	assert.Len(t, configMaps.configMaps, 3)
+
+	stored, err := readFile(configMaps.cache(), "charts", "charts/big-1.0.0.tgz")
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+
+	_, err = readFile(configMaps.cache(), "other", "charts/big-1.0.0.tgz")
+	assert.Error(t, err)
+}
+
+func chartArchive(t *testing.T, name, version, values string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, writeFile(tw, name+"/Chart.yaml", []byte("apiVersion: v2\nname: "+name+"\nversion: "+version+"\n")))
+	require.NoError(t, writeFile(tw, name+"/values.yaml", []byte(values)))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func bundleArchive(t *testing.T, files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		require.NoError(t, writeFile(tw, name, files[name]))
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		files[header.Name], err = ioutil.ReadAll(tr)
+		require.NoError(t, err)
+	}
+}
+
+type fakeContent struct {
+	index  *repo.IndexFile
+	chart  []byte
+	icon   []byte
+	prov   []byte
+	policy catalog.VerificationPolicy
+}
+
+func (f *fakeContent) UnfilteredIndex(namespace, name string) (*repo.IndexFile, error) {
+	return f.index, nil
+}
+
+func (f *fakeContent) ChartVersionArchive(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(f.chart)), nil
+}
+
+func (f *fakeContent) ChartVersionIcon(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
+	return ioutil.NopCloser(bytes.NewReader(f.icon)), ".svg", nil
+}
+
+func (f *fakeContent) ChartVersionSignature(namespace, name string, chart *repo.ChartVersion, ext string) ([]byte, error) {
+	if ext != ".prov" || f.prov == nil {
+		return nil, fmt.Errorf("no %s: %w", ext, validation.NotFound)
+	}
+	return f.prov, nil
+}
+
+func (f *fakeContent) VerificationPolicy(namespace, name string) (catalog.VerificationPolicy, error) {
+	return f.policy, nil
+}
+
+type fakeConfigMaps struct {
+	configMaps map[string]*corev1.ConfigMap
+}
+
+type fakeConfigMapCache struct {
+	*fakeConfigMaps
+}
+
+func (f *fakeConfigMaps) cache() fakeConfigMapCache {
+	return fakeConfigMapCache{f}
+}
+
+func (f fakeConfigMapCache) Get(namespace, name string) (*corev1.ConfigMap, error) {
+	return f.fakeConfigMaps.Get(namespace, name, metav1.GetOptions{})
+}
+
+func (f *fakeConfigMaps) Create(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	f.configMaps[cm.Name] = cm
+	return cm, nil
+}
+
+func (f *fakeConfigMaps) Update(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	f.configMaps[cm.Name] = cm
+	return cm, nil
+}
+
+func (f *fakeConfigMaps) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	delete(f.configMaps, name)
+	return nil
+}
+
+func (f *fakeConfigMaps) Get(namespace, name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+	if cm, ok := f.configMaps[name]; ok {
+		return cm, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+
+func (f *fakeConfigMaps) List(namespace string, opts metav1.ListOptions) (*corev1.ConfigMapList, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.ConfigMapList{}
+	for _, cm := range f.configMaps {
+		if selector.Matches(labels.Set(cm.Labels)) {
+			list.Items = append(list.Items, *cm)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeConfigMaps) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, nil
+}
+
+func (f *fakeConfigMaps) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*corev1.ConfigMap, error) {
+	return nil, nil
+}
+
+type fakeClusterRepos struct {
+	clusterRepos map[string]*catalog.ClusterRepo
+}
+
+func (f *fakeClusterRepos) Create(cr *catalog.ClusterRepo) (*catalog.ClusterRepo, error) {
+	f.clusterRepos[cr.Name] = cr
+	return cr, nil
+}
+
+func (f *fakeClusterRepos) Update(cr *catalog.ClusterRepo) (*catalog.ClusterRepo, error) {
+	f.clusterRepos[cr.Name] = cr
+	return cr, nil
+}
+
+func (f *fakeClusterRepos) UpdateStatus(cr *catalog.ClusterRepo) (*catalog.ClusterRepo, error) {
+	return f.Update(cr)
+}
+
+func (f *fakeClusterRepos) Delete(name string, options *metav1.DeleteOptions) error {
+	delete(f.clusterRepos, name)
+	return nil
+}
+
+func (f *fakeClusterRepos) Get(name string, options metav1.GetOptions) (*catalog.ClusterRepo, error) {
+	if cr, ok := f.clusterRepos[name]; ok {
+		return cr, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "clusterrepos"}, name)
+}
+
+func (f *fakeClusterRepos) List(opts metav1.ListOptions) (*catalog.ClusterRepoList, error) {
+	return nil, nil
+}
+
+func (f *fakeClusterRepos) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, nil
+}
+
+func (f *fakeClusterRepos) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*catalog.ClusterRepo, error) {
+	return nil, nil
+}
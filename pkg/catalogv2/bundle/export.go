@@ -0,0 +1,225 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"github.com/rancher/rancher/pkg/image"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// Content is the catalog content bundles are exported from, as served by content.Manager. Repos
+// are exported with every chart version, not only those supported by this Rancher.
+type Content interface {
+	UnfilteredIndex(namespace, name string) (*repo.IndexFile, error)
+	ChartVersionArchive(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, error)
+	ChartVersionIcon(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, string, error)
+	ChartVersionSignature(namespace, name string, chart *repo.ChartVersion, ext string) ([]byte, error)
+	VerificationPolicy(namespace, name string) (catalog.VerificationPolicy, error)
+}
+
+// Export writes a gzipped tar archive of the given ClusterRepos to w. For each repo the archive holds
+// the chart archives, their provenance files and cosign signatures, and icons under repos/<name>/
+// and an index.yaml referring to them. Repos that enforce chart verification are only exported if
+// every chart has a signature file, so that the imported repo can still verify them. The images
+// referenced by the values of the charts are listed in images.txt, images-sources.txt and
+// windows-images.txt, in the format the rancher-save-images and rancher-load-images scripts expect.
+func Export(w io.Writer, content Content, repoNames []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	linuxImages := map[string]map[string]bool{}
+	windowsImages := map[string]map[string]bool{}
+	for _, name := range repoNames {
+		if err := exportRepo(tw, content, name, linuxImages, windowsImages); err != nil {
+			return fmt.Errorf("failed to export repo %s: %w", name, err)
+		}
+	}
+
+	images, imagesAndSources := image.ImageAndSourceLists(linuxImages)
+	windows, _ := image.ImageAndSourceLists(windowsImages)
+	if err := writeFile(tw, imagesFile, []byte(joinLines(images))); err != nil {
+		return err
+	}
+	if err := writeFile(tw, imagesAndSourcesFile, []byte(joinLines(imagesAndSources))); err != nil {
+		return err
+	}
+	if err := writeFile(tw, windowsImagesFile, []byte(joinLines(windows))); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func exportRepo(tw *tar.Writer, content Content, name string, linuxImages, windowsImages map[string]map[string]bool) error {
+	index, err := content.UnfilteredIndex("", name)
+	if err != nil {
+		return err
+	}
+	policy, err := content.VerificationPolicy("", name)
+	if err != nil {
+		return err
+	}
+
+	var chartNames []string
+	for chartName := range index.Entries {
+		chartNames = append(chartNames, chartName)
+	}
+	sort.Strings(chartNames)
+
+	for _, chartName := range chartNames {
+		for _, chartVersion := range index.Entries[chartName] {
+			file := fmt.Sprintf("%s-%s", chartVersion.Name, chartVersion.Version)
+
+			data, err := readAll(content.ChartVersionArchive("", name, chartVersion))
+			if err != nil {
+				return fmt.Errorf("failed to export chart %s: %w", file, err)
+			}
+			chartFile := path.Join(chartsDir, file+".tgz")
+			if err := writeFile(tw, path.Join(reposDir, name, chartFile), data); err != nil {
+				return err
+			}
+
+			source := fmt.Sprintf("%s:%s", chartVersion.Name, chartVersion.Version)
+			if err := addImages(linuxImages, source, data, image.Linux); err != nil {
+				return err
+			}
+			if err := addImages(windowsImages, source, data, image.Windows); err != nil {
+				return err
+			}
+
+			signed, err := exportSignatures(tw, content, name, chartFile, chartVersion)
+			if err != nil {
+				return fmt.Errorf("failed to export signatures of chart %s: %w", file, err)
+			}
+			if !signed && policy == catalog.VerificationEnforce {
+				return fmt.Errorf("repo enforces chart verification but chart %s has no provenance file or signature to export", file)
+			}
+
+			iconFile, err := exportIcon(tw, content, name, file, chartVersion)
+			if err != nil {
+				return err
+			}
+
+			digest := sha256.Sum256(data)
+			chartVersion.URLs = []string{chartFile}
+			chartVersion.Digest = hex.EncodeToString(digest[:])
+			chartVersion.Icon = iconFile
+		}
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFile(tw, path.Join(reposDir, name, indexFile), data)
+}
+
+// exportSignatures writes the provenance file and cosign signature of a chart version next to its
+// archive and reports whether it has any. Signatures of charts from OCI registries and git repos
+// are not available as files and so are not exported.
+func exportSignatures(tw *tar.Writer, content Content, name, chartFile string, chartVersion *repo.ChartVersion) (bool, error) {
+	signed := false
+	for _, ext := range []string{verify.ProvenanceExt, verify.SignatureExt} {
+		data, err := content.ChartVersionSignature("", name, chartVersion, ext)
+		if errors.Is(err, validation.NotFound) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if err := writeFile(tw, path.Join(reposDir, name, chartFile+ext), data); err != nil {
+			return false, err
+		}
+		signed = true
+	}
+	return signed, nil
+}
+
+// exportIcon writes the icon of a chart version to the archive and returns its path in the repo. A
+// chart whose icon cannot be downloaded keeps referencing the original icon.
+func exportIcon(tw *tar.Writer, content Content, name, file string, chartVersion *repo.ChartVersion) (string, error) {
+	if chartVersion.Icon == "" {
+		return "", nil
+	}
+	icon, suffix, err := content.ChartVersionIcon("", name, chartVersion)
+	data, err := readAll(icon, err)
+	if err != nil {
+		logrus.Warnf("Failed to export icon of chart %s of repo %s: %v", file, name, err)
+		return chartVersion.Icon, nil
+	}
+	iconFile := path.Join(iconsDir, file+suffix)
+	return iconFile, writeFile(tw, path.Join(reposDir, name, iconFile), data)
+}
+
+// addImages adds the images referenced by the values of a chart archive and its subcharts to imagesSet.
+func addImages(imagesSet map[string]map[string]bool, source string, data []byte, osType image.OSType) error {
+	c, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to load chart %s: %w", source, err)
+	}
+
+	charts := []*chart.Chart{c}
+	for len(charts) > 0 {
+		c, charts = charts[0], charts[1:]
+		for _, f := range c.Raw {
+			if f.Name != "values.yaml" {
+				continue
+			}
+			if err := image.AddImagesFromValues(imagesSet, source, f.Data, osType); err != nil {
+				return err
+			}
+		}
+		charts = append(charts, c.Dependencies()...)
+	}
+	return nil
+}
+
+func readAll(rc io.ReadCloser, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
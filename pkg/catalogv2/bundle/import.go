@@ -0,0 +1,302 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// maxSize is the size of the chunks files are stored in, below the 1MiB limit of ConfigMaps.
+	maxSize = 512 * 1024
+	// maxFileSize and maxBundleSize bound what an import stores in ConfigMaps, and so in etcd. etcd
+	// rejects writes once its database reaches its quota, 2GiB by default, and keeps the previous
+	// revisions of replaced ConfigMaps until it is compacted, so bundles are kept small.
+	maxFileSize   = 5 * 1024 * 1024
+	maxBundleSize = 50 * 1024 * 1024
+)
+
+// Importer seeds ClusterRepos from bundles written by Export. The files of a bundled repo are
+// stored in ConfigMaps owned by its ClusterRepo, so that every Rancher replica can serve them.
+type Importer struct {
+	clusterRepos catalogcontrollers.ClusterRepoClient
+	configMaps   corecontrollers.ConfigMapClient
+}
+
+func NewImporter(clusterRepos catalogcontrollers.ClusterRepoClient, configMaps corecontrollers.ConfigMapClient) *Importer {
+	return &Importer{
+		clusterRepos: clusterRepos,
+		configMaps:   configMaps,
+	}
+}
+
+type importedRepo struct {
+	clusterRepo *catalog.ClusterRepo
+	// created is set if the ClusterRepo was created by this import
+	created    bool
+	configMaps map[string]bool
+	// createdConfigMaps are the ConfigMaps that did not exist before this import
+	createdConfigMaps []string
+	// index is only stored once every other file of the repo is, so that a failed import leaves the
+	// index of a previous import in place
+	index    []byte
+	finished bool
+}
+
+// Import seeds a ClusterRepo named after each repo of the bundle read from r, returning their names.
+// ClusterRepos seeded by a previous import are replaced, other existing ClusterRepos are left alone
+// and fail the import. If the import fails, the ClusterRepos and files it created are removed.
+func (i *Importer) Import(r io.Reader) ([]string, error) {
+	repos := map[string]*importedRepo{}
+	names, err := i.importBundle(r, repos)
+	if err != nil {
+		i.cleanup(repos)
+		return nil, err
+	}
+	return names, nil
+}
+
+func (i *Importer) importBundle(r io.Reader, repos map[string]*importedRepo) ([]string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+
+	var (
+		tr    = tar.NewReader(gz)
+		names []string
+		total int64
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		// images lists are only used to mirror images to a private registry
+		parts := strings.SplitN(path.Clean(header.Name), "/", 3)
+		if header.Typeflag != tar.TypeReg || len(parts) != 3 || parts[0] != reposDir {
+			continue
+		}
+		name, file := parts[1], parts[2]
+
+		if header.Size > maxFileSize {
+			return nil, fmt.Errorf("%s in bundle is larger than %d bytes: %w", header.Name, maxFileSize, validation.InvalidBodyContent)
+		}
+		total += header.Size
+		if total > maxBundleSize {
+			return nil, fmt.Errorf("bundle is larger than %d bytes: %w", maxBundleSize, validation.InvalidBodyContent)
+		}
+
+		imported, ok := repos[name]
+		if !ok {
+			clusterRepo, created, err := i.ensureClusterRepo(name)
+			if err != nil {
+				return nil, err
+			}
+			imported = &importedRepo{
+				clusterRepo: clusterRepo,
+				created:     created,
+				configMaps:  map[string]bool{},
+			}
+			repos[name] = imported
+			names = append(names, name)
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from bundle: %w", header.Name, err)
+		}
+		if file == indexFile {
+			imported.index = data
+			continue
+		}
+		if err := i.storeFile(imported, file, data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range names {
+		if err := i.finish(repos[name]); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// cleanup removes the ClusterRepos and ConfigMaps created by a failed import. Repos that were
+// finished before the failure are complete and kept.
+func (i *Importer) cleanup(repos map[string]*importedRepo) {
+	for name, imported := range repos {
+		if imported.finished {
+			continue
+		}
+		for _, cm := range imported.createdConfigMaps {
+			if err := i.configMaps.Delete(namespaces.System, cm, nil); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Errorf("Failed to remove %s of failed import of clusterrepo %s: %v", cm, name, err)
+			}
+		}
+		if !imported.created {
+			continue
+		}
+		if err := i.clusterRepos.Delete(name, nil); err != nil && !apierrors.IsNotFound(err) {
+			logrus.Errorf("Failed to remove clusterrepo %s of failed import: %v", name, err)
+		}
+	}
+}
+
+// ensureClusterRepo returns the ClusterRepo of a bundled repo and whether it was created.
+func (i *Importer) ensureClusterRepo(name string) (*catalog.ClusterRepo, bool, error) {
+	clusterRepo, err := i.clusterRepos.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		clusterRepo, err = i.clusterRepos.Create(&catalog.ClusterRepo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: catalog.RepoSpec{
+				URL: URL(name),
+			},
+		})
+		return clusterRepo, err == nil, err
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if clusterRepo.Spec.URL != URL(name) {
+		return nil, false, fmt.Errorf("clusterrepo %s already exists and was not imported from a bundle: %w", name, validation.Conflict)
+	}
+	return clusterRepo, false, nil
+}
+
+// storeFile writes data to chunked ConfigMaps, linked by the catalog.cattle.io/next annotation like
+// the ConfigMaps of repo indexes.
+func (i *Importer) storeFile(imported *importedRepo, file string, data []byte) error {
+	name := imported.clusterRepo.Name
+	for chunk := 0; ; chunk++ {
+		var left []byte
+		if len(data) > maxSize {
+			data, left = data[:maxSize], data[maxSize:]
+		}
+
+		next := ""
+		if len(left) > 0 {
+			next = configMapName(name, file, chunk+1)
+		}
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configMapName(name, file, chunk),
+				Namespace: namespaces.System,
+				Labels: map[string]string{
+					bundleLabel: name,
+				},
+				Annotations: map[string]string{
+					fileAnnotation: file,
+					nextAnnotation: next,
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: catalog.SchemeGroupVersion.String(),
+					Kind:       "ClusterRepo",
+					Name:       name,
+					UID:        imported.clusterRepo.UID,
+				}},
+			},
+			BinaryData: map[string][]byte{
+				"content": data,
+			},
+		}
+		created, err := i.apply(cm)
+		if err != nil {
+			return fmt.Errorf("failed to store %s of bundle %s: %w", file, name, err)
+		}
+		imported.configMaps[cm.Name] = true
+		if created {
+			imported.createdConfigMaps = append(imported.createdConfigMaps, cm.Name)
+		}
+
+		if len(left) == 0 {
+			return nil
+		}
+		data = left
+	}
+}
+
+// apply creates or updates cm, returning whether it was created.
+func (i *Importer) apply(cm *corev1.ConfigMap) (bool, error) {
+	existing, err := i.configMaps.Get(cm.Namespace, cm.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = i.configMaps.Create(cm)
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	}
+
+	existing = existing.DeepCopy()
+	existing.Labels = cm.Labels
+	existing.Annotations = cm.Annotations
+	existing.OwnerReferences = cm.OwnerReferences
+	existing.BinaryData = cm.BinaryData
+	_, err = i.configMaps.Update(existing)
+	return false, err
+}
+
+// finish stores the index of the repo, removes the files of previous imports that are not part of
+// this one and refreshes the repo.
+func (i *Importer) finish(imported *importedRepo) error {
+	name := imported.clusterRepo.Name
+	if imported.index == nil {
+		return fmt.Errorf("bundle is missing the index of repo %s", name)
+	}
+	if err := i.storeFile(imported, indexFile, imported.index); err != nil {
+		return err
+	}
+
+	existing, err := i.configMaps.List(namespaces.System, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{bundleLabel: name}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	for _, cm := range existing.Items {
+		if imported.configMaps[cm.Name] {
+			continue
+		}
+		if err := i.configMaps.Delete(cm.Namespace, cm.Name, nil); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	clusterRepo, err := i.clusterRepos.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	clusterRepo = clusterRepo.DeepCopy()
+	now := metav1.NewTime(time.Now().Add(-time.Second))
+	clusterRepo.Spec.ForceUpdate = &now
+	if _, err := i.clusterRepos.Update(clusterRepo); err != nil {
+		return err
+	}
+
+	imported.finished = true
+	logrus.Infof("Imported clusterrepo %s from bundle", name)
+	return nil
+}
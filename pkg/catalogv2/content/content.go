@@ -3,20 +3,25 @@
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/url"
+	"path"
 	"sync"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/catalogv2"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/git"
 	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/rancher/pkg/catalogv2/oci"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/settings"
 	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
@@ -102,17 +107,28 @@ func (c *Manager) readBytes(cm *corev1.ConfigMap) ([]byte, error) {
 }
 
 func (c *Manager) Index(namespace, name string) (*repo.IndexFile, error) {
-	r, err := c.getRepo(namespace, name)
+	index, err := c.UnfilteredIndex(namespace, name)
 	if err != nil {
 		return nil, err
 	}
 
-	cm, err := c.configMaps.Get(r.status.IndexConfigMapNamespace, r.status.IndexConfigMapName)
+	k8sVersion, err := c.k8sVersion()
 	if err != nil {
 		return nil, err
 	}
 
-	k8sVersion, err := c.k8sVersion()
+	return c.filterReleases(index, k8sVersion), nil
+}
+
+// UnfilteredIndex returns the index of a repo with every chart version, including those Index
+// leaves out because they do not support the running Rancher or Kubernetes version.
+func (c *Manager) UnfilteredIndex(namespace, name string) (*repo.IndexFile, error) {
+	r, err := c.getRepo(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := c.configMaps.Get(r.status.IndexConfigMapNamespace, r.status.IndexConfigMapName)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +137,7 @@ func (c *Manager) Index(namespace, name string) (*repo.IndexFile, error) {
 	if cache, ok := c.IndexCache[fmt.Sprintf("%s/%s", r.status.IndexConfigMapNamespace, r.status.IndexConfigMapName)]; ok {
 		if cm.ResourceVersion == cache.revision {
 			c.lock.RUnlock()
-			return c.filterReleases(deepCopyIndex(cache.index), k8sVersion), nil
+			return deepCopyIndex(cache.index), nil
 		}
 	}
 	c.lock.RUnlock()
@@ -158,7 +174,7 @@ func (c *Manager) Index(namespace, name string) (*repo.IndexFile, error) {
 	}
 	c.lock.Unlock()
 
-	return c.filterReleases(deepCopyIndex(index), k8sVersion), nil
+	return deepCopyIndex(index), nil
 }
 
 func (c *Manager) k8sVersion() (*semver.Version, error) {
@@ -253,6 +269,11 @@ func (c *Manager) Icon(namespace, name, chartName, version string) (io.ReadClose
 		return nil, "", err
 	}
 
+	return c.ChartVersionIcon(namespace, name, chart)
+}
+
+// ChartVersionIcon returns the icon of a chart version of the index of the repo.
+func (c *Manager) ChartVersionIcon(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
 	repo, err := c.getRepo(namespace, name)
 	if err != nil {
 		return nil, "", err
@@ -262,6 +283,14 @@ func (c *Manager) Icon(namespace, name, chartName, version string) (io.ReadClose
 		return git.Icon(namespace, name, repo.status.URL, chart)
 	}
 
+	if bundle.IsBundle(repo.status.URL) {
+		return bundle.Icon(c.configMaps, repo.status.URL, chart)
+	}
+
+	if oci.IsOCI(repo.status.URL) {
+		return oci.Icon(chart)
+	}
+
 	secret, err := catalogv2.GetSecret(c.secrets, repo.spec, repo.metadata.Namespace)
 	if err != nil {
 		return nil, "", err
@@ -286,6 +315,11 @@ func (c *Manager) Chart(namespace, name, chartName, version string) (io.ReadClos
 		return nil, err
 	}
 
+	return c.ChartVersionArchive(namespace, name, chart)
+}
+
+// ChartVersionArchive returns the chart archive of a chart version of the index of the repo.
+func (c *Manager) ChartVersionArchive(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, error) {
 	repo, err := c.getRepo(namespace, name)
 	if err != nil {
 		return nil, err
@@ -295,11 +329,19 @@ func (c *Manager) Chart(namespace, name, chartName, version string) (io.ReadClos
 		return git.Chart(namespace, name, repo.status.URL, chart)
 	}
 
+	if bundle.IsBundle(repo.status.URL) {
+		return bundle.Chart(c.configMaps, repo.status.URL, chart)
+	}
+
 	secret, err := catalogv2.GetSecret(c.secrets, repo.spec, repo.metadata.Namespace)
 	if err != nil {
 		return nil, err
 	}
 
+	if oci.IsOCI(repo.status.URL) {
+		return oci.Chart(secret, repo.status.URL, repo.spec.CABundle, repo.spec.InsecureSkipTLSverify, chart)
+	}
+
 	return helmhttp.Chart(secret, repo.status.URL, repo.spec.CABundle, repo.spec.InsecureSkipTLSverify, chart)
 }
 
@@ -312,3 +354,141 @@ func (c *Manager) Info(namespace, name, chartName, version string) (*types.Chart
 
 	return helm.InfoFromTarball(chart)
 }
+
+// Verify checks the signature of chart data, as returned by Chart, against the keyring secret of
+// the repo. Charts from OCI registries are checked for cosign signatures, charts from HTTP repos
+// and bundles for a Helm provenance file, or for a cosign blob signature if the keyring only holds
+// a cosign key.
+func (c *Manager) Verify(namespace, name, chartName, version string, chartData []byte) (*v1.ChartVerificationStatus, error) {
+	index, err := c.Index(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	chart, err := index.Get(chartName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := c.getRepo(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &v1.ChartVerificationStatus{
+		Chart:   chart.Name,
+		Version: chart.Version,
+	}
+
+	keyring, err := catalogv2.GetKeyringSecret(c.secrets, repo.spec, repo.metadata.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if keyring == nil {
+		result.Message = "no keyring secret is configured for the repo"
+		return result, nil
+	}
+
+	if repo.status.Commit != "" {
+		result.Message = "signatures of charts in git repos can not be verified"
+		return result, nil
+	}
+
+	secret, err := catalogv2.GetSecret(c.secrets, repo.spec, repo.metadata.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	cosignKey := keyring.Data[verify.CosignKeyKey]
+	switch {
+	case oci.IsOCI(repo.status.URL):
+		result.Method = verify.MethodCosign
+		err = verifyCosignImage(secret, repo, chart, cosignKey, chartData)
+	case len(keyring.Data[verify.KeyringKey]) > 0:
+		result.Method = verify.MethodProvenance
+		var prov []byte
+		prov, err = c.signature(secret, repo, chart, verify.ProvenanceExt)
+		if err == nil {
+			result.SignedBy, err = verify.Provenance(keyring.Data[verify.KeyringKey], path.Base(chart.URLs[0]), chartData, prov)
+		}
+	case len(cosignKey) > 0:
+		result.Method = verify.MethodCosign
+		var sig []byte
+		sig, err = c.signature(secret, repo, chart, verify.SignatureExt)
+		if err == nil {
+			err = verify.CosignBlob(cosignKey, chartData, string(sig))
+		}
+	default:
+		err = fmt.Errorf("keyring secret has neither a %s nor a %s key", verify.KeyringKey, verify.CosignKeyKey)
+	}
+
+	if err != nil {
+		result.Message = err.Error()
+		return result, nil
+	}
+	result.Verified = true
+	return result, nil
+}
+
+// ChartVersionSignature returns the signature file stored next to the archive of a chart version,
+// such as its provenance file. Only HTTP repos and repos imported from a bundle serve them.
+func (c *Manager) ChartVersionSignature(namespace, name string, chart *repo.ChartVersion, ext string) ([]byte, error) {
+	repo, err := c.getRepo(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := catalogv2.GetSecret(c.secrets, repo.spec, repo.metadata.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return c.signature(secret, repo, chart, ext)
+}
+
+// VerificationPolicy returns the chart verification policy of the repo.
+func (c *Manager) VerificationPolicy(namespace, name string) (v1.VerificationPolicy, error) {
+	repo, err := c.getRepo(namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if repo.spec.Verification == nil || repo.spec.Verification.Policy == "" {
+		return v1.VerificationOff, nil
+	}
+	return repo.spec.Verification.Policy, nil
+}
+
+func (c *Manager) signature(secret *corev1.Secret, r repoDef, chart *repo.ChartVersion, ext string) ([]byte, error) {
+	switch {
+	case r.status.Commit != "" || oci.IsOCI(r.status.URL):
+		return nil, fmt.Errorf("failed to find %s of chartName %s version %s, only HTTP repos and bundles serve signature files: %w", ext, chart.Name, chart.Version, validation.NotFound)
+	case bundle.IsBundle(r.status.URL):
+		return bundle.Signature(c.configMaps, r.status.URL, chart, ext)
+	}
+	return helmhttp.Signature(secret, r.status.URL, r.spec.CABundle, r.spec.InsecureSkipTLSverify, chart, ext)
+}
+
+// verifyCosignImage checks the cosign signature of the manifest the chart tag resolves to, and that
+// chartData is the chart archive of that manifest, so that a tag moved after the chart was pulled
+// does not verify.
+func verifyCosignImage(secret *corev1.Secret, r repoDef, chart *repo.ChartVersion, cosignKey, chartData []byte) error {
+	if len(cosignKey) == 0 {
+		return fmt.Errorf("keyring secret has no %s key", verify.CosignKeyKey)
+	}
+
+	signed, err := oci.Signatures(secret, r.status.URL, r.spec.CABundle, r.spec.InsecureSkipTLSverify, chart)
+	if err != nil {
+		return err
+	}
+	if digest := fmt.Sprintf("sha256:%x", sha256.Sum256(chartData)); digest != signed.ChartDigest {
+		return fmt.Errorf("chart archive %s is not the chart %s of manifest %s", digest, signed.ChartDigest, signed.Digest)
+	}
+	if len(signed.Signatures) == 0 {
+		return fmt.Errorf("no cosign signature found for %s", signed.Digest)
+	}
+	for _, signature := range signed.Signatures {
+		if err = verify.CosignImage(cosignKey, signed.Digest, signature.Payload, signature.Signature); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -8,6 +8,7 @@
 	"io/ioutil"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
@@ -140,3 +141,11 @@ func decodeHelm3(data string) (*release.Release, error) {
 	}
 	return &rls, nil
 }
+
+// DecodeHelm3Secret decodes the helm 3 release stored in a release secret.
+func DecodeHelm3Secret(secret *corev1.Secret) (*release.Release, error) {
+	if !isHelm3(secret.Labels) {
+		return nil, ErrNotHelmRelease
+	}
+	return decodeHelm3(string(secret.Data["release"]))
+}
@@ -0,0 +1,296 @@
+package helmop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// DiffUpgrade renders the charts of an upgrade the way helm upgrade does and returns how the
+// resources of the releases would change. Nothing is changed in the cluster and the releases are
+// read with the credentials of the user.
+func (s *Operations) DiffUpgrade(ctx context.Context, repoNamespace, repoName string, body io.Reader) ([]types2.ResourceDiff, error) {
+	upgradeArgs := &types2.ChartUpgradeAction{}
+	if err := json.NewDecoder(body).Decode(upgradeArgs); err != nil {
+		return nil, err
+	}
+
+	client, err := s.cg.K8sInterface(types.GetAPIContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	caps, err := capabilities(client)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseNamespace := namespace(upgradeArgs.Namespace)
+	diffs := []types2.ResourceDiff{}
+	for _, chartUpgrade := range upgradeArgs.Charts {
+		if chartUpgrade.ReleaseName == "" {
+			return nil, apierror.NewAPIError(validation.InvalidBodyContent, "releaseName is required to preview an upgrade")
+		}
+
+		cmd, err := s.getChartCommand(repoNamespace, repoName, chartUpgrade.ChartName, chartUpgrade.Version, chartUpgrade.Annotations, chartUpgrade.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := currentRelease(ctx, client, releaseNamespace, chartUpgrade.ReleaseName)
+		if err != nil {
+			return nil, err
+		}
+
+		manifests, err := renderUpgrade(cmd.Chart, chartUpgrade, current, releaseNamespace, caps)
+		if err != nil {
+			return nil, err
+		}
+
+		var currentManifest string
+		if current != nil {
+			currentManifest = current.Manifest
+		}
+		releaseDiffs, err := diffManifests(chartUpgrade.ReleaseName, currentManifest, manifests)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, releaseDiffs...)
+	}
+
+	return diffs, nil
+}
+
+func capabilities(client kubernetes.Interface) (*chartutil.Capabilities, error) {
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	apiVersions, err := action.GetVersionSet(client.Discovery())
+	if err != nil {
+		return nil, err
+	}
+	return &chartutil.Capabilities{
+		KubeVersion: chartutil.KubeVersion{
+			Version: version.GitVersion,
+			Major:   version.Major,
+			Minor:   version.Minor,
+		},
+		APIVersions: apiVersions,
+		HelmVersion: chartutil.DefaultCapabilities.HelmVersion,
+	}, nil
+}
+
+// currentRelease returns the release helm upgrades from, which is the last deployed revision or,
+// if no revision was deployed successfully, the last revision.
+func currentRelease(ctx context.Context, client kubernetes.Interface, namespace, name string) (*release.Release, error) {
+	releases, err := releases(ctx, client, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var deployed, last *release.Release
+	for _, rel := range releases {
+		if last == nil || rel.Version > last.Version {
+			last = rel
+		}
+		if rel.Info != nil && rel.Info.Status == release.StatusDeployed && (deployed == nil || rel.Version > deployed.Version) {
+			deployed = rel
+		}
+	}
+	if deployed != nil {
+		return deployed, nil
+	}
+	return last, nil
+}
+
+// renderUpgrade renders the manifests of an upgrade to chartData, merging values like helm upgrade
+// does without --reuse-values: the values of the current release are only kept if no values are
+// given and resetValues is not set.
+func renderUpgrade(chartData []byte, chartUpgrade types2.ChartUpgrade, current *release.Release, namespace string, caps *chartutil.Capabilities) ([]releaseutil.Manifest, error) {
+	chart, err := loader.LoadArchive(bytes.NewReader(chartData))
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}(chartUpgrade.Values)
+	options := chartutil.ReleaseOptions{
+		Name:      chartUpgrade.ReleaseName,
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	if current != nil {
+		if !chartUpgrade.ResetValues && len(values) == 0 && len(current.Config) > 0 {
+			values = current.Config
+		}
+		options.Revision = current.Version + 1
+		options.IsInstall = false
+		options.IsUpgrade = true
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	if err := chartutil.ProcessDependencies(chart, values); err != nil {
+		return nil, err
+	}
+	renderValues, err := chartutil.ToRenderValues(chart, values, options, caps)
+	if err != nil {
+		return nil, err
+	}
+	files, err := engine.Render(chart, renderValues)
+	if err != nil {
+		return nil, err
+	}
+	for name := range files {
+		if strings.HasSuffix(name, "NOTES.txt") {
+			delete(files, name)
+		}
+	}
+
+	_, manifests, err := releaseutil.SortManifests(files, caps.APIVersions, releaseutil.InstallOrder)
+	return manifests, err
+}
+
+type resourceKey struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+}
+
+// diffManifests compares the manifest of the current release with the rendered manifests of the
+// upgrade, returning a diff per added, removed or changed resource.
+func diffManifests(releaseName, currentManifest string, manifests []releaseutil.Manifest) ([]types2.ResourceDiff, error) {
+	var currentDocs []string
+	for _, doc := range releaseutil.SplitManifests(currentManifest) {
+		currentDocs = append(currentDocs, doc)
+	}
+	current, currentKeys, err := parseResources(currentDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	var upgradeDocs []string
+	for _, manifest := range manifests {
+		upgradeDocs = append(upgradeDocs, manifest.Content)
+	}
+	upgrade, upgradeKeys, err := parseResources(upgradeDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Changed and added resources in install order, followed by the removed resources
+	keys := upgradeKeys
+	sort.Slice(currentKeys, func(i, j int) bool {
+		return fmt.Sprint(currentKeys[i]) < fmt.Sprint(currentKeys[j])
+	})
+	for _, key := range currentKeys {
+		if _, ok := upgrade[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var diffs []types2.ResourceDiff
+	for _, key := range keys {
+		before, inCurrent := current[key]
+		after, inUpgrade := upgrade[key]
+		if before == after {
+			continue
+		}
+
+		diff := types2.ResourceDiff{
+			ReleaseName: releaseName,
+			APIVersion:  key.apiVersion,
+			Kind:        key.kind,
+			Namespace:   key.namespace,
+			Name:        key.name,
+			Change:      "changed",
+		}
+		if !inCurrent {
+			diff.Change = "added"
+		} else if !inUpgrade {
+			diff.Change = "removed"
+		}
+		diff.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(before),
+			B:        difflib.SplitLines(after),
+			FromFile: "current",
+			ToFile:   "upgrade",
+			Context:  3,
+		})
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// parseResources indexes manifest documents by the resource they describe, returning the keys in
+// the order of the documents.
+func parseResources(docs []string) (map[resourceKey]string, []resourceKey, error) {
+	resources := map[resourceKey]string{}
+	var keys []resourceKey
+	for _, doc := range docs {
+		doc = normalizeManifest(doc)
+		if doc == "" {
+			continue
+		}
+
+		head := struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}{}
+		if err := yaml.Unmarshal([]byte(doc), &head); err != nil {
+			return nil, nil, err
+		}
+
+		key := resourceKey{
+			apiVersion: head.APIVersion,
+			kind:       head.Kind,
+			namespace:  head.Metadata.Namespace,
+			name:       head.Metadata.Name,
+		}
+		if _, ok := resources[key]; !ok {
+			keys = append(keys, key)
+		}
+		resources[key] = doc + "\n"
+	}
+	return resources, keys, nil
+}
+
+// normalizeManifest drops the source comments helm adds to the manifest of a release.
+func normalizeManifest(doc string) string {
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.HasPrefix(line, "# Source: ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
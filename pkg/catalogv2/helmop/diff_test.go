@@ -0,0 +1,53 @@
+package helmop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+func TestDiffManifests(t *testing.T) {
+	current := `---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: old
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+# Source: app/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: removed
+`
+	manifests := []releaseutil.Manifest{
+		{Name: "app/templates/configmap.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  key: new\n"},
+		{Name: "app/templates/service.yaml", Content: "apiVersion: v1\nkind: Service\nmetadata:\n  name: app\n"},
+		{Name: "app/templates/deployment.yaml", Content: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n"},
+	}
+
+	diffs, err := diffManifests("app", current, manifests)
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+
+	assert.Equal(t, "ConfigMap", diffs[0].Kind)
+	assert.Equal(t, "changed", diffs[0].Change)
+	assert.Contains(t, diffs[0].Diff, "-  key: old\n+  key: new\n")
+
+	assert.Equal(t, "Deployment", diffs[1].Kind)
+	assert.Equal(t, "added", diffs[1].Change)
+
+	assert.Equal(t, "Secret", diffs[2].Kind)
+	assert.Equal(t, "removed", diffs[2].Change)
+	assert.Equal(t, "app", diffs[2].ReleaseName)
+}
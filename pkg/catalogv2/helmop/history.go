@@ -0,0 +1,109 @@
+package helmop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// History returns the revisions of the release of an app, newest first, the way helm history does.
+//
+// Unlike the other actions, history is not run as an Operation. Operations run helm in a pod that
+// impersonates the user so that changes to a release are made with the rights of the user and their
+// logs are kept as a record of the change. History changes nothing and needs no chart, it only reads
+// the release secrets, which the pod would read with the same rights as the user. The secrets are
+// therefore listed directly with the credentials of the user, which is subject to the same access
+// checks, without starting a pod and leaving an Operation behind for every read. The app is only
+// used to find the name of the release.
+func (s *Operations) History(ctx context.Context, namespace, name string, options io.Reader) ([]types2.ReleaseRevision, error) {
+	app, err := s.apps.Get(namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	historyArgs := &types2.ChartHistoryAction{}
+	if err := json.NewDecoder(options).Decode(historyArgs); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	client, err := s.cg.K8sInterface(types.GetAPIContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := releases(ctx, client, app.Namespace, app.Spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	return releaseHistory(releases, historyArgs.Max), nil
+}
+
+func releaseHistory(releases []*release.Release, max int) []types2.ReleaseRevision {
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version > releases[j].Version
+	})
+	if max > 0 && len(releases) > max {
+		releases = releases[:max]
+	}
+
+	revisions := []types2.ReleaseRevision{}
+	for _, rel := range releases {
+		revision := types2.ReleaseRevision{
+			Revision: rel.Version,
+		}
+		if rel.Info != nil {
+			if !rel.Info.LastDeployed.IsZero() {
+				revision.Updated = rel.Info.LastDeployed.UTC().Format(time.RFC3339)
+			}
+			revision.Status = rel.Info.Status.String()
+			revision.Description = rel.Info.Description
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			revision.Chart = rel.Chart.Metadata.Name + "-" + rel.Chart.Metadata.Version
+			revision.AppVersion = rel.Chart.Metadata.AppVersion
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions
+}
+
+// releases returns every stored revision of the release name in namespace.
+func releases(ctx context.Context, client kubernetes.Interface, namespace, name string) ([]*release.Release, error) {
+	selector, err := labels.ValidatedSelectorFromSet(labels.Set{
+		"owner": "helm",
+		"name":  name,
+	})
+	if err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("invalid release name %s", name))
+	}
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*release.Release
+	for i := range secrets.Items {
+		rel, err := helm.DecodeHelm3Secret(&secrets.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rel)
+	}
+	return result, nil
+}
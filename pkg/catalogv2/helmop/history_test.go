@@ -0,0 +1,70 @@
+package helmop
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func releaseSecret(t *testing.T, name string, version int, status release.Status) *corev1.Secret {
+	data, err := json.Marshal(&release.Release{
+		Name:    name,
+		Version: version,
+		Info:    &release.Info{Status: status, Description: fmt.Sprintf("revision %d", version)},
+		Chart:   &chart.Chart{Metadata: &chart.Metadata{Name: "nginx", Version: fmt.Sprintf("1.0.%d", version), AppVersion: "1.21"}},
+	})
+	require.NoError(t, err)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, version),
+			Namespace: "default",
+			Labels:    map[string]string{"owner": "helm", "name": name},
+		},
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(data))},
+	}
+}
+
+func TestReleaseHistory(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		releaseSecret(t, "app", 1, release.StatusSuperseded),
+		releaseSecret(t, "app", 3, release.StatusFailed),
+		releaseSecret(t, "app", 2, release.StatusDeployed),
+		releaseSecret(t, "other", 1, release.StatusDeployed),
+	)
+
+	rels, err := releases(context.Background(), client, "default", "app")
+	require.NoError(t, err)
+	require.Len(t, rels, 3)
+
+	current, err := currentRelease(context.Background(), client, "default", "app")
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Version, "the last deployed revision is upgraded from")
+
+	history := releaseHistory(rels, 0)
+	require.Len(t, history, 3)
+	assert.Equal(t, 3, history[0].Revision)
+	assert.Equal(t, "failed", history[0].Status)
+	assert.Equal(t, "nginx-1.0.3", history[0].Chart)
+	assert.Equal(t, "1.21", history[0].AppVersion)
+	assert.Equal(t, "revision 3", history[0].Description)
+	assert.Equal(t, 1, history[2].Revision)
+
+	history = releaseHistory(rels, 2)
+	require.Len(t, history, 2)
+	assert.Equal(t, 3, history[0].Revision)
+	assert.Equal(t, 2, history[1].Revision)
+
+	rels, err = releases(context.Background(), client, "default", "missing")
+	require.NoError(t, err)
+	assert.Empty(t, releaseHistory(rels, 0))
+}
@@ -14,10 +14,12 @@
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
 	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
@@ -32,6 +34,7 @@
 	"github.com/rancher/wrangler/pkg/data/convert"
 	corev1controllers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -110,6 +113,20 @@ func (s *Operations) Uninstall(ctx context.Context, user user.Info, namespace, n
 	return s.createOperation(ctx, user, status, cmds)
 }
 
+func (s *Operations) Rollback(ctx context.Context, user user.Info, namespace, name string, options io.Reader) (*catalog.Operation, error) {
+	status, cmds, err := s.getRollbackArgs(namespace, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = s.getUser(user, namespace, name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createOperation(ctx, user, status, cmds)
+}
+
 func (s *Operations) Upgrade(ctx context.Context, user user.Info, namespace, name string, options io.Reader) (*catalog.Operation, error) {
 	status, cmds, err := s.getUpgradeCommand(namespace, name, options)
 	if err != nil {
@@ -294,6 +311,42 @@ func (s *Operations) getUninstallArgs(appNamespace, appName string, body io.Read
 	return status, Commands{cmd}, nil
 }
 
+func (s *Operations) getRollbackArgs(appNamespace, appName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
+	rel, err := s.apps.Get(appNamespace, appName, metav1.GetOptions{})
+	if err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	rollbackArgs := &types2.ChartRollbackAction{}
+	if err := json.NewDecoder(body).Decode(rollbackArgs); err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	// Without a revision helm rolls back to the previous one
+	if rollbackArgs.Revision < 0 || (rel.Spec.Version > 0 && rollbackArgs.Revision >= rel.Spec.Version) {
+		return catalog.OperationStatus{}, nil, apierror.NewAPIError(validation.InvalidBodyContent,
+			fmt.Sprintf("revision %d is not a previous revision of release %s", rollbackArgs.Revision, rel.Spec.Name))
+	}
+
+	cmd := Command{
+		Operation: "rollback",
+		ArgObjects: []interface{}{
+			rollbackArgs,
+		},
+		ReleaseName:      rel.Spec.Name,
+		ReleaseNamespace: rel.Namespace,
+		Revision:         rollbackArgs.Revision,
+	}
+
+	status := catalog.OperationStatus{
+		Action:    cmd.Operation,
+		Release:   rel.Spec.Name,
+		Namespace: appNamespace,
+	}
+
+	return status, Commands{cmd}, nil
+}
+
 func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
 	var (
 		upgradeArgs = &types2.ChartUpgradeAction{}
@@ -327,6 +380,9 @@ func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.R
 		}
 
 		status.Release = chartUpgrade.ReleaseName
+		if cmd.Verification != nil {
+			status.Verifications = append(status.Verifications, *cmd.Verification)
+		}
 		commands = append(commands, cmd)
 	}
 
@@ -342,6 +398,8 @@ type Command struct {
 	Chart            []byte
 	ReleaseName      string
 	ReleaseNamespace string
+	Revision         int
+	Verification     *catalog.ChartVerificationStatus
 }
 
 type Commands []Command
@@ -419,6 +477,7 @@ func (c Command) renderArgs() ([]string, error) {
 	delete(dataMap, "releaseName")
 	delete(dataMap, "chartName")
 	delete(dataMap, "projectId")
+	delete(dataMap, "revision")
 	if v, ok := dataMap["disableOpenAPIValidation"]; ok {
 		delete(dataMap, "disableOpenAPIValidation")
 		dataMap["disableOpenapiValidation"] = v
@@ -447,6 +506,9 @@ func (c Command) renderArgs() ([]string, error) {
 	if c.ReleaseName != "" {
 		args = append(args, c.ReleaseName)
 	}
+	if c.Revision > 0 {
+		args = append(args, strconv.Itoa(c.Revision))
+	}
 	if len(c.Chart) > 0 {
 		args = append(args, filepath.Join(helmDataPath, c.ChartFile))
 	}
@@ -545,15 +607,21 @@ func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion st
 		return Command{}, err
 	}
 
+	verification, err := s.verifyChart(namespace, name, chartName, chartVersion, chartData)
+	if err != nil {
+		return Command{}, err
+	}
+
 	chartData, err = injectAnnotation(chartData, annotations)
 	if err != nil {
 		return Command{}, err
 	}
 
 	c := Command{
-		ValuesFile: fmt.Sprintf("values-%s-%s.yaml", chartName, sanitizeVersion(chartVersion)),
-		ChartFile:  fmt.Sprintf("%s-%s.tgz", chartName, sanitizeVersion(chartVersion)),
-		Chart:      chartData,
+		ValuesFile:   fmt.Sprintf("values-%s-%s.yaml", chartName, sanitizeVersion(chartVersion)),
+		ChartFile:    fmt.Sprintf("%s-%s.tgz", chartName, sanitizeVersion(chartVersion)),
+		Chart:        chartData,
+		Verification: verification,
 	}
 
 	if len(values) > 0 {
@@ -566,6 +634,31 @@ func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion st
 	return c, nil
 }
 
+// verifyChart checks the signature of a chart according to the verification policy of its repo.
+// The enforce policy refuses charts that fail verification, the warn policy only records them.
+func (s *Operations) verifyChart(namespace, name, chartName, chartVersion string, chartData []byte) (*catalog.ChartVerificationStatus, error) {
+	repoSpec, err := s.getSpec(namespace, name, false)
+	if err != nil {
+		return nil, err
+	}
+	if repoSpec.Verification == nil || repoSpec.Verification.Policy == "" || repoSpec.Verification.Policy == catalog.VerificationOff {
+		return nil, nil
+	}
+
+	verification, err := s.contentManager.Verify(namespace, name, chartName, chartVersion, chartData)
+	if err != nil {
+		return nil, err
+	}
+	if !verification.Verified {
+		if repoSpec.Verification.Policy == catalog.VerificationEnforce {
+			return nil, apierror.NewAPIError(validation.PermissionDenied,
+				fmt.Sprintf("failed to verify chart %s version %s: %s", chartName, chartVersion, verification.Message))
+		}
+		logrus.Warnf("Failed to verify chart %s version %s from repo %s: %s", chartName, chartVersion, name, verification.Message)
+	}
+	return verification, nil
+}
+
 func (s *Operations) getInstallCommand(repoNamespace, repoName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
 	installArgs := &types2.ChartInstallAction{}
 	err := json.NewDecoder(body).Decode(installArgs)
@@ -606,6 +699,9 @@ func (s *Operations) getInstallCommand(repoNamespace, repoName string, body io.R
 		}
 
 		status.Release = chartInstall.ReleaseName
+		if cmd.Verification != nil {
+			status.Verifications = append(status.Verifications, *cmd.Verification)
+		}
 
 		cmds = append(cmds, cmd)
 	}
@@ -624,7 +720,7 @@ func namespace(ns string) string {
 }
 
 func (s *Operations) createOperation(ctx context.Context, user user.Info, status catalog.OperationStatus, cmds Commands) (*catalog.Operation, error) {
-	if status.Action != "uninstall" {
+	if status.Action == "install" || status.Action == "upgrade" {
 		_, err := s.createNamespace(ctx, status.Namespace, status.ProjectID)
 		if err != nil {
 			return nil, err
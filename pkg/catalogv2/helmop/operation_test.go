@@ -0,0 +1,59 @@
+package helmop
+
+import (
+	"strings"
+	"testing"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeApps struct {
+	catalogcontrollers.AppClient
+	app *catalog.App
+}
+
+func (f fakeApps) Get(namespace, name string, options metav1.GetOptions) (*catalog.App, error) {
+	return f.app, nil
+}
+
+func TestGetRollbackArgs(t *testing.T) {
+	s := &Operations{
+		apps: fakeApps{app: &catalog.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec:       catalog.ReleaseSpec{Name: "app", Version: 3},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		body string
+		args []string
+		err  bool
+	}{
+		{name: "previous revision", body: `{}`, args: []string{"helm", "rollback", "--namespace=default", "app"}},
+		{name: "revision", body: `{"revision":1,"wait":true}`, args: []string{"helm", "rollback", "--namespace=default", "--wait=true", "app", "1"}},
+		{name: "current revision", body: `{"revision":3}`, err: true},
+		{name: "negative revision", body: `{"revision":-1}`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, cmds, err := s.getRollbackArgs("default", "app", strings.NewReader(tt.body))
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "rollback", status.Action)
+			assert.Equal(t, "app", status.Release)
+
+			args, err := cmds.CommandArgs()
+			require.NoError(t, err)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
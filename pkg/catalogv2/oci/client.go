@@ -0,0 +1,333 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxResponseSize bounds what is read from the registry, as manifests, configs and chart archives
+// are all held in memory.
+const maxResponseSize = 20 * 1024 * 1024
+
+var (
+	challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	nextLink       = regexp.MustCompile(`<([^>]+)>;\s*rel="?next"?`)
+)
+
+// client talks to a registry implementing the OCI distribution API.
+type client struct {
+	http     *http.Client
+	host     string
+	username string
+	password string
+	// authorization holds the Authorization header per repository, as bearer tokens are scoped
+	// to the repositories they were requested for.
+	authorization map[string]string
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type manifest struct {
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+}
+
+func newClient(secret *corev1.Secret, host string, caBundle []byte, insecureSkipTLSVerify bool) (*client, error) {
+	c := &client{
+		host:          host,
+		authorization: map[string]string{},
+	}
+
+	// Only TLS secrets are handed to the HTTP client, credentials are sent in response to the
+	// challenges of the registry.
+	var tlsSecret *corev1.Secret
+	if secret != nil {
+		switch secret.Type {
+		case corev1.SecretTypeBasicAuth:
+			c.username = string(secret.Data[corev1.BasicAuthUsernameKey])
+			c.password = string(secret.Data[corev1.BasicAuthPasswordKey])
+		case corev1.SecretTypeDockerConfigJson:
+			username, password, err := dockerConfigCredentials(secret.Data[corev1.DockerConfigJsonKey], host)
+			if err != nil {
+				return nil, err
+			}
+			c.username, c.password = username, password
+		case corev1.SecretTypeTLS:
+			tlsSecret = secret
+		}
+	}
+
+	httpClient, err := helmhttp.HelmClient(tlsSecret, caBundle, insecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	c.http = httpClient
+	return c, nil
+}
+
+// dockerConfigCredentials returns the credentials for host from a .dockerconfigjson file.
+func dockerConfigCredentials(data []byte, host string) (string, string, error) {
+	config := struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", "", fmt.Errorf("failed to parse docker config: %w", err)
+	}
+
+	for server, auth := range config.Auths {
+		if u, err := url.Parse(server); err == nil && u.Host != "" {
+			server = u.Host
+		}
+		if server != host {
+			continue
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", "", fmt.Errorf("failed to decode docker config auth for %s: %w", host, err)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				return parts[0], parts[1], nil
+			}
+		}
+		return auth.Username, auth.Password, nil
+	}
+	return "", "", nil
+}
+
+// get requests path from the registry, answering an authentication challenge if needed.
+func (c *client) get(repository, path string, accept ...string) ([]byte, http.Header, error) {
+	resp, err := c.send(repository, path, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		authorization, err := c.authorize(resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, nil, err
+		}
+		c.authorization[repository] = authorization
+
+		resp, err = c.send(repository, path, accept)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, nil, fmt.Errorf("response of %s from %s exceeds %d bytes", path, c.host, maxResponseSize)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return data, resp.Header, nil
+	case http.StatusNotFound:
+		return nil, nil, fmt.Errorf("failed to find %s on %s: %w", path, c.host, validation.NotFound)
+	default:
+		return nil, nil, fmt.Errorf("failed to get %s from %s: %s", path, c.host, resp.Status)
+	}
+}
+
+func (c *client) send(repository, path string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://"+c.host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, mediaType := range accept {
+		req.Header.Add("Accept", mediaType)
+	}
+	if authorization := c.authorization[repository]; authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return c.http.Do(req)
+}
+
+// authorize returns the Authorization header answering challenge, requesting a bearer token from
+// the token service of the registry if asked to.
+func (c *client) authorize(challenge string) (string, error) {
+	parts := strings.SplitN(challenge, " ", 2)
+	params := map[string]string{}
+	if len(parts) == 2 {
+		for _, match := range challengeParam.FindAllStringSubmatch(parts[1], -1) {
+			params[match[1]] = match[2]
+		}
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "basic":
+		if c.username == "" && c.password == "" {
+			return "", fmt.Errorf("registry %s requires credentials", c.host)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.username+":"+c.password)), nil
+	case "bearer":
+		return c.token(params)
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge from registry %s: %q", c.host, challenge)
+	}
+}
+
+func (c *client) token(params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q from registry %s", params["realm"], c.host)
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		query.Set("scope", params["scope"])
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get token for registry %s: %s", c.host, resp.Status)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse token for registry %s: %w", c.host, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// list follows the pagination links of a list endpoint, collecting the items of each page.
+func (c *client) list(repository, path string, items func(data []byte) error) error {
+	for path != "" {
+		data, header, err := c.get(repository, path)
+		if err != nil {
+			return err
+		}
+		if err := items(data); err != nil {
+			return err
+		}
+		path = ""
+		if match := nextLink.FindStringSubmatch(header.Get("Link")); match != nil {
+			next, err := url.Parse(match[1])
+			if err != nil {
+				return err
+			}
+			path = next.RequestURI()
+		}
+	}
+	return nil
+}
+
+func (c *client) tags(repository string) ([]string, error) {
+	var tags []string
+	err := c.list(repository, "/v2/"+repository+"/tags/list", func(data []byte) error {
+		page := struct {
+			Tags []string `json:"tags"`
+		}{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		tags = append(tags, page.Tags...)
+		return nil
+	})
+	return tags, err
+}
+
+// repositories lists the repositories of the registry below prefix.
+func (c *client) repositories(prefix string) ([]string, error) {
+	var repositories []string
+	err := c.list("", "/v2/_catalog", func(data []byte) error {
+		page := struct {
+			Repositories []string `json:"repositories"`
+		}{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, repository := range page.Repositories {
+			if prefix == "" || strings.HasPrefix(repository, prefix+"/") {
+				repositories = append(repositories, repository)
+			}
+		}
+		return nil
+	})
+	return repositories, err
+}
+
+// manifest returns the manifest of reference and its digest. reference is either a tag or the
+// digest of the manifest, which is then checked against the content.
+func (c *client) manifest(repository, reference string) (*manifest, string, error) {
+	data, _, err := c.get(repository, "/v2/"+repository+"/manifests/"+reference, manifestMediaType)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := sha256Digest(data)
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", fmt.Errorf("manifest %s@%s has digest %s", repository, reference, digest)
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, "", fmt.Errorf("failed to parse manifest %s:%s: %w", repository, reference, err)
+	}
+	return m, digest, nil
+}
+
+// blob downloads the blob with digest, checking that the content matches it.
+func (c *client) blob(repository, digest string) ([]byte, error) {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported digest %s of blob in %s", digest, repository)
+	}
+	data, _, err := c.get(repository, "/v2/"+repository+"/blobs/"+digest)
+	if err != nil {
+		return nil, err
+	}
+	if actual := sha256Digest(data); actual != digest {
+		return nil, fmt.Errorf("blob %s@%s has digest %s", repository, digest, actual)
+	}
+	return data, nil
+}
+
+func sha256Digest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
@@ -0,0 +1,332 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	// Scheme is the URL scheme of repos served from an OCI registry, such as oci://registry.example.com/charts.
+	Scheme = "oci"
+
+	manifestMediaType         = "application/vnd.oci.image.manifest.v1+json"
+	helmConfigMediaType       = "application/vnd.cncf.helm.config.v1+json"
+	chartLayerMediaType       = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	legacyChartLayerMediaType = "application/tar+gzip"
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+const chartCacheTTL = 24 * time.Hour
+
+// chartCache holds the chart entries of manifests by digest. Manifests are immutable, so only the
+// charts pushed since the index was last built have their config downloaded.
+var chartCache = cache.NewLRUExpireCache(10000)
+
+type chartEntry struct {
+	metadata    *chart.Metadata
+	layerDigest string
+}
+
+// IsOCI returns whether repoURL points to an OCI registry.
+func IsOCI(repoURL string) bool {
+	return strings.HasPrefix(repoURL, Scheme+"://")
+}
+
+// reference is a repository or tagged artifact in a registry, parsed from oci://host/repository[:tag].
+type reference struct {
+	host       string
+	repository string
+	tag        string
+}
+
+func parseReference(ociURL string) (reference, error) {
+	u, err := url.Parse(ociURL)
+	if err != nil {
+		return reference{}, err
+	}
+	if u.Scheme != Scheme || u.Host == "" {
+		return reference{}, fmt.Errorf("invalid OCI URL %s, expected %s://<registry>/<repository>", ociURL, Scheme)
+	}
+
+	ref := reference{
+		host:       u.Host,
+		repository: strings.Trim(u.Path, "/"),
+	}
+	if i := strings.LastIndex(ref.repository, ":"); i > strings.LastIndex(ref.repository, "/") {
+		ref.repository, ref.tag = ref.repository[:i], ref.repository[i+1:]
+	}
+	return ref, nil
+}
+
+func (r reference) String() string {
+	s := Scheme + "://" + r.host + "/" + r.repository
+	if r.tag != "" {
+		s += ":" + r.tag
+	}
+	return s
+}
+
+// DownloadIndex builds an index of the charts pushed to the registry at repoURL. The URL either
+// points to a single chart repository, whose tags are the chart versions, or to a namespace whose
+// chart repositories are listed through the catalog API of the registry.
+func DownloadIndex(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool) (*repo.IndexFile, error) {
+	ref, err := parseReference(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newClient(secret, ref.host, caBundle, insecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	defer c.http.CloseIdleConnections()
+
+	logrus.Infof("Building repo index from %s", repoURL)
+
+	repositories := []string{ref.repository}
+	var tags []string
+	if ref.repository != "" {
+		tags, err = c.tags(ref.repository)
+		if err != nil && !errors.Is(err, validation.NotFound) {
+			return nil, err
+		}
+	}
+	if len(tags) == 0 {
+		tags = nil
+		repositories, err = c.repositories(ref.repository)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A repository or tag that cannot be read is left out of the index, so that it does not hide
+	// the other charts of the registry.
+	index := repo.NewIndexFile()
+	for _, repository := range repositories {
+		if tags == nil {
+			if tags, err = c.tags(repository); err != nil {
+				logrus.Errorf("failed to list tags of %s/%s: %v", ref.host, repository, err)
+				continue
+			}
+		}
+		for _, tag := range tags {
+			chartRef := reference{host: ref.host, repository: repository, tag: tag}
+			chartVersion, err := c.chartVersion(chartRef)
+			if err != nil {
+				logrus.Errorf("failed to index %s: %v", chartRef, err)
+				continue
+			}
+			if chartVersion != nil {
+				index.Entries[chartVersion.Name] = append(index.Entries[chartVersion.Name], chartVersion)
+			}
+		}
+		tags = nil
+	}
+
+	return index, nil
+}
+
+// chartVersion returns the index entry of the chart tagged ref, or nil if it is not a chart. Helm
+// replaces the + of semantic versions with _ when pushing, as + is not allowed in tags.
+func (c *client) chartVersion(ref reference) (*repo.ChartVersion, error) {
+	if _, err := semver.NewVersion(strings.ReplaceAll(ref.tag, "_", "+")); err != nil {
+		return nil, nil
+	}
+
+	m, digest, err := c.manifest(ref.repository, ref.tag)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := ref.host + "/" + ref.repository + "@" + digest
+	entry, ok := chartCache.Get(cacheKey)
+	if !ok {
+		entry, err = c.chartEntry(ref, m)
+		if err != nil {
+			return nil, err
+		}
+		chartCache.Add(cacheKey, entry, chartCacheTTL)
+	}
+
+	cached := entry.(*chartEntry)
+	if cached.metadata == nil {
+		return nil, nil
+	}
+	metadata := *cached.metadata
+	return &repo.ChartVersion{
+		Metadata: &metadata,
+		URLs:     []string{ref.String()},
+		Digest:   strings.TrimPrefix(cached.layerDigest, "sha256:"),
+	}, nil
+}
+
+// chartEntry reads the chart metadata from the config of m. The metadata is nil if m is not a chart.
+func (c *client) chartEntry(ref reference, m *manifest) (*chartEntry, error) {
+	layer, ok := chartLayer(m)
+	if !ok || m.Config.MediaType != helmConfigMediaType {
+		return &chartEntry{}, nil
+	}
+
+	config, err := c.blob(ref.repository, m.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &chart.Metadata{}
+	if err := json.Unmarshal(config, metadata); err != nil {
+		logrus.Errorf("failed to parse chart metadata of %s: %v", ref, err)
+		return &chartEntry{}, nil
+	}
+	if metadata.Name == "" || metadata.Version == "" {
+		return &chartEntry{}, nil
+	}
+	return &chartEntry{metadata: metadata, layerDigest: layer.Digest}, nil
+}
+
+func chartLayer(m *manifest) (descriptor, bool) {
+	for _, layer := range m.Layers {
+		if layer.MediaType == chartLayerMediaType || layer.MediaType == legacyChartLayerMediaType {
+			return layer, true
+		}
+	}
+	return descriptor{}, false
+}
+
+// Chart pulls the chart archive of a chart version indexed by DownloadIndex.
+func Chart(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, chart *repo.ChartVersion) (io.ReadCloser, error) {
+	ref, err := chartReference(repoURL, chart)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newClient(secret, ref.host, caBundle, insecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	defer c.http.CloseIdleConnections()
+
+	m, _, err := c.manifest(ref.repository, ref.tag)
+	if err != nil {
+		return nil, err
+	}
+	layer, ok := chartLayer(m)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a helm chart: %w", ref, validation.NotFound)
+	}
+
+	data, err := c.blob(ref.repository, layer.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewBuffer(data)), nil
+}
+
+// Signature is a cosign signature of a chart.
+type Signature struct {
+	// Payload is the signed simple signing payload referencing the chart manifest
+	Payload []byte
+	// Signature is the base64 encoded signature of the payload
+	Signature string
+}
+
+// SignedChart is the manifest the tag of a chart resolves to and the cosign signatures stored for it.
+type SignedChart struct {
+	// Digest is the digest of the chart manifest, which the signatures sign
+	Digest string
+	// ChartDigest is the digest of the chart archive layer of the manifest
+	ChartDigest string
+	Signatures  []Signature
+}
+
+// Signatures resolves the tag of a chart and returns the cosign signatures stored for the manifest
+// it resolves to. Cosign stores the signatures of an artifact as layers of the sha256-<digest>.sig
+// tag of its repository.
+func Signatures(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, chart *repo.ChartVersion) (*SignedChart, error) {
+	ref, err := chartReference(repoURL, chart)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newClient(secret, ref.host, caBundle, insecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	defer c.http.CloseIdleConnections()
+
+	chartManifest, digest, err := c.manifest(ref.repository, ref.tag)
+	if err != nil {
+		return nil, err
+	}
+	layer, ok := chartLayer(chartManifest)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a helm chart: %w", ref, validation.NotFound)
+	}
+	signed := &SignedChart{
+		Digest:      digest,
+		ChartDigest: layer.Digest,
+	}
+
+	m, _, err := c.manifest(ref.repository, strings.Replace(digest, ":", "-", 1)+".sig")
+	if err != nil {
+		return signed, err
+	}
+
+	for _, layer := range m.Layers {
+		signature, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := c.blob(ref.repository, layer.Digest)
+		if err != nil {
+			return signed, err
+		}
+		signed.Signatures = append(signed.Signatures, Signature{Payload: payload, Signature: signature})
+	}
+	return signed, nil
+}
+
+// chartReference returns the reference of a chart version indexed by DownloadIndex.
+func chartReference(repoURL string, chart *repo.ChartVersion) (reference, error) {
+	if len(chart.URLs) == 0 {
+		return reference{}, fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+
+	repoRef, err := parseReference(repoURL)
+	if err != nil {
+		return reference{}, err
+	}
+	ref, err := parseReference(chart.URLs[0])
+	if err != nil {
+		return reference{}, err
+	}
+	// The credentials of the repo must only be sent to its own registry
+	if ref.host != repoRef.host || ref.tag == "" {
+		return reference{}, fmt.Errorf("invalid chart URL %s for repo %s", chart.URLs[0], repoURL)
+	}
+	return ref, nil
+}
+
+// Icon downloads the icon of a chart. Charts in a registry can only reference icons by an
+// absolute http(s) URL, which is fetched without the credentials of the registry.
+func Icon(chart *repo.ChartVersion) (io.ReadCloser, string, error) {
+	u, err := url.Parse(chart.Icon)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, "", fmt.Errorf("failed to find icon of chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+	return helmhttp.Icon(nil, chart.Icon, nil, false, chart)
+}
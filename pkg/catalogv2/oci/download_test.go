@@ -0,0 +1,121 @@
+package oci
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// chartArchiveDigest is the digest of a chart archive the test registry does not have.
+var chartArchiveDigest = sha256Digest([]byte("original chart archive"))
+
+func newRegistry(t *testing.T) (*httptest.Server, map[string]int) {
+	fetched := map[string]int{}
+	config := `{"name":"nginx","version":"1.0.0+up1","apiVersion":"v2"}`
+	blobs := map[string]string{
+		sha256Digest([]byte(config)):          config,
+		sha256Digest([]byte("chart archive")): "chart archive",
+		// served for a digest it does not match
+		chartArchiveDigest: "tampered chart archive",
+	}
+	chartManifest, _ := json.Marshal(manifest{
+		Config: descriptor{MediaType: helmConfigMediaType, Digest: sha256Digest([]byte(config))},
+		Layers: []descriptor{{MediaType: chartLayerMediaType, Digest: sha256Digest([]byte("chart archive"))}},
+	})
+	tampered, _ := json.Marshal(manifest{
+		Config: descriptor{MediaType: helmConfigMediaType, Digest: sha256Digest([]byte(config))},
+		Layers: []descriptor{{MediaType: chartLayerMediaType, Digest: chartArchiveDigest}},
+	})
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if username, password, _ := r.BasicAuth(); username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"secret-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["charts/nginx","other/nginx"]}`))
+		case r.URL.Path == "/v2/charts/nginx/tags/list":
+			// 2.0.0 has no manifest and is left out of the index
+			w.Write([]byte(`{"tags":["1.0.0_up1","2.0.0","latest"]}`))
+		case r.URL.Path == "/v2/charts/nginx/manifests/1.0.0_up1":
+			w.Write(chartManifest)
+		case r.URL.Path == "/v2/charts/nginx/manifests/1.0.1":
+			w.Write(tampered)
+		case strings.HasPrefix(r.URL.Path, "/v2/charts/nginx/blobs/"):
+			fetched[r.URL.Path]++
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/charts/nginx/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(blob))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, fetched
+}
+
+func TestDownloadIndexAndChart(t *testing.T) {
+	server, fetched := newRegistry(t)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	secret := &corev1.Secret{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://` + u.Host + `":{"auth":"dXNlcjpwYXNz"}}}`),
+		},
+	}
+
+	for _, repoURL := range []string{"oci://" + u.Host + "/charts", "oci://" + u.Host + "/charts/nginx"} {
+		index, err := DownloadIndex(secret, repoURL, nil, true)
+		require.NoError(t, err, repoURL)
+		require.Len(t, index.Entries["nginx"], 1, repoURL)
+
+		chartVersion := index.Entries["nginx"][0]
+		assert.Equal(t, "1.0.0+up1", chartVersion.Version)
+		assert.Equal(t, []string{"oci://" + u.Host + "/charts/nginx:1.0.0_up1"}, chartVersion.URLs)
+		assert.Equal(t, strings.TrimPrefix(sha256Digest([]byte("chart archive")), "sha256:"), chartVersion.Digest)
+
+		chart, err := Chart(secret, repoURL, nil, true, chartVersion)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(chart)
+		require.NoError(t, err)
+		assert.Equal(t, "chart archive", string(data))
+	}
+	// the chart metadata is cached by manifest digest
+	assert.Equal(t, 1, fetched["/v2/charts/nginx/blobs/"+sha256Digest([]byte(`{"name":"nginx","version":"1.0.0+up1","apiVersion":"v2"}`))])
+
+	// blobs are checked against their digest
+	_, err = Chart(secret, "oci://"+u.Host+"/charts", nil, true, &repo.ChartVersion{URLs: []string{"oci://" + u.Host + "/charts/nginx:1.0.1"}})
+	assert.Error(t, err)
+
+	_, err = DownloadIndex(nil, "oci://"+u.Host+"/charts", nil, true)
+	assert.Error(t, err)
+
+	_, err = Chart(secret, "oci://"+u.Host+"/charts", nil, true, &repo.ChartVersion{URLs: []string{"oci://example.com/charts/nginx:1.0.0"}})
+	assert.Error(t, err)
+}
@@ -17,3 +17,15 @@ func GetSecret(secrets corev1controllers.SecretCache, repoSpec *v1.RepoSpec, rep
 
 	return secrets.Get(ns, repoSpec.ClientSecret.Name)
 }
+
+func GetKeyringSecret(secrets corev1controllers.SecretCache, repoSpec *v1.RepoSpec, repoNamespace string) (*corev1.Secret, error) {
+	if repoSpec.Verification == nil || repoSpec.Verification.KeyringSecret == nil {
+		return nil, nil
+	}
+	ns := repoSpec.Verification.KeyringSecret.Namespace
+	if repoNamespace != "" {
+		ns = repoNamespace
+	}
+
+	return secrets.Get(ns, repoSpec.Verification.KeyringSecret.Name)
+}
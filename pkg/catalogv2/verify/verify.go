@@ -0,0 +1,148 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"helm.sh/helm/v3/pkg/provenance"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// KeyringKey is the key of the keyring secret holding the GPG keyring for provenance files.
+	KeyringKey = "keyring"
+	// CosignKeyKey is the key of the keyring secret holding the PEM encoded cosign public key.
+	CosignKeyKey = "cosign.pub"
+
+	MethodProvenance = "provenance"
+	MethodCosign     = "cosign"
+
+	// ProvenanceExt and SignatureExt are the extensions of the Helm provenance file and the cosign
+	// blob signature stored next to a chart archive.
+	ProvenanceExt = ".prov"
+	SignatureExt  = ".sig"
+)
+
+// Provenance verifies the Helm provenance file of the chart archive named fileName against keyring
+// and returns the identity of the signer.
+func Provenance(keyring []byte, fileName string, chart, prov []byte) (string, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyring))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(keyring))
+		if err != nil {
+			return "", fmt.Errorf("failed to read keyring: %w", err)
+		}
+	}
+
+	block, _ := clearsign.Decode(prov)
+	if block == nil {
+		return "", fmt.Errorf("provenance file has no signature block")
+	}
+	signer, err := openpgp.CheckDetachedSignature(entities, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return "", fmt.Errorf("provenance signature is not valid: %w", err)
+	}
+
+	// The signed message is the chart metadata followed by the sums of the chart archives
+	parts := bytes.Split(block.Plaintext, []byte("\n...\n"))
+	if len(parts) < 2 {
+		return "", fmt.Errorf("provenance file has no file sums")
+	}
+	sums := &provenance.SumCollection{}
+	if err := yaml.Unmarshal(parts[1], sums); err != nil {
+		return "", fmt.Errorf("failed to parse provenance file sums: %w", err)
+	}
+	digest, err := provenance.Digest(bytes.NewReader(chart))
+	if err != nil {
+		return "", err
+	}
+	if sum, ok := sums.Files[fileName]; !ok {
+		return "", fmt.Errorf("provenance file has no sum for %s", fileName)
+	} else if sum != "sha256:"+digest {
+		return "", fmt.Errorf("sha256 sum does not match for %s", fileName)
+	}
+
+	return identity(signer), nil
+}
+
+func identity(entity *openpgp.Entity) string {
+	var names []string
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return entity.PrimaryKey.KeyIdString()
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// CosignBlob verifies a base64 encoded signature created with cosign sign-blob over data.
+func CosignBlob(publicKey, data []byte, signature string) error {
+	return verifySignature(publicKey, data, signature)
+}
+
+// CosignImage verifies a cosign signature of the registry artifact with the given manifest
+// digest. The signature covers the simple signing payload, which in turn references the digest.
+func CosignImage(publicKey []byte, digest string, payload []byte, signature string) error {
+	if err := verifySignature(publicKey, payload, signature); err != nil {
+		return err
+	}
+
+	simpleSigning := struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+	}{}
+	if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+		return fmt.Errorf("failed to parse signature payload: %w", err)
+	}
+	if simpleSigning.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signature is for %s, not %s", simpleSigning.Critical.Image.DockerManifestDigest, digest)
+	}
+	return nil
+}
+
+func verifySignature(publicKey, data []byte, signature string) error {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return fmt.Errorf("cosign public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse cosign public key: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	digest := sha256.Sum256(data)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return fmt.Errorf("cosign signature is not valid")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("cosign signature is not valid: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported cosign public key type %T", key)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+func TestProvenance(t *testing.T) {
+	entity, err := openpgp.NewEntity("Chart Signer", "", "signer@example.com", nil)
+	require.NoError(t, err)
+
+	keyring := &bytes.Buffer{}
+	w, err := armor.Encode(keyring, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	chart := []byte("chart archive")
+	digest, err := provenance.Digest(bytes.NewReader(chart))
+	require.NoError(t, err)
+
+	prov := &bytes.Buffer{}
+	w, err = clearsign.Encode(prov, entity.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("name: nginx\nversion: 1.0.0\n\n...\nfiles:\n  nginx-1.0.0.tgz: sha256:" + digest + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	signer, err := Provenance(keyring.Bytes(), "nginx-1.0.0.tgz", chart, prov.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "Chart Signer <signer@example.com>", signer)
+
+	_, err = Provenance(keyring.Bytes(), "nginx-1.0.0.tgz", []byte("tampered"), prov.Bytes())
+	assert.Error(t, err)
+
+	_, err = Provenance(keyring.Bytes(), "other-1.0.0.tgz", chart, prov.Bytes())
+	assert.Error(t, err)
+
+	other, err := openpgp.NewEntity("Other", "", "other@example.com", nil)
+	require.NoError(t, err)
+	otherKeyring := &bytes.Buffer{}
+	require.NoError(t, other.Serialize(otherKeyring))
+	_, err = Provenance(otherKeyring.Bytes(), "nginx-1.0.0.tgz", chart, prov.Bytes())
+	assert.Error(t, err)
+}
+
+func TestCosign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	sign := func(data []byte) string {
+		digest := sha256.Sum256(data)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	chart := []byte("chart archive")
+	assert.NoError(t, CosignBlob(publicKey, chart, sign(chart)+"\n"))
+	assert.Error(t, CosignBlob(publicKey, []byte("tampered"), sign(chart)))
+
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"registry.example.com/charts/nginx"},"image":{"docker-manifest-digest":"sha256:abc"},"type":"cosign container image signature"}}`)
+	assert.NoError(t, CosignImage(publicKey, "sha256:abc", payload, sign(payload)))
+	assert.Error(t, CosignImage(publicKey, "sha256:def", payload, sign(payload)))
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	corev1controllers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// Path is the path webhooks refreshing a ClusterRepo are sent to.
+	Path = "/v1-catalog/clusterrepos/{name}/refresh"
+
+	tokenKey = "token"
+	// maxPayload is the largest payload GitHub sends for webhooks.
+	maxPayload = 25 * 1024 * 1024
+)
+
+// handler refreshes the index of a ClusterRepo when a GitHub, GitLab or generic webhook
+// authenticated with the token of the webhook secret of the repo is received.
+//
+// GitHub requests are authenticated by the X-Hub-Signature-256 HMAC of the payload, GitLab requests
+// by the X-Gitlab-Token header and other requests by an Authorization: Bearer header.
+type handler struct {
+	clusterRepos catalogcontrollers.ClusterRepoClient
+	repoCache    catalogcontrollers.ClusterRepoCache
+	secrets      corev1controllers.SecretCache
+}
+
+func New(clusterRepos catalogcontrollers.ClusterRepoController, secrets corev1controllers.SecretCache) http.Handler {
+	return &handler{
+		clusterRepos: clusterRepos,
+		repoCache:    clusterRepos.Cache(),
+		secrets:      secrets,
+	}
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := mux.Vars(req)["name"]
+	repo, err := h.repoCache.Get(name)
+	if err != nil || repo.Spec.WebhookSecret == nil {
+		// do not reveal which repos exist
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	secret, err := h.secrets.Get(repo.Spec.WebhookSecret.Namespace, repo.Spec.WebhookSecret.Name)
+	if err != nil || len(secret.Data[tokenKey]) == 0 {
+		logrus.Errorf("Failed to get webhook secret of clusterrepo %s: %v", name, err)
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	payload, err := ioutil.ReadAll(io.LimitReader(req.Body, maxPayload))
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !authenticated(req, payload, secret.Data[tokenKey]) {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !shouldRefresh(req, payload, &repo.Spec) {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		repo, err := h.clusterRepos.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		now := metav1.NewTime(time.Now().Add(-time.Second))
+		repo.Spec.ForceUpdate = &now
+		_, err = h.clusterRepos.Update(repo)
+		return err
+	})
+	if err != nil {
+		logrus.Errorf("Failed to refresh clusterrepo %s from webhook: %v", name, err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logrus.Infof("Refreshing clusterrepo %s from webhook", name)
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func authenticated(req *http.Request, payload, token []byte) bool {
+	if signature := req.Header.Get("X-Hub-Signature-256"); signature != "" {
+		mac := hmac.New(sha256.New, token)
+		mac.Write(payload)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(signature), []byte(expected))
+	}
+	if gitlabToken := req.Header.Get("X-Gitlab-Token"); gitlabToken != "" {
+		return subtle.ConstantTimeCompare([]byte(gitlabToken), token) == 1
+	}
+	if bearer := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "); bearer != req.Header.Get("Authorization") {
+		return subtle.ConstantTimeCompare([]byte(bearer), token) == 1
+	}
+	return false
+}
+
+// shouldRefresh ignores GitHub ping events and pushes to branches other than the one the repo follows.
+func shouldRefresh(req *http.Request, payload []byte, spec *catalog.RepoSpec) bool {
+	if req.Header.Get("X-GitHub-Event") == "ping" {
+		return false
+	}
+
+	push := struct {
+		Ref string `json:"ref"`
+	}{}
+	if err := json.Unmarshal(payload, &push); err != nil || push.Ref == "" {
+		return true
+	}
+	if spec.GitRepo == "" || spec.GitBranch == "" || !strings.HasPrefix(push.Ref, "refs/heads/") {
+		return true
+	}
+	return push.Ref == "refs/heads/"+spec.GitBranch
+}
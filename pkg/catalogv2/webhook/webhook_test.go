@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticated(t *testing.T) {
+	token := []byte("secret")
+	payload := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, token)
+	mac.Write(payload)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"github", map[string]string{"X-Hub-Signature-256": signature}, true},
+		{"github wrong signature", map[string]string{"X-Hub-Signature-256": "sha256=00"}, false},
+		{"gitlab", map[string]string{"X-Gitlab-Token": "secret"}, true},
+		{"gitlab wrong token", map[string]string{"X-Gitlab-Token": "other"}, false},
+		{"bearer", map[string]string{"Authorization": "Bearer secret"}, true},
+		{"basic", map[string]string{"Authorization": "secret"}, false},
+		{"none", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, authenticated(req, payload, token))
+		})
+	}
+}
+
+func TestShouldRefresh(t *testing.T) {
+	gitSpec := &catalog.RepoSpec{GitRepo: "https://github.com/rancher/charts", GitBranch: "main"}
+	httpSpec := &catalog.RepoSpec{URL: "https://charts.example.com"}
+
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	assert.True(t, shouldRefresh(req, []byte(`{"ref":"refs/heads/main"}`), gitSpec))
+	assert.False(t, shouldRefresh(req, []byte(`{"ref":"refs/heads/dev"}`), gitSpec))
+	assert.True(t, shouldRefresh(req, []byte(`{"ref":"refs/tags/v1.0.0"}`), gitSpec))
+	assert.True(t, shouldRefresh(req, []byte(`{"ref":"refs/heads/dev"}`), httpSpec))
+	assert.True(t, shouldRefresh(req, nil, gitSpec))
+
+	req.Header.Set("X-GitHub-Event", "ping")
+	assert.False(t, shouldRefresh(req, []byte(`{"zen":"Keep it simple."}`), gitSpec))
+}
@@ -12,6 +12,7 @@
 	ClusterAuthTokenFieldNamespaceId     = "namespaceId"
 	ClusterAuthTokenFieldOwnerReferences = "ownerReferences"
 	ClusterAuthTokenFieldRemoved         = "removed"
+	ClusterAuthTokenFieldScope           = "scope"
 	ClusterAuthTokenFieldSecretKeyHash   = "hash"
 	ClusterAuthTokenFieldUUID            = "uuid"
 	ClusterAuthTokenFieldUserName        = "userName"
@@ -28,6 +29,7 @@ type ClusterAuthToken struct {
 	NamespaceId     string            `json:"namespaceId,omitempty" yaml:"namespaceId,omitempty"`
 	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
 	Removed         string            `json:"removed,omitempty" yaml:"removed,omitempty"`
+	Scope           *TokenScope       `json:"scope,omitempty" yaml:"scope,omitempty"`
 	SecretKeyHash   string            `json:"hash,omitempty" yaml:"hash,omitempty"`
 	UUID            string            `json:"uuid,omitempty" yaml:"uuid,omitempty"`
 	UserName        string            `json:"userName,omitempty" yaml:"userName,omitempty"`
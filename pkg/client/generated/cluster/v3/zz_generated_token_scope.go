@@ -0,0 +1,14 @@
+package client
+
+const (
+	TokenScopeType          = "tokenScope"
+	TokenScopeFieldClusters = "clusters"
+	TokenScopeFieldProjects = "projects"
+	TokenScopeFieldRules    = "rules"
+)
+
+type TokenScope struct {
+	Clusters []string         `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+	Projects []string         `json:"projects,omitempty" yaml:"projects,omitempty"`
+	Rules    []TokenScopeRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
@@ -0,0 +1,12 @@
+package client
+
+const (
+	GoogleChatConfigType          = "googleChatConfig"
+	GoogleChatConfigFieldProxyURL = "proxyUrl"
+	GoogleChatConfigFieldURL      = "url"
+)
+
+type GoogleChatConfig struct {
+	ProxyURL string `json:"proxyUrl,omitempty" yaml:"proxyUrl,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+}
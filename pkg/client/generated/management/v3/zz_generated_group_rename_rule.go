@@ -0,0 +1,12 @@
+package client
+
+const (
+	GroupRenameRuleType             = "groupRenameRule"
+	GroupRenameRuleFieldPattern     = "pattern"
+	GroupRenameRuleFieldReplacement = "replacement"
+)
+
+type GroupRenameRule struct {
+	Pattern     string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
@@ -0,0 +1,12 @@
+package client
+
+const (
+	NotifierMessageTemplateType       = "notifierMessageTemplate"
+	NotifierMessageTemplateFieldBody  = "body"
+	NotifierMessageTemplateFieldTitle = "title"
+)
+
+type NotifierMessageTemplate struct {
+	Body  string `json:"body,omitempty" yaml:"body,omitempty"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+}
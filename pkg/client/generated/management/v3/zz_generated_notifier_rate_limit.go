@@ -0,0 +1,14 @@
+package client
+
+const (
+	NotifierRateLimitType                    = "notifierRateLimit"
+	NotifierRateLimitFieldDedupWindowSeconds = "dedupWindowSeconds"
+	NotifierRateLimitFieldIntervalSeconds    = "intervalSeconds"
+	NotifierRateLimitFieldMaxMessages        = "maxMessages"
+)
+
+type NotifierRateLimit struct {
+	DedupWindowSeconds int64 `json:"dedupWindowSeconds,omitempty" yaml:"dedupWindowSeconds,omitempty"`
+	IntervalSeconds    int64 `json:"intervalSeconds,omitempty" yaml:"intervalSeconds,omitempty"`
+	MaxMessages        int64 `json:"maxMessages,omitempty" yaml:"maxMessages,omitempty"`
+}
@@ -13,6 +13,10 @@
 	OIDCConfigFieldCreatorID           = "creatorId"
 	OIDCConfigFieldEnabled             = "enabled"
 	OIDCConfigFieldGroupSearchEnabled  = "groupSearchEnabled"
+	OIDCConfigFieldGroupRenameRules    = "groupRenameRules"
+	OIDCConfigFieldGroupsClaim         = "groupsClaim"
+	OIDCConfigFieldGroupsExclude       = "groupsExclude"
+	OIDCConfigFieldGroupsInclude       = "groupsInclude"
 	OIDCConfigFieldIssuer              = "issuer"
 	OIDCConfigFieldLabels              = "labels"
 	OIDCConfigFieldName                = "name"
@@ -37,6 +41,10 @@ type OIDCConfig struct {
 	CreatorID           string            `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
 	Enabled             bool              `json:"enabled,omitempty" yaml:"enabled,omitempty"`
 	GroupSearchEnabled  *bool             `json:"groupSearchEnabled,omitempty" yaml:"groupSearchEnabled,omitempty"`
+	GroupRenameRules    []GroupRenameRule `json:"groupRenameRules,omitempty" yaml:"groupRenameRules,omitempty"`
+	GroupsClaim         string            `json:"groupsClaim,omitempty" yaml:"groupsClaim,omitempty"`
+	GroupsExclude       []string          `json:"groupsExclude,omitempty" yaml:"groupsExclude,omitempty"`
+	GroupsInclude       []string          `json:"groupsInclude,omitempty" yaml:"groupsInclude,omitempty"`
 	Issuer              string            `json:"issuer,omitempty" yaml:"issuer,omitempty"`
 	Labels              map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 	Name                string            `json:"name,omitempty" yaml:"name,omitempty"`
@@ -0,0 +1,18 @@
+package client
+
+const (
+	OpsgenieConfigType                  = "opsgenieConfig"
+	OpsgenieConfigFieldAPIKey           = "apiKey"
+	OpsgenieConfigFieldAPIURL           = "apiUrl"
+	OpsgenieConfigFieldDefaultRecipient = "defaultRecipient"
+	OpsgenieConfigFieldPriority         = "priority"
+	OpsgenieConfigFieldProxyURL         = "proxyUrl"
+)
+
+type OpsgenieConfig struct {
+	APIKey           string `json:"apiKey,omitempty" yaml:"apiKey,omitempty"`
+	APIURL           string `json:"apiUrl,omitempty" yaml:"apiUrl,omitempty"`
+	DefaultRecipient string `json:"defaultRecipient,omitempty" yaml:"defaultRecipient,omitempty"`
+	Priority         string `json:"priority,omitempty" yaml:"priority,omitempty"`
+	ProxyURL         string `json:"proxyUrl,omitempty" yaml:"proxyUrl,omitempty"`
+}
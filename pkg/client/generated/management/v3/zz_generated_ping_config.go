@@ -17,6 +17,8 @@
 	PingConfigFieldOwnerReferences     = "ownerReferences"
 	PingConfigFieldRancherAPIHost      = "rancherApiHost"
 	PingConfigFieldRemoved             = "removed"
+	PingConfigFieldSignAuthnRequests   = "signAuthnRequests"
+	PingConfigFieldSingleLogoutEnabled = "singleLogoutEnabled"
 	PingConfigFieldSpCert              = "spCert"
 	PingConfigFieldSpKey               = "spKey"
 	PingConfigFieldType                = "type"
@@ -41,6 +43,8 @@ type PingConfig struct {
 	OwnerReferences     []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
 	RancherAPIHost      string            `json:"rancherApiHost,omitempty" yaml:"rancherApiHost,omitempty"`
 	Removed             string            `json:"removed,omitempty" yaml:"removed,omitempty"`
+	SignAuthnRequests   bool              `json:"signAuthnRequests,omitempty" yaml:"signAuthnRequests,omitempty"`
+	SingleLogoutEnabled bool              `json:"singleLogoutEnabled,omitempty" yaml:"singleLogoutEnabled,omitempty"`
 	SpCert              string            `json:"spCert,omitempty" yaml:"spCert,omitempty"`
 	SpKey               string            `json:"spKey,omitempty" yaml:"spKey,omitempty"`
 	Type                string            `json:"type,omitempty" yaml:"type,omitempty"`
@@ -18,6 +18,8 @@
 	ShibbolethConfigFieldOwnerReferences     = "ownerReferences"
 	ShibbolethConfigFieldRancherAPIHost      = "rancherApiHost"
 	ShibbolethConfigFieldRemoved             = "removed"
+	ShibbolethConfigFieldSignAuthnRequests   = "signAuthnRequests"
+	ShibbolethConfigFieldSingleLogoutEnabled = "singleLogoutEnabled"
 	ShibbolethConfigFieldSpCert              = "spCert"
 	ShibbolethConfigFieldSpKey               = "spKey"
 	ShibbolethConfigFieldType                = "type"
@@ -43,6 +45,8 @@ type ShibbolethConfig struct {
 	OwnerReferences     []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
 	RancherAPIHost      string            `json:"rancherApiHost,omitempty" yaml:"rancherApiHost,omitempty"`
 	Removed             string            `json:"removed,omitempty" yaml:"removed,omitempty"`
+	SignAuthnRequests   bool              `json:"signAuthnRequests,omitempty" yaml:"signAuthnRequests,omitempty"`
+	SingleLogoutEnabled bool              `json:"singleLogoutEnabled,omitempty" yaml:"singleLogoutEnabled,omitempty"`
 	SpCert              string            `json:"spCert,omitempty" yaml:"spCert,omitempty"`
 	SpKey               string            `json:"spKey,omitempty" yaml:"spKey,omitempty"`
 	Type                string            `json:"type,omitempty" yaml:"type,omitempty"`
@@ -0,0 +1,16 @@
+package client
+
+const (
+	TelegramConfigType                  = "telegramConfig"
+	TelegramConfigFieldAPIURL           = "apiUrl"
+	TelegramConfigFieldDefaultRecipient = "defaultRecipient"
+	TelegramConfigFieldProxyURL         = "proxyUrl"
+	TelegramConfigFieldToken            = "token"
+)
+
+type TelegramConfig struct {
+	APIURL           string `json:"apiUrl,omitempty" yaml:"apiUrl,omitempty"`
+	DefaultRecipient string `json:"defaultRecipient,omitempty" yaml:"defaultRecipient,omitempty"`
+	ProxyURL         string `json:"proxyUrl,omitempty" yaml:"proxyUrl,omitempty"`
+	Token            string `json:"token,omitempty" yaml:"token,omitempty"`
+}
@@ -0,0 +1,10 @@
+package client
+
+const (
+	TOTPRecoveryCodesOutputType               = "totpRecoveryCodesOutput"
+	TOTPRecoveryCodesOutputFieldRecoveryCodes = "recoveryCodes"
+)
+
+type TOTPRecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recoveryCodes,omitempty" yaml:"recoveryCodes,omitempty"`
+}
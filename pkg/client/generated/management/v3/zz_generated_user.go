@@ -71,10 +71,20 @@ type UserOperations interface {
 
 	ActionRefreshauthprovideraccess(resource *User) error
 
+	ActionResettotp(resource *User) error
+
 	ActionSetpassword(resource *User, input *SetPasswordInput) (*User, error)
 
+	ActionUnlock(resource *User) error
+
+	CollectionActionActivatetotp(resource *UserCollection, input *TOTPCodeInput) (*TOTPRecoveryCodesOutput, error)
+
 	CollectionActionChangepassword(resource *UserCollection, input *ChangePasswordInput) error
 
+	CollectionActionDisabletotp(resource *UserCollection, input *TOTPCodeInput) error
+
+	CollectionActionEnrolltotp(resource *UserCollection) (*TOTPEnrollOutput, error)
+
 	CollectionActionRefreshauthprovideraccess(resource *UserCollection) error
 }
 
@@ -152,17 +162,44 @@ func (c *UserClient) ActionRefreshauthprovideraccess(resource *User) error {
 	return err
 }
 
+func (c *UserClient) ActionResettotp(resource *User) error {
+	err := c.apiClient.Ops.DoAction(UserType, "resettotp", &resource.Resource, nil, nil)
+	return err
+}
+
 func (c *UserClient) ActionSetpassword(resource *User, input *SetPasswordInput) (*User, error) {
 	resp := &User{}
 	err := c.apiClient.Ops.DoAction(UserType, "setpassword", &resource.Resource, input, resp)
 	return resp, err
 }
 
+func (c *UserClient) ActionUnlock(resource *User) error {
+	err := c.apiClient.Ops.DoAction(UserType, "unlock", &resource.Resource, nil, nil)
+	return err
+}
+
+func (c *UserClient) CollectionActionActivatetotp(resource *UserCollection, input *TOTPCodeInput) (*TOTPRecoveryCodesOutput, error) {
+	resp := &TOTPRecoveryCodesOutput{}
+	err := c.apiClient.Ops.DoCollectionAction(UserType, "activatetotp", &resource.Collection, input, resp)
+	return resp, err
+}
+
 func (c *UserClient) CollectionActionChangepassword(resource *UserCollection, input *ChangePasswordInput) error {
 	err := c.apiClient.Ops.DoCollectionAction(UserType, "changepassword", &resource.Collection, input, nil)
 	return err
 }
 
+func (c *UserClient) CollectionActionDisabletotp(resource *UserCollection, input *TOTPCodeInput) error {
+	err := c.apiClient.Ops.DoCollectionAction(UserType, "disabletotp", &resource.Collection, input, nil)
+	return err
+}
+
+func (c *UserClient) CollectionActionEnrolltotp(resource *UserCollection) (*TOTPEnrollOutput, error) {
+	resp := &TOTPEnrollOutput{}
+	err := c.apiClient.Ops.DoCollectionAction(UserType, "enrolltotp", &resource.Collection, nil, resp)
+	return resp, err
+}
+
 func (c *UserClient) CollectionActionRefreshauthprovideraccess(resource *UserCollection) error {
 	err := c.apiClient.Ops.DoCollectionAction(UserType, "refreshauthprovideraccess", &resource.Collection, nil, nil)
 	return err
@@ -1,32 +1,38 @@
 package client
 
 const (
-	UserAttributeType                 = "userAttribute"
-	UserAttributeFieldAnnotations     = "annotations"
-	UserAttributeFieldCreated         = "created"
-	UserAttributeFieldCreatorID       = "creatorId"
-	UserAttributeFieldGroupPrincipals = "groupPrincipals"
-	UserAttributeFieldLabels          = "labels"
-	UserAttributeFieldLastRefresh     = "lastRefresh"
-	UserAttributeFieldName            = "name"
-	UserAttributeFieldNeedsRefresh    = "needsRefresh"
-	UserAttributeFieldOwnerReferences = "ownerReferences"
-	UserAttributeFieldRemoved         = "removed"
-	UserAttributeFieldUUID            = "uuid"
-	UserAttributeFieldUserName        = "userName"
+	UserAttributeType                     = "userAttribute"
+	UserAttributeFieldAnnotations         = "annotations"
+	UserAttributeFieldCreated             = "created"
+	UserAttributeFieldCreatorID           = "creatorId"
+	UserAttributeFieldFailedLoginAttempts = "failedLoginAttempts"
+	UserAttributeFieldGroupPrincipals     = "groupPrincipals"
+	UserAttributeFieldLabels              = "labels"
+	UserAttributeFieldLastRefresh         = "lastRefresh"
+	UserAttributeFieldLockedUntil         = "lockedUntil"
+	UserAttributeFieldName                = "name"
+	UserAttributeFieldNeedsRefresh        = "needsRefresh"
+	UserAttributeFieldOwnerReferences     = "ownerReferences"
+	UserAttributeFieldPasswordChangedAt   = "passwordChangedAt"
+	UserAttributeFieldRemoved             = "removed"
+	UserAttributeFieldUUID                = "uuid"
+	UserAttributeFieldUserName            = "userName"
 )
 
 type UserAttribute struct {
-	Annotations     map[string]string    `json:"annotations,omitempty" yaml:"annotations,omitempty"`
-	Created         string               `json:"created,omitempty" yaml:"created,omitempty"`
-	CreatorID       string               `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
-	GroupPrincipals map[string]Principal `json:"groupPrincipals,omitempty" yaml:"groupPrincipals,omitempty"`
-	Labels          map[string]string    `json:"labels,omitempty" yaml:"labels,omitempty"`
-	LastRefresh     string               `json:"lastRefresh,omitempty" yaml:"lastRefresh,omitempty"`
-	Name            string               `json:"name,omitempty" yaml:"name,omitempty"`
-	NeedsRefresh    bool                 `json:"needsRefresh,omitempty" yaml:"needsRefresh,omitempty"`
-	OwnerReferences []OwnerReference     `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
-	Removed         string               `json:"removed,omitempty" yaml:"removed,omitempty"`
-	UUID            string               `json:"uuid,omitempty" yaml:"uuid,omitempty"`
-	UserName        string               `json:"userName,omitempty" yaml:"userName,omitempty"`
+	Annotations         map[string]string    `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Created             string               `json:"created,omitempty" yaml:"created,omitempty"`
+	CreatorID           string               `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
+	FailedLoginAttempts int64                `json:"failedLoginAttempts,omitempty" yaml:"failedLoginAttempts,omitempty"`
+	GroupPrincipals     map[string]Principal `json:"groupPrincipals,omitempty" yaml:"groupPrincipals,omitempty"`
+	Labels              map[string]string    `json:"labels,omitempty" yaml:"labels,omitempty"`
+	LastRefresh         string               `json:"lastRefresh,omitempty" yaml:"lastRefresh,omitempty"`
+	LockedUntil         string               `json:"lockedUntil,omitempty" yaml:"lockedUntil,omitempty"`
+	Name                string               `json:"name,omitempty" yaml:"name,omitempty"`
+	NeedsRefresh        bool                 `json:"needsRefresh,omitempty" yaml:"needsRefresh,omitempty"`
+	OwnerReferences     []OwnerReference     `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
+	PasswordChangedAt   string               `json:"passwordChangedAt,omitempty" yaml:"passwordChangedAt,omitempty"`
+	Removed             string               `json:"removed,omitempty" yaml:"removed,omitempty"`
+	UUID                string               `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	UserName            string               `json:"userName,omitempty" yaml:"userName,omitempty"`
 }
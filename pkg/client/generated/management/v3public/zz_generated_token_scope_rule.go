@@ -0,0 +1,12 @@
+package client
+
+const (
+	TokenScopeRuleType           = "tokenScopeRule"
+	TokenScopeRuleFieldResources = "resources"
+	TokenScopeRuleFieldVerbs     = "verbs"
+)
+
+type TokenScopeRule struct {
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Verbs     []string `json:"verbs,omitempty" yaml:"verbs,omitempty"`
+}
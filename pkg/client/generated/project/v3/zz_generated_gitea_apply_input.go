@@ -0,0 +1,20 @@
+package client
+
+const (
+	GiteaApplyInputType              = "giteaApplyInput"
+	GiteaApplyInputFieldClientID     = "clientId"
+	GiteaApplyInputFieldClientSecret = "clientSecret"
+	GiteaApplyInputFieldCode         = "code"
+	GiteaApplyInputFieldHostname     = "hostname"
+	GiteaApplyInputFieldRedirectURL  = "redirectUrl"
+	GiteaApplyInputFieldTLS          = "tls"
+)
+
+type GiteaApplyInput struct {
+	ClientID     string `json:"clientId,omitempty" yaml:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+	Code         string `json:"code,omitempty" yaml:"code,omitempty"`
+	Hostname     string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	RedirectURL  string `json:"redirectUrl,omitempty" yaml:"redirectUrl,omitempty"`
+	TLS          bool   `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
@@ -10,8 +10,10 @@
 
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/catalogv2"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/git"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/rancher/pkg/catalogv2/oci"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	namespaces "github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/wrangler/pkg/apply"
@@ -30,13 +32,16 @@
 
 var (
 	interval = 5 * time.Minute
+	// minInterval keeps a short refresh interval from downloading the index over and over.
+	minInterval = time.Minute
 )
 
 type repoHandler struct {
-	secrets      corev1controllers.SecretCache
-	clusterRepos catalogcontrollers.ClusterRepoController
-	configMaps   corev1controllers.ConfigMapClient
-	apply        apply.Apply
+	secrets        corev1controllers.SecretCache
+	clusterRepos   catalogcontrollers.ClusterRepoController
+	configMaps     corev1controllers.ConfigMapClient
+	configMapCache corev1controllers.ConfigMapCache
+	apply          apply.Apply
 }
 
 func RegisterRepos(ctx context.Context,
@@ -45,10 +50,11 @@ func RegisterRepos(ctx context.Context,
 	clusterRepos catalogcontrollers.ClusterRepoController,
 	configMap corev1controllers.ConfigMapController) {
 	h := &repoHandler{
-		secrets:      secrets,
-		clusterRepos: clusterRepos,
-		configMaps:   configMap,
-		apply:        apply.WithCacheTypes(configMap).WithStrictCaching().WithSetOwnerReference(false, false),
+		secrets:        secrets,
+		clusterRepos:   clusterRepos,
+		configMaps:     configMap,
+		configMapCache: configMap.Cache(),
+		apply:          apply.WithCacheTypes(configMap).WithStrictCaching().WithSetOwnerReference(false, false),
 	}
 
 	catalogcontrollers.RegisterClusterRepoStatusHandler(ctx, clusterRepos,
@@ -70,13 +76,13 @@ func RegisterReposForFollowers(ctx context.Context,
 }
 
 func (r *repoHandler) ClusterRepoDownloadEnsureStatusHandler(repo *catalog.ClusterRepo, status catalog.RepoStatus) (catalog.RepoStatus, error) {
-	r.clusterRepos.EnqueueAfter(repo.Name, interval)
+	r.clusterRepos.EnqueueAfter(repo.Name, refreshInterval(&repo.Spec))
 	return r.ensure(&repo.Spec, status, &repo.ObjectMeta)
 }
 
 func (r *repoHandler) ClusterRepoDownloadStatusHandler(repo *catalog.ClusterRepo, status catalog.RepoStatus) (catalog.RepoStatus, error) {
 	if !shouldRefresh(&repo.Spec, &status) {
-		r.clusterRepos.EnqueueAfter(repo.Name, interval)
+		r.clusterRepos.EnqueueAfter(repo.Name, refreshInterval(&repo.Spec))
 		return status, nil
 	}
 
@@ -216,6 +222,14 @@ func (r *repoHandler) download(repoSpec *catalog.RepoSpec, status catalog.RepoSt
 			return status, nil
 		}
 		index, err = git.BuildOrGetIndex(metadata.Namespace, metadata.Name, repoSpec.GitRepo)
+	} else if bundle.IsBundle(repoSpec.URL) {
+		status.URL = repoSpec.URL
+		status.Branch = ""
+		index, err = bundle.DownloadIndex(r.configMapCache, repoSpec.URL)
+	} else if oci.IsOCI(repoSpec.URL) {
+		status.URL = repoSpec.URL
+		status.Branch = ""
+		index, err = oci.DownloadIndex(secret, repoSpec.URL, repoSpec.CABundle, repoSpec.InsecureSkipTLSverify)
 	} else if repoSpec.URL != "" {
 		status.URL = repoSpec.URL
 		status.Branch = ""
@@ -263,6 +277,16 @@ func shouldRefresh(spec *catalog.RepoSpec, status *catalog.RepoStatus) bool {
 	if spec.ForceUpdate != nil && spec.ForceUpdate.After(status.DownloadTime.Time) && spec.ForceUpdate.Time.Before(time.Now()) {
 		return true
 	}
-	refreshTime := time.Now().Add(-interval)
+	refreshTime := time.Now().Add(-refreshInterval(spec))
 	return refreshTime.After(status.DownloadTime.Time)
 }
+
+func refreshInterval(spec *catalog.RepoSpec) time.Duration {
+	if spec.RefreshInterval <= 0 {
+		return interval
+	}
+	if d := time.Duration(spec.RefreshInterval) * time.Second; d > minInterval {
+		return d
+	}
+	return minInterval
+}
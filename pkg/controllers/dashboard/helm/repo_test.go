@@ -209,3 +209,9 @@ func TestShouldRefresh(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshInterval(t *testing.T) {
+	assert.Equal(t, interval, refreshInterval(&catalog.RepoSpec{}))
+	assert.Equal(t, minInterval, refreshInterval(&catalog.RepoSpec{RefreshInterval: 1}))
+	assert.Equal(t, 10*time.Minute, refreshInterval(&catalog.RepoSpec{RefreshInterval: 600}))
+}
@@ -4,12 +4,17 @@
 	"fmt"
 	"time"
 
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	clusterv3 "github.com/rancher/rancher/pkg/generated/norman/cluster.cattle.io/v3"
 	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func NewClusterAuthToken(token *managementv3.Token, rawTokenValue string) (*clusterv3.ClusterAuthToken, error) {
+	if err := VerifyScope(token.Scope, token.ClusterName); err != nil {
+		return nil, err
+	}
+
 	hash, err := CreateHash(rawTokenValue)
 	if err != nil {
 		return nil, err
@@ -27,6 +32,7 @@ func NewClusterAuthToken(token *managementv3.Token, rawTokenValue string) (*clus
 		SecretKeyHash: hash,
 		ExpiresAt:     token.ExpiresAt,
 		Enabled:       tokenEnabled,
+		Scope:         token.Scope.DeepCopy(),
 	}
 	return result, nil
 }
@@ -47,5 +53,44 @@ func VerifyClusterAuthToken(secretKey string, clusterAuthToken *clusterv3.Cluste
 		}
 	}
 
+	if err := verifyScope(clusterAuthToken.Scope); err != nil {
+		return err
+	}
+
 	return VerifyHash(clusterAuthToken.SecretKeyHash, secretKey)
 }
+
+// VerifyScope returns an error if a token with scope must not be synced to clusterName, either
+// because it is scoped to other clusters or because the cluster cannot enforce its scope.
+func VerifyScope(scope *v3.TokenScope, clusterName string) error {
+	if err := verifyScopeCluster(scope, clusterName); err != nil {
+		return err
+	}
+	return verifyScope(scope)
+}
+
+// verifyScopeCluster returns an error if a token scoped to other clusters is synced to clusterName.
+func verifyScopeCluster(scope *v3.TokenScope, clusterName string) error {
+	if scope == nil || len(scope.Clusters) == 0 {
+		return nil
+	}
+	for _, cluster := range scope.Clusters {
+		if cluster == "*" || cluster == clusterName {
+			return nil
+		}
+	}
+	return fmt.Errorf("token is not scoped to cluster %s", clusterName)
+}
+
+// verifyScope returns an error for scopes the cluster cannot enforce. The authorized cluster
+// endpoint only sees the token, not the request, so it cannot check projects, resources or verbs.
+// The cluster restriction is enforced by only syncing the token to clusters in its scope.
+func verifyScope(scope *v3.TokenScope) error {
+	if scope == nil {
+		return nil
+	}
+	if len(scope.Projects) > 0 || len(scope.Rules) > 0 {
+		return fmt.Errorf("token scope cannot be enforced by the cluster")
+	}
+	return nil
+}
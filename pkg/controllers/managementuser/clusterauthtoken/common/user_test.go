@@ -5,6 +5,7 @@
 	"testing"
 	"time"
 
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/stretchr/testify/assert"
 
 	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
@@ -52,3 +53,29 @@ func TestInvalidExpiresAt(t *testing.T) {
 	clusterAuthToken, _ := NewClusterAuthToken(&token, token.Token)
 	assert.NotNil(t, VerifyClusterAuthToken(token.Token, clusterAuthToken))
 }
+
+func TestScopedToken(t *testing.T) {
+	token := getToken()
+	token.ClusterName = "c-abcde"
+	token.Scope = &v3.TokenScope{Clusters: []string{"c-abcde"}}
+	clusterAuthToken, err := NewClusterAuthToken(&token, token.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, token.Scope, clusterAuthToken.Scope)
+	assert.Nil(t, VerifyClusterAuthToken(token.Token, clusterAuthToken))
+
+	token.Scope = &v3.TokenScope{Clusters: []string{"c-fghij"}}
+	_, err = NewClusterAuthToken(&token, token.Token)
+	assert.NotNil(t, err)
+
+	token.Scope = &v3.TokenScope{Rules: []v3.TokenScopeRule{{Resources: []string{"pods"}, Verbs: []string{"get"}}}}
+	_, err = NewClusterAuthToken(&token, token.Token)
+	assert.NotNil(t, err)
+
+	token.Scope = &v3.TokenScope{Projects: []string{"c-abcde:p-abcde"}}
+	_, err = NewClusterAuthToken(&token, token.Token)
+	assert.NotNil(t, err)
+
+	// Cluster auth tokens synced before the scope was checked on creation are still refused.
+	clusterAuthToken.Scope = token.Scope
+	assert.NotNil(t, VerifyClusterAuthToken(token.Token, clusterAuthToken))
+}
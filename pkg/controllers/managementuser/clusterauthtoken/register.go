@@ -50,6 +50,7 @@ func Register(ctx context.Context, cluster *config.UserContext) {
 		clusterName,
 		&tokenHandler{
 			namespace,
+			clusterName,
 			clusterAuthToken,
 			clusterAuthTokenLister,
 			clusterUserAttribute,
@@ -5,6 +5,8 @@
 	"reflect"
 	"sort"
 
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/clusterauthtoken/common"
 	clusterv3 "github.com/rancher/rancher/pkg/generated/norman/cluster.cattle.io/v3"
 	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -17,10 +19,12 @@ type tokenAttributeCompare struct {
 	username  string
 	expiresAt string
 	enabled   bool
+	scope     *v3.TokenScope
 }
 
 type tokenHandler struct {
 	namespace                  string
+	clusterName                string
 	clusterAuthToken           clusterv3.ClusterAuthTokenInterface
 	clusterAuthTokenLister     clusterv3.ClusterAuthTokenLister
 	clusterUserAttribute       clusterv3.ClusterUserAttributeInterface
@@ -52,6 +56,15 @@ func (h *tokenHandler) Updated(token *managementv3.Token) (runtime.Object, error
 		return nil, err
 	}
 
+	// Tokens whose scope changed so that the cluster can no longer enforce it are removed from the cluster.
+	if err := common.VerifyScope(token.Scope, h.clusterName); err != nil {
+		err = h.clusterAuthToken.Delete(clusterAuthToken.Name, &metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
 	err = h.updateClusterUserAttribute(token)
 	if err != nil {
 		return nil, err
@@ -62,18 +75,22 @@ func (h *tokenHandler) Updated(token *managementv3.Token) (runtime.Object, error
 		enabled:   tokenEnabled,
 		expiresAt: token.ExpiresAt,
 		username:  token.UserID,
+		scope:     token.Scope,
 	}
 	old := tokenAttributeCompare{
 		enabled:   clusterAuthToken.Enabled,
 		expiresAt: clusterAuthToken.ExpiresAt,
 		username:  clusterAuthToken.UserName,
+		scope:     clusterAuthToken.Scope,
 	}
 	if reflect.DeepEqual(current, old) {
 		return nil, nil
 	}
+	clusterAuthToken = clusterAuthToken.DeepCopy()
 	clusterAuthToken.UserName = token.UserID
 	clusterAuthToken.Enabled = tokenEnabled
 	clusterAuthToken.ExpiresAt = token.ExpiresAt
+	clusterAuthToken.Scope = token.Scope.DeepCopy()
 
 	_, err = h.clusterAuthToken.Update(clusterAuthToken)
 	if errors.IsNotFound(err) {
@@ -16,6 +16,20 @@ func GetGroupID(namespace, name string) string {
 	return fmt.Sprintf("%s:%s", namespace, name)
 }
 
+// IsSentByRancher reports whether the alerts for notifier are sent by rancher rather than by alertmanager,
+// which has no receiver for Google Chat and Telegram. DingTalk, Microsoft Teams and webhook alerts are posted
+// by alertmanager in its own format, so they are sent by rancher when the notifier has a message template.
+func IsSentByRancher(notifier *v3.Notifier) bool {
+	if notifier.Spec.GoogleChatConfig != nil || notifier.Spec.TelegramConfig != nil {
+		return true
+	}
+	tmpl := notifier.Spec.MessageTemplate
+	if tmpl == nil || (tmpl.Title == "" && tmpl.Body == "") {
+		return false
+	}
+	return notifier.Spec.DingtalkConfig != nil || notifier.Spec.MSTeamsConfig != nil || notifier.Spec.WebhookConfig != nil
+}
+
 func GetAlertManagerSecretName(appName string) string {
 	return fmt.Sprintf("alertmanager-%s", appName)
 }
@@ -379,6 +379,7 @@ func (d *ConfigSyncer) addProjectAlert2Config(config *alertconfig.Config, projec
 					}
 
 				}
+				d.limitRoute(r1, config.Route, notifiers, group.Spec.Recipients)
 				d.appendRoute(config.Route, r1)
 			}
 		}
@@ -426,6 +427,7 @@ func (d *ConfigSyncer) addClusterAlert2Config(config *alertconfig.Config, alerts
 
 			}
 
+			d.limitRoute(r1, config.Route, notifiers, group.Spec.Recipients)
 			d.appendRoute(config.Route, r1)
 		}
 	}
@@ -477,6 +479,84 @@ func (d *ConfigSyncer) appendRoute(route *alertconfig.Route, subRoute *alertconf
 	route.Routes = append(route.Routes, subRoute)
 }
 
+// limitRoute slows down the route of an alert group and its sub routes to the rate limits of the notifiers
+// alertmanager sends its alerts to. Alertmanager sends the notification of a group of alerts at most once per group interval and
+// repeats it once per repeat interval, so both are raised to spread the messages allowed by a rate limit evenly
+// over its interval, and the repeat interval to its dedup window. The routes inherit their intervals from parent
+// when not set.
+func (d *ConfigSyncer) limitRoute(route, parent *alertconfig.Route, notifiers []*v3.Notifier, recipients []v32.Recipient) {
+	var groupInterval, repeatInterval time.Duration
+	for _, r := range recipients {
+		notifier := d.getNotifier(r.NotifierName, notifiers)
+		if notifier == nil || notifier.Spec.RateLimit == nil || common.IsSentByRancher(notifier) {
+			continue
+		}
+		rateLimit := notifier.Spec.RateLimit
+		interval := time.Duration(rateLimit.IntervalSeconds) * time.Second
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		if rateLimit.MaxMessages > 0 && interval/time.Duration(rateLimit.MaxMessages) > groupInterval {
+			groupInterval = interval / time.Duration(rateLimit.MaxMessages)
+		}
+		if dedupWindow := time.Duration(rateLimit.DedupWindowSeconds) * time.Second; dedupWindow > repeatInterval {
+			repeatInterval = dedupWindow
+		}
+	}
+	if groupInterval > repeatInterval {
+		repeatInterval = groupInterval
+	}
+	if groupInterval == 0 && repeatInterval == 0 {
+		return
+	}
+	limitIntervals(route, parent.GroupInterval, parent.RepeatInterval, model.Duration(groupInterval), model.Duration(repeatInterval))
+}
+
+func limitIntervals(route *alertconfig.Route, groupInterval, repeatInterval *model.Duration, minGroupInterval, minRepeatInterval model.Duration) {
+	if route.GroupInterval != nil {
+		groupInterval = route.GroupInterval
+	}
+	if groupInterval == nil || *groupInterval < minGroupInterval {
+		gi := minGroupInterval
+		route.GroupInterval = &gi
+		groupInterval = &gi
+	}
+	if route.RepeatInterval != nil {
+		repeatInterval = route.RepeatInterval
+	}
+	if repeatInterval == nil || *repeatInterval < minRepeatInterval {
+		ri := minRepeatInterval
+		route.RepeatInterval = &ri
+		repeatInterval = &ri
+	}
+	for _, subRoute := range route.Routes {
+		limitIntervals(subRoute, groupInterval, repeatInterval, minGroupInterval, minRepeatInterval)
+	}
+}
+
+// messageTemplates translates the message template of a notifier into the alertmanager templates of the
+// title and text of its receiver, which alertmanager executes for each notification. The given title and text
+// are kept when the notifier has no template for them.
+func messageTemplates(notifier *v3.Notifier, title, text string) (string, string) {
+	tmpl := notifier.Spec.MessageTemplate
+	if tmpl == nil {
+		return title, text
+	}
+	renderedTitle, renderedText := title, text
+	var err error
+	if tmpl.Title != "" {
+		renderedTitle, err = notifierutil.AlertmanagerTemplate("title", tmpl.Title, title, text)
+	}
+	if err == nil && tmpl.Body != "" {
+		renderedText, err = notifierutil.AlertmanagerTemplate("body", tmpl.Body, title, text)
+	}
+	if err != nil {
+		logrus.Errorf("Failed to translate the message template of notifier %s:%s, %v", notifier.Namespace, notifier.Name, err)
+		return title, text
+	}
+	return renderedTitle, renderedText
+}
+
 func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertconfig.Receiver, recipients []v32.Recipient) bool {
 	receiverExist := false
 	for _, r := range recipients {
@@ -486,6 +566,9 @@ func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertco
 				logrus.Debugf("Can not find the notifier %s", r.NotifierName)
 				continue
 			}
+			if common.IsSentByRancher(notifier) {
+				continue
+			}
 			commonNotifierConfig := alertconfig.NotifierConfig{
 				VSendResolved: notifier.Spec.SendResolved,
 			}
@@ -493,8 +576,8 @@ func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertco
 				pagerduty := &alertconfig.PagerdutyConfig{
 					NotifierConfig: commonNotifierConfig,
 					ServiceKey:     alertconfig.Secret(notifier.Spec.PagerdutyConfig.ServiceKey),
-					Description:    `{{ template "rancher.title" . }}`,
 				}
+				pagerduty.Description, _ = messageTemplates(notifier, `{{ template "rancher.title" . }}`, "")
 
 				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.PagerdutyConfig.HTTPClientConfig) {
 					url, err := toAlertManagerURL(notifier.Spec.PagerdutyConfig.HTTPClientConfig.ProxyURL)
@@ -518,8 +601,8 @@ func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertco
 					APISecret:      alertconfig.Secret(notifier.Spec.WechatConfig.Secret),
 					AgentID:        notifier.Spec.WechatConfig.Agent,
 					CorpID:         notifier.Spec.WechatConfig.Corp,
-					Message:        `{{ template "wechat.text" . }}`,
 				}
+				_, wechat.Message = messageTemplates(notifier, "", `{{ template "wechat.text" . }}`)
 
 				recipient := notifier.Spec.WechatConfig.DefaultRecipient
 				if r.Recipient != "" {
@@ -596,11 +679,10 @@ func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertco
 					NotifierConfig: commonNotifierConfig,
 					APIURL:         alertconfig.Secret(notifier.Spec.SlackConfig.URL),
 					Channel:        notifier.Spec.SlackConfig.DefaultRecipient,
-					Text:           `{{ template "slack.text" . }}`,
-					Title:          `{{ template "rancher.title" . }}`,
 					TitleLink:      "",
 					Color:          `{{ if eq (index .Alerts 0).Labels.severity "critical" }}danger{{ else if eq (index .Alerts 0).Labels.severity "warning" }}warning{{ else }}good{{ end }}`,
 				}
+				slack.Title, slack.Text = messageTemplates(notifier, `{{ template "rancher.title" . }}`, `{{ template "slack.text" . }}`)
 				if r.Recipient != "" {
 					slack.Channel = r.Recipient
 				}
@@ -618,9 +700,65 @@ func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertco
 				receiver.SlackConfigs = append(receiver.SlackConfigs, slack)
 				receiverExist = true
 
+			} else if notifier.Spec.OpsgenieConfig != nil {
+				opsgenie := &alertconfig.OpsGenieConfig{
+					NotifierConfig: commonNotifierConfig,
+					APIKey:         alertconfig.Secret(notifier.Spec.OpsgenieConfig.APIKey),
+					Source:         "rancher",
+					Priority:       notifier.Spec.OpsgenieConfig.Priority,
+					Teams:          notifier.Spec.OpsgenieConfig.DefaultRecipient,
+				}
+				opsgenie.Message, opsgenie.Description = messageTemplates(notifier, `{{ template "rancher.title" . }}`, `{{ template "slack.text" . }}`)
+				if notifier.Spec.OpsgenieConfig.APIURL != "" {
+					opsgenie.APIHost = notifier.Spec.OpsgenieConfig.APIURL
+				}
+				if r.Recipient != "" {
+					opsgenie.Teams = r.Recipient
+				}
+
+				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.OpsgenieConfig.HTTPClientConfig) {
+					url, err := toAlertManagerURL(notifier.Spec.OpsgenieConfig.HTTPClientConfig.ProxyURL)
+					if err != nil {
+						logrus.Errorf("Failed to parse opsgenie proxy url %s, %v", notifier.Spec.OpsgenieConfig.HTTPClientConfig.ProxyURL, err)
+						continue
+					}
+					opsgenie.HTTPConfig = &alertconfig.HTTPClientConfig{
+						ProxyURL: *url,
+					}
+				}
+				receiver.OpsGenieConfigs = append(receiver.OpsGenieConfigs, opsgenie)
+				receiverExist = true
+
+			} else if notifier.Spec.MattermostConfig != nil {
+				// mattermost incoming webhooks accept slack compatible payloads
+				mattermost := &alertconfig.SlackConfig{
+					NotifierConfig: commonNotifierConfig,
+					APIURL:         alertconfig.Secret(notifier.Spec.MattermostConfig.URL),
+					Channel:        notifier.Spec.MattermostConfig.DefaultRecipient,
+					Color:          `{{ if eq (index .Alerts 0).Labels.severity "critical" }}danger{{ else if eq (index .Alerts 0).Labels.severity "warning" }}warning{{ else }}good{{ end }}`,
+				}
+				mattermost.Title, mattermost.Text = messageTemplates(notifier, `{{ template "rancher.title" . }}`, `{{ template "slack.text" . }}`)
+				if r.Recipient != "" {
+					mattermost.Channel = r.Recipient
+				}
+
+				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.MattermostConfig.HTTPClientConfig) {
+					url, err := toAlertManagerURL(notifier.Spec.MattermostConfig.HTTPClientConfig.ProxyURL)
+					if err != nil {
+						logrus.Errorf("Failed to parse mattermost proxy url %s, %v", notifier.Spec.MattermostConfig.HTTPClientConfig.ProxyURL, err)
+						continue
+					}
+					mattermost.HTTPConfig = &alertconfig.HTTPClientConfig{
+						ProxyURL: *url,
+					}
+				}
+				receiver.SlackConfigs = append(receiver.SlackConfigs, mattermost)
+				receiverExist = true
+
 			} else if notifier.Spec.SMTPConfig != nil {
+				subject, html := messageTemplates(notifier, `{{ template "rancher.title" . }}`, `{{ template "email.text" . }}`)
 				header := map[string]string{}
-				header["Subject"] = `{{ template "rancher.title" . }}`
+				header["Subject"] = subject
 				email := &alertconfig.EmailConfig{
 					NotifierConfig: commonNotifierConfig,
 					Smarthost:      notifier.Spec.SMTPConfig.Host + ":" + strconv.Itoa(notifier.Spec.SMTPConfig.Port),
@@ -630,7 +768,7 @@ func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertco
 					To:             notifier.Spec.SMTPConfig.DefaultRecipient,
 					Headers:        header,
 					From:           notifier.Spec.SMTPConfig.Sender,
-					HTML:           `{{ template "email.text" . }}`,
+					HTML:           html,
 				}
 				if r.Recipient != "" {
 					email.To = r.Recipient
@@ -730,6 +868,25 @@ func (d *ConfigSyncer) syncWebhookConfig(notifiers []*v3.Notifier, cAlertGroupsM
 
 	oldConfig := configSecret.Data["config.yaml"]
 
+	config := d.webhookReceiverConfig(notifiers, recipients)
+
+	newConfig, err := yaml.Marshal(config)
+	if err != nil {
+		return errors.Wrapf(err, "Marshal secrets")
+	}
+	if !bytes.Equal(oldConfig, newConfig) {
+		configSecret.Data["config.yaml"] = newConfig
+		if _, err = secretClient.Update(configSecret); err != nil {
+			return errors.Wrapf(err, "Update secret")
+		}
+	}
+
+	return nil
+}
+
+// webhookReceiverConfig returns the providers and receivers of the webhook receiver for the notifiers of
+// recipients that alertmanager cannot send to directly.
+func (d *ConfigSyncer) webhookReceiverConfig(notifiers []*v3.Notifier, recipients []v32.Recipient) WebhookReceiverConfig {
 	providers := make(map[string]*Provider)
 	receivers := make(map[string]*Receiver)
 	for _, r := range recipients {
@@ -739,6 +896,9 @@ func (d *ConfigSyncer) syncWebhookConfig(notifiers []*v3.Notifier, cAlertGroupsM
 				logrus.Debugf("Can not find the notifier %s", r.NotifierName)
 				continue
 			}
+			if common.IsSentByRancher(notifier) {
+				continue
+			}
 			if notifier.Spec.DingtalkConfig != nil {
 				provider := &Provider{
 					Type:       DingTalk,
@@ -772,21 +932,8 @@ func (d *ConfigSyncer) syncWebhookConfig(notifiers []*v3.Notifier, cAlertGroupsM
 		}
 	}
 
-	config := WebhookReceiverConfig{
+	return WebhookReceiverConfig{
 		Providers: providers,
 		Receivers: receivers,
 	}
-
-	newConfig, err := yaml.Marshal(config)
-	if err != nil {
-		return errors.Wrapf(err, "Marshal secrets")
-	}
-	if !bytes.Equal(oldConfig, newConfig) {
-		configSecret.Data["config.yaml"] = newConfig
-		if _, err = secretClient.Update(configSecret); err != nil {
-			return errors.Wrapf(err, "Update secret")
-		}
-	}
-
-	return nil
 }
@@ -8,6 +8,7 @@
 
 	"github.com/prometheus/common/model"
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	alertconfig "github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/config"
 	"github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/manager"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -128,6 +129,163 @@ func TestAddProjectAlert2Config(t *testing.T) {
 
 }
 
+func TestMessageTemplates(t *testing.T) {
+	notifier := &v3.Notifier{
+		Spec: v32.NotifierSpec{
+			SlackConfig: &v32.SlackConfig{URL: "www.slack.com"},
+		},
+	}
+
+	title, text := messageTemplates(notifier, "title", "text")
+	if title != "title" || text != "text" {
+		t.Errorf("expect the default templates without message template, actual %q, %q", title, text)
+	}
+
+	notifier.Spec.MessageTemplate = &v32.NotifierMessageTemplate{
+		Title: `{{ if eq .Labels.severity "critical" }}[CRITICAL] {{ end }}{{ .Title }}`,
+	}
+	title, text = messageTemplates(notifier, `{{ template "rancher.title" . }}`, "text")
+	if title != `{{if eq .CommonLabels.severity "critical"}}[CRITICAL] {{end}}{{ template "rancher.title" . }}` || text != "text" {
+		t.Errorf("unexpected translated title template %q and text %q", title, text)
+	}
+
+	notifier.Spec.MessageTemplate = &v32.NotifierMessageTemplate{
+		Body: "{{ .ClusterName }}/{{ .ProjectName }}: {{ .Content }}{{ .Labels.unknown }}",
+	}
+	title, text = messageTemplates(notifier, "title", `{{ template "slack.text" . }}`)
+	if title != "title" || text != `{{.CommonLabels.cluster_name}}/{{.CommonLabels.project_name}}: {{ template "slack.text" . }}{{.CommonLabels.unknown}}` {
+		t.Errorf("unexpected translated text template %q and title %q", text, title)
+	}
+
+	notifier.Spec.MessageTemplate = &v32.NotifierMessageTemplate{Body: "{{ .Unknown }}"}
+	title, text = messageTemplates(notifier, "title", "text")
+	if title != "title" || text != "text" {
+		t.Errorf("expect the default templates when the message template fails, actual %q, %q", title, text)
+	}
+}
+
+func TestLimitRoute(t *testing.T) {
+	duration := func(d time.Duration) *model.Duration {
+		md := model.Duration(d)
+		return &md
+	}
+	limited := []*v3.Notifier{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "limited", Namespace: namespace},
+			Spec: v32.NotifierSpec{
+				SlackConfig: &v32.SlackConfig{URL: "www.slack.com"},
+				RateLimit: &v32.NotifierRateLimit{
+					MaxMessages:        2,
+					IntervalSeconds:    600,
+					DedupWindowSeconds: 7200,
+				},
+			},
+		},
+	}
+	limitedRecipients := []v32.Recipient{{NotifierName: clusterName + ":limited"}}
+	configSyncer := ConfigSyncer{clusterName: clusterName}
+
+	parent := &alertconfig.Route{GroupInterval: duration(10 * time.Second), RepeatInterval: duration(time.Hour)}
+	route := &alertconfig.Route{
+		Routes: []*alertconfig.Route{
+			{},
+			{GroupInterval: duration(time.Minute), RepeatInterval: duration(3 * time.Hour)},
+			{GroupInterval: duration(time.Hour)},
+		},
+	}
+	configSyncer.limitRoute(route, parent, limited, limitedRecipients)
+
+	tests := []struct {
+		caseName       string
+		route          *alertconfig.Route
+		groupInterval  *model.Duration
+		repeatInterval *model.Duration
+	}{
+		{"group route", route, duration(5 * time.Minute), duration(2 * time.Hour)},
+		{"inherited rule route", route.Routes[0], nil, nil},
+		{"faster rule route", route.Routes[1], duration(5 * time.Minute), duration(3 * time.Hour)},
+		{"slower rule route", route.Routes[2], duration(time.Hour), nil},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.route.GroupInterval, tt.groupInterval) {
+			t.Errorf("test %s failed, expect group interval %v, actual %v", tt.caseName, tt.groupInterval, tt.route.GroupInterval)
+		}
+		if !reflect.DeepEqual(tt.route.RepeatInterval, tt.repeatInterval) {
+			t.Errorf("test %s failed, expect repeat interval %v, actual %v", tt.caseName, tt.repeatInterval, tt.route.RepeatInterval)
+		}
+	}
+
+	unlimited := &alertconfig.Route{}
+	configSyncer.limitRoute(unlimited, parent, notifiers, recipients)
+	if unlimited.GroupInterval != nil || unlimited.RepeatInterval != nil {
+		t.Errorf("expect routes of notifiers without rate limit to keep their intervals, actual %v, %v", unlimited.GroupInterval, unlimited.RepeatInterval)
+	}
+}
+
+func TestNotifiersSentByRancher(t *testing.T) {
+	rancherNotifiers := []*v3.Notifier{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "googlechat", Namespace: namespace},
+			Spec: v32.NotifierSpec{
+				GoogleChatConfig: &v32.GoogleChatConfig{
+					URL:              "https://chat.googleapis.com/v1/spaces/space/messages",
+					HTTPClientConfig: &v32.HTTPClientConfig{ProxyURL: "http://proxy:3128"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "telegram", Namespace: namespace},
+			Spec: v32.NotifierSpec{
+				TelegramConfig: &v32.TelegramConfig{
+					Token:            "token",
+					DefaultRecipient: "-100",
+					APIURL:           "https://api.telegram.org",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "templated-dingtalk", Namespace: namespace},
+			Spec: v32.NotifierSpec{
+				DingtalkConfig:  &v32.DingtalkConfig{URL: "https://oapi.dingtalk.com/robot/send?access_token=token"},
+				MessageTemplate: &v32.NotifierMessageTemplate{Body: "{{.ClusterName}}: {{.Content}}"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "templated-msteams", Namespace: namespace},
+			Spec: v32.NotifierSpec{
+				MSTeamsConfig:   &v32.MSTeamsConfig{URL: "https://outlook.office.com/webhook/id"},
+				MessageTemplate: &v32.NotifierMessageTemplate{Title: "[{{.Labels.severity}}] {{.Title}}"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "templated-webhook", Namespace: namespace},
+			Spec: v32.NotifierSpec{
+				WebhookConfig:   &v32.WebhookConfig{URL: "http://example.com/alerts"},
+				MessageTemplate: &v32.NotifierMessageTemplate{Body: "{{.Content}}"},
+			},
+		},
+	}
+	rancherRecipients := []v32.Recipient{
+		{NotifierName: clusterName + ":googlechat", NotifierType: "googlechat"},
+		{NotifierName: clusterName + ":telegram", NotifierType: "telegram", Recipient: "-200"},
+		{NotifierName: clusterName + ":templated-dingtalk", NotifierType: "dingtalk"},
+		{NotifierName: clusterName + ":templated-msteams", NotifierType: "msteams"},
+		{NotifierName: clusterName + ":templated-webhook", NotifierType: "webhook"},
+	}
+	configSyncer := ConfigSyncer{clusterName: clusterName}
+
+	// alertmanager has no receiver for these notifiers or would not apply their message templates, rancher
+	// sends their alerts itself
+	receiver := &alertconfig.Receiver{Name: groupID}
+	if configSyncer.addRecipients(rancherNotifiers, receiver, rancherRecipients) {
+		t.Errorf("expect no receiver, actual %+v", receiver)
+	}
+	config := configSyncer.webhookReceiverConfig(rancherNotifiers, rancherRecipients)
+	if len(config.Providers) != 0 || len(config.Receivers) != 0 {
+		t.Errorf("expect no webhook receiver providers and receivers, actual %v %v", config.Providers, config.Receivers)
+	}
+}
+
 var (
 	clusterName = "testCluster"
 	projectName = "testProject"
@@ -0,0 +1,173 @@
+package statesyncer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/common"
+	"github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/deployer"
+	"github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/manager"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifiers"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+	"github.com/sirupsen/logrus"
+)
+
+var alertTemplate = template.Must(template.New("alert").Parse(deployer.NotificationTmpl))
+
+// alertNotifier sends the alerts to the notifiers alertmanager does not send to, see common.IsSentByRancher.
+// An alert is sent once when it starts firing and, if the notifier sends resolved alerts, once when it is
+// resolved. The alerts sent are only kept in memory, so the alerts firing when rancher starts are sent again.
+type alertNotifier struct {
+	clusterName             string
+	notifierLister          v3.NotifierLister
+	clusterAlertGroupLister v3.ClusterAlertGroupLister
+	projectAlertGroupLister v3.ProjectAlertGroupLister
+	dialerFactory           dialer.Factory
+	sent                    map[string]*manager.APIAlert
+}
+
+// alertData is the data the notification template is executed with for a single alert.
+type alertData struct {
+	Status       string
+	Labels       map[string]string
+	Annotations  map[string]string
+	CommonLabels map[string]string
+	GroupLabels  map[string]string
+}
+
+func (n *alertNotifier) notify(ctx context.Context, apiAlerts []*manager.APIAlert) {
+	sent := map[string]*manager.APIAlert{}
+	for _, alert := range apiAlerts {
+		if _, ok := n.sent[alert.Fingerprint]; ok {
+			sent[alert.Fingerprint] = alert
+			continue
+		}
+		if alert.Status.State == manager.AlertStateActive {
+			n.send(ctx, alert, false)
+			sent[alert.Fingerprint] = alert
+		}
+	}
+	for fingerprint, alert := range n.sent {
+		if _, ok := sent[fingerprint]; !ok {
+			n.send(ctx, alert, true)
+		}
+	}
+	n.sent = sent
+}
+
+func (n *alertNotifier) send(ctx context.Context, alert *manager.APIAlert, resolved bool) {
+	labels := map[string]string{}
+	for k, v := range alert.Labels {
+		labels[string(k)] = string(v)
+	}
+	recipients, err := n.recipients(labels["group_id"], resolved)
+	if err != nil {
+		logrus.Errorf("Error occurred while getting the recipients of alert group %s, %v", labels["group_id"], err)
+		return
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	msg, err := alertMessage(alert, labels, resolved)
+	if err != nil {
+		logrus.Errorf("Error occurred while rendering alert %s, %v", labels["alert_name"], err)
+		return
+	}
+	clusterDialer, err := n.dialerFactory.ClusterDialer(n.clusterName)
+	if err != nil {
+		logrus.Errorf("Error occurred while getting the dialer of cluster %s, %v", n.clusterName, err)
+		return
+	}
+	for _, r := range recipients {
+		err = notifiers.SendMessage(ctx, r.notifier, r.recipient, msg, clusterDialer)
+		if errors.Is(err, notifiers.ErrRateLimited) {
+			logrus.Warnf("Alert %s: %v", labels["alert_name"], err)
+		} else if err != nil {
+			logrus.Errorf("Error occurred while sending alert %s to notifier %s:%s, %v", labels["alert_name"], r.notifier.Namespace, r.notifier.Name, err)
+		}
+	}
+}
+
+type recipient struct {
+	notifier  *v3.Notifier
+	recipient string
+}
+
+// recipients returns the recipients of the cluster or project alert group with groupID whose notifiers
+// are sent to by rancher and, for resolved alerts, send resolved alerts.
+func (n *alertNotifier) recipients(groupID string, resolved bool) ([]recipient, error) {
+	groupRecipients, err := n.groupRecipients(groupID)
+	if err != nil {
+		return nil, err
+	}
+	var recipients []recipient
+	for _, r := range groupRecipients {
+		notifierNamespace, notifierName := ref.Parse(r.NotifierName)
+		notifier, err := n.notifierLister.Get(notifierNamespace, notifierName)
+		if err != nil {
+			return nil, err
+		}
+		if common.IsSentByRancher(notifier) && (!resolved || notifier.Spec.SendResolved) {
+			recipients = append(recipients, recipient{notifier: notifier, recipient: r.Recipient})
+		}
+	}
+	return recipients, nil
+}
+
+func (n *alertNotifier) groupRecipients(groupID string) ([]v32.Recipient, error) {
+	namespace, name := ref.Parse(groupID)
+	if namespace == "" || name == "" {
+		return nil, nil
+	}
+	if namespace == n.clusterName {
+		group, err := n.clusterAlertGroupLister.Get(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		return group.Spec.Recipients, nil
+	}
+	group, err := n.projectAlertGroupLister.Get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return group.Spec.Recipients, nil
+}
+
+// alertMessage renders alert with the same notification template alertmanager uses for its receivers.
+func alertMessage(alert *manager.APIAlert, labels map[string]string, resolved bool) (*notifiers.Message, error) {
+	data := alertData{
+		Status:       "firing",
+		Labels:       labels,
+		Annotations:  map[string]string{},
+		CommonLabels: labels,
+		GroupLabels:  labels,
+	}
+	if resolved {
+		data.Status = "resolved"
+	}
+	for k, v := range alert.Annotations {
+		data.Annotations[string(k)] = string(v)
+	}
+
+	var title, content bytes.Buffer
+	if err := alertTemplate.ExecuteTemplate(&title, "rancher.title", data); err != nil {
+		return nil, err
+	}
+	if err := alertTemplate.ExecuteTemplate(&content, "__text_single", data); err != nil {
+		return nil, err
+	}
+	return &notifiers.Message{
+		Title:       strings.TrimSpace(title.String()),
+		Content:     strings.TrimSpace(content.String()),
+		Labels:      labels,
+		ClusterName: labels["cluster_name"],
+		ProjectName: labels["project_name"],
+	}, nil
+}
@@ -0,0 +1,107 @@
+package statesyncer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/manager"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeDialerFactory struct {
+	dialer.Factory
+}
+
+func (fakeDialerFactory) ClusterDialer(clusterName string) (dialer.Dialer, error) {
+	return nil, nil
+}
+
+func TestAlertNotifierNotify(t *testing.T) {
+	assert := assert.New(t)
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		assert.Nil(json.NewDecoder(r.Body).Decode(&body))
+		messages = append(messages, body["text"])
+	}))
+	defer server.Close()
+
+	notifiers := map[string]*v3.Notifier{
+		"googlechat": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "c-abcde", Name: "googlechat"},
+			Spec: v32.NotifierSpec{
+				SendResolved:     true,
+				GoogleChatConfig: &v32.GoogleChatConfig{URL: server.URL},
+			},
+		},
+		"slack": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "c-abcde", Name: "slack"},
+			Spec: v32.NotifierSpec{
+				SlackConfig: &v32.SlackConfig{URL: server.URL},
+			},
+		},
+	}
+	n := &alertNotifier{
+		clusterName: "c-abcde",
+		notifierLister: &fakes.NotifierListerMock{
+			GetFunc: func(namespace, name string) (*v3.Notifier, error) {
+				return notifiers[name], nil
+			},
+		},
+		clusterAlertGroupLister: &fakes.ClusterAlertGroupListerMock{
+			GetFunc: func(namespace, name string) (*v3.ClusterAlertGroup, error) {
+				return &v3.ClusterAlertGroup{
+					Spec: v32.ClusterGroupSpec{
+						Recipients: []v32.Recipient{
+							{NotifierName: "c-abcde:googlechat"},
+							{NotifierName: "c-abcde:slack"},
+						},
+					},
+				}, nil
+			},
+		},
+		dialerFactory: fakeDialerFactory{},
+	}
+
+	alert := &manager.APIAlert{
+		Alert: &model.Alert{
+			Labels: model.LabelSet{
+				"group_id":     "c-abcde:node-alert",
+				"alert_type":   "nodeHealthy",
+				"alert_name":   "Node unhealthy",
+				"severity":     "critical",
+				"cluster_name": "local",
+				"node_name":    "node1",
+			},
+		},
+		Status:      manager.AlertStatus{State: manager.AlertStateActive},
+		Fingerprint: "1",
+	}
+	content := "Alert Name: Node unhealthy\nSeverity: critical\nCluster Name: local"
+	firing := "The kubelet on the node node1 is not healthy\n" + content
+	resolved := "[Resolved]The kubelet on the node node1 is not healthy\n" + content
+
+	// firing alerts are sent once
+	n.notify(context.Background(), []*manager.APIAlert{alert})
+	n.notify(context.Background(), []*manager.APIAlert{alert})
+	assert.Equal([]string{firing}, messages)
+
+	// muted alerts are not resolved
+	muted := *alert
+	muted.Status.State = manager.AlertStateSuppressed
+	n.notify(context.Background(), []*manager.APIAlert{&muted})
+	assert.Len(messages, 1)
+
+	// only the notifiers sending resolved alerts are told when alerts go away
+	n.notify(context.Background(), nil)
+	assert.Equal([]string{firing, resolved}, messages)
+}
@@ -21,13 +21,20 @@ func StartStateSyncer(ctx context.Context, cluster *config.UserContext, manager
 		projectAlertRules: cluster.Management.Management.ProjectAlertRules(""),
 		alertManager:      manager,
 		clusterName:       cluster.ClusterName,
+		alertNotifier: &alertNotifier{
+			clusterName:             cluster.ClusterName,
+			notifierLister:          cluster.Management.Management.Notifiers(cluster.ClusterName).Controller().Lister(),
+			clusterAlertGroupLister: cluster.Management.Management.ClusterAlertGroups(cluster.ClusterName).Controller().Lister(),
+			projectAlertGroupLister: cluster.Management.Management.ProjectAlertGroups("").Controller().Lister(),
+			dialerFactory:           cluster.Management.Dialer,
+		},
 	}
 	go s.watch(ctx, 10*time.Second)
 }
 
 func (s *StateSyncer) watch(ctx context.Context, interval time.Duration) {
 	for range ticker.Context(ctx, interval) {
-		s.syncState()
+		s.syncState(ctx)
 	}
 }
 
@@ -36,10 +43,11 @@ type StateSyncer struct {
 	projectAlertRules v3.ProjectAlertRuleInterface
 	alertManager      *manager.AlertManager
 	clusterName       string
+	alertNotifier     *alertNotifier
 }
 
 //synchronize the state between alert CRD and alertmanager.
-func (s *StateSyncer) syncState() error {
+func (s *StateSyncer) syncState(ctx context.Context) error {
 
 	if s.alertManager.IsDeploy == false {
 		return nil
@@ -47,6 +55,8 @@ func (s *StateSyncer) syncState() error {
 
 	apiAlerts, err := s.alertManager.GetAlertList()
 	if err == nil {
+		s.alertNotifier.notify(ctx, apiAlerts)
+
 		clusterAlerts, err := s.clusterAlertRules.Controller().Lister().List("", labels.NewSelector())
 		if err != nil {
 			return err
@@ -486,7 +486,7 @@ func (l *Lifecycle) doNotify(obj *v3.PipelineExecution) (runtime.Object, error)
 	if err != nil {
 		return obj, err
 	}
-	clusterName, _ := ref.Parse(obj.Spec.ProjectName)
+	clusterName, projectName := ref.Parse(obj.Spec.ProjectName)
 	clusterDialer, err := l.DialerFactory.ClusterDialer(clusterName)
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting dialer")
@@ -494,11 +494,22 @@ func (l *Lifecycle) doNotify(obj *v3.PipelineExecution) (runtime.Object, error)
 	if obj.Spec.PipelineConfig.Notification.Message != "" {
 		message = obj.Spec.PipelineConfig.Notification.Message
 	}
+	labels := map[string]string{
+		"pipeline":       obj.Spec.PipelineName,
+		"run":            strconv.Itoa(obj.Spec.Run),
+		"repository":     obj.Spec.RepositoryURL,
+		"branch":         obj.Spec.Branch,
+		"event":          obj.Spec.Event,
+		"executionState": obj.Status.ExecutionState,
+	}
 	var g errgroup.Group
 	for i := range toSendRecipients {
 		toSendRecipient := toSendRecipients[i]
 		notifierMessage := &notifiers.Message{
-			Content: message,
+			Content:     message,
+			Labels:      labels,
+			ClusterName: clusterName,
+			ProjectName: projectName,
 		}
 		if toSendRecipient.Notifier.Spec.SMTPConfig != nil {
 			repoName := getRepoNameFromURL(obj.Spec.RepositoryURL)
@@ -506,7 +517,13 @@ func (l *Lifecycle) doNotify(obj *v3.PipelineExecution) (runtime.Object, error)
 			notifierMessage.Content = strings.Replace(message, "\n", "<br>\n", -1)
 		}
 		g.Go(func() error {
-			return notifiers.SendMessage(l.ctx, toSendRecipient.Notifier, toSendRecipient.Recipient, notifierMessage, clusterDialer)
+			err := notifiers.SendMessage(l.ctx, toSendRecipient.Notifier, toSendRecipient.Recipient, notifierMessage, clusterDialer)
+			if errors.Is(err, notifiers.ErrRateLimited) {
+				// retrying would resend to the other recipients, so only report the dropped message
+				logrus.Warnf("Pipeline execution %s: %v", obj.Name, err)
+				return nil
+			}
+			return err
 		})
 	}
 	return obj, g.Wait()
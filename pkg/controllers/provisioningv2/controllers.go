@@ -7,9 +7,11 @@
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetcluster"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetworkspace"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/managedchart"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/autoscaler"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/bootstrap"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/dynamicschema"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machinedrain"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machinehealth"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machinenodelookup"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machineorphan"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machineprovision"
@@ -43,6 +45,7 @@ func Register(ctx context.Context, clients *wrangler.Context) error {
 		if features.MCM.Enabled() {
 			dynamicschema.Register(ctx, clients)
 			machineprovision.Register(ctx, clients)
+			autoscaler.Register(ctx, clients)
 		}
 		rkecluster.Register(ctx, clients)
 		provisioningcluster.Register(ctx, clients)
@@ -52,6 +55,7 @@ func Register(ctx context.Context, clients *wrangler.Context) error {
 		planner.Register(ctx, clients, rkePlanner)
 		planstatus.Register(ctx, clients)
 		machinestatus.Register(ctx, clients)
+		machinehealth.Register(ctx, clients)
 		unmanaged.Register(ctx, clients)
 		rkecontrolplane.Register(ctx, clients)
 		managesystemagent.Register(ctx, clients)
@@ -0,0 +1,342 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/provisioningcluster"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/provisioningv2/kubeconfig"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const (
+	workloadKubeconfigPath   = "/etc/cluster-autoscaler/workload/value"
+	managementKubeconfigPath = "/etc/cluster-autoscaler/management/value"
+
+	serviceAccountCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type handler struct {
+	clusterCache       rocontrollers.ClusterCache
+	machineDeployments capicontrollers.MachineDeploymentController
+	kubeconfigManager  *kubeconfig.Manager
+}
+
+func Register(ctx context.Context, clients *wrangler.Context) {
+	h := &handler{
+		clusterCache:       clients.Provisioning.Cluster().Cache(),
+		machineDeployments: clients.CAPI.MachineDeployment(),
+		kubeconfigManager:  kubeconfig.New(clients),
+	}
+	rocontrollers.RegisterClusterGeneratingHandler(ctx, clients.Provisioning.Cluster(),
+		clients.Apply.
+			WithCacheTypes(clients.Core.Secret(),
+				clients.Core.ServiceAccount(),
+				clients.RBAC.Role(),
+				clients.RBAC.RoleBinding(),
+				clients.Apps.Deployment(),
+				clients.Mgmt.ClusterRoleTemplateBinding()),
+		"", "cluster-autoscaler", h.OnChange, nil)
+
+	clients.CAPI.MachineDeployment().OnChange(ctx, "autoscaler-replicas", h.OnMachineDeploymentChange)
+	relatedresource.Watch(ctx, "autoscaler-replicas-trigger", func(namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+		cluster, ok := obj.(*rancherv1.Cluster)
+		if !ok || cluster.Spec.RKEConfig == nil {
+			return nil, nil
+		}
+		var result []relatedresource.Key
+		for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+			if provisioningcluster.AutoscalingEnabled(machinePool) {
+				result = append(result, relatedresource.Key{
+					Namespace: namespace,
+					Name:      name.SafeConcatName(cluster.Name, machinePool.Name),
+				})
+			}
+		}
+		return result, nil
+	}, clients.CAPI.MachineDeployment(), clients.Provisioning.Cluster())
+}
+
+func autoscaled(cluster *rancherv1.Cluster) bool {
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if provisioningcluster.AutoscalingEnabled(machinePool) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnChange runs a cluster autoscaler in the namespace of the cluster for as long as any of its machine pools is
+// autoscaled. The autoscaler watches the workload cluster for pending pods and scales the machine deployments of the
+// cluster in the management cluster, where it is only allowed to touch the CAPI objects of that namespace. In the
+// workload cluster it authenticates as a user of its own that is only bound to the cluster-autoscaler role.
+func (h *handler) OnChange(cluster *rancherv1.Cluster, status rancherv1.ClusterStatus) ([]runtime.Object, rancherv1.ClusterStatus, error) {
+	if cluster.Spec.RKEConfig == nil || status.ClusterName == "" || !autoscaled(cluster) {
+		return nil, status, nil
+	}
+
+	autoscalerName := name.SafeConcatName(cluster.Name, "cluster-autoscaler")
+
+	managementKubeconfig, err := managementKubeconfigSecret(cluster, autoscalerName)
+	if err != nil {
+		return nil, status, err
+	}
+
+	workloadKubeconfig, err := h.kubeconfigManager.GetAutoscalerKubeConfig(cluster, status)
+	if err != nil {
+		return nil, status, err
+	}
+
+	crtb, err := h.kubeconfigManager.GetCRTBForAutoscaler(cluster, status)
+	if err != nil {
+		return nil, status, err
+	}
+
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      autoscalerName,
+			Namespace: cluster.Namespace,
+		},
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      autoscalerName,
+			Namespace: cluster.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:     []string{"get", "list", "watch", "update", "patch"},
+				APIGroups: []string{"cluster.x-k8s.io"},
+				Resources: []string{
+					"machinedeployments",
+					"machinedeployments/scale",
+					"machinesets",
+					"machinesets/scale",
+					"machines",
+				},
+			},
+			{
+				Verbs:     []string{"get", "list", "watch"},
+				APIGroups: []string{"cluster.x-k8s.io"},
+				Resources: []string{"clusters"},
+			},
+			{
+				Verbs:     []string{"get", "list", "watch"},
+				APIGroups: []string{"rke-machine.cattle.io"},
+				Resources: []string{"*"},
+			},
+		},
+	}
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      autoscalerName,
+			Namespace: cluster.Namespace,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      autoscalerName,
+				Namespace: cluster.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     autoscalerName,
+		},
+	}
+
+	return []runtime.Object{
+		sa,
+		role,
+		rb,
+		managementKubeconfig,
+		workloadKubeconfig,
+		crtb,
+		deployment(cluster, autoscalerName, workloadKubeconfig.Name),
+	}, status, nil
+}
+
+// OnMachineDeploymentChange sizes the machine deployment of an autoscaled pool. The size is not applied with the rest
+// of the machine deployment, so that applying the cluster never undoes a scale up or down of the autoscaler, and is
+// only set here when the machine deployment is created or out of the bounds of its pool.
+func (h *handler) OnMachineDeploymentChange(key string, machineDeployment *capi.MachineDeployment) (*capi.MachineDeployment, error) {
+	if machineDeployment == nil || machineDeployment.DeletionTimestamp != nil {
+		return machineDeployment, nil
+	}
+
+	cluster, err := h.clusterCache.Get(machineDeployment.Namespace, machineDeployment.Spec.ClusterName)
+	if apierror.IsNotFound(err) {
+		return machineDeployment, nil
+	} else if err != nil {
+		return machineDeployment, err
+	}
+	if cluster.Spec.RKEConfig == nil {
+		return machineDeployment, nil
+	}
+
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if name.SafeConcatName(cluster.Name, machinePool.Name) != machineDeployment.Name ||
+			!provisioningcluster.AutoscalingEnabled(machinePool) {
+			continue
+		}
+		replicas := autoscalerReplicas(machinePool, machineDeployment)
+		if machineDeployment.Spec.Replicas != nil && *machineDeployment.Spec.Replicas == replicas {
+			return machineDeployment, nil
+		}
+		machineDeployment = machineDeployment.DeepCopy()
+		machineDeployment.Spec.Replicas = &replicas
+		return h.machineDeployments.Update(machineDeployment)
+	}
+
+	return machineDeployment, nil
+}
+
+// autoscalerReplicas returns the size of the machine deployment of an autoscaled pool. A machine deployment that was
+// not changed since it was created starts at the quantity of the pool, after that whatever size the autoscaler last
+// picked is kept. The size always stays within the bounds of the pool.
+func autoscalerReplicas(machinePool rancherv1.RKEMachinePool, existing *capi.MachineDeployment) int32 {
+	replicas := *machinePool.AutoscalingMinSize
+	if existing != nil && existing.Generation > 1 && existing.Spec.Replicas != nil {
+		replicas = *existing.Spec.Replicas
+	} else if machinePool.Quantity != nil {
+		replicas = *machinePool.Quantity
+	}
+
+	if replicas < *machinePool.AutoscalingMinSize {
+		replicas = *machinePool.AutoscalingMinSize
+	} else if replicas > *machinePool.AutoscalingMaxSize {
+		replicas = *machinePool.AutoscalingMaxSize
+	}
+	return replicas
+}
+
+// managementKubeconfigSecret builds the kubeconfig the autoscaler uses for the management cluster. It authenticates
+// with the token of the autoscaler service account, so the autoscaler only gets the rights of its role.
+func managementKubeconfigSecret(cluster *rancherv1.Cluster, autoscalerName string) (*corev1.Secret, error) {
+	data, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"cluster": {
+				Server:               "https://kubernetes.default.svc",
+				CertificateAuthority: serviceAccountCAPath,
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"user": {
+				TokenFile: serviceAccountTokenPath,
+			},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default": {
+				Cluster:   "cluster",
+				AuthInfo:  "user",
+				Namespace: cluster.Namespace,
+			},
+		},
+		CurrentContext: "default",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.SafeConcatName(autoscalerName, "management", "kubeconfig"),
+			Namespace: cluster.Namespace,
+		},
+		Data: map[string][]byte{
+			"value": data,
+		},
+	}, nil
+}
+
+func deployment(cluster *rancherv1.Cluster, autoscalerName, workloadKubeconfigName string) *appsv1.Deployment {
+	labels := map[string]string{
+		"app.kubernetes.io/name":     "cluster-autoscaler",
+		"app.kubernetes.io/instance": autoscalerName,
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      autoscalerName,
+			Namespace: cluster.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &[]int32{1}[0],
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{
+							Name: "workload-kubeconfig",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName:  workloadKubeconfigName,
+									DefaultMode: &[]int32{0400}[0],
+								},
+							},
+						},
+						{
+							Name: "management-kubeconfig",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName:  name.SafeConcatName(autoscalerName, "management", "kubeconfig"),
+									DefaultMode: &[]int32{0400}[0],
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "cluster-autoscaler",
+							Image: settings.PrefixPrivateRegistry(settings.ClusterAutoscalerImage.Get()),
+							Command: []string{
+								"/cluster-autoscaler",
+							},
+							Args: []string{
+								"--cloud-provider=clusterapi",
+								"--kubeconfig=" + workloadKubeconfigPath,
+								"--cloud-config=" + managementKubeconfigPath,
+								fmt.Sprintf("--node-group-auto-discovery=clusterapi:namespace=%s,clusterName=%s", cluster.Namespace, cluster.Name),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "workload-kubeconfig",
+									ReadOnly:  true,
+									MountPath: "/etc/cluster-autoscaler/workload",
+								},
+								{
+									Name:      "management-kubeconfig",
+									ReadOnly:  true,
+									MountPath: "/etc/cluster-autoscaler/management",
+								},
+							},
+						},
+					},
+					ServiceAccountName: autoscalerName,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package autoscaler
+
+import (
+	"testing"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestAutoscalerReplicas(t *testing.T) {
+	machineDeployment := func(generation int64, replicas *int32) *capi.MachineDeployment {
+		return &capi.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: generation},
+			Spec:       capi.MachineDeploymentSpec{Replicas: replicas},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		quantity *int32
+		min, max int32
+		existing *capi.MachineDeployment
+		replicas int32
+	}{
+		{name: "nil existing starts at quantity", quantity: int32Ptr(2), min: 1, max: 5, replicas: 2},
+		{name: "nil existing without quantity starts at min", min: 1, max: 5, replicas: 1},
+		{name: "scaled down to min is kept", quantity: int32Ptr(2), min: 1, max: 5, existing: machineDeployment(3, int32Ptr(1)), replicas: 1},
+		{name: "quantity below min", quantity: int32Ptr(1), min: 2, max: 5, replicas: 2},
+		{name: "quantity above max", quantity: int32Ptr(8), min: 2, max: 5, replicas: 5},
+		{name: "new machine deployment starts at quantity", quantity: int32Ptr(3), min: 1, max: 5, existing: machineDeployment(1, int32Ptr(1)), replicas: 3},
+		{name: "scaled machine deployment is kept", quantity: int32Ptr(3), min: 1, max: 5, existing: machineDeployment(4, int32Ptr(4)), replicas: 4},
+		{name: "scaled machine deployment above max", quantity: int32Ptr(3), min: 1, max: 5, existing: machineDeployment(4, int32Ptr(7)), replicas: 5},
+		{name: "scaled machine deployment below min", quantity: int32Ptr(3), min: 2, max: 5, existing: machineDeployment(4, int32Ptr(1)), replicas: 2},
+		{name: "existing without replicas", quantity: int32Ptr(3), min: 1, max: 5, existing: machineDeployment(2, nil), replicas: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machinePool := rancherv1.RKEMachinePool{
+				Quantity:           tt.quantity,
+				AutoscalingMinSize: int32Ptr(tt.min),
+				AutoscalingMaxSize: int32Ptr(tt.max),
+			}
+			assert.Equal(t, tt.replicas, autoscalerReplicas(machinePool, tt.existing))
+		})
+	}
+}
+
+func TestDeploymentWorkloadKubeconfig(t *testing.T) {
+	cluster := &rancherv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default"},
+	}
+	d := deployment(cluster, "c1-cluster-autoscaler", "c1-autoscaler-kubeconfig")
+
+	secrets := map[string]string{}
+	for _, volume := range d.Spec.Template.Spec.Volumes {
+		secrets[volume.Name] = volume.Secret.SecretName
+	}
+	assert.Equal(t, "c1-autoscaler-kubeconfig", secrets["workload-kubeconfig"])
+	assert.NotEqual(t, "c1-kubeconfig", secrets["workload-kubeconfig"])
+}
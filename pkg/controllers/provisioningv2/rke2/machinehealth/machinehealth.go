@@ -0,0 +1,290 @@
+package machinehealth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/provisioningcluster"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/provisioningv2/rke2/planner"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+const (
+	// etcdQuorumSkipRemediation is the value of the skip remediation annotation when it was set by this handler, so
+	// that an annotation set by a user is never removed.
+	etcdQuorumSkipRemediation = "etcd-quorum"
+
+	// probeGracePeriod is how much longer than the plan failure timeout the probes of a machine may be unhealthy
+	// before it is replaced, as the probes fail for a while whenever the plan restarts the services they check.
+	probeGracePeriod = 5 * time.Minute
+
+	// planFailurePrefix starts the failure message of the machines marked as failed by this handler, so that a
+	// failure set by another controller is never cleared.
+	planFailurePrefix = "plan has not been applied"
+)
+
+type handler struct {
+	machines         capicontrollers.MachineController
+	machineCache     capicontrollers.MachineCache
+	provClusterCache provisioningcontrollers.ClusterCache
+}
+
+func Register(ctx context.Context, clients *wrangler.Context) {
+	h := handler{
+		machines:         clients.CAPI.Machine(),
+		machineCache:     clients.CAPI.Machine().Cache(),
+		provClusterCache: clients.Provisioning.Cluster().Cache(),
+	}
+	clients.CAPI.Machine().OnChange(ctx, "machine-health", h.OnChange)
+
+	relatedresource.Watch(ctx, "machine-health-etcd", func(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+		machine, ok := obj.(*capi.Machine)
+		if !ok || !isEtcd(machine) {
+			return nil, nil
+		}
+		// the health of one etcd member decides whether the others may be remediated
+		members, err := h.etcdMachines(machine.Namespace, machine.Spec.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+		var result []relatedresource.Key
+		for _, member := range members {
+			if member.Name == machine.Name {
+				continue
+			}
+			result = append(result, relatedresource.Key{
+				Namespace: member.Namespace,
+				Name:      member.Name,
+			})
+		}
+		return result, nil
+	}, clients.CAPI.Machine(), clients.CAPI.Machine())
+}
+
+func isEtcd(machine *capi.Machine) bool {
+	return machine.Labels[planner.EtcdRoleLabel] == "true"
+}
+
+func (h *handler) etcdMachines(namespace, clusterName string) ([]*capi.Machine, error) {
+	machines, err := h.machineCache.List(namespace, labels.SelectorFromSet(map[string]string{
+		capi.ClusterLabelName: clusterName,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*capi.Machine
+	for _, machine := range machines {
+		if isEtcd(machine) {
+			result = append(result, machine)
+		}
+	}
+	return result, nil
+}
+
+func (h *handler) OnChange(key string, machine *capi.Machine) (*capi.Machine, error) {
+	if machine == nil ||
+		machine.DeletionTimestamp != nil ||
+		machine.Spec.Bootstrap.ConfigRef == nil ||
+		machine.Spec.Bootstrap.ConfigRef.Kind != "RKEBootstrap" {
+		return machine, nil
+	}
+
+	if isEtcd(machine) {
+		var err error
+		machine, err = h.guardEtcdQuorum(machine)
+		if err != nil {
+			return machine, err
+		}
+	}
+
+	return h.checkPlanFailure(machine)
+}
+
+// guardEtcdQuorum keeps an etcd machine from being remediated while any other etcd member is unhealthy or being
+// removed, as deleting it then would leave etcd without quorum.
+func (h *handler) guardEtcdQuorum(machine *capi.Machine) (*capi.Machine, error) {
+	members, err := h.etcdMachines(machine.Namespace, machine.Spec.ClusterName)
+	if err != nil {
+		return machine, err
+	}
+
+	safe := etcdQuorumSafe(machine, members)
+	value, skipping := machine.Annotations[capi.MachineSkipRemediationAnnotation]
+	if safe == !skipping || (skipping && value != etcdQuorumSkipRemediation) {
+		return machine, nil
+	}
+
+	machine = machine.DeepCopy()
+	if safe {
+		delete(machine.Annotations, capi.MachineSkipRemediationAnnotation)
+	} else {
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+		machine.Annotations[capi.MachineSkipRemediationAnnotation] = etcdQuorumSkipRemediation
+	}
+	return h.machines.Update(machine)
+}
+
+// etcdQuorumSafe returns whether etcd keeps its quorum when machine is remediated, which requires enough members
+// and every other member to be healthy.
+func etcdQuorumSafe(machine *capi.Machine, members []*capi.Machine) bool {
+	if len(members) < provisioningcluster.MinEtcdForRemediation {
+		return false
+	}
+	for _, member := range members {
+		if member.Name == machine.Name {
+			continue
+		}
+		if member.DeletionTimestamp != nil ||
+			member.Status.NodeRef == nil ||
+			!memberHealthy(member) {
+			return false
+		}
+	}
+	return true
+}
+
+// memberHealthy returns whether an etcd member is healthy. The MachineHealthCheck only reports on the pools that
+// have an unhealthy node timeout, so the health of the node and the plan of the member are checked as well.
+func memberHealthy(member *capi.Machine) bool {
+	if machineCondition(member, capi.MachineHealthCheckSuccededCondition) == corev1.ConditionFalse {
+		return false
+	}
+	if status := machineCondition(member, capi.MachineNodeHealthyCondition); status == corev1.ConditionFalse ||
+		status == corev1.ConditionUnknown {
+		return false
+	}
+	if cond := provisionedCondition(member); cond != nil && cond.Status != corev1.ConditionTrue {
+		if _, failing := planFailureTimeout(cond.Reason, 0); failing {
+			return false
+		}
+	}
+	return true
+}
+
+func machineCondition(machine *capi.Machine, conditionType capi.ConditionType) corev1.ConditionStatus {
+	for _, cond := range machine.Status.Conditions {
+		if cond.Type == conditionType {
+			return cond.Status
+		}
+	}
+	return ""
+}
+
+func (h *handler) machinePool(machine *capi.Machine) (*rancherv1.Cluster, *rancherv1.RKEMachinePool, error) {
+	cluster, err := h.provClusterCache.Get(machine.Namespace, machine.Spec.ClusterName)
+	if apierror.IsNotFound(err) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if cluster.Spec.RKEConfig == nil {
+		return nil, nil, nil
+	}
+
+	for i, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if name.SafeConcatName(cluster.Name, machinePool.Name) == machine.Labels[capi.MachineDeploymentLabelName] {
+			return cluster, &cluster.Spec.RKEConfig.MachinePools[i], nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// checkPlanFailure marks a machine as failed once it could not apply its plan for longer than the pool allows, which
+// makes the pool's MachineHealthCheck replace it. The failure is cleared when the plan is applied before the machine
+// is replaced, or when the machine must not be remediated to keep etcd quorum.
+func (h *handler) checkPlanFailure(machine *capi.Machine) (*capi.Machine, error) {
+	if machine.Status.NodeRef == nil {
+		return machine, nil
+	}
+	if machine.Annotations[capi.MachineSkipRemediationAnnotation] == etcdQuorumSkipRemediation {
+		return h.clearPlanFailure(machine)
+	}
+
+	cluster, machinePool, err := h.machinePool(machine)
+	if err != nil || machinePool == nil || machinePool.PlanFailureTimeout == nil ||
+		!provisioningcluster.RemediationEnabled(cluster, *machinePool) {
+		return machine, err
+	}
+
+	cond := provisionedCondition(machine)
+	if cond == nil {
+		return machine, nil
+	}
+	if cond.Status == corev1.ConditionTrue {
+		return h.clearPlanFailure(machine)
+	}
+	timeout, failing := planFailureTimeout(cond.Reason, machinePool.PlanFailureTimeout.Duration)
+	if !failing || machine.Status.FailureReason != nil {
+		return machine, nil
+	}
+
+	failingFor := time.Since(cond.LastTransitionTime.Time)
+	if failingFor < timeout {
+		h.machines.EnqueueAfter(machine.Namespace, machine.Name, timeout-failingFor)
+		return machine, nil
+	}
+
+	machine = machine.DeepCopy()
+	reason := capierrors.UpdateMachineError
+	message := fmt.Sprintf("%s for %s: %s", planFailurePrefix, timeout, cond.Message)
+	machine.Status.FailureReason = &reason
+	machine.Status.FailureMessage = &message
+	return h.machines.UpdateStatus(machine)
+}
+
+// planFailureTimeout returns how long the plan of a machine may fail for the reason of its Provisioned condition
+// before the machine is replaced, and whether the reason is a failure at all. Waiting on a plan is not a failure as
+// the plans of the machines of a cluster are applied one after the other.
+func planFailureTimeout(reason string, timeout time.Duration) (time.Duration, bool) {
+	switch reason {
+	case planner.ErrorStatus:
+		return timeout, true
+	case planner.UnHealthyProbes:
+		return timeout + probeGracePeriod, true
+	default:
+		return 0, false
+	}
+}
+
+func provisionedCondition(machine *capi.Machine) *capi.Condition {
+	for i, cond := range machine.Status.Conditions {
+		if string(cond.Type) == string(planner.Provisioned) {
+			return &machine.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func isPlanFailure(machine *capi.Machine) bool {
+	return machine.Status.FailureReason != nil && *machine.Status.FailureReason == capierrors.UpdateMachineError &&
+		machine.Status.FailureMessage != nil && strings.HasPrefix(*machine.Status.FailureMessage, planFailurePrefix)
+}
+
+// clearPlanFailure removes the failure set by checkPlanFailure from a machine.
+func (h *handler) clearPlanFailure(machine *capi.Machine) (*capi.Machine, error) {
+	if !isPlanFailure(machine) {
+		return machine, nil
+	}
+	machine = machine.DeepCopy()
+	machine.Status.FailureReason = nil
+	machine.Status.FailureMessage = nil
+	return h.machines.UpdateStatus(machine)
+}
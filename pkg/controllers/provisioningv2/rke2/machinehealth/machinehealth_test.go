@@ -0,0 +1,147 @@
+package machinehealth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher/rancher/pkg/provisioningv2/rke2/planner"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+func TestPlanFailureTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  string
+		timeout time.Duration
+		failing bool
+	}{
+		{name: "error", reason: planner.ErrorStatus, timeout: 10 * time.Minute, failing: true},
+		{name: "unhealthy probes", reason: planner.UnHealthyProbes, timeout: 10*time.Minute + probeGracePeriod, failing: true},
+		{name: "waiting", reason: planner.WaitingPlanStatus},
+		{name: "no agent", reason: planner.NoAgentPlanStatus},
+		{name: "in sync", reason: planner.InSyncPlanStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, failing := planFailureTimeout(tt.reason, 10*time.Minute)
+			assert.Equal(t, tt.failing, failing)
+			assert.Equal(t, tt.timeout, timeout)
+		})
+	}
+}
+
+func TestEtcdQuorumSafe(t *testing.T) {
+	member := func(name string, mutate func(*capi.Machine)) *capi.Machine {
+		machine := &capi.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: capi.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Name: name},
+				Conditions: capi.Conditions{
+					{Type: capi.MachineHealthCheckSuccededCondition, Status: corev1.ConditionTrue},
+				},
+			},
+		}
+		if mutate != nil {
+			mutate(machine)
+		}
+		return machine
+	}
+	unhealthy := func(machine *capi.Machine) {
+		machine.Status.Conditions[0].Status = corev1.ConditionFalse
+	}
+
+	tests := []struct {
+		name    string
+		members []*capi.Machine
+		safe    bool
+	}{
+		{
+			name:    "healthy members",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", nil), member("etcd-2", nil)},
+			safe:    true,
+		},
+		{
+			name:    "remediated machine itself unhealthy",
+			members: []*capi.Machine{member("etcd-0", unhealthy), member("etcd-1", nil), member("etcd-2", nil)},
+			safe:    true,
+		},
+		{
+			name:    "too few members",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", nil)},
+		},
+		{
+			name:    "other member unhealthy",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", unhealthy), member("etcd-2", nil)},
+		},
+		{
+			name: "other member without health check and node not ready",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", func(machine *capi.Machine) {
+				machine.Status.Conditions = capi.Conditions{
+					{Type: capi.MachineNodeHealthyCondition, Status: corev1.ConditionFalse},
+				}
+			}), member("etcd-2", nil)},
+		},
+		{
+			name: "other member without health check and node unreachable",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", func(machine *capi.Machine) {
+				machine.Status.Conditions = capi.Conditions{
+					{Type: capi.MachineNodeHealthyCondition, Status: corev1.ConditionUnknown},
+				}
+			}), member("etcd-2", nil)},
+		},
+		{
+			name: "other member without health check and failing plan",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", nil), member("etcd-2", func(machine *capi.Machine) {
+				machine.Status.Conditions = capi.Conditions{
+					{Type: capi.ConditionType(planner.Provisioned), Status: corev1.ConditionFalse, Reason: planner.UnHealthyProbes},
+				}
+			})},
+		},
+		{
+			name: "other member waiting on its plan",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", nil), member("etcd-2", func(machine *capi.Machine) {
+				machine.Status.Conditions = append(machine.Status.Conditions,
+					capi.Condition{Type: capi.ConditionType(planner.Provisioned), Status: corev1.ConditionFalse, Reason: planner.WaitingPlanStatus},
+					capi.Condition{Type: capi.MachineNodeHealthyCondition, Status: corev1.ConditionTrue})
+			})},
+			safe: true,
+		},
+		{
+			name: "other member deleting",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", func(machine *capi.Machine) {
+				machine.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			}), member("etcd-2", nil)},
+		},
+		{
+			name: "other member without node",
+			members: []*capi.Machine{member("etcd-0", nil), member("etcd-1", nil), member("etcd-2", func(machine *capi.Machine) {
+				machine.Status.NodeRef = nil
+			})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.safe, etcdQuorumSafe(tt.members[0], tt.members))
+		})
+	}
+}
+
+func TestIsPlanFailure(t *testing.T) {
+	failure := func(reason capierrors.MachineStatusError, message string) *capi.Machine {
+		return &capi.Machine{
+			Status: capi.MachineStatus{
+				FailureReason:  &reason,
+				FailureMessage: &message,
+			},
+		}
+	}
+
+	assert.True(t, isPlanFailure(failure(capierrors.UpdateMachineError, planFailurePrefix+" for 10m0s: error")))
+	assert.False(t, isPlanFailure(failure(capierrors.UpdateMachineError, "failed to update the machine")))
+	assert.False(t, isPlanFailure(failure(capierrors.CreateMachineError, planFailurePrefix+" for 10m0s: error")))
+	assert.False(t, isPlanFailure(&capi.Machine{}))
+}
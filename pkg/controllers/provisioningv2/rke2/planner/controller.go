@@ -26,6 +26,7 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *planner.P
 	}
 	v1.RegisterRKEControlPlaneStatusHandler(ctx,
 		clients.RKE.RKEControlPlane(), "", "planner", h.OnChange)
+	// plan and machine changes also refresh the report of a control plane in dry run
 	relatedresource.Watch(ctx, "planner", func(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
 		if secret, ok := obj.(*corev1.Secret); ok {
 			clusterName := secret.Labels[bootstrap.ClusterNameLabel]
@@ -35,6 +36,15 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *planner.P
 					Name:      clusterName,
 				}}, nil
 			}
+			// the cluster state secret
+			for _, owner := range secret.OwnerReferences {
+				if owner.Kind == "RKEControlPlane" {
+					return []relatedresource.Key{{
+						Namespace: secret.Namespace,
+						Name:      owner.Name,
+					}}, nil
+				}
+			}
 		} else if machine, ok := obj.(*capi.Machine); ok {
 			return []relatedresource.Key{{
 				Namespace: machine.Namespace,
@@ -48,6 +58,16 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *planner.P
 func (h *handler) OnChange(cluster *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus) (rkev1.RKEControlPlaneStatus, error) {
 	status.ObservedGeneration = cluster.Generation
 
+	if cluster.Spec.DryRun {
+		report, err := h.planner.DryRun(cluster)
+		if err != nil {
+			return status, err
+		}
+		status.DryRun = report
+		return status, nil
+	}
+	status.DryRun = nil
+
 	err := h.planner.Process(cluster)
 	var errWaiting planner.ErrWaiting
 	if errors.As(err, &errWaiting) {
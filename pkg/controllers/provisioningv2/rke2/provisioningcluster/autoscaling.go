@@ -0,0 +1,45 @@
+package provisioningcluster
+
+import (
+	"fmt"
+	"strconv"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+)
+
+const (
+	autoscalerMinSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	autoscalerMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+)
+
+// AutoscalingEnabled returns whether the size of the pool is managed by the cluster autoscaler.
+func AutoscalingEnabled(machinePool rancherv1.RKEMachinePool) bool {
+	return machinePool.AutoscalingMinSize != nil && machinePool.AutoscalingMaxSize != nil
+}
+
+func validateAutoscaling(machinePool rancherv1.RKEMachinePool) error {
+	if !AutoscalingEnabled(machinePool) {
+		return nil
+	}
+	if machinePool.EtcdRole || machinePool.ControlPlaneRole {
+		return fmt.Errorf("autoscaling is only supported for worker machinePools, machinePool [%s] has the etcd or control-plane role", machinePool.Name)
+	}
+	if *machinePool.AutoscalingMinSize < 1 || *machinePool.AutoscalingMinSize > *machinePool.AutoscalingMaxSize {
+		return fmt.Errorf("invalid autoscaling size for machinePool [%s], must satisfy 1 <= autoscalingMinSize <= autoscalingMaxSize", machinePool.Name)
+	}
+	return nil
+}
+
+func autoscalerAnnotations(machinePool rancherv1.RKEMachinePool) map[string]string {
+	if !AutoscalingEnabled(machinePool) {
+		return machinePool.MachineDeploymentAnnotations
+	}
+
+	result := map[string]string{}
+	for k, v := range machinePool.MachineDeploymentAnnotations {
+		result[k] = v
+	}
+	result[autoscalerMinSizeAnnotation] = strconv.Itoa(int(*machinePool.AutoscalingMinSize))
+	result[autoscalerMaxSizeAnnotation] = strconv.Itoa(int(*machinePool.AutoscalingMaxSize))
+	return result
+}
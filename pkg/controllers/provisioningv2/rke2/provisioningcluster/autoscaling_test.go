@@ -0,0 +1,76 @@
+package provisioningcluster
+
+import (
+	"testing"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAutoscaling(t *testing.T) {
+	size := func(i int32) *int32 {
+		return &i
+	}
+
+	tests := []struct {
+		name        string
+		machinePool rancherv1.RKEMachinePool
+		valid       bool
+	}{
+		{
+			name:        "not autoscaled",
+			machinePool: rancherv1.RKEMachinePool{EtcdRole: true, Quantity: size(3)},
+			valid:       true,
+		},
+		{
+			name:        "only min size set",
+			machinePool: rancherv1.RKEMachinePool{EtcdRole: true, AutoscalingMinSize: size(-1)},
+			valid:       true,
+		},
+		{
+			name:        "worker pool",
+			machinePool: rancherv1.RKEMachinePool{WorkerRole: true, AutoscalingMinSize: size(1), AutoscalingMaxSize: size(3)},
+			valid:       true,
+		},
+		{
+			name:        "min equals max",
+			machinePool: rancherv1.RKEMachinePool{WorkerRole: true, AutoscalingMinSize: size(2), AutoscalingMaxSize: size(2)},
+			valid:       true,
+		},
+		{
+			name:        "quantity out of bounds",
+			machinePool: rancherv1.RKEMachinePool{WorkerRole: true, Quantity: size(5), AutoscalingMinSize: size(1), AutoscalingMaxSize: size(3)},
+			valid:       true,
+		},
+		{
+			name:        "min 0",
+			machinePool: rancherv1.RKEMachinePool{WorkerRole: true, AutoscalingMinSize: size(0), AutoscalingMaxSize: size(3)},
+		},
+		{
+			name:        "negative min",
+			machinePool: rancherv1.RKEMachinePool{WorkerRole: true, AutoscalingMinSize: size(-1), AutoscalingMaxSize: size(3)},
+		},
+		{
+			name:        "min above max",
+			machinePool: rancherv1.RKEMachinePool{WorkerRole: true, AutoscalingMinSize: size(4), AutoscalingMaxSize: size(3)},
+		},
+		{
+			name:        "etcd pool",
+			machinePool: rancherv1.RKEMachinePool{EtcdRole: true, WorkerRole: true, AutoscalingMinSize: size(1), AutoscalingMaxSize: size(3)},
+		},
+		{
+			name:        "control plane pool",
+			machinePool: rancherv1.RKEMachinePool{ControlPlaneRole: true, AutoscalingMinSize: size(1), AutoscalingMaxSize: size(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAutoscaling(tt.machinePool)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package provisioningcluster
+
+import (
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const (
+	defaultNodeStartupTimeout = 15 * time.Minute
+
+	// MinEtcdForRemediation is the smallest etcd member count that survives losing a member to remediation.
+	MinEtcdForRemediation = 3
+)
+
+// RemediationEnabled returns whether unhealthy machines of the pool are replaced automatically. Machines of an etcd
+// pool are only replaced when the cluster has enough etcd members to lose one without losing quorum.
+func RemediationEnabled(cluster *rancherv1.Cluster, machinePool rancherv1.RKEMachinePool) bool {
+	if machinePool.UnhealthyNodeTimeout == nil {
+		return false
+	}
+	return !machinePool.EtcdRole || etcdQuantity(cluster) >= MinEtcdForRemediation
+}
+
+func etcdQuantity(cluster *rancherv1.Cluster) (count int32) {
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if !machinePool.EtcdRole {
+			continue
+		}
+		if machinePool.Quantity == nil {
+			count++
+		} else {
+			count += *machinePool.Quantity
+		}
+	}
+	return
+}
+
+func machineHealthCheck(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, machinePool rancherv1.RKEMachinePool, machinePoolName string) *capi.MachineHealthCheck {
+	nodeStartupTimeout := metav1.Duration{Duration: defaultNodeStartupTimeout}
+	if machinePool.NodeStartupTimeout != nil {
+		nodeStartupTimeout = *machinePool.NodeStartupTimeout
+	}
+
+	maxUnhealthy := machinePool.MaxUnhealthy
+	if machinePool.EtcdRole {
+		// never take out more than one etcd member at a time
+		one := intstr.FromInt(1)
+		maxUnhealthy = &one
+	}
+
+	return &capi.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      machinePoolName,
+		},
+		Spec: capi.MachineHealthCheckSpec{
+			ClusterName: capiCluster.Name,
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					capi.MachineDeploymentLabelName: machinePoolName,
+				},
+			},
+			UnhealthyConditions: []capi.UnhealthyCondition{
+				{
+					Type:    corev1.NodeReady,
+					Status:  corev1.ConditionFalse,
+					Timeout: *machinePool.UnhealthyNodeTimeout,
+				},
+				{
+					Type:    corev1.NodeReady,
+					Status:  corev1.ConditionUnknown,
+					Timeout: *machinePool.UnhealthyNodeTimeout,
+				},
+			},
+			MaxUnhealthy:       maxUnhealthy,
+			NodeStartupTimeout: &nodeStartupTimeout,
+		},
+	}
+}
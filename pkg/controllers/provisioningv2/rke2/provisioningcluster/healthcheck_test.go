@@ -0,0 +1,101 @@
+package provisioningcluster
+
+import (
+	"testing"
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestRemediationEnabled(t *testing.T) {
+	quantity := func(i int32) *int32 {
+		return &i
+	}
+	timeout := &metav1.Duration{Duration: 5 * time.Minute}
+
+	tests := []struct {
+		name         string
+		machinePools []rancherv1.RKEMachinePool
+		enabled      bool
+	}{
+		{
+			name: "no unhealthy node timeout",
+			machinePools: []rancherv1.RKEMachinePool{
+				{EtcdRole: true, Quantity: quantity(3)},
+			},
+		},
+		{
+			name: "worker pool",
+			machinePools: []rancherv1.RKEMachinePool{
+				{WorkerRole: true, Quantity: quantity(1), UnhealthyNodeTimeout: timeout},
+				{EtcdRole: true, Quantity: quantity(1)},
+			},
+			enabled: true,
+		},
+		{
+			name: "etcd pool with quorum",
+			machinePools: []rancherv1.RKEMachinePool{
+				{EtcdRole: true, Quantity: quantity(3), UnhealthyNodeTimeout: timeout},
+			},
+			enabled: true,
+		},
+		{
+			name: "etcd members across pools",
+			machinePools: []rancherv1.RKEMachinePool{
+				{EtcdRole: true, Quantity: quantity(2), UnhealthyNodeTimeout: timeout},
+				{EtcdRole: true, ControlPlaneRole: true},
+			},
+			enabled: true,
+		},
+		{
+			name: "etcd pool without quorum",
+			machinePools: []rancherv1.RKEMachinePool{
+				{EtcdRole: true, Quantity: quantity(2), UnhealthyNodeTimeout: timeout},
+				{WorkerRole: true, Quantity: quantity(3)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &rancherv1.Cluster{
+				Spec: rancherv1.ClusterSpec{
+					RKEConfig: &rancherv1.RKEConfig{
+						MachinePools: tt.machinePools,
+					},
+				},
+			}
+			assert.Equal(t, tt.enabled, RemediationEnabled(cluster, tt.machinePools[0]))
+		})
+	}
+}
+
+func TestMachineHealthCheck(t *testing.T) {
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"}}
+	capiCluster := &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"}}
+	maxUnhealthy := intstr.FromString("40%")
+	machinePool := rancherv1.RKEMachinePool{
+		UnhealthyNodeTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+		MaxUnhealthy:         &maxUnhealthy,
+	}
+
+	mhc := machineHealthCheck(cluster, capiCluster, machinePool, "test-pool")
+	assert.Equal(t, "test-pool", mhc.Name)
+	assert.Equal(t, "test", mhc.Spec.ClusterName)
+	assert.Equal(t, map[string]string{capi.MachineDeploymentLabelName: "test-pool"}, mhc.Spec.Selector.MatchLabels)
+	assert.Equal(t, &maxUnhealthy, mhc.Spec.MaxUnhealthy)
+	assert.Equal(t, defaultNodeStartupTimeout, mhc.Spec.NodeStartupTimeout.Duration)
+	for _, cond := range mhc.Spec.UnhealthyConditions {
+		assert.Equal(t, 5*time.Minute, cond.Timeout.Duration)
+	}
+
+	machinePool.EtcdRole = true
+	machinePool.NodeStartupTimeout = &metav1.Duration{Duration: time.Hour}
+	mhc = machineHealthCheck(cluster, capiCluster, machinePool, "test-pool")
+	one := intstr.FromInt(1)
+	assert.Equal(t, &one, mhc.Spec.MaxUnhealthy)
+	assert.Equal(t, time.Hour, mhc.Spec.NodeStartupTimeout.Duration)
+}
@@ -205,7 +205,7 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 
 	machinePoolNames := map[string]bool{}
 	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
-		if machinePool.Quantity != nil && *machinePool.Quantity == 0 {
+		if machinePool.Quantity != nil && *machinePool.Quantity == 0 && !AutoscalingEnabled(machinePool) {
 			continue
 		}
 		if machinePool.Name == "" || machinePool.NodeConfig == nil || machinePool.NodeConfig.Name == "" || machinePool.NodeConfig.Kind == "" {
@@ -217,6 +217,10 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 			return nil, fmt.Errorf("at least one role of etcd, control-plane or worker must be assigned to machinePool [%s]", machinePool.Name)
 		}
 
+		if err := validateAutoscaling(machinePool); err != nil {
+			return nil, err
+		}
+
 		if machinePoolNames[machinePool.Name] {
 			return nil, fmt.Errorf("duplicate machinePool name [%s] used", machinePool.Name)
 		}
@@ -257,7 +261,7 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 				Namespace:   cluster.Namespace,
 				Name:        machinePoolName,
 				Labels:      machineDeploymentLabels,
-				Annotations: machinePool.MachineDeploymentAnnotations,
+				Annotations: autoscalerAnnotations(machinePool),
 			},
 			Spec: capi.MachineDeploymentSpec{
 				ClusterName: capiCluster.Name,
@@ -286,6 +290,11 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 				Paused: machinePool.Paused,
 			},
 		}
+		if AutoscalingEnabled(machinePool) {
+			// the size is left to the autoscaler, applying it would undo any scale up or down
+			machineDeployment.Spec.Replicas = nil
+		}
+
 		if machinePool.RollingUpdate != nil {
 			machineDeployment.Spec.Strategy = &capi.MachineDeploymentStrategy{
 				Type: capi.RollingUpdateMachineDeploymentStrategyType,
@@ -339,6 +348,10 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 		}
 
 		result = append(result, machineDeployment)
+
+		if RemediationEnabled(cluster, machinePool) {
+			result = append(result, machineHealthCheck(cluster, capiCluster, machinePool, machinePoolName))
+		}
 	}
 
 	return result, nil
@@ -379,6 +392,9 @@ func rkeControlPlane(cluster *rancherv1.Cluster) *rkev1.RKEControlPlane {
 			ManagementClusterName: cluster.Status.ClusterName,
 			AgentEnvVars:          cluster.Spec.AgentEnvVars,
 			ClusterName:           cluster.Name,
+			RotateCertificates:    cluster.Spec.RKEConfig.RotateCertificates.DeepCopy(),
+			RotateEncryptionKeys:  cluster.Spec.RKEConfig.RotateEncryptionKeys.DeepCopy(),
+			DryRun:                cluster.Spec.RKEConfig.DryRun,
 		},
 	}
 }
@@ -225,6 +225,23 @@ func addRoles(wrangler *wrangler.Context, management *config.ManagementContext)
 	rb.addRoleTemplate("Manage Navlinks", "navlinks-manage", "cluster", false, false, false).
 		addRule().apiGroups("ui.cattle.io").resources("navlinks").verbs("*")
 
+	// Bound to the user of the cluster autoscaler of provisioned clusters
+	rb.addRoleTemplate("Cluster Autoscaler", "cluster-autoscaler", "cluster", false, true, false).
+		addRule().apiGroups("").resources("events", "endpoints").verbs("create", "patch").
+		addRule().apiGroups("").resources("pods/eviction").verbs("create").
+		addRule().apiGroups("").resources("pods/status").verbs("update").
+		addRule().apiGroups("").resources("endpoints").resourceNames("cluster-autoscaler").verbs("get", "update").
+		addRule().apiGroups("").resources("nodes").verbs("get", "list", "watch", "update").
+		addRule().apiGroups("").resources("namespaces", "pods", "services", "replicationcontrollers", "persistentvolumeclaims", "persistentvolumes").verbs("get", "list", "watch").
+		addRule().apiGroups("").resources("configmaps").verbs("create", "list", "watch").
+		addRule().apiGroups("").resources("configmaps").resourceNames("cluster-autoscaler-status").verbs("get", "update", "delete", "watch").
+		addRule().apiGroups("apps", "extensions").resources("daemonsets", "replicasets", "statefulsets").verbs("get", "list", "watch").
+		addRule().apiGroups("batch").resources("jobs", "cronjobs").verbs("get", "list", "watch").
+		addRule().apiGroups("policy").resources("poddisruptionbudgets").verbs("list", "watch").
+		addRule().apiGroups("storage.k8s.io").resources("storageclasses", "csinodes", "csidrivers", "csistoragecapacities").verbs("get", "list", "watch").
+		addRule().apiGroups("coordination.k8s.io").resources("leases").verbs("create").
+		addRule().apiGroups("coordination.k8s.io").resources("leases").resourceNames("cluster-autoscaler").verbs("get", "update")
+
 	// Project roles
 	rb.addRoleTemplate("Project Owner", "project-owner", "project", false, false, false).
 		addRule().apiGroups("ui.cattle.io").resources("navlinks").verbs("get", "list", "watch").
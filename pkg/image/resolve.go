@@ -104,6 +104,10 @@ func pickImagesFromValuesYAML(imagesSet map[string]map[string]bool, charts map[s
 	if err != nil {
 		return err
 	}
+	return pickImagesFromValues(imagesSet, chartNameAndVersion, data, osType)
+}
+
+func pickImagesFromValues(imagesSet map[string]map[string]bool, chartNameAndVersion string, data []byte, osType OSType) error {
 	dataInterface := map[interface{}]interface{}{}
 	if err := yaml.Unmarshal(data, &dataInterface); err != nil {
 		return err
@@ -115,6 +119,24 @@ func pickImagesFromValuesYAML(imagesSet map[string]map[string]bool, charts map[s
 	return nil
 }
 
+// AddImagesFromValues adds the images referenced by the repository and tag fields of a values.yaml of
+// chartNameAndVersion to imagesSet. Unlike image list generation at build time, values of third party
+// charts with an invalid os field are an error rather than a panic.
+func AddImagesFromValues(imagesSet map[string]map[string]bool, chartNameAndVersion string, values []byte, osType OSType) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("failed to pick images from values of %s: %v", chartNameAndVersion, recovered)
+		}
+	}()
+	return pickImagesFromValues(imagesSet, chartNameAndVersion, values, osType)
+}
+
+// ImageAndSourceLists returns the sorted images of imagesSet and the same images followed by their
+// sources, in the formats of the rancher-images.txt and rancher-images-sources.txt files.
+func ImageAndSourceLists(imagesSet map[string]map[string]bool) ([]string, []string) {
+	return generateImageAndSourceLists(imagesSet)
+}
+
 func generateImages(chartNameAndVersion string, inputMap map[interface{}]interface{}, output map[string]map[string]bool, osType OSType) {
 	r, repoOk := inputMap["repository"]
 	t, tagOk := inputMap["tag"]
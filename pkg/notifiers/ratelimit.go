@@ -0,0 +1,82 @@
+package notifiers
+
+import (
+	"sync"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+)
+
+const defaultRateLimitInterval = 60 * time.Second
+
+var throttle = newLimiter()
+
+// limiter tracks the messages sent through each notifier to enforce its rate limit and dedup window.
+// The state is kept in memory, so each rancher replica enforces the rate limit on its own messages only.
+// Alerts are sent by alertmanager, which is slowed down to the rate limit by the intervals of the routes of
+// the notifier, except for the Google Chat and Telegram alerts rancher sends through the limiter itself.
+type limiter struct {
+	sync.Mutex
+	now  func() time.Time
+	sent map[string][]time.Time
+	seen map[string]map[string]time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		now:  time.Now,
+		sent: map[string][]time.Time{},
+		seen: map[string]map[string]time.Time{},
+	}
+}
+
+// allow reports whether msg can be sent to recipient through notifier, and records it if so.
+// Notifiers without a rate limit, or not yet saved, are never throttled.
+func (l *limiter) allow(notifier *v3.Notifier, recipient string, msg *Message) bool {
+	rateLimit := notifier.Spec.RateLimit
+	if rateLimit == nil || notifier.Name == "" {
+		return true
+	}
+	key := notifier.Namespace + ":" + notifier.Name
+
+	l.Lock()
+	defer l.Unlock()
+	now := l.now()
+
+	dedupWindow := time.Duration(rateLimit.DedupWindowSeconds) * time.Second
+	digest := hashKey(recipient + "\n" + msg.Title + "\n" + msg.Content)
+	seen := l.seen[key]
+	for k, at := range seen {
+		if now.Sub(at) >= dedupWindow {
+			delete(seen, k)
+		}
+	}
+	if _, ok := seen[digest]; ok {
+		return false
+	}
+
+	interval := time.Duration(rateLimit.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultRateLimitInterval
+	}
+	var sent []time.Time
+	for _, at := range l.sent[key] {
+		if now.Sub(at) < interval {
+			sent = append(sent, at)
+		}
+	}
+	if rateLimit.MaxMessages > 0 && len(sent) >= rateLimit.MaxMessages {
+		l.sent[key] = sent
+		return false
+	}
+
+	l.sent[key] = append(sent, now)
+	if dedupWindow > 0 {
+		if seen == nil {
+			seen = map[string]time.Time{}
+			l.seen[key] = seen
+		}
+		seen[digest] = now
+	}
+	return true
+}
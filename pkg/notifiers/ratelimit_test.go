@@ -0,0 +1,86 @@
+package notifiers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newLimiter()
+	l.now = func() time.Time { return now }
+
+	notifier := &v3.Notifier{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "c-abcde", Name: "n-slack"},
+		Spec: v32.NotifierSpec{
+			RateLimit: &v32.NotifierRateLimit{
+				MaxMessages:        2,
+				IntervalSeconds:    60,
+				DedupWindowSeconds: 300,
+			},
+		},
+	}
+
+	assert.True(l.allow(notifier, "", &Message{Content: "a"}))
+	// duplicates are dropped within the dedup window
+	assert.False(l.allow(notifier, "", &Message{Content: "a"}))
+	assert.True(l.allow(notifier, "#other", &Message{Content: "a"}))
+	// the rate limit is reached
+	assert.False(l.allow(notifier, "", &Message{Content: "b"}))
+
+	now = now.Add(61 * time.Second)
+	assert.True(l.allow(notifier, "", &Message{Content: "b"}))
+	assert.False(l.allow(notifier, "", &Message{Content: "a"}))
+
+	now = now.Add(300 * time.Second)
+	assert.True(l.allow(notifier, "", &Message{Content: "a"}))
+
+	// notifiers without rate limit or not saved yet are never throttled
+	unlimited := &v3.Notifier{ObjectMeta: metav1.ObjectMeta{Namespace: "c-abcde", Name: "n-teams"}}
+	for i := 0; i < 5; i++ {
+		assert.True(l.allow(unlimited, "", &Message{Content: "a"}))
+	}
+	unsaved := notifier.DeepCopy()
+	unsaved.Name = ""
+	for i := 0; i < 5; i++ {
+		assert.True(l.allow(unsaved, "", &Message{Content: "a"}))
+	}
+}
+
+func TestSendMessageRateLimited(t *testing.T) {
+	assert := assert.New(t)
+	sent := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	}))
+	defer server.Close()
+	defer func(l *limiter) { throttle = l }(throttle)
+	throttle = newLimiter()
+
+	notifier := &v3.Notifier{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "c-abcde", Name: "n-webhook"},
+		Spec: v32.NotifierSpec{
+			WebhookConfig: &v32.WebhookConfig{URL: server.URL},
+			RateLimit:     &v32.NotifierRateLimit{MaxMessages: 1, IntervalSeconds: 60},
+		},
+	}
+
+	assert.Nil(SendMessage(context.Background(), notifier, "", &Message{Content: "a"}, nil))
+	err := SendMessage(context.Background(), notifier, "", &Message{Content: "b"}, nil)
+	assert.True(errors.Is(err, ErrRateLimited))
+	assert.Equal(1, sent)
+
+	// test messages are not throttled
+	assert.Nil(SendTestMessage(context.Background(), notifier, "", &Message{Content: "b"}, nil))
+	assert.Equal(2, sent)
+}
@@ -29,11 +29,43 @@
 	"github.com/rancher/rancher/pkg/types/config/dialer"
 )
 
-const contentTypeJSON = "application/json"
+const (
+	contentTypeJSON = "application/json"
 
+	defaultOpsgenieAPIURL   = "https://api.opsgenie.com"
+	defaultOpsgeniePriority = "P3"
+	opsgenieMessageLimit    = 130
+	defaultTelegramAPIURL   = "https://api.telegram.org"
+)
+
+// Message is the notification passed to SendMessage. Labels, ClusterName and ProjectName are not
+// sent as is, but are available to the message templates of the notifier.
 type Message struct {
-	Title   string
-	Content string
+	Title       string
+	Content     string
+	Labels      map[string]string
+	ClusterName string
+	ProjectName string
+}
+
+// text returns the message as a single block, for notifiers that have no separate title.
+func (m *Message) text() string {
+	if m.Title == "" {
+		return m.Content
+	}
+	if m.Content == "" {
+		return m.Title
+	}
+	return m.Title + "\n" + m.Content
+}
+
+// body returns the text sent by notifiers that only send the content of messages. The title is added
+// when the notifier has a title template, as it is then part of what the user asked to be sent.
+func body(notifier *v3.Notifier, msg *Message) string {
+	if notifier.Spec.MessageTemplate == nil || notifier.Spec.MessageTemplate.Title == "" {
+		return msg.Content
+	}
+	return msg.text()
 }
 
 type wechatToken struct {
@@ -50,12 +82,44 @@ type dingtalkResponse struct {
 	Errmsg  string `json:"errmsg"`
 }
 
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// ErrRateLimited is returned by SendMessage when the message is dropped because of the rate limit of the notifier.
+var ErrRateLimited = errors.New("notifier rate limit exceeded")
+
+// SendMessage renders msg with the message template of the notifier and sends it to recipient, or to the
+// default recipient of the notifier if empty. Messages over the rate limit of the notifier are dropped and
+// an error wrapping ErrRateLimited is returned.
 func SendMessage(ctx context.Context, notifier *v3.Notifier, recipient string, msg *Message, dialer dialer.Dialer) error {
+	msg, err := RenderMessage(notifier.Spec.MessageTemplate, msg)
+	if err != nil {
+		return err
+	}
+	if !throttle.allow(notifier, recipient, msg) {
+		return errors.Wrapf(ErrRateLimited, "dropped message %q to notifier %s:%s", msg.Title, notifier.Namespace, notifier.Name)
+	}
+	return send(ctx, notifier, recipient, msg, dialer)
+}
+
+// SendTestMessage sends msg like SendMessage but bypasses the rate limit, so testing a notifier always reaches it.
+func SendTestMessage(ctx context.Context, notifier *v3.Notifier, recipient string, msg *Message, dialer dialer.Dialer) error {
+	msg, err := RenderMessage(notifier.Spec.MessageTemplate, msg)
+	if err != nil {
+		return err
+	}
+	return send(ctx, notifier, recipient, msg, dialer)
+}
+
+func send(ctx context.Context, notifier *v3.Notifier, recipient string, msg *Message, dialer dialer.Dialer) error {
+
 	if notifier.Spec.SlackConfig != nil {
 		if recipient == "" {
 			recipient = notifier.Spec.SlackConfig.DefaultRecipient
 		}
-		return TestSlack(notifier.Spec.SlackConfig.URL, recipient, msg.Content, notifier.Spec.SlackConfig.HTTPClientConfig, dialer)
+		return TestSlack(notifier.Spec.SlackConfig.URL, recipient, body(notifier, msg), notifier.Spec.SlackConfig.HTTPClientConfig, dialer)
 	}
 
 	if notifier.Spec.SMTPConfig != nil {
@@ -67,7 +131,7 @@ func SendMessage(ctx context.Context, notifier *v3.Notifier, recipient string, m
 	}
 
 	if notifier.Spec.PagerdutyConfig != nil {
-		return TestPagerduty(notifier.Spec.PagerdutyConfig.ServiceKey, msg.Content, notifier.Spec.PagerdutyConfig.HTTPClientConfig, dialer)
+		return TestPagerduty(notifier.Spec.PagerdutyConfig.ServiceKey, body(notifier, msg), notifier.Spec.PagerdutyConfig.HTTPClientConfig, dialer)
 	}
 
 	if notifier.Spec.WechatConfig != nil {
@@ -76,19 +140,50 @@ func SendMessage(ctx context.Context, notifier *v3.Notifier, recipient string, m
 			recipient = s.DefaultRecipient
 		}
 		return TestWechat(notifier.Spec.WechatConfig.Secret, notifier.Spec.WechatConfig.Agent, notifier.Spec.WechatConfig.Corp, notifier.Spec.WechatConfig.RecipientType,
-			recipient, msg.Content, notifier.Spec.WechatConfig.HTTPClientConfig, dialer)
+			recipient, body(notifier, msg), notifier.Spec.WechatConfig.HTTPClientConfig, dialer)
 	}
 
 	if notifier.Spec.WebhookConfig != nil {
-		return TestWebhook(notifier.Spec.WebhookConfig.URL, msg.Content, notifier.Spec.WebhookConfig.HTTPClientConfig, dialer)
+		if recipient == "" {
+			recipient = notifier.Spec.WebhookConfig.URL
+		}
+		return TestWebhook(recipient, body(notifier, msg), notifier.Spec.WebhookConfig.HTTPClientConfig, dialer)
 	}
 
 	if notifier.Spec.DingtalkConfig != nil {
-		return TestDingtalk(notifier.Spec.DingtalkConfig.URL, notifier.Spec.DingtalkConfig.Secret, msg.Content, notifier.Spec.DingtalkConfig.HTTPClientConfig, dialer)
+		return TestDingtalk(notifier.Spec.DingtalkConfig.URL, notifier.Spec.DingtalkConfig.Secret, body(notifier, msg), notifier.Spec.DingtalkConfig.HTTPClientConfig, dialer)
 	}
 
 	if notifier.Spec.MSTeamsConfig != nil {
-		return TestMicrosoftTeams(notifier.Spec.MSTeamsConfig.URL, msg.Content, notifier.Spec.MSTeamsConfig.HTTPClientConfig, dialer)
+		return TestMicrosoftTeams(notifier.Spec.MSTeamsConfig.URL, body(notifier, msg), notifier.Spec.MSTeamsConfig.HTTPClientConfig, dialer)
+	}
+
+	if notifier.Spec.OpsgenieConfig != nil {
+		s := notifier.Spec.OpsgenieConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestOpsgenie(s.APIURL, s.APIKey, s.Priority, recipient, msg.Title, msg.Content, s.HTTPClientConfig, dialer)
+	}
+
+	if notifier.Spec.GoogleChatConfig != nil {
+		return TestGoogleChat(notifier.Spec.GoogleChatConfig.URL, msg.text(), notifier.Spec.GoogleChatConfig.HTTPClientConfig, dialer)
+	}
+
+	if notifier.Spec.MattermostConfig != nil {
+		s := notifier.Spec.MattermostConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestMattermost(s.URL, recipient, msg.text(), s.HTTPClientConfig, dialer)
+	}
+
+	if notifier.Spec.TelegramConfig != nil {
+		s := notifier.Spec.TelegramConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestTelegram(s.APIURL, s.Token, recipient, msg.text(), s.HTTPClientConfig, dialer)
 	}
 
 	return errors.New("Notifier not configured")
@@ -229,10 +324,14 @@ func TestDingtalk(url, secret, msg string, cfg *v32.HTTPClientConfig, dialer dia
 		msg = "Dingtalk setting validated"
 	}
 
-	content := `{"msgtype": "text",
-		"text": {"content": "` + msg + `"},
-		"at": {"isAtAll": true}
-	}`
+	content, err := json.Marshal(map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg},
+		"at":      map[string]bool{"isAtAll": true},
+	})
+	if err != nil {
+		return err
+	}
 
 	url = getDingtalkURL(url, secret)
 
@@ -241,7 +340,7 @@ func TestDingtalk(url, secret, msg string, cfg *v32.HTTPClientConfig, dialer dia
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(content))
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
 	if err != nil {
 		return err
 	}
@@ -279,14 +378,17 @@ func TestMicrosoftTeams(url, msg string, cfg *v32.HTTPClientConfig, dialer diale
 		msg = "MicrosoftTeams setting validated"
 	}
 
-	content := `{"text":"` + msg + `"}`
+	content, err := json.Marshal(map[string]string{"text": msg})
+	if err != nil {
+		return err
+	}
 
 	client, err := NewClientFromConfig(cfg, dialer)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(content))
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
 	if err != nil {
 		return err
 	}
@@ -305,6 +407,177 @@ func TestMicrosoftTeams(url, msg string, cfg *v32.HTTPClientConfig, dialer diale
 	return nil
 }
 
+// TestOpsgenie creates an Opsgenie alert. The alias is derived from the message so that Opsgenie
+// deduplicates identical alerts that are still open.
+func TestOpsgenie(apiURL, key, priority, team, title, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" && title == "" {
+		msg = "Opsgenie setting validated"
+	}
+	if apiURL == "" {
+		apiURL = defaultOpsgenieAPIURL
+	}
+	if priority == "" {
+		priority = defaultOpsgeniePriority
+	}
+	if title == "" {
+		title = strings.SplitN(msg, "\n", 2)[0]
+	}
+	if r := []rune(title); len(r) > opsgenieMessageLimit {
+		title = string(r[:opsgenieMessageLimit])
+	}
+
+	og := &opsgenieAlert{
+		Message:     title,
+		Alias:       hashKey(title + "\n" + msg),
+		Description: msg,
+		Priority:    priority,
+		Source:      "rancher",
+	}
+	if team != "" {
+		og.Responders = []opsgenieResponder{{Name: team, Type: "team"}}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(og); err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/v2/alerts", &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Authorization", "GenieKey "+key)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		res, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes, response: %v", resp.StatusCode, string(res))
+	}
+
+	return nil
+}
+
+func TestGoogleChat(url, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Google Chat setting validated"
+	}
+
+	data, err := json.Marshal(map[string]string{"text": msg})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := post(client, url, contentTypeJSON, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func TestMattermost(url, channel, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Mattermost setting validated"
+	}
+	req := struct {
+		Text    string `json:"text"`
+		Channel string `json:"channel,omitempty"`
+	}{
+		Text:    msg,
+		Channel: channel,
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := post(client, url, contentTypeJSON, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		res, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes, response: %v", resp.StatusCode, string(res))
+	}
+
+	return nil
+}
+
+func TestTelegram(apiURL, token, chatID, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Telegram setting validated"
+	}
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"chat_id": chatID,
+		"text":    msg,
+	})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(apiURL, "/"), token)
+	resp, err := post(client, url, contentTypeJSON, bytes.NewBuffer(data))
+	if err != nil {
+		// the url contains the bot token, keep it out of the error
+		return errors.New("Failed to send Telegram message")
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var tgResp telegramResponse
+	if err := json.Unmarshal(respBytes, &tgResp); err != nil {
+		return fmt.Errorf("HTTP status code is %d, failed to parse Telegram response: %v", resp.StatusCode, err)
+	}
+
+	if !tgResp.OK {
+		return fmt.Errorf("Failed to send Telegram message. %s", tgResp.Description)
+	}
+
+	return nil
+}
+
 func TestWebhook(url, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
 	if msg == "" {
 		msg = "Webhook setting validated"
@@ -557,6 +830,20 @@ func hashKey(s string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description,omitempty"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+	Priority    string              `json:"priority"`
+	Source      string              `json:"source"`
+}
+
 type wechatEventPayload struct {
 	Content string `json:"content"`
 }
@@ -1,10 +1,17 @@
 package notifiers
 
 import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 
+	"github.com/prometheus/common/model"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,3 +46,82 @@ type testCase struct {
 	}
 
 }
+
+func TestTestOpsgenie(t *testing.T) {
+	assert := assert.New(t)
+	var received []opsgenieAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v2/alerts", r.URL.Path)
+		assert.Equal("GenieKey test-key", r.Header.Get("Authorization"))
+		alert := opsgenieAlert{}
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Nil(json.Unmarshal(body, &alert))
+		received = append(received, alert)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	assert.Nil(TestOpsgenie(server.URL+"/", "test-key", "", "ops", "", "disk full\non node-1", nil, nil))
+	assert.Nil(TestOpsgenie(server.URL, "test-key", "P1", "", "", "disk full\non node-1", nil, nil))
+	assert.Len(received, 2)
+	assert.Equal("disk full", received[0].Message)
+	assert.Equal("P3", received[0].Priority)
+	assert.Equal([]opsgenieResponder{{Name: "ops", Type: "team"}}, received[0].Responders)
+	assert.Equal("P1", received[1].Priority)
+	assert.Empty(received[1].Responders)
+	// identical messages share an alias, so Opsgenie deduplicates them
+	assert.Equal(received[0].Alias, received[1].Alias)
+}
+
+func TestTestTelegram(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottest-token/sendMessage" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+			return
+		}
+		req := map[string]string{}
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Nil(json.Unmarshal(body, &req))
+		assert.Equal("-100123", req["chat_id"])
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	assert.Nil(TestTelegram(server.URL, "test-token", "-100123", "", nil, nil))
+	err := TestTelegram(server.URL, "wrong-token", "-100123", "", nil, nil)
+	assert.EqualError(err, "Failed to send Telegram message. Unauthorized")
+}
+
+func TestSendWebhook(t *testing.T) {
+	assert := assert.New(t)
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alerts := model.Alerts{}
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Nil(json.Unmarshal(body, &alerts))
+		received = append(received, r.URL.Path+" "+string(alerts[0].Labels["test_msg"]))
+	}))
+	defer server.Close()
+
+	notifier := &v3.Notifier{
+		Spec: v32.NotifierSpec{
+			WebhookConfig: &v32.WebhookConfig{URL: server.URL + "/default"},
+		},
+	}
+	msg := &Message{Title: "title", Content: "content", ClusterName: "c-abcde"}
+
+	// without a title template only the content is sent
+	assert.Nil(SendTestMessage(context.Background(), notifier, "", msg, nil))
+	notifier.Spec.MessageTemplate = &v32.NotifierMessageTemplate{Body: "{{.ClusterName}}: {{.Content}}"}
+	assert.Nil(SendTestMessage(context.Background(), notifier, "", msg, nil))
+	notifier.Spec.MessageTemplate = &v32.NotifierMessageTemplate{Title: "[{{.ClusterName}}] {{.Title}}"}
+	assert.Nil(SendTestMessage(context.Background(), notifier, server.URL+"/recipient", msg, nil))
+
+	assert.Equal([]string{
+		"/default content",
+		"/default c-abcde: content",
+		"/recipient [c-abcde] title\ncontent",
+	}, received)
+}
@@ -0,0 +1,214 @@
+package notifiers
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"text/template/parse"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+)
+
+// ValidateMessageTemplate checks that the title and body of tmpl are valid Go templates that can be
+// translated to alertmanager templates.
+func ValidateMessageTemplate(tmpl *v32.NotifierMessageTemplate) error {
+	if tmpl == nil {
+		return nil
+	}
+	if _, err := AlertmanagerTemplate("title", tmpl.Title, "", ""); err != nil {
+		return err
+	}
+	if _, err := AlertmanagerTemplate("body", tmpl.Body, "", ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AlertmanagerTemplate translates a message template into an alertmanager template, which
+// alertmanager executes against the alert group of each notification. .Labels becomes the
+// .CommonLabels of the alerts and .ClusterName and .ProjectName their cluster_name and
+// project_name labels. .Title and .Content become the title and content alertmanager templates,
+// so they can only be output on their own and not be used in functions or conditions.
+func AlertmanagerTemplate(name, text, title, content string) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	if len(t.Templates()) > 1 {
+		return "", fmt.Errorf("invalid %s template: templates cannot be defined", name)
+	}
+	if t.Tree == nil {
+		return "", nil
+	}
+
+	translator := &alertmanagerTranslator{title: title, content: content}
+	if err := translator.list(t.Tree.Root, true); err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	return t.Tree.Root.String(), nil
+}
+
+type alertmanagerTranslator struct {
+	title   string
+	content string
+}
+
+// list translates the nodes of list in place. root reports whether dot is the message, rather
+// than a value set by range or with.
+func (a *alertmanagerTranslator) list(list *parse.ListNode, root bool) error {
+	if list == nil {
+		return nil
+	}
+	for i, node := range list.Nodes {
+		if action, ok := node.(*parse.ActionNode); ok {
+			switch outputField(action.Pipe, root) {
+			case "Title":
+				list.Nodes[i] = &parse.TextNode{NodeType: parse.NodeText, Pos: action.Pos, Text: []byte(a.title)}
+				continue
+			case "Content":
+				list.Nodes[i] = &parse.TextNode{NodeType: parse.NodeText, Pos: action.Pos, Text: []byte(a.content)}
+				continue
+			}
+		}
+		if err := a.node(node, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *alertmanagerTranslator) node(node parse.Node, root bool) error {
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		return a.pipe(n.Pipe, root)
+	case *parse.IfNode:
+		return a.branch(&n.BranchNode, root, root)
+	case *parse.RangeNode:
+		return a.branch(&n.BranchNode, root, false)
+	case *parse.WithNode:
+		return a.branch(&n.BranchNode, root, false)
+	case *parse.ListNode:
+		return a.list(n, root)
+	case *parse.TemplateNode:
+		return fmt.Errorf("templates cannot be called")
+	}
+	return nil
+}
+
+func (a *alertmanagerTranslator) branch(branch *parse.BranchNode, root, listRoot bool) error {
+	if err := a.pipe(branch.Pipe, root); err != nil {
+		return err
+	}
+	if err := a.list(branch.List, listRoot); err != nil {
+		return err
+	}
+	return a.list(branch.ElseList, root)
+}
+
+func (a *alertmanagerTranslator) pipe(pipe *parse.PipeNode, root bool) error {
+	if pipe == nil {
+		return nil
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			if err := a.arg(arg, root); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *alertmanagerTranslator) arg(arg parse.Node, root bool) error {
+	var err error
+	switch n := arg.(type) {
+	case *parse.FieldNode:
+		if root {
+			n.Ident, err = alertmanagerField(n.Ident)
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			var ident []string
+			ident, err = alertmanagerField(n.Ident[1:])
+			n.Ident = append([]string{"$"}, ident...)
+		}
+	case *parse.DotNode:
+		if root {
+			err = fmt.Errorf("the message can only be referenced through its fields")
+		}
+	case *parse.PipeNode:
+		err = a.pipe(n, root)
+	case *parse.ChainNode:
+		err = a.arg(n.Node, root)
+	}
+	return err
+}
+
+// alertmanagerField translates the field of the message ident refers to.
+func alertmanagerField(ident []string) ([]string, error) {
+	switch ident[0] {
+	case "Labels":
+		return append([]string{"CommonLabels"}, ident[1:]...), nil
+	case "ClusterName":
+		return []string{"CommonLabels", "cluster_name"}, nil
+	case "ProjectName":
+		return []string{"CommonLabels", "project_name"}, nil
+	case "Title", "Content":
+		return nil, fmt.Errorf(".%s can only be output on its own", ident[0])
+	}
+	return nil, fmt.Errorf("unknown field .%s", ident[0])
+}
+
+// outputField returns the name of the message field that pipe outputs on its own, if any.
+func outputField(pipe *parse.PipeNode, root bool) string {
+	if len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return ""
+	}
+	switch n := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		if root && len(n.Ident) == 1 {
+			return n.Ident[0]
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) == 2 && n.Ident[0] == "$" {
+			return n.Ident[1]
+		}
+	}
+	return ""
+}
+
+// RenderMessage executes the templates of tmpl against msg and returns the rendered message.
+// An empty title or body template keeps the original title or content.
+func RenderMessage(tmpl *v32.NotifierMessageTemplate, msg *Message) (*Message, error) {
+	rendered := *msg
+	if tmpl == nil {
+		return &rendered, nil
+	}
+	if tmpl.Title != "" {
+		title, err := execute("title", tmpl.Title, msg)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Title = title
+	}
+	if tmpl.Body != "" {
+		content, err := execute("body", tmpl.Body, msg)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Content = content
+	}
+	return &rendered, nil
+}
+
+func execute(name, text string, msg *Message) (string, error) {
+	t, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %v", name, err)
+	}
+	return buf.String(), nil
+}
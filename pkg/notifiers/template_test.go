@@ -0,0 +1,85 @@
+package notifiers
+
+import (
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMessage(t *testing.T) {
+	assert := assert.New(t)
+	msg := &Message{
+		Title:       "original title",
+		Content:     "original content",
+		Labels:      map[string]string{"severity": "critical"},
+		ClusterName: "c-abcde",
+		ProjectName: "p-fghij",
+	}
+
+	rendered, err := RenderMessage(nil, msg)
+	assert.Nil(err)
+	assert.Equal(msg, rendered)
+
+	rendered, err = RenderMessage(&v32.NotifierMessageTemplate{
+		Title: "[{{.Labels.severity}}] {{.ClusterName}}/{{.ProjectName}}",
+	}, msg)
+	assert.Nil(err)
+	assert.Equal("[critical] c-abcde/p-fghij", rendered.Title)
+	assert.Equal("original content", rendered.Content)
+
+	rendered, err = RenderMessage(&v32.NotifierMessageTemplate{
+		Body: "{{.Title}}: {{.Content}} {{.Labels.missing}}",
+	}, msg)
+	assert.Nil(err)
+	assert.Equal("original title", rendered.Title)
+	assert.Equal("original title: original content ", rendered.Content)
+
+	_, err = RenderMessage(&v32.NotifierMessageTemplate{Body: "{{.Unknown}}"}, msg)
+	assert.NotNil(err)
+}
+
+func TestValidateMessageTemplate(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(ValidateMessageTemplate(nil))
+	assert.Nil(ValidateMessageTemplate(&v32.NotifierMessageTemplate{Title: "{{.Title}}", Body: "{{range $k, $v := .Labels}}{{$k}}={{$v}} {{end}}"}))
+	assert.NotNil(ValidateMessageTemplate(&v32.NotifierMessageTemplate{Title: "{{.Title"}))
+	assert.NotNil(ValidateMessageTemplate(&v32.NotifierMessageTemplate{Body: "{{if}}"}))
+	assert.NotNil(ValidateMessageTemplate(&v32.NotifierMessageTemplate{Body: `{{if eq .Title "x"}}x{{end}}`}))
+}
+
+func TestAlertmanagerTemplate(t *testing.T) {
+	assert := assert.New(t)
+	title, content := `{{ template "rancher.title" . }}`, `{{ template "slack.text" . }}`
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "", want: ""},
+		{text: "plain", want: "plain"},
+		{text: "{{.Title}}: {{.Content}}", want: title + ": " + content},
+		{text: `{{if eq .Labels.severity "critical"}}!{{end}}{{$.Title}}`, want: `{{if eq .CommonLabels.severity "critical"}}!{{end}}` + title},
+		{text: "{{.ClusterName}}/{{.ProjectName}}", want: "{{.CommonLabels.cluster_name}}/{{.CommonLabels.project_name}}"},
+		{text: `{{index .Labels "alert_name"}}`, want: `{{index .CommonLabels "alert_name"}}`},
+		{text: "{{range $k, $v := .Labels}}{{$k}}={{$v}} {{end}}", want: "{{range $k, $v := .CommonLabels}}{{$k}}={{$v}} {{end}}"},
+		{text: "{{with .Labels}}{{.severity}} {{$.ClusterName}}{{end}}", want: "{{with .CommonLabels}}{{.severity}} {{$.CommonLabels.cluster_name}}{{end}}"},
+	}
+	for _, tt := range tests {
+		got, err := AlertmanagerTemplate("body", tt.text, title, content)
+		assert.Nil(err, tt.text)
+		assert.Equal(tt.want, got, tt.text)
+	}
+
+	for _, text := range []string{
+		`{{if eq .Title "x"}}x{{end}}`,
+		"{{.Title | printf \"%s\"}}",
+		"{{printf \"%v\" .}}",
+		"{{.Unknown}}",
+		`{{define "x"}}x{{end}}`,
+		`{{template "rancher.title" .}}`,
+	} {
+		_, err := AlertmanagerTemplate("body", text, title, content)
+		assert.NotNil(err, text)
+	}
+}
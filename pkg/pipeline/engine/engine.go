@@ -1,9 +1,15 @@
 package engine
 
 import (
+	"fmt"
+
 	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/pipeline/engine/jenkins"
+	"github.com/rancher/rancher/pkg/pipeline/engine/kubernetes"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
 	"github.com/rancher/rancher/pkg/types/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 type PipelineEngine interface {
@@ -27,7 +33,7 @@ func New(cluster *config.UserContext, useCache bool) PipelineEngine {
 	pipelineSettingLister := cluster.Management.Project.PipelineSettings("").Controller().Lister()
 	dialer := cluster.Management.Dialer
 
-	engine := &jenkins.Engine{
+	jenkinsEngine := &jenkins.Engine{
 		UseCache:                   useCache,
 		ServiceLister:              serviceLister,
 		PodLister:                  podLister,
@@ -42,5 +48,101 @@ func New(cluster *config.UserContext, useCache bool) PipelineEngine {
 		Dialer:      dialer,
 		ClusterName: cluster.ClusterName,
 	}
-	return engine
+	kubernetesEngine := &kubernetes.Engine{
+		Jenkins:               jenkinsEngine,
+		K8sClient:             cluster.K8sClient,
+		PodLister:             podLister,
+		SecretLister:          secretLister,
+		PipelineLister:        pipelineLister,
+		PipelineSettingLister: pipelineSettingLister,
+	}
+	return &engineSelector{
+		engines: map[string]PipelineEngine{
+			utils.EngineJenkins:    jenkinsEngine,
+			utils.EngineKubernetes: kubernetesEngine,
+		},
+		pipelineSettingLister: pipelineSettingLister,
+	}
+}
+
+// engineSelector runs each execution with the engine set by the execution-engine setting of its project.
+// The engine an execution started with is recorded on it, so that changing the setting only affects
+// later executions.
+type engineSelector struct {
+	engines               map[string]PipelineEngine
+	pipelineSettingLister v3.PipelineSettingLister
+}
+
+func (s *engineSelector) engineFor(execution *v3.PipelineExecution) (PipelineEngine, string, error) {
+	name := execution.Annotations[utils.PipelineEngineAnnotation]
+	if name == "" {
+		_, projectID := ref.Parse(execution.Spec.ProjectName)
+		setting, err := s.pipelineSettingLister.Get(projectID, utils.SettingExecutionEngine)
+		if apierrors.IsNotFound(err) {
+			name = utils.SettingExecutionEngineDefault
+		} else if err != nil {
+			return nil, "", err
+		} else if setting.Value != "" {
+			name = setting.Value
+		} else {
+			name = setting.Default
+		}
+	}
+	engine, ok := s.engines[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown pipeline engine %q", name)
+	}
+	return engine, name, nil
+}
+
+func (s *engineSelector) PreCheck(execution *v3.PipelineExecution) (bool, error) {
+	engine, _, err := s.engineFor(execution)
+	if err != nil {
+		return false, err
+	}
+	return engine.PreCheck(execution)
+}
+
+func (s *engineSelector) RunPipelineExecution(execution *v3.PipelineExecution) error {
+	engine, name, err := s.engineFor(execution)
+	if err != nil {
+		return err
+	}
+	if execution.Annotations == nil {
+		execution.Annotations = map[string]string{}
+	}
+	execution.Annotations[utils.PipelineEngineAnnotation] = name
+	return engine.RunPipelineExecution(execution)
+}
+
+func (s *engineSelector) RerunExecution(execution *v3.PipelineExecution) error {
+	engine, _, err := s.engineFor(execution)
+	if err != nil {
+		return err
+	}
+	return engine.RerunExecution(execution)
+}
+
+func (s *engineSelector) StopExecution(execution *v3.PipelineExecution) error {
+	engine, _, err := s.engineFor(execution)
+	if err != nil {
+		return err
+	}
+	return engine.StopExecution(execution)
+}
+
+func (s *engineSelector) GetStepLog(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	engine, _, err := s.engineFor(execution)
+	if err != nil {
+		return "", err
+	}
+	return engine.GetStepLog(execution, stage, step)
+}
+
+func (s *engineSelector) SyncExecution(execution *v3.PipelineExecution) (bool, error) {
+	engine, _, err := s.engineFor(execution)
+	if err != nil {
+		return false, err
+	}
+	return engine.SyncExecution(execution)
 }
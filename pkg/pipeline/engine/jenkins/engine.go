@@ -151,7 +151,7 @@ func (j *Engine) RunPipelineExecution(execution *v3.PipelineExecution) error {
 		return err
 	}
 
-	if err := j.preparePipeline(execution); err != nil {
+	if err := j.PreparePipeline(execution); err != nil {
 		return err
 	}
 	if _, err := client.buildJob(jobName, map[string]string{}); err != nil {
@@ -160,7 +160,9 @@ func (j *Engine) RunPipelineExecution(execution *v3.PipelineExecution) error {
 	return nil
 }
 
-func (j *Engine) preparePipeline(execution *v3.PipelineExecution) error {
+// PreparePipeline stores the credentials of the registries the publish image steps of execution push to
+// in the pipeline namespace.
+func (j *Engine) PreparePipeline(execution *v3.PipelineExecution) error {
 	var registry string
 	for _, stage := range execution.Spec.PipelineConfig.Stages {
 		for _, step := range stage.Steps {
@@ -576,7 +578,7 @@ func (j Engine) GetStepLog(execution *v3.PipelineExecution, stage int, step int)
 	if curStep.State == utils.StateWaiting {
 		return "", nil
 	} else if curStep.State != utils.StateBuilding {
-		return j.getStepLogFromMinioStore(execution, stage, step)
+		return j.GetStoredStepLog(execution, stage, step)
 	}
 	return j.getStepLogFromJenkins(execution, stage, step)
 }
@@ -632,12 +634,7 @@ func (j Engine) setCredential(client *Client, execution *v3.PipelineExecution, c
 	if credentialID == "" {
 		return nil
 	}
-	ns, name := ref.Parse(credentialID)
-	credential, err := j.SourceCodeCredentialLister.Get(ns, name)
-	if err != nil {
-		return err
-	}
-	err = client.getCredential(credentialID)
+	err := client.getCredential(credentialID)
 	if e, ok := err.(*httperror.APIError); !ok || e.Code.Status != http.StatusNotFound {
 		return err
 	}
@@ -647,27 +644,11 @@ func (j Engine) setCredential(client *Client, execution *v3.PipelineExecution, c
 	jenkinsCred.Scope = "GLOBAL"
 	jenkinsCred.ID = execution.Name
 
-	_, projID := ref.Parse(execution.Spec.ProjectName)
-	scpConfig, err := providers.GetSourceCodeProviderConfig(credential.Spec.SourceCodeType, projID)
-	if err != nil {
-		return err
-	}
-	remote, err := remote.New(scpConfig)
+	jenkinsCred.Username, jenkinsCred.Password, err = j.GitCredential(execution, credentialID)
 	if err != nil {
 		return err
 	}
 
-	jenkinsCred.Username = credential.Spec.GitLoginName
-	jenkinsCred.Password = credential.Spec.AccessToken
-	if credential.Spec.GitCloneToken != "" {
-		jenkinsCred.Password = credential.Spec.GitCloneToken
-	}
-	if accessToken, err := utils.EnsureAccessToken(j.SourceCodeCredentials, remote, credential); err != nil {
-		return err
-	} else if accessToken != credential.Spec.AccessToken {
-		jenkinsCred.Password = accessToken
-	}
-
 	bodyContent := map[string]interface{}{}
 	bodyContent["credentials"] = jenkinsCred
 	b, err := json.Marshal(bodyContent)
@@ -679,6 +660,37 @@ func (j Engine) setCredential(client *Client, execution *v3.PipelineExecution, c
 	return client.createCredential(buff.Bytes())
 }
 
+// GitCredential returns the username and password execution clones its repository with, refreshing
+// the access token of the source code credential when it expired.
+func (j Engine) GitCredential(execution *v3.PipelineExecution, credentialID string) (string, string, error) {
+	ns, name := ref.Parse(credentialID)
+	credential, err := j.SourceCodeCredentialLister.Get(ns, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, projID := ref.Parse(execution.Spec.ProjectName)
+	scpConfig, err := providers.GetSourceCodeProviderConfig(credential.Spec.SourceCodeType, projID)
+	if err != nil {
+		return "", "", err
+	}
+	remote, err := remote.New(scpConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	password := credential.Spec.AccessToken
+	if credential.Spec.GitCloneToken != "" {
+		password = credential.Spec.GitCloneToken
+	}
+	if accessToken, err := utils.EnsureAccessToken(j.SourceCodeCredentials, remote, credential); err != nil {
+		return "", "", err
+	} else if accessToken != credential.Spec.AccessToken {
+		password = accessToken
+	}
+	return credential.Spec.GitLoginName, password, nil
+}
+
 func translatePreparingMessage(log string) string {
 	log = strings.TrimRight(log, "\n")
 	lines := strings.Split(log, "\n")
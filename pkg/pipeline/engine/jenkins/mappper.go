@@ -187,6 +187,43 @@ func (c *jenkinsPipelineConverter) convertPipelineExecutionToPipelineScript() (s
 	return fmt.Sprintf(pipelineBlock, b.String(), timeout, pipelinebuffer.String()), nil
 }
 
+// StepPodSpec returns the spec of the pod the steps of execution run in, without the Jenkins agent,
+// and the containers of the steps by stage and step. The containers are configured as for Jenkins
+// but have no command, which is left to the engine running them.
+func StepPodSpec(execution *v3.PipelineExecution, pipelineSettingLister v3.PipelineSettingLister, secretLister apiv1.SecretLister) (*v1.PodSpec, [][]v1.Container, error) {
+	c, err := initJenkinsPipelineConverter(execution, pipelineSettingLister, secretLister)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := utils.ValidPipelineConfig(c.execution.Spec.PipelineConfig); err != nil {
+		return nil, nil, err
+	}
+	parsePreservedEnvVar(c.execution)
+
+	pod := c.getBasePodTemplate()
+	containers := make([][]v1.Container, len(c.execution.Spec.PipelineConfig.Stages))
+	for j, stage := range c.execution.Spec.PipelineConfig.Stages {
+		for k := range stage.Steps {
+			container, err := c.getStepContainer(j, k)
+			if err != nil {
+				return nil, nil, err
+			}
+			container.Command = nil
+			container.TTY = false
+			containers[j] = append(containers[j], container)
+		}
+	}
+	if c.opts.gitCaCerts != "" {
+		c.injectGitCaCert(pod)
+		//the clone step checks out the repository in place of the agent
+		c.injectGitCaCertToContainer(&containers[0][0])
+	}
+	if len(c.opts.imagePullSecretNames) > 0 {
+		c.configImagePullSecrets(pod)
+	}
+	return &pod.Spec, containers, nil
+}
+
 func (c *jenkinsPipelineConverter) getBasePodTemplate() *v1.Pod {
 	ns := utils.GetPipelineCommonName(c.execution.Spec.ProjectName)
 	pod := &v1.Pod{
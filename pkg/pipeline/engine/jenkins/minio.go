@@ -79,7 +79,8 @@ func (j *Engine) getMinioClient(ns string) (*minio.Client, error) {
 	return client, nil
 }
 
-func (j Engine) getStepLogFromMinioStore(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+// GetStoredStepLog returns the log of a finished step of execution from the log store of the pipeline namespace.
+func (j Engine) GetStoredStepLog(execution *v3.PipelineExecution, stage int, step int) (string, error) {
 	bucketName := utils.MinioLogBucket
 	logName := fmt.Sprintf("%s-%d-%d", execution.Name, stage, step)
 	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
@@ -103,6 +104,15 @@ func (j Engine) getStepLogFromMinioStore(execution *v3.PipelineExecution, stage
 }
 
 func (j *Engine) saveStepLogToMinio(execution *v3.PipelineExecution, stage int, step int) error {
+	message, err := j.getStepLogFromJenkins(execution, stage, step)
+	if err != nil {
+		return err
+	}
+	return j.StoreStepLog(execution, stage, step, message)
+}
+
+// StoreStepLog saves the log of a finished step of execution to the log store of the pipeline namespace.
+func (j *Engine) StoreStepLog(execution *v3.PipelineExecution, stage int, step int, message string) error {
 	bucketName := utils.MinioLogBucket
 	logName := fmt.Sprintf("%s-%d-%d", execution.Name, stage, step)
 	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
@@ -122,11 +132,6 @@ func (j *Engine) saveStepLogToMinio(execution *v3.PipelineExecution, stage int,
 		}
 	}
 
-	message, err := j.getStepLogFromJenkins(execution, stage, step)
-	if err != nil {
-		return err
-	}
-
 	_, err = client.PutObject(context.TODO(), bucketName, logName, strings.NewReader(message), int64(len(message)), minio.PutObjectOptions{})
 	return err
 }
@@ -0,0 +1,643 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/engine/jenkins"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	labelKeyStage  = "stage"
+	executorName   = "pipeline-executor"
+	workspaceName  = "workspace"
+	workspacePath  = "/workspace"
+	gitUsernameKey = "username"
+	gitPasswordKey = "password"
+
+	cloneScript = `set -e
+if [ -n "$GIT_USERNAME" ]; then
+  git config --global credential.helper '!f() { echo "username=$GIT_USERNAME"; echo "password=$GIT_PASSWORD"; }; f'
+fi
+git init -q .
+git remote add origin "$CICD_GIT_URL"
+git fetch -q origin "+$CICD_GIT_REF:refs/remotes/local/temp"
+git checkout -q local/temp
+`
+)
+
+// waitingFailures are the reasons a step container waits for that do not resolve without user action.
+var waitingFailures = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// Engine runs each stage of a pipeline execution as a Job in the pipeline namespace. The steps of a stage
+// are the containers of the pod of its Job and the stages share a workspace volume the clone step checks
+// out the repository to.
+type Engine struct {
+	// Jenkins provides the registry and git credentials, step containers and log store shared with
+	// the Jenkins engine.
+	Jenkins *jenkins.Engine
+
+	K8sClient             kubernetes.Interface
+	PodLister             v1.PodLister
+	SecretLister          v1.SecretLister
+	PipelineLister        v3.PipelineLister
+	PipelineSettingLister v3.PipelineSettingLister
+}
+
+func (e *Engine) PreCheck(execution *v3.PipelineExecution) (bool, error) {
+	set := labels.Set(map[string]string{utils.LabelKeyApp: utils.MinioName})
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	pods, err := e.PodLister.List(ns, set.AsSelector())
+	if err != nil {
+		return false, err
+	}
+	if len(pods) <= 0 {
+		return false, errors.New("minio pod not found")
+	}
+
+	for _, cond := range pods[0].Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (e *Engine) RunPipelineExecution(execution *v3.PipelineExecution) error {
+	logrus.Debug("start RunPipelineExecution")
+	if err := e.cleanup(execution); err != nil {
+		return err
+	}
+	if err := e.Jenkins.PreparePipeline(execution); err != nil {
+		return err
+	}
+
+	ns, name := ref.Parse(execution.Spec.PipelineName)
+	pipeline, err := e.PipelineLister.Get(ns, name)
+	if err != nil {
+		return err
+	}
+	if err := e.createGitSecret(execution, pipeline.Spec.SourceCodeCredentialName); err != nil {
+		return err
+	}
+	return e.createWorkspace(execution)
+}
+
+func (e *Engine) RerunExecution(execution *v3.PipelineExecution) error {
+	return e.RunPipelineExecution(execution)
+}
+
+func (e *Engine) StopExecution(execution *v3.PipelineExecution) error {
+	for i, stage := range execution.Status.Stages {
+		for j, step := range stage.Steps {
+			if step.State != utils.StateBuilding {
+				continue
+			}
+			if err := e.saveStepLog(execution, i, j); err != nil {
+				logrus.Warnf("failed to save log of step %d-%d of pipeline execution %s: %v", i, j, execution.Name, err)
+			}
+		}
+	}
+	return e.cleanup(execution)
+}
+
+func (e *Engine) SyncExecution(execution *v3.PipelineExecution) (bool, error) {
+	if utils.IsFinishState(execution.Status.ExecutionState) {
+		// the state syncer fails executions it could not sync without finishing them, finish them so the
+		// lifecycle cleans up their Jobs, workspace and git credential as it does for any finished execution
+		if execution.Labels[utils.PipelineFinishLabel] != "true" {
+			execution.Labels[utils.PipelineFinishLabel] = "true"
+			return true, nil
+		}
+		return false, nil
+	}
+	if len(execution.Status.Stages) != len(execution.Spec.PipelineConfig.Stages) {
+		return false, errors.New("error sync execution - index out of range")
+	}
+
+	updated := false
+	for i, stage := range execution.Status.Stages {
+		if stage.State == utils.StateSuccess || stage.State == utils.StateSkipped {
+			continue
+		}
+		done, stageUpdated, err := e.syncStage(execution, i)
+		updated = updated || stageUpdated
+		if err != nil || !done {
+			return updated, err
+		}
+	}
+
+	execution.Labels[utils.PipelineFinishLabel] = "true"
+	execution.Status.ExecutionState = utils.StateSuccess
+	execution.Status.Ended = time.Now().Format(time.RFC3339)
+	v32.PipelineExecutionConditionProvisioned.True(execution)
+	v32.PipelineExecutionConditionBuilt.True(execution)
+	return true, nil
+}
+
+// syncStage updates the status of a stage from the pod of its Job, creating the Job when the stage
+// did not start yet. It returns whether the stage succeeded or was skipped.
+func (e *Engine) syncStage(execution *v3.PipelineExecution, stage int) (bool, bool, error) {
+	stageConfig := execution.Spec.PipelineConfig.Stages[stage]
+	if !utils.MatchAll(stageConfig.When, execution) {
+		for i := range execution.Status.Stages[stage].Steps {
+			execution.Status.Stages[stage].Steps[i].State = utils.StateSkipped
+		}
+		execution.Status.Stages[stage].State = utils.StateSkipped
+		return true, true, nil
+	}
+
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	job, err := e.K8sClient.BatchV1().Jobs(ns).Get(context.TODO(), jobName(execution, stage), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if err := e.startStage(execution, stage); err != nil {
+			return false, false, err
+		}
+		return execution.Status.Stages[stage].State == utils.StateSkipped, true, nil
+	} else if err != nil {
+		return false, false, err
+	}
+
+	pod, err := e.getStagePod(execution, stage)
+	if err != nil {
+		return false, false, err
+	}
+	if pod == nil {
+		if message := jobFailure(job); message != "" {
+			failExecution(execution, stage, message)
+			return false, true, nil
+		}
+		return false, false, nil
+	}
+
+	updated := false
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+	for i, step := range execution.Status.Stages[stage].Steps {
+		if utils.IsFinishState(step.State) {
+			continue
+		}
+		status, ok := statuses[stepName(stage, i)]
+		if !ok {
+			continue
+		}
+		if state := status.State.Terminated; state != nil {
+			if state.ExitCode == 0 {
+				finishStep(execution, stage, i, utils.StateSuccess, state.StartedAt, state.FinishedAt)
+			} else {
+				finishStep(execution, stage, i, utils.StateFailed, state.StartedAt, state.FinishedAt)
+			}
+			if err := e.saveStepLog(execution, stage, i); err != nil {
+				return false, updated, err
+			}
+			updated = true
+		} else if state := status.State.Running; state != nil && step.State != utils.StateBuilding {
+			buildingStep(execution, stage, i, state.StartedAt)
+			updated = true
+		} else if state := status.State.Waiting; state != nil && waitingFailures[state.Reason] {
+			now := metav1.Now()
+			finishStep(execution, stage, i, utils.StateFailed, now, now)
+			updated = true
+		}
+	}
+	if updated && !v32.PipelineExecutionConditionProvisioned.IsTrue(execution) {
+		v32.PipelineExecutionConditionProvisioned.True(execution)
+	}
+
+	for _, step := range execution.Status.Stages[stage].Steps {
+		if step.State == utils.StateFailed {
+			e.abortStage(execution, stage)
+			failExecution(execution, stage, fmt.Sprintf("Got FAILED status in '%s' stage", stageConfig.Name))
+			return false, true, nil
+		}
+	}
+	if message := jobFailure(job); message != "" {
+		e.abortStage(execution, stage)
+		failExecution(execution, stage, message)
+		return false, true, nil
+	}
+	if !utils.IsStageSuccess(execution.Status.Stages[stage]) {
+		return false, updated, nil
+	}
+
+	execution.Status.Stages[stage].State = utils.StateSuccess
+	execution.Status.Stages[stage].Ended = time.Now().Format(time.RFC3339)
+	return true, true, nil
+}
+
+// startStage creates the Job running the steps of a stage.
+func (e *Engine) startStage(execution *v3.PipelineExecution, stage int) error {
+	spec, containers, err := jenkins.StepPodSpec(execution, e.PipelineSettingLister, e.SecretLister)
+	if err != nil {
+		return err
+	}
+
+	stageConfig := execution.Spec.PipelineConfig.Stages[stage]
+	for i, step := range stageConfig.Steps {
+		if !utils.MatchAll(step.When, execution) {
+			execution.Status.Stages[stage].Steps[i].State = utils.StateSkipped
+			continue
+		}
+		container := containers[stage][i]
+		container.Command = stepCommand(&step)
+		container.WorkingDir = workspacePath
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      workspaceName,
+			MountPath: workspacePath,
+		})
+		if step.SourceCodeConfig != nil {
+			container.Env = append(container.Env, gitEnv(execution, "GIT_USERNAME", gitUsernameKey), gitEnv(execution, "GIT_PASSWORD", gitPasswordKey))
+		}
+		spec.Containers = append(spec.Containers, container)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	if len(spec.Containers) == 0 {
+		//every step of the stage is skipped
+		execution.Status.Stages[stage].State = utils.StateSkipped
+		execution.Status.Stages[stage].Ended = now
+		return nil
+	}
+
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: workspaceName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: workspaceClaimName(execution),
+			},
+		},
+	})
+
+	deadline := activeDeadline(execution)
+	if deadline <= 0 {
+		failExecution(execution, stage, fmt.Sprintf("Timeout before '%s' stage", stageConfig.Name))
+		return nil
+	}
+	backoffLimit := int32(0)
+	stageLabels := stageLabels(execution, stage)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName(execution, stage),
+			Namespace: utils.GetPipelineCommonName(execution.Spec.ProjectName),
+			Labels:    stageLabels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: stageLabels,
+				},
+				Spec: *spec,
+			},
+		},
+	}
+	if _, err := e.K8sClient.BatchV1().Jobs(job.Namespace).Create(context.TODO(), job, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	execution.Status.Stages[stage].State = utils.StateBuilding
+	if execution.Status.Stages[stage].Started == "" {
+		execution.Status.Stages[stage].Started = now
+	}
+	if execution.Status.ExecutionState == utils.StateWaiting {
+		execution.Status.ExecutionState = utils.StateBuilding
+	}
+	if execution.Status.Started == "" {
+		execution.Status.Started = now
+	}
+	v32.PipelineExecutionConditionBuilt.CreateUnknownIfNotExists(execution)
+	v32.PipelineExecutionConditionBuilt.Message(execution, fmt.Sprintf("Running '%s' stage", stageConfig.Name))
+	return nil
+}
+
+// activeDeadline returns the seconds left before the timeout of an execution, counted from the start of
+// its first stage, so the Jobs of all its stages together run no longer than the timeout.
+func activeDeadline(execution *v3.PipelineExecution) int64 {
+	timeout := utils.DefaultTimeout
+	if execution.Spec.PipelineConfig.Timeout > 0 {
+		timeout = execution.Spec.PipelineConfig.Timeout
+	}
+	started := time.Now()
+	if t, err := time.Parse(time.RFC3339, execution.Status.Started); err == nil {
+		started = t
+	}
+	left := time.Until(started.Add(time.Duration(timeout) * time.Minute))
+	return int64(math.Ceil(left.Seconds()))
+}
+
+// abortStage marks the steps of a stage that are still building as aborted, saving their logs.
+func (e *Engine) abortStage(execution *v3.PipelineExecution, stage int) {
+	now := time.Now().Format(time.RFC3339)
+	for i, step := range execution.Status.Stages[stage].Steps {
+		if step.State != utils.StateBuilding {
+			continue
+		}
+		if err := e.saveStepLog(execution, stage, i); err != nil {
+			logrus.Warnf("failed to save log of step %d-%d of pipeline execution %s: %v", stage, i, execution.Name, err)
+		}
+		execution.Status.Stages[stage].Steps[i].State = utils.StateAborted
+		execution.Status.Stages[stage].Steps[i].Ended = now
+	}
+}
+
+func (e *Engine) GetStepLog(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	if len(execution.Status.Stages) <= stage || len(execution.Status.Stages[stage].Steps) <= step {
+		return "", errors.New("invalid step index")
+	}
+	curStep := execution.Status.Stages[stage].Steps[step]
+	if curStep.State == utils.StateWaiting {
+		return "", nil
+	} else if curStep.State != utils.StateBuilding {
+		return e.Jenkins.GetStoredStepLog(execution, stage, step)
+	}
+	return e.getPodLog(execution, stage, step)
+}
+
+func (e *Engine) saveStepLog(execution *v3.PipelineExecution, stage int, step int) error {
+	pod, err := e.getStagePod(execution, stage)
+	if err != nil || pod == nil {
+		//nothing to save once the pod is gone
+		return err
+	}
+	log, err := e.getPodLog(execution, stage, step)
+	if err != nil {
+		return err
+	}
+	return e.Jenkins.StoreStepLog(execution, stage, step, log)
+}
+
+func (e *Engine) getPodLog(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	pod, err := e.getStagePod(execution, stage)
+	if err != nil || pod == nil {
+		return "", err
+	}
+	name := stepName(stage, step)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != name {
+			continue
+		}
+		if status.State.Running == nil && status.State.Terminated == nil {
+			return "", nil
+		}
+		log, err := e.K8sClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: name,
+		}).DoRaw(context.TODO())
+		return string(log), err
+	}
+	return "", nil
+}
+
+// getStagePod returns the pod of the Job of a stage, or nil when it is not created yet.
+func (e *Engine) getStagePod(execution *v3.PipelineExecution, stage int) (*corev1.Pod, error) {
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	pods, err := e.PodLister.List(ns, labels.SelectorFromSet(stageLabels(execution, stage)))
+	if err != nil {
+		return nil, err
+	}
+	var pod *corev1.Pod
+	for _, p := range pods {
+		if pod == nil || p.CreationTimestamp.After(pod.CreationTimestamp.Time) {
+			pod = p
+		}
+	}
+	return pod, nil
+}
+
+func (e *Engine) createGitSecret(execution *v3.PipelineExecution, credentialID string) error {
+	if credentialID == "" {
+		return nil
+	}
+	username, password, err := e.Jenkins.GitCredential(execution, credentialID)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gitSecretName(execution),
+			Namespace: utils.GetPipelineCommonName(execution.Spec.ProjectName),
+			Labels:    executionLabels(execution),
+		},
+		Data: map[string][]byte{
+			gitUsernameKey: []byte(username),
+			gitPasswordKey: []byte(password),
+		},
+	}
+	_, err = e.K8sClient.CoreV1().Secrets(secret.Namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = e.K8sClient.CoreV1().Secrets(secret.Namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+func (e *Engine) createWorkspace(execution *v3.PipelineExecution) error {
+	size, err := e.getWorkspaceSize(execution)
+	if err != nil {
+		return err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workspaceClaimName(execution),
+			Namespace: utils.GetPipelineCommonName(execution.Spec.ProjectName),
+			Labels:    executionLabels(execution),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			// the stages run one after the other, so a single node mounts the workspace at a time
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	_, err = e.K8sClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func (e *Engine) getWorkspaceSize(execution *v3.PipelineExecution) (resource.Quantity, error) {
+	_, projectID := ref.Parse(execution.Spec.ProjectName)
+	size := utils.SettingWorkspaceSizeDefault
+	setting, err := e.PipelineSettingLister.Get(projectID, utils.SettingWorkspaceSize)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return resource.Quantity{}, err
+	} else if err == nil && setting.Value != "" {
+		size = setting.Value
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return quantity, errors.Wrap(err, "invalid workspace size config")
+	}
+	return quantity, nil
+}
+
+// cleanup removes the Jobs, workspace and git credential of execution.
+func (e *Engine) cleanup(execution *v3.PipelineExecution) error {
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	propagation := metav1.DeletePropagationBackground
+	if err := e.K8sClient.BatchV1().Jobs(ns).DeleteCollection(context.TODO(), metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	}, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(executionLabels(execution)).String(),
+	}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := e.K8sClient.CoreV1().PersistentVolumeClaims(ns).Delete(context.TODO(), workspaceClaimName(execution), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := e.K8sClient.CoreV1().Secrets(ns).Delete(context.TODO(), gitSecretName(execution), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func stepCommand(step *v32.Step) []string {
+	if step.SourceCodeConfig != nil {
+		return []string{"sh", "-c", cloneScript}
+	} else if step.RunScriptConfig != nil {
+		return []string{"sh", "-xe", "-c", step.RunScriptConfig.ShellScript}
+	} else if step.PublishImageConfig != nil {
+		return []string{"sh", "-c", "/usr/local/bin/dockerd-entrypoint.sh /bin/drone-docker"}
+	} else if step.ApplyYamlConfig != nil {
+		return []string{"sh", "-c", "kube-apply"}
+	} else if step.PublishCatalogConfig != nil {
+		return []string{"sh", "-c", "publish-catalog"}
+	} else if step.ApplyAppConfig != nil {
+		return []string{"sh", "-c", "apply-app"}
+	}
+	return nil
+}
+
+func gitEnv(execution *v3.PipelineExecution, name string, key string) corev1.EnvVar {
+	optional := true
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: gitSecretName(execution),
+			},
+			Key:      key,
+			Optional: &optional,
+		}},
+	}
+}
+
+func buildingStep(execution *v3.PipelineExecution, stage int, step int, started metav1.Time) {
+	execution.Status.Stages[stage].Steps[step].State = utils.StateBuilding
+	if execution.Status.Stages[stage].Steps[step].Started == "" {
+		execution.Status.Stages[stage].Steps[step].Started = started.Format(time.RFC3339)
+	}
+}
+
+func finishStep(execution *v3.PipelineExecution, stage int, step int, state string, started metav1.Time, ended metav1.Time) {
+	execution.Status.Stages[stage].Steps[step].State = state
+	if execution.Status.Stages[stage].Steps[step].Started == "" {
+		execution.Status.Stages[stage].Steps[step].Started = started.Format(time.RFC3339)
+	}
+	execution.Status.Stages[stage].Steps[step].Ended = ended.Format(time.RFC3339)
+}
+
+// failExecution fails execution at a stage and clears the waiting status of the stages and steps
+// that will not run.
+func failExecution(execution *v3.PipelineExecution, stage int, message string) {
+	now := time.Now().Format(time.RFC3339)
+	execution.Status.Stages[stage].State = utils.StateFailed
+	if execution.Status.Stages[stage].Ended == "" {
+		execution.Status.Stages[stage].Ended = now
+	}
+	if execution.Status.ExecutionState != utils.StateAborted {
+		execution.Status.ExecutionState = utils.StateFailed
+		v32.PipelineExecutionConditionBuilt.False(execution)
+		v32.PipelineExecutionConditionBuilt.Message(execution, message)
+	}
+	if execution.Status.Ended == "" {
+		execution.Status.Ended = now
+	}
+	if v32.PipelineExecutionConditionProvisioned.IsUnknown(execution) {
+		v32.PipelineExecutionConditionProvisioned.True(execution)
+	}
+	execution.Labels[utils.PipelineFinishLabel] = "true"
+
+	for i := range execution.Status.Stages {
+		stage := &execution.Status.Stages[i]
+		if stage.State == utils.StateWaiting {
+			stage.State = ""
+		}
+		for j := range stage.Steps {
+			if stage.Steps[j].State == utils.StateWaiting {
+				stage.Steps[j].State = ""
+			}
+		}
+	}
+}
+
+// jobFailure returns the message of the failed condition of job, if any.
+func jobFailure(job *batchv1.Job) string {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			if cond.Message != "" {
+				return cond.Message
+			}
+			return cond.Reason
+		}
+	}
+	return ""
+}
+
+func executionLabels(execution *v3.PipelineExecution) map[string]string {
+	return map[string]string{
+		utils.LabelKeyApp:       executorName,
+		utils.LabelKeyExecution: execution.Name,
+	}
+}
+
+func stageLabels(execution *v3.PipelineExecution, stage int) map[string]string {
+	labels := executionLabels(execution)
+	labels[labelKeyStage] = strconv.Itoa(stage)
+	return labels
+}
+
+func stepName(stage int, step int) string {
+	return fmt.Sprintf("step-%d-%d", stage, step)
+}
+
+func jobName(execution *v3.PipelineExecution, stage int) string {
+	return fmt.Sprintf("%s-stage-%d", execution.Name, stage)
+}
+
+func workspaceClaimName(execution *v3.PipelineExecution) string {
+	return fmt.Sprintf("%s-%s", execution.Name, workspaceName)
+}
+
+func gitSecretName(execution *v3.PipelineExecution) string {
+	return fmt.Sprintf("%s-git", execution.Name)
+}
@@ -0,0 +1,148 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "p-1" + utils.PipelineNamespaceSuffix
+
+func newTestExecution(stageStates ...string) *v32.PipelineExecution {
+	execution := &v32.PipelineExecution{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pipeline-1-1",
+			Namespace: "p-1",
+			Labels:    map[string]string{utils.PipelineFinishLabel: "false"},
+		},
+		Spec: v32.PipelineExecutionSpec{
+			ProjectName: "c-1:p-1",
+		},
+		Status: v32.PipelineExecutionStatus{
+			ExecutionState: utils.StateBuilding,
+		},
+	}
+	for _, state := range stageStates {
+		execution.Spec.PipelineConfig.Stages = append(execution.Spec.PipelineConfig.Stages, v32.Stage{
+			Name:  "stage",
+			Steps: []v32.Step{{RunScriptConfig: &v32.RunScriptConfig{Image: "busybox", ShellScript: "true"}}},
+		})
+		execution.Status.Stages = append(execution.Status.Stages, v32.StageStatus{
+			State: state,
+			Steps: []v32.StepStatus{{State: state}},
+		})
+	}
+	return execution
+}
+
+func newTestEngine(objects ...runtime.Object) (*Engine, *fake.Clientset) {
+	client := fake.NewSimpleClientset(objects...)
+	return &Engine{
+		K8sClient: client,
+		PodLister: &fakes.PodListerMock{
+			ListFunc: func(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+				return nil, nil
+			},
+		},
+	}, client
+}
+
+func TestSyncExecutionFinishes(t *testing.T) {
+	execution := newTestExecution(utils.StateSuccess, utils.StateSkipped)
+	engine, _ := newTestEngine()
+
+	updated, err := engine.SyncExecution(execution)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, utils.StateSuccess, execution.Status.ExecutionState)
+	assert.Equal(t, "true", execution.Labels[utils.PipelineFinishLabel], "finished executions are cleaned up by the lifecycle")
+	assert.True(t, v32.PipelineExecutionConditionBuilt.IsTrue(execution))
+}
+
+func TestSyncExecutionFailedJob(t *testing.T) {
+	execution := newTestExecution(utils.StateWaiting, utils.StateWaiting)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName(execution, 0),
+			Namespace: testNamespace,
+			Labels:    stageLabels(execution, 0),
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "DeadlineExceeded",
+				Message: "Job was active longer than specified deadline",
+			}},
+		},
+	}
+	engine, _ := newTestEngine(job)
+
+	updated, err := engine.SyncExecution(execution)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, utils.StateFailed, execution.Status.ExecutionState)
+	assert.Equal(t, "true", execution.Labels[utils.PipelineFinishLabel], "failed executions are cleaned up by the lifecycle")
+	assert.Equal(t, "Job was active longer than specified deadline", v32.PipelineExecutionConditionBuilt.GetMessage(execution))
+	assert.Equal(t, utils.StateFailed, execution.Status.Stages[0].State)
+	assert.Equal(t, "", execution.Status.Stages[1].State, "stages that will not run are no longer waiting")
+	assert.Equal(t, "", execution.Status.Stages[1].Steps[0].State)
+}
+
+func TestSyncExecutionFailedBySyncer(t *testing.T) {
+	engine, _ := newTestEngine()
+
+	// the state syncer fails an execution it got an error syncing without finishing it
+	execution := newTestExecution(utils.StateWaiting)
+	execution.Status.ExecutionState = utils.StateFailed
+	updated, err := engine.SyncExecution(execution)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, "true", execution.Labels[utils.PipelineFinishLabel])
+
+	updated, err = engine.SyncExecution(execution)
+	require.NoError(t, err)
+	assert.False(t, updated, "finished executions are not synced")
+}
+
+func TestStopExecutionCleansUp(t *testing.T) {
+	execution := newTestExecution(utils.StateSuccess, utils.StateFailed)
+	engine, client := newTestEngine(
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: workspaceClaimName(execution), Namespace: testNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: gitSecretName(execution), Namespace: testNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pipeline-1-2-git", Namespace: testNamespace}},
+	)
+	// the object tracker of the fake client does not implement deleting collections
+	var jobSelectors []string
+	client.PrependReactor("delete-collection", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		assert.Equal(t, testNamespace, action.GetNamespace())
+		jobSelectors = append(jobSelectors, action.(k8stesting.DeleteCollectionAction).GetListRestrictions().Labels.String())
+		return true, nil, nil
+	})
+
+	require.NoError(t, engine.StopExecution(execution))
+	assert.Equal(t, []string{labels.SelectorFromSet(executionLabels(execution)).String()}, jobSelectors)
+
+	_, err := client.CoreV1().PersistentVolumeClaims(testNamespace).Get(context.TODO(), workspaceClaimName(execution), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the workspace is deleted")
+	_, err = client.CoreV1().Secrets(testNamespace).Get(context.TODO(), gitSecretName(execution), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the git credential is deleted")
+	_, err = client.CoreV1().Secrets(testNamespace).Get(context.TODO(), "pipeline-1-2-git", metav1.GetOptions{})
+	assert.NoError(t, err, "the git credential of other executions is kept")
+
+	// cleaning up again, as the lifecycle does when it retries, does not fail on what is already gone
+	require.NoError(t, engine.StopExecution(execution))
+}
@@ -0,0 +1,166 @@
+package drivers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/gitea"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+)
+
+const (
+	GiteaWebhookHeader   = "X-Gitea-Event"
+	giteaSignatureHeader = "X-Gitea-Signature"
+	giteaPushEvent       = "push"
+	giteaPREvent         = "pull_request"
+
+	giteaActionOpen   = "opened"
+	giteaActionSync   = "synchronized"
+	giteaActionReopen = "reopened"
+
+	giteaStateOpen = "open"
+)
+
+type GiteaDriver struct {
+	PipelineLister             v3.PipelineLister
+	PipelineExecutions         v3.PipelineExecutionInterface
+	SourceCodeCredentials      v3.SourceCodeCredentialInterface
+	SourceCodeCredentialLister v3.SourceCodeCredentialLister
+}
+
+func (g GiteaDriver) Execute(req *http.Request) (int, error) {
+	var signature string
+	if signature = req.Header.Get(giteaSignatureHeader); len(signature) == 0 {
+		return http.StatusUnprocessableEntity, errors.New("gitea webhook missing signature")
+	}
+	event := req.Header.Get(GiteaWebhookHeader)
+	if event != giteaPushEvent && event != giteaPREvent {
+		return http.StatusUnprocessableEntity, fmt.Errorf("not trigger for event:%s", event)
+	}
+
+	pipelineID := req.URL.Query().Get("pipelineId")
+	ns, name := ref.Parse(pipelineID)
+	pipeline, err := g.PipelineLister.Get(ns, name)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+	if match := verifyGiteaWebhookSignature([]byte(pipeline.Status.Token), signature, body); !match {
+		return http.StatusUnprocessableEntity, errors.New("gitea webhook invalid signature")
+	}
+
+	if pipeline.Status.PipelineState == "inactive" {
+		return http.StatusUnavailableForLegalReasons, errors.New("Pipeline is not active")
+	}
+
+	info := &model.BuildInfo{}
+	if event == giteaPushEvent {
+		info, err = giteaParsePushPayload(body)
+		if err != nil {
+			return http.StatusUnprocessableEntity, err
+		}
+	} else if event == giteaPREvent {
+		info, err = giteaParsePullRequestPayload(body)
+		if err != nil {
+			return http.StatusUnprocessableEntity, err
+		}
+	}
+
+	return validateAndGeneratePipelineExecution(g.PipelineExecutions, g.SourceCodeCredentials, g.SourceCodeCredentialLister, info, pipeline)
+}
+
+func verifyGiteaWebhookSignature(secret []byte, signature string, body []byte) bool {
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	computed := hmac.New(sha256.New, secret)
+	computed.Write(body)
+
+	return hmac.Equal(computed.Sum(nil), actual)
+}
+
+func giteaParsePushPayload(raw []byte) (*model.BuildInfo, error) {
+	info := &model.BuildInfo{}
+	payload := &gitea.PushEventPayload{}
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, err
+	}
+	if payload.HeadCommit == nil {
+		return nil, errors.New("no commit found in push event")
+	}
+	info.TriggerType = utils.TriggerTypeWebhook
+	info.Commit = payload.HeadCommit.ID
+	info.Ref = payload.Ref
+	info.HTMLLink = payload.HeadCommit.URL
+	info.Message = payload.HeadCommit.Message
+	if payload.HeadCommit.Author != nil {
+		info.Email = payload.HeadCommit.Author.Email
+	}
+	if payload.Sender != nil {
+		info.AvatarURL = payload.Sender.AvatarURL
+		info.Author = payload.Sender.Login
+		info.Sender = payload.Sender.Login
+	}
+
+	if strings.HasPrefix(payload.Ref, RefsTagPrefix) {
+		//git tag is triggered as a push event
+		info.Event = utils.WebhookEventTag
+		info.Branch = strings.TrimPrefix(payload.Ref, RefsTagPrefix)
+	} else {
+		info.Event = utils.WebhookEventPush
+		info.Branch = strings.TrimPrefix(payload.Ref, RefsBranchPrefix)
+	}
+	return info, nil
+}
+
+func giteaParsePullRequestPayload(raw []byte) (*model.BuildInfo, error) {
+	info := &model.BuildInfo{}
+	payload := &gitea.PullRequestEventPayload{}
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, err
+	}
+
+	action := payload.Action
+	if action != giteaActionOpen && action != giteaActionSync && action != giteaActionReopen {
+		return nil, fmt.Errorf("no trigger for %s action", action)
+	}
+	pr := payload.PullRequest
+	if pr == nil || pr.Head == nil || pr.Base == nil {
+		return nil, errors.New("invalid pull request payload")
+	}
+	if pr.State != giteaStateOpen {
+		return nil, fmt.Errorf("no trigger for closed pull requests")
+	}
+
+	info.TriggerType = utils.TriggerTypeWebhook
+	info.Event = utils.WebhookEventPullRequest
+	info.Branch = pr.Base.Ref
+	info.Ref = fmt.Sprintf("refs/pull/%d/head", pr.Number)
+	info.HTMLLink = pr.HTMLURL
+	info.Title = pr.Title
+	info.Message = pr.Title
+	info.Commit = pr.Head.SHA
+	if pr.User != nil {
+		info.Author = pr.User.Login
+		info.AvatarURL = pr.User.AvatarURL
+		info.Email = pr.User.Email
+	}
+	if payload.Sender != nil {
+		info.Sender = payload.Sender.Login
+	}
+	return info, nil
+}
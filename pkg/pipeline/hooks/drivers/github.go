@@ -39,6 +39,10 @@ type GithubDriver struct {
 }
 
 func (g GithubDriver) Execute(req *http.Request) (int, error) {
+	if req.Header.Get(GiteaWebhookHeader) != "" {
+		//gitea also sends github event headers, leave it to the gitea driver
+		return http.StatusOK, nil
+	}
 	var signature string
 	if signature = req.Header.Get(githubSignatureHeader); len(signature) == 0 {
 		return http.StatusUnprocessableEntity, errors.New("github webhook missing signature")
@@ -6,6 +6,7 @@
 	"github.com/rancher/rancher/pkg/pipeline/providers/bitbucketcloud"
 	"github.com/rancher/rancher/pkg/pipeline/providers/bitbucketserver"
 	"github.com/rancher/rancher/pkg/pipeline/providers/common"
+	"github.com/rancher/rancher/pkg/pipeline/providers/gitea"
 	"github.com/rancher/rancher/pkg/pipeline/providers/github"
 	"github.com/rancher/rancher/pkg/pipeline/providers/gitlab"
 	"github.com/rancher/rancher/pkg/pipeline/remote/model"
@@ -85,15 +86,20 @@ func configure(management *config.ScaledContext) {
 	bsProvider := &bitbucketserver.BsProvider{
 		BaseProvider: baseProvider,
 	}
+	gtProvider := &gitea.GtProvider{
+		BaseProvider: baseProvider,
+	}
 
 	providers[model.GithubType] = ghProvider
 	providers[model.GitlabType] = glProvider
 	providers[model.BitbucketCloudType] = bcProvider
 	providers[model.BitbucketServerType] = bsProvider
+	providers[model.GiteaType] = gtProvider
 
 	providersByType[client.GithubPipelineConfigType] = ghProvider
 	providersByType[client.GitlabPipelineConfigType] = glProvider
 	providersByType[client.BitbucketCloudPipelineConfigType] = bcProvider
 	providersByType[client.BitbucketServerPipelineConfigType] = bsProvider
+	providersByType[client.GiteaPipelineConfigType] = gtProvider
 
 }
@@ -0,0 +1,516 @@
+package gitea
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/httperror"
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	"github.com/tomnomnom/linkheader"
+	"golang.org/x/oauth2"
+)
+
+const (
+	maxPerPage       = "50"
+	giteaAPI         = "%s%s/api/v1"
+	defaultGiteaHost = "gitea.com"
+	hookType         = "gitea"
+)
+
+type client struct {
+	Scheme       string
+	Host         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	API          string
+}
+
+func New(config *v32.GiteaPipelineConfig) (model.Remote, error) {
+	if config == nil {
+		return nil, errors.New("empty gitea config")
+	}
+	giteaClient := &client{
+		Host:         config.Hostname,
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
+	}
+	if giteaClient.Host == "" {
+		giteaClient.Host = defaultGiteaHost
+	}
+	if config.TLS || giteaClient.Host == defaultGiteaHost {
+		giteaClient.Scheme = "https://"
+	} else {
+		giteaClient.Scheme = "http://"
+	}
+	giteaClient.API = fmt.Sprintf(giteaAPI, giteaClient.Scheme, giteaClient.Host)
+	return giteaClient, nil
+}
+
+func (c *client) Type() string {
+	return model.GiteaType
+}
+
+func (c *client) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		RedirectURL:  c.RedirectURL,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  fmt.Sprintf("%s%s/login/oauth/authorize", c.Scheme, c.Host),
+			TokenURL: fmt.Sprintf("%s%s/login/oauth/access_token", c.Scheme, c.Host),
+		},
+	}
+}
+
+func (c *client) Login(code string) (*v3.SourceCodeCredential, error) {
+	token, err := c.oauthConfig().Exchange(oauth2.NoContext, code)
+	if err != nil {
+		return nil, err
+	} else if strings.ToLower(token.TokenType) != "bearer" || token.AccessToken == "" {
+		return nil, fmt.Errorf("Fail to get accesstoken with oauth config")
+	}
+
+	user, err := c.getUser(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	cred := convertUser(user)
+	cred.Spec.AccessToken = token.AccessToken
+	cred.Spec.RefreshToken = token.RefreshToken
+	cred.Spec.Expiry = token.Expiry.Format(time.RFC3339)
+	return cred, nil
+}
+
+// Refresh renews the access token of cred, as Gitea OAuth access tokens expire after an hour by default.
+func (c *client) Refresh(cred *v3.SourceCodeCredential) (bool, error) {
+	if cred == nil {
+		return false, errors.New("cannot refresh empty credentials")
+	}
+	source := c.oauthConfig().TokenSource(
+		oauth2.NoContext, &oauth2.Token{RefreshToken: cred.Spec.RefreshToken})
+
+	token, err := source.Token()
+	if err != nil || len(token.AccessToken) == 0 {
+		return false, err
+	}
+
+	cred.Spec.AccessToken = token.AccessToken
+	cred.Spec.RefreshToken = token.RefreshToken
+	cred.Spec.Expiry = token.Expiry.Format(time.RFC3339)
+
+	return true, nil
+}
+
+func (c *client) Repos(account *v3.SourceCodeCredential) ([]v3.SourceCodeRepository, error) {
+	if account == nil {
+		return nil, fmt.Errorf("empty account")
+	}
+	responseBodies, err := paginateGitea(account.Spec.AccessToken, c.API+"/user/repos")
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repository
+	for _, b := range responseBodies {
+		var reposObj []Repository
+		if err := json.Unmarshal(b, &reposObj); err != nil {
+			return nil, err
+		}
+		repos = append(repos, reposObj...)
+	}
+	return convertRepos(repos), nil
+}
+
+func (c *client) CreateHook(pipeline *v3.Pipeline, accessToken string) (string, error) {
+	owner, repo, err := getOwnerRepoFromURL(pipeline.Spec.RepositoryURL)
+	if err != nil {
+		return "", err
+	}
+	hookURL := fmt.Sprintf("%s/hooks?pipelineId=%s", settings.ServerURL.Get(), ref.Ref(pipeline))
+	hook := Hook{
+		Type: hookType,
+		Config: HookConfig{
+			URL:         hookURL,
+			ContentType: "json",
+			Secret:      pipeline.Status.Token,
+		},
+		Events: []string{
+			"push",
+			"pull_request",
+		},
+		Active: true,
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/hooks", c.API, owner, repo)
+	b, err := doRequestToGitea(http.MethodPost, url, accessToken, hook)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(b, &hook); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(hook.ID), nil
+}
+
+func (c *client) DeleteHook(pipeline *v3.Pipeline, accessToken string) error {
+	owner, repo, err := getOwnerRepoFromURL(pipeline.Spec.RepositoryURL)
+	if err != nil {
+		return err
+	}
+	hook, err := c.getHook(pipeline, accessToken)
+	if err != nil {
+		return err
+	}
+	if hook != nil {
+		url := fmt.Sprintf("%s/repos/%s/%s/hooks/%d", c.API, owner, repo, hook.ID)
+		if _, err := doRequestToGitea(http.MethodDelete, url, accessToken, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) getHook(pipeline *v3.Pipeline, accessToken string) (*Hook, error) {
+	owner, repo, err := getOwnerRepoFromURL(pipeline.Spec.RepositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/hooks", c.API, owner, repo)
+	responseBodies, err := paginateGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range responseBodies {
+		var hooks []Hook
+		if err := json.Unmarshal(b, &hooks); err != nil {
+			return nil, err
+		}
+		for _, hook := range hooks {
+			if strings.HasSuffix(hook.Config.URL, fmt.Sprintf("hooks?pipelineId=%s", ref.Ref(pipeline))) {
+				return &hook, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) getFileFromRepo(filename string, owner string, repo string, ref string, accessToken string) (*ContentsResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", c.API, owner, repo, filename, url.QueryEscape(ref))
+	b, err := getFromGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+	file := &ContentsResponse{}
+	if err := json.Unmarshal(b, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (c *client) GetPipelineFileInRepo(repoURL string, ref string, accessToken string) ([]byte, error) {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		defaultBranch, err := c.getDefaultBranch(owner, repo, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		ref = defaultBranch
+	}
+	file, err := c.getFileFromRepo(utils.PipelineFileYml, owner, repo, ref, accessToken)
+	if err != nil {
+		//look for both suffix
+		file, err = c.getFileFromRepo(utils.PipelineFileYaml, owner, repo, ref, accessToken)
+	}
+	if err != nil {
+		logrus.Debugf("error GetPipelineFileInRepo - %v", err)
+		return nil, nil
+	}
+	if file.Content != "" {
+		return base64.StdEncoding.DecodeString(file.Content)
+	}
+	return nil, nil
+}
+
+func (c *client) SetPipelineFileInRepo(repoURL string, branch string, accessToken string, content []byte) error {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return err
+	}
+	currentFile, err := c.getFileFromRepo(utils.PipelineFileYml, owner, repo, branch, accessToken)
+	currentFileName := utils.PipelineFileYml
+	if err != nil {
+		if httpErr, ok := err.(*httperror.APIError); !ok || httpErr.Code.Status != http.StatusNotFound {
+			return err
+		}
+		//look for both suffix
+		currentFile, err = c.getFileFromRepo(utils.PipelineFileYaml, owner, repo, branch, accessToken)
+		if err != nil {
+			if httpErr, ok := err.(*httperror.APIError); !ok || httpErr.Code.Status != http.StatusNotFound {
+				return err
+			}
+		} else {
+			currentFileName = utils.PipelineFileYaml
+		}
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", c.API, owner, repo, currentFileName)
+	method := http.MethodPost
+	option := FileOptions{
+		Content: base64.StdEncoding.EncodeToString(content),
+		Message: "Create .rancher-pipeline.yml file",
+		Branch:  branch,
+	}
+	if currentFile != nil {
+		//update pipeline file
+		method = http.MethodPut
+		option.Message = fmt.Sprintf("Update %s file", currentFileName)
+		option.SHA = currentFile.SHA
+	}
+
+	_, err = doRequestToGitea(method, url, accessToken, option)
+	return err
+}
+
+func (c *client) GetBranches(repoURL string, accessToken string) ([]string, error) {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/branches", c.API, owner, repo)
+	responseBodies, err := paginateGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, b := range responseBodies {
+		var branches []Branch
+		if err := json.Unmarshal(b, &branches); err != nil {
+			return nil, err
+		}
+		for _, branch := range branches {
+			result = append(result, branch.Name)
+		}
+	}
+	return result, nil
+}
+
+func (c *client) getDefaultBranch(owner string, repo string, accessToken string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s", c.API, owner, repo)
+	b, err := getFromGitea(accessToken, url)
+	if err != nil {
+		return "", err
+	}
+	repository := &Repository{}
+	if err := json.Unmarshal(b, repository); err != nil {
+		return "", err
+	}
+	return repository.DefaultBranch, nil
+}
+
+func (c *client) GetHeadInfo(repoURL string, branch string, accessToken string) (*model.BuildInfo, error) {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/branches/%s", c.API, owner, repo, branch)
+	b, err := getFromGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+	branchObj := &Branch{}
+	if err := json.Unmarshal(b, branchObj); err != nil {
+		return nil, err
+	}
+	if branchObj.Commit == nil {
+		return nil, errors.New("no commit found")
+	}
+
+	info := &model.BuildInfo{}
+	info.Commit = branchObj.Commit.ID
+	info.Ref = "refs/heads/" + branch
+	info.Branch = branch
+	info.Message = branchObj.Commit.Message
+	info.HTMLLink = branchObj.Commit.URL
+	if branchObj.Commit.Author != nil {
+		info.Email = branchObj.Commit.Author.Email
+		info.Author = branchObj.Commit.Author.Name
+	}
+	user, err := c.getUser(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	info.AvatarURL = user.AvatarURL
+
+	return info, nil
+}
+
+func (c *client) getUser(accessToken string) (*User, error) {
+	b, err := getFromGitea(accessToken, c.API+"/user")
+	if err != nil {
+		return nil, err
+	}
+	user := &User{}
+	if err := json.Unmarshal(b, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func convertUser(giteaUser *User) *v3.SourceCodeCredential {
+	if giteaUser == nil {
+		return nil
+	}
+	cred := &v3.SourceCodeCredential{}
+	cred.Spec.SourceCodeType = model.GiteaType
+
+	cred.Spec.AvatarURL = giteaUser.AvatarURL
+	cred.Spec.HTMLURL = giteaUser.Website
+	cred.Spec.LoginName = giteaUser.Login
+	cred.Spec.GitLoginName = giteaUser.Login
+	cred.Spec.DisplayName = giteaUser.FullName
+	if cred.Spec.DisplayName == "" {
+		cred.Spec.DisplayName = giteaUser.Login
+	}
+
+	return cred
+}
+
+func convertRepos(repos []Repository) []v3.SourceCodeRepository {
+	result := []v3.SourceCodeRepository{}
+	for _, repo := range repos {
+		r := v3.SourceCodeRepository{}
+		r.Spec.URL = repo.CloneURL
+		r.Spec.DefaultBranch = repo.DefaultBranch
+		if repo.Permissions != nil {
+			r.Spec.Permissions.Pull = repo.Permissions.Pull
+			r.Spec.Permissions.Push = repo.Permissions.Push
+			r.Spec.Permissions.Admin = repo.Permissions.Admin
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func getFromGitea(accessToken string, url string) ([]byte, error) {
+	return doRequestToGitea(http.MethodGet, url, accessToken, nil)
+}
+
+func doRequestToGitea(method string, url string, accessToken string, opt interface{}) ([]byte, error) {
+	resp, err := requestGitea(method, url, accessToken, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func requestGitea(method string, url string, accessToken string, opt interface{}) (*http.Response, error) {
+	var body io.Reader
+	if opt != nil {
+		b, err := json.Marshal(opt)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	//set to max 50 per page to reduce query time
+	if method == http.MethodGet {
+		q := req.URL.Query()
+		if q.Get("limit") == "" {
+			q.Set("limit", maxPerPage)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+	if accessToken != "" {
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+	}
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	req.Header.Add("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Check the status code
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		var body bytes.Buffer
+		io.Copy(&body, resp.Body)
+		return nil, httperror.NewAPIErrorLong(resp.StatusCode, "", body.String())
+	}
+	return resp, nil
+}
+
+func paginateGitea(accessToken string, url string) ([][]byte, error) {
+	var responseBodies [][]byte
+	var nextURL = url
+	for nextURL != "" {
+		response, err := requestGitea(http.MethodGet, nextURL, accessToken, nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		responseBodies = append(responseBodies, body)
+		nextURL = nextGiteaPage(response)
+	}
+	return responseBodies, nil
+}
+
+func nextGiteaPage(response *http.Response) string {
+	header := response.Header.Get("link")
+	if header != "" {
+		links := linkheader.Parse(header)
+		for _, link := range links {
+			if link.Rel == "next" {
+				return link.URL
+			}
+		}
+	}
+	return ""
+}
+
+func getOwnerRepoFromURL(repoURL string) (string, string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git"), "/")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("error getting owner/repo from gitrepoUrl:%v", repoURL)
+	}
+	// gitea may be served from a sub path
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
@@ -70,9 +70,10 @@
 
 	ConditionChanged = "Changed"
 
-	PipelineFinishLabel    = "pipeline.project.cattle.io/finish"
-	LocalRegistryPortLabel = "pipeline.project.cattle.io/local-registry-port"
-	PipelineNamespaceLabel = "pipeline.project.cattle.io/pipeline-namespace"
+	PipelineFinishLabel      = "pipeline.project.cattle.io/finish"
+	LocalRegistryPortLabel   = "pipeline.project.cattle.io/local-registry-port"
+	PipelineNamespaceLabel   = "pipeline.project.cattle.io/pipeline-namespace"
+	PipelineEngineAnnotation = "pipeline.project.cattle.io/engine"
 
 	PipelineFileYml  = ".rancher-pipeline.yml"
 	PipelineFileYaml = ".rancher-pipeline.yaml"
@@ -107,6 +108,13 @@
 	SettingExecutorCPURequestDefault    = "10m"
 	SettingExecutorCPULimit             = "executor-cpu-limit"
 	SettingExecutorCPULimitDefault      = "1"
+	SettingExecutionEngine              = "execution-engine"
+	SettingExecutionEngineDefault       = EngineJenkins
+	SettingWorkspaceSize                = "workspace-size"
+	SettingWorkspaceSizeDefault         = "1Gi"
+
+	EngineJenkins    = "jenkins"
+	EngineKubernetes = "kubernetes"
 
 	PipelineToolsMemoryRequestDefault = "10Mi"
 	PipelineToolsMemoryLimitDefault   = "100Mi"
@@ -64,8 +64,11 @@ func getKubeConfigSecretName(clusterName string) string {
 	return clusterName + "-kubeconfig"
 }
 
-func (m *Manager) getToken(clusterNamespace, clusterName string) (string, error) {
-	kubeConfigSecretName := getKubeConfigSecretName(clusterName)
+func getAutoscalerKubeConfigSecretName(clusterName string) string {
+	return clusterName + "-autoscaler-kubeconfig"
+}
+
+func (m *Manager) getToken(clusterNamespace, kubeConfigSecretName, principalID string) (string, error) {
 	if token, err := m.getSavedToken(clusterNamespace, kubeConfigSecretName); err != nil || token != "" {
 		return token, err
 	}
@@ -76,8 +79,8 @@ func (m *Manager) getToken(clusterNamespace, clusterName string) (string, error)
 		return token, err
 	}
 
-	userName, err := m.EnsureUser(clusterNamespace, clusterName)
-	if err != nil {
+	userName := getUserNameForPrincipal(principalID)
+	if err := m.createUser(principalID, userName); err != nil {
 		return "", err
 	}
 
@@ -131,6 +134,10 @@ func getPrincipalID(clusterNamespace, clusterName string) string {
 	return fmt.Sprintf("system://provisioning/%s/%s", clusterNamespace, clusterName)
 }
 
+func getAutoscalerPrincipalID(clusterNamespace, clusterName string) string {
+	return fmt.Sprintf("system://autoscaler/%s/%s", clusterNamespace, clusterName)
+}
+
 func (m *Manager) createUser(principalID, userName string) error {
 	_, err := m.userCache.Get(userName)
 	if apierror.IsNotFound(err) {
@@ -219,7 +226,26 @@ func (m *Manager) GetCRTBForAdmin(cluster *v1.Cluster, status v1.ClusterStatus)
 	}, nil
 }
 
-func (m *Manager) getKubeConfigData(clusterNamespace, clusterName, secretName, managementClusterName string) (map[string][]byte, error) {
+// GetCRTBForAutoscaler returns the binding that grants the user of the kubeconfig returned by GetAutoscalerKubeConfig
+// only what the cluster autoscaler needs in the cluster.
+func (m *Manager) GetCRTBForAutoscaler(cluster *v1.Cluster, status v1.ClusterStatus) (*v3.ClusterRoleTemplateBinding, error) {
+	if status.ClusterName == "" {
+		return nil, fmt.Errorf("management cluster is not assigned to v1.Cluster")
+	}
+	principalID := getAutoscalerPrincipalID(cluster.Namespace, cluster.Name)
+	return &v3.ClusterRoleTemplateBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.SafeConcatName(status.ClusterName, "cluster-autoscaler"),
+			Namespace: status.ClusterName,
+		},
+		ClusterName:       status.ClusterName,
+		UserName:          getUserNameForPrincipal(principalID),
+		UserPrincipalName: principalID,
+		RoleTemplateName:  "cluster-autoscaler",
+	}, nil
+}
+
+func (m *Manager) getKubeConfigData(clusterNamespace, secretName, principalID, managementClusterName string) (map[string][]byte, error) {
 	secret, err := m.secretCache.Get(clusterNamespace, secretName)
 	if err == nil {
 		if secret.Data == nil || secret.Data["token"] == nil || secret.Annotations["objectset.rio.cattle.io/owner-gvk"] != "provisioning.cattle.io/v1, Kind=Cluster" {
@@ -235,7 +261,7 @@ func (m *Manager) getKubeConfigData(clusterNamespace, clusterName, secretName, m
 		return nil, err
 	}
 
-	lockID := clusterNamespace + "/" + clusterName
+	lockID := clusterNamespace + "/" + secretName
 	m.kubeConfigLocker.Lock(lockID)
 	defer m.kubeConfigLocker.Unlock(lockID)
 
@@ -244,7 +270,7 @@ func (m *Manager) getKubeConfigData(clusterNamespace, clusterName, secretName, m
 		return secret.Data, nil
 	}
 
-	tokenValue, err := m.getToken(clusterNamespace, clusterName)
+	tokenValue, err := m.getToken(clusterNamespace, secretName, principalID)
 	if err != nil {
 		return nil, err
 	}
@@ -313,7 +339,26 @@ func (m *Manager) GetKubeConfig(cluster *v1.Cluster, status v1.ClusterStatus) (*
 		secretName = getKubeConfigSecretName(cluster.Name)
 	)
 
-	data, err := m.getKubeConfigData(cluster.Namespace, cluster.Name, secretName, status.ClusterName)
+	data, err := m.getKubeConfigData(cluster.Namespace, secretName, getPrincipalID(cluster.Namespace, cluster.Name), status.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      secretName,
+		},
+		Data: data,
+	}, nil
+}
+
+// GetAutoscalerKubeConfig returns the kubeconfig the cluster autoscaler uses for the cluster. It authenticates as a
+// user of its own rather than the admin of the cluster, which has no rights until it is bound by the binding returned
+// by GetCRTBForAutoscaler.
+func (m *Manager) GetAutoscalerKubeConfig(cluster *v1.Cluster, status v1.ClusterStatus) (*corev1.Secret, error) {
+	secretName := getAutoscalerKubeConfigSecretName(cluster.Name)
+	data, err := m.getKubeConfigData(cluster.Namespace, secretName, getAutoscalerPrincipalID(cluster.Namespace, cluster.Name), status.ClusterName)
 	if err != nil {
 		return nil, err
 	}
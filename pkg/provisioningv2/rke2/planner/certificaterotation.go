@@ -0,0 +1,157 @@
+package planner
+
+import (
+	"fmt"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	rkecontroller "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type certificateRotation struct {
+	controlPlane rkecontroller.RKEControlPlaneClient
+	secrets      corecontrollers.SecretCache
+	store        *PlanStore
+}
+
+func newCertificateRotation(clients *wrangler.Context, store *PlanStore) *certificateRotation {
+	return &certificateRotation{
+		controlPlane: clients.RKE.RKEControlPlane(),
+		secrets:      clients.Core.Secret().Cache(),
+		store:        store,
+	}
+}
+
+func (r *certificateRotation) setState(controlPlane *rkev1.RKEControlPlane, spec *rkev1.RotateCertificates, phase rkev1.RotateCertificatesPhase) error {
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateCertificatesPhase = phase
+	controlPlane.Status.RotateCertificates = spec
+	_, err := r.controlPlane.UpdateStatus(controlPlane)
+	if err != nil {
+		return err
+	}
+	return ErrWaiting("refreshing certificate rotation state")
+}
+
+func (r *certificateRotation) resetRotateCertificatesState(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Status.RotateCertificates == nil && controlPlane.Status.RotateCertificatesPhase == "" {
+		return nil
+	}
+	return r.setState(controlPlane, nil, "")
+}
+
+func (r *certificateRotation) startOrRestartRotation(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Status.RotateCertificates == nil || !equality.Semantic.DeepEqual(*controlPlane.Spec.RotateCertificates, *controlPlane.Status.RotateCertificates) {
+		return r.setState(controlPlane, controlPlane.Spec.RotateCertificates, rkev1.RotateCertificatesPhaseStarted)
+	}
+	return nil
+}
+
+func certificateRotationEnv(controlPlane *rkev1.RKEControlPlane) []string {
+	return []string{
+		fmt.Sprintf("CERTIFICATE_ROTATION_GENERATION=%d", controlPlane.Spec.RotateCertificates.Generation),
+	}
+}
+
+// serverPlan stops the server, rotates the requested certificates and starts the server again. The
+// generation is part of the plan so that a new rotation is never mistaken for an already applied one,
+// and the probes of the machine keep the rotation from moving on before the server is healthy again.
+func (r *certificateRotation) serverPlan(controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) (plan.NodePlan, error) {
+	args := []string{
+		"certificate",
+		"rotate",
+	}
+	for _, service := range controlPlane.Spec.RotateCertificates.Services {
+		args = append(args, "--service", service)
+	}
+
+	unit := GetRuntimeServerUnit(controlPlane.Spec.KubernetesVersion)
+	nodePlan, err := commonNodePlan(r.secrets, controlPlane, plan.NodePlan{
+		Instructions: []plan.Instruction{
+			ensureInstalledInstruction(controlPlane),
+			{
+				Name:    "shutdown",
+				Command: "systemctl",
+				Args:    []string{"stop", unit},
+			},
+			{
+				Name:    "rotate",
+				Env:     certificateRotationEnv(controlPlane),
+				Args:    args,
+				Command: GetRuntimeCommand(controlPlane.Spec.KubernetesVersion),
+			},
+			{
+				Name:    "start",
+				Command: "systemctl",
+				Args:    []string{"start", unit},
+			},
+		},
+	})
+	if err != nil {
+		return nodePlan, err
+	}
+	return addProbes(nodePlan, controlPlane, machine)
+}
+
+// workerPlan restarts the agent so it picks up client certificates signed by the rotated servers.
+func (r *certificateRotation) workerPlan(controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) (plan.NodePlan, error) {
+	nodePlan, err := commonNodePlan(r.secrets, controlPlane, plan.NodePlan{
+		Instructions: []plan.Instruction{
+			{
+				Name:    "restart",
+				Env:     certificateRotationEnv(controlPlane),
+				Command: "systemctl",
+				Args:    []string{"restart", GetRuntimeAgentUnit(controlPlane.Spec.KubernetesVersion)},
+			},
+		},
+	})
+	if err != nil {
+		return nodePlan, err
+	}
+	return addProbes(nodePlan, controlPlane, machine)
+}
+
+func (r *certificateRotation) Rotate(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan) error {
+	if controlPlane.Spec.RotateCertificates == nil {
+		return r.resetRotateCertificatesState(controlPlane)
+	}
+
+	// A cluster created with a rotation requested has no certificates to rotate yet, the rotation waits for the
+	// cluster to be provisioned rather than holding up its bootstrap.
+	if !Provisioned.IsTrue(controlPlane) && controlPlane.Status.RotateCertificatesPhase == "" {
+		return nil
+	}
+
+	if err := r.startOrRestartRotation(controlPlane); err != nil {
+		return err
+	}
+
+	switch controlPlane.Status.RotateCertificatesPhase {
+	case rkev1.RotateCertificatesPhaseStarted:
+		return r.setState(controlPlane, controlPlane.Spec.RotateCertificates, rkev1.RotateCertificatesPhaseControlPlane)
+	case rkev1.RotateCertificatesPhaseControlPlane:
+		if err := assignAndCheckPlans(r.store, "rotating certificates on control plane", clusterPlan, isServer,
+			controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency, func(machine *capi.Machine) (plan.NodePlan, error) {
+				return r.serverPlan(controlPlane, machine)
+			}); err != nil {
+			return err
+		}
+		return r.setState(controlPlane, controlPlane.Spec.RotateCertificates, rkev1.RotateCertificatesPhaseWorker)
+	case rkev1.RotateCertificatesPhaseWorker:
+		if err := assignAndCheckPlans(r.store, "rotating certificates on worker", clusterPlan, isOnlyWorker,
+			controlPlane.Spec.UpgradeStrategy.WorkerConcurrency, func(machine *capi.Machine) (plan.NodePlan, error) {
+				return r.workerPlan(controlPlane, machine)
+			}); err != nil {
+			return err
+		}
+		return r.setState(controlPlane, controlPlane.Spec.RotateCertificates, rkev1.RotateCertificatesPhaseFinished)
+	case rkev1.RotateCertificatesPhaseFinished:
+		return nil
+	default:
+		return r.setState(controlPlane, controlPlane.Spec.RotateCertificates, rkev1.RotateCertificatesPhaseStarted)
+	}
+}
@@ -0,0 +1,180 @@
+package planner
+
+import (
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCertificateRotation() (*certificateRotation, *fakeControlPlanes, *fakePlanSecrets) {
+	controlPlanes := &fakeControlPlanes{}
+	store, secrets := newTestStore()
+	return &certificateRotation{controlPlane: controlPlanes, store: store}, controlPlanes, secrets
+}
+
+func newTestCertificateRotationControlPlane(generation int64) *rkev1.RKEControlPlane {
+	controlPlane := &rkev1.RKEControlPlane{
+		Spec: rkev1.RKEControlPlaneSpec{
+			KubernetesVersion: "v1.21.5+rke2r2",
+			RotateCertificates: &rkev1.RotateCertificates{
+				Generation: generation,
+				Services:   []string{"kube-apiserver"},
+			},
+		},
+	}
+	Provisioned.True(controlPlane)
+	return controlPlane
+}
+
+func TestCertificateRotationWaitsForProvisioning(t *testing.T) {
+	r, controlPlanes, _ := newTestCertificateRotation()
+	controlPlane := newTestCertificateRotationControlPlane(1)
+	controlPlane.Status.Conditions = nil
+	clusterPlan := newTestPlan(newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel))
+
+	// The rotation does not hold up the bootstrap of a new cluster.
+	assert.NoError(t, r.Rotate(controlPlane, clusterPlan))
+	assert.False(t, controlPlanes.apply(controlPlane))
+
+	Provisioned.True(controlPlane)
+	assert.True(t, isErrWaiting(r.Rotate(controlPlane, clusterPlan)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, rkev1.RotateCertificatesPhaseStarted, controlPlane.Status.RotateCertificatesPhase)
+
+	// Once started, the rotation carries on while the cluster is not ready.
+	controlPlane.Status.Conditions = nil
+	assert.True(t, isErrWaiting(r.Rotate(controlPlane, clusterPlan)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, rkev1.RotateCertificatesPhaseControlPlane, controlPlane.Status.RotateCertificatesPhase)
+}
+
+func TestCertificateRotate(t *testing.T) {
+	r, controlPlanes, secrets := newTestCertificateRotation()
+	controlPlane := newTestCertificateRotationControlPlane(1)
+	clusterPlan := newTestPlan(
+		newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel),
+		newTestMachine("cp-1", ControlPlaneRoleLabel),
+		newTestMachine("etcd-0", EtcdRoleLabel),
+		newTestMachine("worker-0", WorkerRoleLabel),
+		newTestMachine("worker-1", WorkerRoleLabel),
+	)
+
+	rotate := func(expected rkev1.RotateCertificatesPhase) {
+		t.Helper()
+		err := r.Rotate(controlPlane, clusterPlan)
+		assert.True(t, isErrWaiting(err), "unexpected error %v", err)
+		require.True(t, controlPlanes.apply(controlPlane))
+		require.Equal(t, expected, controlPlane.Status.RotateCertificatesPhase)
+	}
+	// rotateOn assigns the plan of the phase to each machine in turn, the default concurrency is one machine at a
+	// time, and only moves on once the probes of the machine are healthy.
+	rotateOn := func(machines []string, instructions []string) {
+		t.Helper()
+		for _, machine := range machines {
+			assert.True(t, isErrWaiting(r.Rotate(controlPlane, clusterPlan)))
+			nodePlan, ok := secrets.assigned(machine)
+			require.True(t, ok, "no plan assigned to %s", machine)
+			assert.Empty(t, secrets.plans, "more than one machine rotated at once")
+			var names []string
+			for _, instruction := range nodePlan.Instructions {
+				names = append(names, instruction.Name)
+			}
+			assert.Equal(t, instructions, names)
+			assert.Contains(t, nodePlan.Probes, "kubelet")
+
+			clusterPlan.Nodes[machine] = &plan.Node{Plan: nodePlan, AppliedPlan: &nodePlan}
+			assert.True(t, isErrWaiting(r.Rotate(controlPlane, clusterPlan)))
+			assert.Empty(t, secrets.plans, "rotation moved on before the probes of %s were healthy", machine)
+			assert.False(t, controlPlanes.apply(controlPlane))
+
+			applyPlan(clusterPlan, machine, nodePlan, nil)
+		}
+	}
+
+	rotate(rkev1.RotateCertificatesPhaseStarted)
+	rotate(rkev1.RotateCertificatesPhaseControlPlane)
+	rotateOn([]string{"cp-0", "cp-1", "etcd-0"}, []string{"install", "shutdown", "rotate", "start"})
+	rotate(rkev1.RotateCertificatesPhaseWorker)
+	rotateOn([]string{"worker-0", "worker-1"}, []string{"restart"})
+	rotate(rkev1.RotateCertificatesPhaseFinished)
+
+	assert.NoError(t, r.Rotate(controlPlane, clusterPlan))
+	assert.False(t, controlPlanes.apply(controlPlane))
+
+	// A new generation starts over.
+	controlPlane.Spec.RotateCertificates.Generation = 2
+	rotate(rkev1.RotateCertificatesPhaseStarted)
+	assert.Equal(t, int64(2), controlPlane.Status.RotateCertificates.Generation)
+
+	// Removing the rotation from the spec clears its state.
+	controlPlane.Spec.RotateCertificates = nil
+	rotate("")
+	assert.Nil(t, controlPlane.Status.RotateCertificates)
+}
+
+func TestCertificateRotationServerPlan(t *testing.T) {
+	r, _, _ := newTestCertificateRotation()
+	controlPlane := newTestCertificateRotationControlPlane(3)
+	controlPlane.Spec.RotateCertificates.Services = []string{"kube-apiserver", "etcd"}
+
+	nodePlan, err := r.serverPlan(controlPlane, newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel))
+	require.NoError(t, err)
+	require.Len(t, nodePlan.Instructions, 4)
+	assert.Equal(t, []string{"stop", "rke2-server"}, nodePlan.Instructions[1].Args)
+	assert.Equal(t, []string{"certificate", "rotate", "--service", "kube-apiserver", "--service", "etcd"}, nodePlan.Instructions[2].Args)
+	assert.Equal(t, []string{"CERTIFICATE_ROTATION_GENERATION=3"}, nodePlan.Instructions[2].Env)
+	assert.Equal(t, []string{"start", "rke2-server"}, nodePlan.Instructions[3].Args)
+	for _, probe := range []string{"etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler", "kubelet"} {
+		assert.Contains(t, nodePlan.Probes, probe)
+	}
+}
+
+func TestCertificateRotationControlPlaneConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency string
+		rotating    int
+	}{
+		{name: "default", rotating: 1},
+		{name: "two", concurrency: "2", rotating: 2},
+		{name: "percentage", concurrency: "50%", rotating: 2},
+		{name: "unlimited", concurrency: "0", rotating: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, controlPlanes, secrets := newTestCertificateRotation()
+			controlPlane := newTestCertificateRotationControlPlane(1)
+			controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency = tt.concurrency
+			controlPlane.Status.RotateCertificates = controlPlane.Spec.RotateCertificates.DeepCopy()
+			controlPlane.Status.RotateCertificatesPhase = rkev1.RotateCertificatesPhaseControlPlane
+			clusterPlan := newTestPlan(
+				newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel),
+				newTestMachine("cp-1", EtcdRoleLabel, ControlPlaneRoleLabel),
+				newTestMachine("cp-2", EtcdRoleLabel, ControlPlaneRoleLabel),
+				newTestMachine("worker-0", WorkerRoleLabel),
+			)
+
+			assert.True(t, isErrWaiting(r.Rotate(controlPlane, clusterPlan)))
+			assert.False(t, controlPlanes.apply(controlPlane))
+			assert.Len(t, secrets.plans, tt.rotating)
+			_, ok := secrets.assigned("worker-0")
+			assert.False(t, ok, "worker rotated with the control plane")
+
+			// Machines still applying the plan count against the concurrency on the next reconcile, the others
+			// only get their plan once these are done.
+			var waiting []string
+			for _, machine := range []string{"cp-0", "cp-1", "cp-2"} {
+				if nodePlan, ok := secrets.assigned(machine); ok {
+					clusterPlan.Nodes[machine] = &plan.Node{Plan: nodePlan}
+					waiting = append(waiting, machine)
+				}
+			}
+			assert.Len(t, waiting, tt.rotating)
+			assert.True(t, isErrWaiting(r.Rotate(controlPlane, clusterPlan)))
+			assert.Empty(t, secrets.plans, "rotated past the concurrency")
+		})
+	}
+}
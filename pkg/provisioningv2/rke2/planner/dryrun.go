@@ -0,0 +1,189 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/wrangler/pkg/name"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DryRun computes the plan of every machine the same way Process does and reports the machines whose plan would
+// change. Nothing is written: no plan is assigned, no machine is drained and no cluster state is generated.
+func (p *Planner) DryRun(controlPlane *rkev1.RKEControlPlane) (*rkev1.DryRunReport, error) {
+	p.locker.Lock(string(controlPlane.UID))
+	defer p.locker.Unlock(string(controlPlane.UID))
+
+	cluster, err := p.getCAPICluster(controlPlane)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterPlan, err := p.store.Load(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := p.loadRKEStateSecret(controlPlane)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &rkev1.DryRunReport{
+		ObservedGeneration: controlPlane.Generation,
+	}
+
+	initJoinServer := initNodeJoinURL(clusterPlan)
+	// a machine with several roles is updated by the first tier that includes it
+	seen := map[string]bool{}
+	for _, tier := range tiers(controlPlane) {
+		var joinServer string
+		switch tier.joinServer {
+		case initNodeJoinServer:
+			joinServer = initJoinServer
+		case controlPlaneJoinServer:
+			joinServer = p.getControlPlaneJoinURL(clusterPlan)
+		}
+
+		entries := collect(clusterPlan, tier.include)
+		if _, _, err := calculateConcurrency(tier.maxUnavailable, entries, tier.exclude); err != nil {
+			report.Error = fmt.Sprintf("invalid %s concurrency: %v", tier.name, err)
+			return report, nil
+		}
+
+		for _, entry := range entries {
+			if tier.exclude(entry.Machine) || seen[entry.Machine.Name] {
+				continue
+			}
+			seen[entry.Machine.Name] = true
+
+			if node := p.dryRunNode(controlPlane, secret, clusterPlan, tier, joinServer, entry); node != nil {
+				report.Nodes = append(report.Nodes, *node)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (p *Planner) dryRunNode(controlPlane *rkev1.RKEControlPlane, secret plan.Secret, clusterPlan *plan.Plan, tier tier, joinServer string, entry planEntry) *rkev1.DryRunNode {
+	node := &rkev1.DryRunNode{
+		MachineName: entry.Machine.Name,
+		Tier:        tier.name,
+	}
+
+	desired, err := p.desiredPlan(controlPlane, secret, entry, isInitNode(entry.Machine), joinServer)
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+
+	if entry.Plan == nil {
+		node.Reasons = []string{"machine has no plan yet and will be provisioned"}
+		return node
+	}
+
+	if equality.Semantic.DeepEqual(entry.Plan.Plan, desired) {
+		return nil
+	}
+
+	node.Reasons = diffPlans(entry.Plan.Plan, desired)
+	// the restart stamp of the install instruction covers the image, the static files and the config generation
+	node.Restart = !equality.Semantic.DeepEqual(entry.Plan.Plan.Instructions, desired.Instructions)
+	// this mirrors drain, which never drains a single node cluster
+	node.Drain = tier.drainOptions.Enabled && len(clusterPlan.Machines) > 1
+	return node
+}
+
+// diffPlans describes the differences between the current plan of a node and its desired plan.
+func diffPlans(current, desired plan.NodePlan) (reasons []string) {
+	currentImages, desiredImages := instructionImages(current), instructionImages(desired)
+	if !equality.Semantic.DeepEqual(currentImages, desiredImages) {
+		reasons = append(reasons, fmt.Sprintf("installer image changes from %v to %v", currentImages, desiredImages))
+	}
+
+	currentFiles, desiredFiles := filesByPath(current), filesByPath(desired)
+	var paths []string
+	for path := range currentFiles {
+		paths = append(paths, path)
+	}
+	for path := range desiredFiles {
+		if _, ok := currentFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		currentFile, inCurrent := currentFiles[path]
+		desiredFile, inDesired := desiredFiles[path]
+		switch {
+		case !inCurrent:
+			reasons = append(reasons, fmt.Sprintf("file %s is added", path))
+		case !inDesired:
+			reasons = append(reasons, fmt.Sprintf("file %s is removed", path))
+		case currentFile != desiredFile:
+			reasons = append(reasons, fmt.Sprintf("file %s changes", path))
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(current.Probes, desired.Probes) {
+		reasons = append(reasons, "health probes change")
+	}
+
+	if len(reasons) == 0 && !equality.Semantic.DeepEqual(current.Instructions, desired.Instructions) {
+		reasons = append(reasons, "install instructions change")
+	}
+
+	return reasons
+}
+
+func instructionImages(nodePlan plan.NodePlan) (result []string) {
+	for _, instruction := range nodePlan.Instructions {
+		if instruction.Image != "" {
+			result = append(result, instruction.Image)
+		}
+	}
+	return
+}
+
+func filesByPath(nodePlan plan.NodePlan) map[string]plan.File {
+	result := map[string]plan.File{}
+	for _, file := range nodePlan.Files {
+		result[file.Path] = file
+	}
+	return result
+}
+
+// initNodeJoinURL is the read only counterpart of electInitNode, it returns the join url of the current init node.
+func initNodeJoinURL(clusterPlan *plan.Plan) string {
+	for _, entry := range collect(clusterPlan, isEtcd) {
+		if isInitNode(entry.Machine) && entry.Machine.DeletionTimestamp == nil {
+			return entry.Machine.Annotations[JoinURLAnnotation]
+		}
+	}
+	return ""
+}
+
+// loadRKEStateSecret is the read only counterpart of ensureRKEStateSecret, the tokens are empty if the cluster state
+// has not been generated yet.
+func (p *Planner) loadRKEStateSecret(controlPlane *rkev1.RKEControlPlane) (plan.Secret, error) {
+	if controlPlane.Spec.UnmanagedConfig {
+		return plan.Secret{}, nil
+	}
+
+	secret, err := p.secretCache.Get(controlPlane.Namespace, name.SafeConcatName(controlPlane.Name, "rke", "state"))
+	if apierror.IsNotFound(err) {
+		return plan.Secret{}, nil
+	} else if err != nil {
+		return plan.Secret{}, err
+	}
+
+	return plan.Secret{
+		ServerToken: string(secret.Data["serverToken"]),
+		AgentToken:  string(secret.Data["agentToken"]),
+	}, nil
+}
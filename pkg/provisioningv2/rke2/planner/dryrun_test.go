@@ -0,0 +1,241 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffPlans(t *testing.T) {
+	base := func() plan.NodePlan {
+		return plan.NodePlan{
+			Files: []plan.File{
+				{Path: "/etc/rancher/rke2/config.yaml.d/50-rancher.yaml", Content: "a"},
+				{Path: "/var/lib/rancher/rke2/server/manifests/rancher/cluster-agent.yaml", Content: "b"},
+			},
+			Instructions: []plan.Instruction{
+				{Image: "rancher/system-agent-installer-rke2:v1.21.5-rke2r2", Env: []string{"RESTART_STAMP=1"}},
+			},
+			Probes: map[string]plan.Probe{
+				"kubelet": {InitialDelaySeconds: 1},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*plan.NodePlan)
+		reasons []string
+	}{
+		{
+			name:   "same plan",
+			mutate: func(*plan.NodePlan) {},
+		},
+		{
+			name: "image",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Instructions[0].Image = "rancher/system-agent-installer-rke2:v1.22.2-rke2r1"
+			},
+			reasons: []string{"installer image changes from [rancher/system-agent-installer-rke2:v1.21.5-rke2r2] to [rancher/system-agent-installer-rke2:v1.22.2-rke2r1]"},
+		},
+		{
+			name: "file added",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Files = append(nodePlan.Files, plan.File{Path: "/etc/rancher/rke2/registries.yaml"})
+			},
+			reasons: []string{"file /etc/rancher/rke2/registries.yaml is added"},
+		},
+		{
+			name: "file removed",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Files = nodePlan.Files[1:]
+			},
+			reasons: []string{"file /etc/rancher/rke2/config.yaml.d/50-rancher.yaml is removed"},
+		},
+		{
+			name: "file changes",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Files[1].Content = "c"
+			},
+			reasons: []string{"file /var/lib/rancher/rke2/server/manifests/rancher/cluster-agent.yaml changes"},
+		},
+		{
+			name: "file order does not matter",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Files[0], nodePlan.Files[1] = nodePlan.Files[1], nodePlan.Files[0]
+			},
+		},
+		{
+			name: "files are sorted by path",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Files = []plan.File{
+					{Path: "/z", Content: "z"},
+					{Path: "/etc/rancher/rke2/config.yaml.d/50-rancher.yaml", Content: "changed"},
+				}
+			},
+			reasons: []string{
+				"file /etc/rancher/rke2/config.yaml.d/50-rancher.yaml changes",
+				"file /var/lib/rancher/rke2/server/manifests/rancher/cluster-agent.yaml is removed",
+				"file /z is added",
+			},
+		},
+		{
+			name: "probes",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Probes["etcd"] = plan.Probe{}
+			},
+			reasons: []string{"health probes change"},
+		},
+		{
+			name: "only the instructions",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Instructions[0].Env = []string{"RESTART_STAMP=2"}
+			},
+			reasons: []string{"install instructions change"},
+		},
+		{
+			name: "instructions and files",
+			mutate: func(nodePlan *plan.NodePlan) {
+				nodePlan.Instructions[0].Env = []string{"RESTART_STAMP=2"}
+				nodePlan.Files[0].Content = "changed"
+			},
+			reasons: []string{"file /etc/rancher/rke2/config.yaml.d/50-rancher.yaml changes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := base()
+			tt.mutate(&desired)
+			assert.Equal(t, tt.reasons, diffPlans(base(), desired))
+		})
+	}
+}
+
+func TestDryRunNode(t *testing.T) {
+	p := &Planner{}
+	controlPlane := &rkev1.RKEControlPlane{
+		Spec: rkev1.RKEControlPlaneSpec{
+			KubernetesVersion: "v1.21.5+rke2r2",
+			UnmanagedConfig:   true,
+		},
+	}
+	worker := newTestMachine("worker-0", WorkerRoleLabel)
+	clusterPlan := newTestPlan(newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel), worker)
+	workerTier := tiers(controlPlane)[3]
+	workerTier.drainOptions.Enabled = true
+
+	desired, err := p.desiredPlan(controlPlane, plan.Secret{}, planEntry{Machine: worker}, false, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		current     *plan.Node
+		clusterPlan *plan.Plan
+		expected    *rkev1.DryRunNode
+	}{
+		{
+			name:     "no plan yet",
+			expected: &rkev1.DryRunNode{MachineName: "worker-0", Tier: "worker", Reasons: []string{"machine has no plan yet and will be provisioned"}},
+		},
+		{
+			name:    "up to date",
+			current: &plan.Node{Plan: desired},
+		},
+		{
+			name: "probes change",
+			current: func() *plan.Node {
+				current := plan.Node{Plan: desired}
+				current.Plan.Probes = nil
+				return &current
+			}(),
+			expected: &rkev1.DryRunNode{MachineName: "worker-0", Tier: "worker", Drain: true, Reasons: []string{"health probes change"}},
+		},
+		{
+			name: "install instructions change",
+			current: &plan.Node{Plan: plan.NodePlan{
+				Probes:       desired.Probes,
+				Instructions: []plan.Instruction{{Image: desired.Instructions[0].Image}},
+			}},
+			expected: &rkev1.DryRunNode{MachineName: "worker-0", Tier: "worker", Restart: true, Drain: true, Reasons: []string{"install instructions change"}},
+		},
+		{
+			name:        "single node cluster is not drained",
+			current:     &plan.Node{Plan: plan.NodePlan{Probes: desired.Probes}},
+			clusterPlan: newTestPlan(worker),
+			expected: &rkev1.DryRunNode{MachineName: "worker-0", Tier: "worker", Restart: true, Reasons: []string{
+				"installer image changes from [] to [" + desired.Instructions[0].Image + "]",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := clusterPlan
+			if tt.clusterPlan != nil {
+				cp = tt.clusterPlan
+			}
+			entry := planEntry{Machine: worker, Plan: tt.current}
+			assert.Equal(t, tt.expected, p.dryRunNode(controlPlane, plan.Secret{}, cp, workerTier, "", entry))
+		})
+	}
+}
+
+func TestTiers(t *testing.T) {
+	controlPlane := &rkev1.RKEControlPlane{}
+	controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency = "1"
+	controlPlane.Spec.UpgradeStrategy.WorkerConcurrency = "10%"
+	result := tiers(controlPlane)
+
+	var names []string
+	for _, tier := range result {
+		names = append(names, tier.name)
+	}
+	assert.Equal(t, []string{"bootstrap", "etcd", "control plane", "worker"}, names)
+
+	assert.Equal(t, noJoinServer, result[0].joinServer)
+	assert.Equal(t, initNodeJoinServer, result[1].joinServer)
+	assert.Equal(t, initNodeJoinServer, result[2].joinServer)
+	assert.Equal(t, controlPlaneJoinServer, result[3].joinServer)
+
+	assert.Equal(t, "1", result[0].maxUnavailable)
+	assert.Equal(t, "1", result[2].maxUnavailable)
+	assert.Equal(t, "10%", result[3].maxUnavailable)
+
+	// every machine is planned by the first tier that includes it, the init node always comes first
+	firstTier := func(labels ...string) string {
+		machine := newTestMachine("machine", labels...)
+		for _, tier := range result {
+			if tier.include(machine) && !tier.exclude(machine) {
+				return tier.name
+			}
+		}
+		return ""
+	}
+	assert.Equal(t, "bootstrap", firstTier(EtcdRoleLabel, ControlPlaneRoleLabel, WorkerRoleLabel, InitNodeLabel))
+	assert.Equal(t, "bootstrap", firstTier(EtcdRoleLabel, InitNodeLabel))
+	assert.Equal(t, "etcd", firstTier(EtcdRoleLabel, ControlPlaneRoleLabel, WorkerRoleLabel))
+	assert.Equal(t, "etcd", firstTier(EtcdRoleLabel))
+	assert.Equal(t, "control plane", firstTier(ControlPlaneRoleLabel, WorkerRoleLabel))
+	assert.Equal(t, "worker", firstTier(WorkerRoleLabel))
+	assert.Equal(t, "", firstTier())
+}
+
+func TestDryRunJoinServers(t *testing.T) {
+	p := &Planner{}
+	initNode := newTestMachine("etcd-0", EtcdRoleLabel, InitNodeLabel)
+	initNode.Annotations = map[string]string{JoinURLAnnotation: "https://etcd-0:9345"}
+	controlPlane := newTestMachine("cp-0", ControlPlaneRoleLabel)
+	controlPlane.Annotations = map[string]string{JoinURLAnnotation: "https://cp-0:9345"}
+	clusterPlan := newTestPlan(initNode, controlPlane, newTestMachine("worker-0", WorkerRoleLabel))
+
+	// the etcd and control plane tiers join the init node, workers join a control plane node
+	assert.Equal(t, "https://etcd-0:9345", initNodeJoinURL(clusterPlan))
+	assert.Equal(t, "https://cp-0:9345", p.getControlPlaneJoinURL(clusterPlan))
+
+	initNode.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	assert.Equal(t, "", initNodeJoinURL(clusterPlan))
+}
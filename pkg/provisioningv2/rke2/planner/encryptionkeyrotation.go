@@ -0,0 +1,348 @@
+package planner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	rkecontroller "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const (
+	encryptionStatusInstruction = "secrets-encrypt-status"
+	encryptionStagePrefix       = "Current Rotation Stage:"
+
+	encryptionStagePrepare           = "prepare"
+	encryptionStageRotate            = "rotate"
+	encryptionStageReencryptFinished = "reencrypt_finished"
+
+	// encryptionPlanTimeout is how long a node has to apply and pass the probes of a plan of the rotation before
+	// the rotation is failed, leaving the leader plenty of time for the re-encryption it waits up to ten minutes on.
+	encryptionPlanTimeout = 30 * time.Minute
+)
+
+type encryptionKeyRotation struct {
+	controlPlane rkecontroller.RKEControlPlaneClient
+	secrets      corecontrollers.SecretCache
+	store        *PlanStore
+}
+
+func newEncryptionKeyRotation(clients *wrangler.Context, store *PlanStore) *encryptionKeyRotation {
+	return &encryptionKeyRotation{
+		controlPlane: clients.RKE.RKEControlPlane(),
+		secrets:      clients.Core.Secret().Cache(),
+		store:        store,
+	}
+}
+
+func (e *encryptionKeyRotation) setState(controlPlane *rkev1.RKEControlPlane, spec *rkev1.RotateEncryptionKeys, phase rkev1.RotateEncryptionKeysPhase) error {
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeysPhase = phase
+	controlPlane.Status.RotateEncryptionKeys = spec
+	_, err := e.controlPlane.UpdateStatus(controlPlane)
+	if err != nil {
+		return err
+	}
+	return ErrWaiting("refreshing encryption key rotation state")
+}
+
+func (e *encryptionKeyRotation) setFailure(controlPlane *rkev1.RKEControlPlane, failure string) error {
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeysFailure = failure
+	_, err := e.controlPlane.UpdateStatus(controlPlane)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("encryption key rotation failed during phase %s: %s", controlPlane.Status.RotateEncryptionKeysPhase, failure)
+}
+
+func (e *encryptionKeyRotation) resetRotateEncryptionKeysState(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Status.RotateEncryptionKeys == nil && controlPlane.Status.RotateEncryptionKeysPhase == "" &&
+		controlPlane.Status.RotateEncryptionKeysLeader == "" && controlPlane.Status.RotateEncryptionKeysFailure == "" {
+		return nil
+	}
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeysLeader = ""
+	controlPlane.Status.RotateEncryptionKeysFailure = ""
+	return e.setState(controlPlane, nil, "")
+}
+
+func (e *encryptionKeyRotation) startOrResumeRotation(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Status.RotateEncryptionKeys != nil && equality.Semantic.DeepEqual(*controlPlane.Spec.RotateEncryptionKeys, *controlPlane.Status.RotateEncryptionKeys) {
+		return nil
+	}
+
+	controlPlane = controlPlane.DeepCopy()
+	if controlPlane.Status.RotateEncryptionKeysFailure != "" && controlPlane.Status.RotateEncryptionKeysPhase != "" {
+		// A new generation after a failure picks the rotation back up at the phase that failed, restarting from
+		// the beginning would try to prepare a key while the previous one is still half way through.
+		controlPlane.Status.RotateEncryptionKeysFailure = ""
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, controlPlane.Status.RotateEncryptionKeysPhase)
+	}
+
+	controlPlane.Status.RotateEncryptionKeysLeader = ""
+	controlPlane.Status.RotateEncryptionKeysFailure = ""
+	return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhaseStarted)
+}
+
+// electLeader picks the control plane node all secrets-encrypt commands are run on, preferring the init node. The
+// choice is recorded in the status so every phase of a rotation talks to the same server.
+func (e *encryptionKeyRotation) electLeader(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, phase rkev1.RotateEncryptionKeysPhase) error {
+	servers := collect(clusterPlan, func(machine *capi.Machine) bool {
+		return isControlPlane(machine) && machine.Status.NodeRef != nil && machine.DeletionTimestamp == nil
+	})
+	if len(servers) == 0 {
+		return ErrWaiting("waiting for a control plane node to rotate encryption keys on")
+	}
+
+	leader := servers[0]
+	for _, server := range servers {
+		if isInitNode(server.Machine) {
+			leader = server
+			break
+		}
+	}
+
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeysLeader = leader.Machine.Name
+	return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, phase)
+}
+
+func rotateEncryptionKeysEnv(controlPlane *rkev1.RKEControlPlane) []string {
+	return []string{
+		fmt.Sprintf("ENCRYPTION_KEY_ROTATION_GENERATION=%d", controlPlane.Spec.RotateEncryptionKeys.Generation),
+		fmt.Sprintf("ENCRYPTION_KEY_ROTATION_PHASE=%s", controlPlane.Status.RotateEncryptionKeysPhase),
+	}
+}
+
+// leaderPlan runs the secrets-encrypt command on the leader, restarts it so the new encryption config is loaded and
+// saves the resulting status. The node is only considered in sync once the probes report it healthy again.
+func (e *encryptionKeyRotation) leaderPlan(controlPlane *rkev1.RKEControlPlane, machine *capi.Machine, command string) (plan.NodePlan, error) {
+	runtimeCommand := GetRuntimeCommand(controlPlane.Spec.KubernetesVersion)
+
+	instructions := []plan.Instruction{
+		{
+			Name:    "secrets-encrypt-" + command,
+			Env:     rotateEncryptionKeysEnv(controlPlane),
+			Args:    []string{"secrets-encrypt", command},
+			Command: runtimeCommand,
+		},
+	}
+
+	if command == "reencrypt" {
+		// re-encryption happens in the background, the server must not be restarted until it is done
+		instructions = append(instructions, plan.Instruction{
+			Name:    "secrets-encrypt-reencrypt-wait",
+			Command: "sh",
+			Args: []string{
+				"-c",
+				fmt.Sprintf("for i in $(seq 1 120); do %s secrets-encrypt status | grep -q '%s' && exit 0; sleep 5; done; exit 1",
+					runtimeCommand, encryptionStageReencryptFinished),
+			},
+		})
+	}
+
+	instructions = append(instructions,
+		plan.Instruction{
+			Name:    "restart",
+			Command: "systemctl",
+			Args:    []string{"restart", GetRuntimeServerUnit(controlPlane.Spec.KubernetesVersion)},
+		},
+		plan.Instruction{
+			Name:       encryptionStatusInstruction,
+			Args:       []string{"secrets-encrypt", "status"},
+			Command:    runtimeCommand,
+			SaveOutput: true,
+		})
+
+	nodePlan, err := commonNodePlan(e.secrets, controlPlane, plan.NodePlan{
+		Instructions: instructions,
+	})
+	if err != nil {
+		return nodePlan, err
+	}
+	return addProbes(nodePlan, controlPlane, machine)
+}
+
+// restartPlan restarts a server that is not the leader so it picks up the encryption config written by the leader.
+func (e *encryptionKeyRotation) restartPlan(controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) (plan.NodePlan, error) {
+	nodePlan, err := commonNodePlan(e.secrets, controlPlane, plan.NodePlan{
+		Instructions: []plan.Instruction{
+			{
+				Name:    "restart",
+				Env:     rotateEncryptionKeysEnv(controlPlane),
+				Command: "systemctl",
+				Args:    []string{"restart", GetRuntimeServerUnit(controlPlane.Spec.KubernetesVersion)},
+			},
+		},
+	})
+	if err != nil {
+		return nodePlan, err
+	}
+	return addProbes(nodePlan, controlPlane, machine)
+}
+
+func encryptionStage(output []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, encryptionStagePrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, encryptionStagePrefix))
+		}
+	}
+	return ""
+}
+
+// checkPlan fails the rotation when entry has not applied nodePlan and become healthy within encryptionPlanTimeout,
+// the rotation would wait on it forever otherwise.
+func (e *encryptionKeyRotation) checkPlan(controlPlane *rkev1.RKEControlPlane, entry planEntry, nodePlan plan.NodePlan) error {
+	if entry.Plan == nil || entry.Plan.InSync || entry.Plan.PlanUpdated.IsZero() ||
+		time.Since(entry.Plan.PlanUpdated) < encryptionPlanTimeout || !equality.Semantic.DeepEqual(entry.Plan.Plan, nodePlan) {
+		return nil
+	}
+	_, _, message := GetPlanStatusReasonMessage(entry.Machine, entry.Plan)
+	return e.setFailure(controlPlane, fmt.Sprintf("plan on [%s] not done after %s: %s%s",
+		entry.Machine.Name, encryptionPlanTimeout, message, planOutput(entry.Plan)))
+}
+
+// planOutput returns the saved output of the plan of node, if the agent got to apply it.
+func planOutput(node *plan.Node) string {
+	if node.AppliedPlan == nil || !equality.Semantic.DeepEqual(*node.AppliedPlan, node.Plan) {
+		return ""
+	}
+	var names []string
+	for name := range node.Output {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var output strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&output, ", %s output: %s", name, strings.TrimSpace(string(node.Output[name])))
+	}
+	return output.String()
+}
+
+func (e *encryptionKeyRotation) leaderStep(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, command, expectedStage string) error {
+	entries := collect(clusterPlan, func(machine *capi.Machine) bool {
+		return machine.Name == controlPlane.Status.RotateEncryptionKeysLeader
+	})
+	if len(entries) == 0 || entries[0].Machine.DeletionTimestamp != nil {
+		// the rotation stage is stored in the datastore so any other server can carry on from here
+		return e.electLeader(controlPlane, clusterPlan, controlPlane.Status.RotateEncryptionKeysPhase)
+	}
+
+	leader := entries[0]
+	nodePlan, err := e.leaderPlan(controlPlane, leader.Machine, command)
+	if err != nil {
+		return err
+	}
+	if err := e.checkPlan(controlPlane, leader, nodePlan); err != nil {
+		return err
+	}
+
+	if err := assignAndCheckPlan(e.store, fmt.Sprintf("encryption key %s on [%s]", command, leader.Machine.Name), leader, nodePlan); err != nil {
+		return err
+	}
+
+	if stage := encryptionStage(leader.Plan.Output[encryptionStatusInstruction]); stage != expectedStage {
+		return e.setFailure(controlPlane, fmt.Sprintf("expected rotation stage %s after %s on [%s] but found [%s]",
+			expectedStage, command, leader.Machine.Name, stage))
+	}
+
+	return nil
+}
+
+func (e *encryptionKeyRotation) restartFollowers(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan) error {
+	isFollower := func(machine *capi.Machine) bool {
+		return isServer(machine) && machine.Name != controlPlane.Status.RotateEncryptionKeysLeader
+	}
+	for _, entry := range collect(clusterPlan, isFollower) {
+		nodePlan, err := e.restartPlan(controlPlane, entry.Machine)
+		if err != nil {
+			return err
+		}
+		if err := e.checkPlan(controlPlane, entry, nodePlan); err != nil {
+			return err
+		}
+	}
+
+	return assignAndCheckPlans(e.store, "restarting for encryption key rotation on control plane", clusterPlan, isFollower,
+		controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency,
+		func(machine *capi.Machine) (plan.NodePlan, error) {
+			return e.restartPlan(controlPlane, machine)
+		})
+}
+
+func (e *encryptionKeyRotation) Rotate(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan) error {
+	if controlPlane.Spec.RotateEncryptionKeys == nil {
+		return e.resetRotateEncryptionKeysState(controlPlane)
+	}
+
+	// A cluster created with a rotation requested has no servers to rotate on yet, the rotation waits for the
+	// cluster to be provisioned rather than holding up its bootstrap.
+	if !Provisioned.IsTrue(controlPlane) && controlPlane.Status.RotateEncryptionKeysPhase == "" {
+		return nil
+	}
+
+	if err := e.startOrResumeRotation(controlPlane); err != nil {
+		return err
+	}
+
+	if controlPlane.Status.RotateEncryptionKeysFailure != "" {
+		return fmt.Errorf("encryption key rotation failed during phase %s: %s, change the generation to resume",
+			controlPlane.Status.RotateEncryptionKeysPhase, controlPlane.Status.RotateEncryptionKeysFailure)
+	}
+
+	switch controlPlane.Status.RotateEncryptionKeysPhase {
+	case rkev1.RotateEncryptionKeysPhaseStarted:
+		if GetRuntime(controlPlane.Spec.KubernetesVersion) == RuntimeK3S &&
+			!convert.ToBool(controlPlane.Spec.MachineGlobalConfig.Data["secrets-encryption"]) {
+			return e.setFailure(controlPlane, "secrets-encryption is not enabled")
+		}
+		return e.electLeader(controlPlane, clusterPlan, rkev1.RotateEncryptionKeysPhasePrepare)
+	case rkev1.RotateEncryptionKeysPhasePrepare:
+		if err := e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare); err != nil {
+			return err
+		}
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhasePostPrepareRestart)
+	case rkev1.RotateEncryptionKeysPhasePostPrepareRestart:
+		if err := e.restartFollowers(controlPlane, clusterPlan); err != nil {
+			return err
+		}
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhaseRotate)
+	case rkev1.RotateEncryptionKeysPhaseRotate:
+		if err := e.leaderStep(controlPlane, clusterPlan, "rotate", encryptionStageRotate); err != nil {
+			return err
+		}
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhasePostRotateRestart)
+	case rkev1.RotateEncryptionKeysPhasePostRotateRestart:
+		if err := e.restartFollowers(controlPlane, clusterPlan); err != nil {
+			return err
+		}
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhaseReencrypt)
+	case rkev1.RotateEncryptionKeysPhaseReencrypt:
+		if err := e.leaderStep(controlPlane, clusterPlan, "reencrypt", encryptionStageReencryptFinished); err != nil {
+			return err
+		}
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhasePostReencryptRestart)
+	case rkev1.RotateEncryptionKeysPhasePostReencryptRestart:
+		if err := e.restartFollowers(controlPlane, clusterPlan); err != nil {
+			return err
+		}
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhaseFinished)
+	case rkev1.RotateEncryptionKeysPhaseFinished:
+		return nil
+	default:
+		return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhaseStarted)
+	}
+}
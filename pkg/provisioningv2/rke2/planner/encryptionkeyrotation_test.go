@@ -0,0 +1,201 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestEncryptionKeyRotation() (*encryptionKeyRotation, *fakeControlPlanes, *fakePlanSecrets) {
+	controlPlanes := &fakeControlPlanes{}
+	store, secrets := newTestStore()
+	return &encryptionKeyRotation{controlPlane: controlPlanes, store: store}, controlPlanes, secrets
+}
+
+func newTestRotationControlPlane(generation int64) *rkev1.RKEControlPlane {
+	controlPlane := &rkev1.RKEControlPlane{
+		Spec: rkev1.RKEControlPlaneSpec{
+			KubernetesVersion:    "v1.21.5+rke2r2",
+			RotateEncryptionKeys: &rkev1.RotateEncryptionKeys{Generation: generation},
+		},
+	}
+	Provisioned.True(controlPlane)
+	return controlPlane
+}
+
+func encryptionStatusOutput(stage string) map[string][]byte {
+	return map[string][]byte{
+		encryptionStatusInstruction: []byte("Encryption Status: Enabled\nCurrent Rotation Stage: " + stage + "\nServer Encryption Hashes: All hashes match\n"),
+	}
+}
+
+func isErrWaiting(err error) bool {
+	var errWaiting ErrWaiting
+	return errors.As(err, &errWaiting)
+}
+
+func TestEncryptionKeyRotationWaitsForProvisioning(t *testing.T) {
+	e, controlPlanes, _ := newTestEncryptionKeyRotation()
+	controlPlane := newTestRotationControlPlane(1)
+	controlPlane.Status.Conditions = nil
+	clusterPlan := newTestPlan(newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel))
+
+	// The rotation does not hold up the bootstrap of a new cluster.
+	assert.NoError(t, e.Rotate(controlPlane, clusterPlan))
+	assert.False(t, controlPlanes.apply(controlPlane))
+
+	Provisioned.True(controlPlane)
+	assert.True(t, isErrWaiting(e.Rotate(controlPlane, clusterPlan)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhaseStarted, controlPlane.Status.RotateEncryptionKeysPhase)
+
+	// Once started, the rotation carries on while the cluster is not ready.
+	controlPlane.Status.Conditions = nil
+	assert.True(t, isErrWaiting(e.Rotate(controlPlane, clusterPlan)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhasePrepare, controlPlane.Status.RotateEncryptionKeysPhase)
+}
+
+func TestEncryptionKeyRotate(t *testing.T) {
+	e, controlPlanes, secrets := newTestEncryptionKeyRotation()
+	controlPlane := newTestRotationControlPlane(1)
+	clusterPlan := newTestPlan(
+		newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel),
+		newTestMachine("cp-1", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel),
+		newTestMachine("cp-2", EtcdRoleLabel, ControlPlaneRoleLabel),
+		newTestMachine("worker-0", WorkerRoleLabel),
+	)
+
+	rotate := func(expected rkev1.RotateEncryptionKeysPhase) {
+		t.Helper()
+		err := e.Rotate(controlPlane, clusterPlan)
+		assert.True(t, isErrWaiting(err), "unexpected error %v", err)
+		require.True(t, controlPlanes.apply(controlPlane))
+		require.Equal(t, expected, controlPlane.Status.RotateEncryptionKeysPhase)
+	}
+	leaderStep := func(command, stage string, next rkev1.RotateEncryptionKeysPhase) {
+		t.Helper()
+		assert.True(t, isErrWaiting(e.Rotate(controlPlane, clusterPlan)))
+		nodePlan, ok := secrets.assigned("cp-1")
+		require.True(t, ok, "no %s plan assigned to the leader", command)
+		assert.Equal(t, "secrets-encrypt-"+command, nodePlan.Instructions[0].Name)
+		for _, follower := range []string{"cp-0", "cp-2", "worker-0"} {
+			_, ok := secrets.assigned(follower)
+			assert.False(t, ok, "%s plan assigned to %s", command, follower)
+		}
+		applyPlan(clusterPlan, "cp-1", nodePlan, encryptionStatusOutput(stage))
+		rotate(next)
+	}
+	restartFollowers := func(next rkev1.RotateEncryptionKeysPhase) {
+		t.Helper()
+		// The followers restart one at a time, the default control plane concurrency.
+		for _, follower := range []string{"cp-0", "cp-2"} {
+			assert.True(t, isErrWaiting(e.Rotate(controlPlane, clusterPlan)))
+			nodePlan, ok := secrets.assigned(follower)
+			require.True(t, ok, "no restart plan assigned to %s", follower)
+			assert.Equal(t, "restart", nodePlan.Instructions[0].Name)
+			assert.Empty(t, secrets.plans, "more than one follower restarted at once")
+			applyPlan(clusterPlan, follower, nodePlan, nil)
+		}
+		rotate(next)
+	}
+
+	rotate(rkev1.RotateEncryptionKeysPhaseStarted)
+	// The init node is preferred as leader.
+	rotate(rkev1.RotateEncryptionKeysPhasePrepare)
+	assert.Equal(t, "cp-1", controlPlane.Status.RotateEncryptionKeysLeader)
+
+	leaderStep("prepare", encryptionStagePrepare, rkev1.RotateEncryptionKeysPhasePostPrepareRestart)
+	restartFollowers(rkev1.RotateEncryptionKeysPhaseRotate)
+	leaderStep("rotate", encryptionStageRotate, rkev1.RotateEncryptionKeysPhasePostRotateRestart)
+	restartFollowers(rkev1.RotateEncryptionKeysPhaseReencrypt)
+	leaderStep("reencrypt", encryptionStageReencryptFinished, rkev1.RotateEncryptionKeysPhasePostReencryptRestart)
+	restartFollowers(rkev1.RotateEncryptionKeysPhaseFinished)
+
+	assert.NoError(t, e.Rotate(controlPlane, clusterPlan))
+	assert.False(t, controlPlanes.apply(controlPlane))
+}
+
+func TestEncryptionKeyRotationLeaderStep(t *testing.T) {
+	e, controlPlanes, secrets := newTestEncryptionKeyRotation()
+	controlPlane := newTestRotationControlPlane(1)
+	controlPlane.Status.RotateEncryptionKeys = controlPlane.Spec.RotateEncryptionKeys.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeysPhase = rkev1.RotateEncryptionKeysPhasePrepare
+	controlPlane.Status.RotateEncryptionKeysLeader = "cp-0"
+	clusterPlan := newTestPlan(
+		newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel),
+		newTestMachine("cp-1", EtcdRoleLabel, ControlPlaneRoleLabel),
+	)
+
+	// A deleted leader is replaced without going back to the start of the rotation.
+	now := metav1.Now()
+	clusterPlan.Machines["cp-0"].DeletionTimestamp = &now
+	assert.True(t, isErrWaiting(e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, "cp-1", controlPlane.Status.RotateEncryptionKeysLeader)
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhasePrepare, controlPlane.Status.RotateEncryptionKeysPhase)
+
+	// The leader is waited on until it applied the plan.
+	assert.True(t, isErrWaiting(e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare)))
+	nodePlan, ok := secrets.assigned("cp-1")
+	require.True(t, ok)
+	clusterPlan.Nodes["cp-1"] = &plan.Node{Plan: nodePlan, PlanUpdated: time.Now()}
+	assert.True(t, isErrWaiting(e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare)))
+	assert.False(t, controlPlanes.apply(controlPlane))
+
+	// A plan that is not applied in time fails the rotation.
+	clusterPlan.Nodes["cp-1"].PlanUpdated = time.Now().Add(-encryptionPlanTimeout)
+	err := e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare)
+	assert.Error(t, err)
+	assert.False(t, isErrWaiting(err))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Contains(t, controlPlane.Status.RotateEncryptionKeysFailure, "plan on [cp-1] not done")
+	controlPlane.Status.RotateEncryptionKeysFailure = ""
+
+	// So does a leader reporting another stage than the command should have brought it to.
+	applyPlan(clusterPlan, "cp-1", nodePlan, encryptionStatusOutput("start"))
+	err = e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare)
+	assert.False(t, isErrWaiting(err))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, "expected rotation stage prepare after prepare on [cp-1] but found [start]", controlPlane.Status.RotateEncryptionKeysFailure)
+
+	applyPlan(clusterPlan, "cp-1", nodePlan, encryptionStatusOutput(encryptionStagePrepare))
+	assert.NoError(t, e.leaderStep(controlPlane, clusterPlan, "prepare", encryptionStagePrepare))
+}
+
+func TestEncryptionKeyRotationResumesAfterFailure(t *testing.T) {
+	e, controlPlanes, _ := newTestEncryptionKeyRotation()
+	controlPlane := newTestRotationControlPlane(1)
+	controlPlane.Status.RotateEncryptionKeys = controlPlane.Spec.RotateEncryptionKeys.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeysPhase = rkev1.RotateEncryptionKeysPhaseRotate
+	controlPlane.Status.RotateEncryptionKeysLeader = "cp-0"
+	controlPlane.Status.RotateEncryptionKeysFailure = "expected rotation stage rotate"
+	clusterPlan := newTestPlan(newTestMachine("cp-0", EtcdRoleLabel, ControlPlaneRoleLabel, InitNodeLabel))
+
+	// A failed rotation stays failed for the same generation.
+	err := e.Rotate(controlPlane, clusterPlan)
+	assert.Error(t, err)
+	assert.False(t, isErrWaiting(err))
+	assert.False(t, controlPlanes.apply(controlPlane))
+
+	// A new generation resumes at the phase that failed, with the same leader.
+	controlPlane.Spec.RotateEncryptionKeys.Generation = 2
+	assert.True(t, isErrWaiting(e.Rotate(controlPlane, clusterPlan)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhaseRotate, controlPlane.Status.RotateEncryptionKeysPhase)
+	assert.Equal(t, "cp-0", controlPlane.Status.RotateEncryptionKeysLeader)
+	assert.Empty(t, controlPlane.Status.RotateEncryptionKeysFailure)
+	assert.Equal(t, int64(2), controlPlane.Status.RotateEncryptionKeys.Generation)
+
+	// Removing the rotation from the spec clears its state.
+	controlPlane.Spec.RotateEncryptionKeys = nil
+	assert.True(t, isErrWaiting(e.Rotate(controlPlane, clusterPlan)))
+	require.True(t, controlPlanes.apply(controlPlane))
+	assert.Equal(t, rkev1.RKEControlPlaneStatus{Conditions: controlPlane.Status.Conditions}, controlPlane.Status)
+}
@@ -66,6 +66,8 @@
 	AddressAnnotation         = "rke.cattle.io/address"
 	InternalAddressAnnotation = "rke.cattle.io/internal-address"
 
+	PlanUpdatedAnnotation = "rke.cattle.io/plan-updated"
+
 	SecretTypeMachinePlan = "rke.cattle.io/machine-plan"
 
 	authnWebhookFileName = "/var/lib/rancher/%s/kube-api-authn-webhook.yaml"
@@ -129,6 +131,8 @@ type Planner struct {
 	locker                        locker.Locker
 	etcdRestore                   *etcdRestore
 	etcdCreate                    *etcdCreate
+	certificateRotation           *certificateRotation
+	encryptionKeyRotation         *encryptionKeyRotation
 	etcdArgs                      s3Args
 }
 
@@ -151,6 +155,8 @@ func New(ctx context.Context, clients *wrangler.Context) *Planner {
 		kubeconfig:                    kubeconfig.New(clients),
 		etcdRestore:                   newETCDRestore(clients, store),
 		etcdCreate:                    newETCDCreate(clients, store),
+		certificateRotation:           newCertificateRotation(clients, store),
+		encryptionKeyRotation:         newEncryptionKeyRotation(clients, store),
 		etcdArgs: s3Args{
 			prefix:      "etcd-",
 			secretCache: clients.Core.Secret().Cache(),
@@ -196,7 +202,7 @@ func (p *Planner) Process(controlPlane *rkev1.RKEControlPlane) error {
 
 	var (
 		firstIgnoreError error
-		joinServer       string
+		initJoinServer   string
 	)
 
 	if err := p.etcdCreate.Create(controlPlane, plan); err != nil {
@@ -207,54 +213,46 @@ func (p *Planner) Process(controlPlane *rkev1.RKEControlPlane) error {
 		return err
 	}
 
-	if _, err := p.electInitNode(controlPlane, plan); err != nil {
-		return err
-	}
-
-	err = p.reconcile(controlPlane, secret, plan, "bootstrap", true, isInitNode, none,
-		controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency, "",
-		controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions)
-	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
-	if err != nil {
-		return err
-	}
-
-	joinServer, err = p.electInitNode(controlPlane, plan)
-	if err != nil {
+	if err := p.certificateRotation.Rotate(controlPlane, plan); err != nil {
 		return err
-	} else if joinServer == "" && firstIgnoreError != nil {
-		return ErrWaiting(firstIgnoreError.Error() + " and join url to be available on bootstrap node")
-	} else if joinServer == "" {
-		return ErrWaiting("waiting for join url to be available on bootstrap node")
 	}
 
-	err = p.reconcile(controlPlane, secret, plan, "etcd", true, isEtcd, isInitNode,
-		controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency, joinServer,
-		controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions)
-	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
-	if err != nil {
+	if err := p.encryptionKeyRotation.Rotate(controlPlane, plan); err != nil {
 		return err
 	}
 
-	err = p.reconcile(controlPlane, secret, plan, "control plane", true, isControlPlane, isInitNode,
-		controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency, joinServer,
-		controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions)
-	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
-	if err != nil {
+	if _, err := p.electInitNode(controlPlane, plan); err != nil {
 		return err
 	}
 
-	joinServer = p.getControlPlaneJoinURL(plan)
-	if joinServer == "" {
-		return ErrWaiting("waiting for control plane to be available")
-	}
+	for _, tier := range tiers(controlPlane) {
+		var joinServer string
+		switch tier.joinServer {
+		case initNodeJoinServer:
+			if initJoinServer == "" {
+				initJoinServer, err = p.electInitNode(controlPlane, plan)
+				if err != nil {
+					return err
+				} else if initJoinServer == "" && firstIgnoreError != nil {
+					return ErrWaiting(firstIgnoreError.Error() + " and join url to be available on bootstrap node")
+				} else if initJoinServer == "" {
+					return ErrWaiting("waiting for join url to be available on bootstrap node")
+				}
+			}
+			joinServer = initJoinServer
+		case controlPlaneJoinServer:
+			joinServer = p.getControlPlaneJoinURL(plan)
+			if joinServer == "" {
+				return ErrWaiting("waiting for control plane to be available")
+			}
+		}
 
-	err = p.reconcile(controlPlane, secret, plan, "worker", false, isOnlyWorker, isInitNode,
-		controlPlane.Spec.UpgradeStrategy.WorkerConcurrency, joinServer,
-		controlPlane.Spec.UpgradeStrategy.WorkerDrainOptions)
-	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
-	if err != nil {
-		return err
+		err = p.reconcile(controlPlane, secret, plan, tier.name, tier.required, tier.include, tier.exclude,
+			tier.maxUnavailable, joinServer, tier.drainOptions)
+		firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
+		if err != nil {
+			return err
+		}
 	}
 
 	if firstIgnoreError != nil {
@@ -264,6 +262,67 @@ func (p *Planner) Process(controlPlane *rkev1.RKEControlPlane) error {
 	return nil
 }
 
+// joinServerSource is where the machines of a tier find the server they join.
+type joinServerSource int
+
+const (
+	noJoinServer joinServerSource = iota
+	initNodeJoinServer
+	controlPlaneJoinServer
+)
+
+// tier is a stage of the rollout of the plans of a cluster. Process reconciles the tiers in order, a tier is only
+// reconciled once the tiers before it are, and DryRun reports the changes of every tier.
+type tier struct {
+	name             string
+	required         bool
+	include, exclude roleFilter
+	maxUnavailable   string
+	joinServer       joinServerSource
+	drainOptions     rkev1.DrainOptions
+}
+
+func tiers(controlPlane *rkev1.RKEControlPlane) []tier {
+	return []tier{
+		{
+			name:           "bootstrap",
+			required:       true,
+			include:        isInitNode,
+			exclude:        none,
+			maxUnavailable: controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency,
+			joinServer:     noJoinServer,
+			drainOptions:   controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions,
+		},
+		{
+			name:           "etcd",
+			required:       true,
+			include:        isEtcd,
+			exclude:        isInitNode,
+			maxUnavailable: controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency,
+			joinServer:     initNodeJoinServer,
+			drainOptions:   controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions,
+		},
+		{
+			name:           "control plane",
+			required:       true,
+			include:        isControlPlane,
+			exclude:        isInitNode,
+			maxUnavailable: controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency,
+			joinServer:     initNodeJoinServer,
+			drainOptions:   controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions,
+		},
+		{
+			name:           "worker",
+			required:       false,
+			include:        isOnlyWorker,
+			exclude:        isInitNode,
+			maxUnavailable: controlPlane.Spec.UpgradeStrategy.WorkerConcurrency,
+			joinServer:     controlPlaneJoinServer,
+			drainOptions:   controlPlane.Spec.UpgradeStrategy.WorkerDrainOptions,
+		},
+	}
+}
+
 func ignoreErrors(firstIgnoreError error, err error) (error, error) {
 	var errIgnore errIgnore
 	if errors.As(err, &errIgnore) {
@@ -929,7 +988,7 @@ func (p *Planner) desiredPlan(controlPlane *rkev1.RKEControlPlane, secret plan.S
 		}
 	}
 
-	nodePlan, err = p.addProbes(nodePlan, controlPlane, entry.Machine)
+	nodePlan, err = addProbes(nodePlan, controlPlane, entry.Machine)
 	if err != nil {
 		return nodePlan, err
 	}
@@ -985,6 +1044,10 @@ func isOnlyControlPlane(machine *capi.Machine) bool {
 	return !isEtcd(machine) && isControlPlane(machine)
 }
 
+func isServer(machine *capi.Machine) bool {
+	return isEtcd(machine) || isControlPlane(machine)
+}
+
 func isWorker(machine *capi.Machine) bool {
 	return machine.Labels[WorkerRoleLabel] == "true"
 }
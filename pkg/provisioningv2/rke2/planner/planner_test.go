@@ -0,0 +1,114 @@
+package planner
+
+import (
+	"encoding/json"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	rkecontroller "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+// fakeControlPlanes keeps the last status update, the phase machines only ever update the status.
+type fakeControlPlanes struct {
+	rkecontroller.RKEControlPlaneClient
+	updated *rkev1.RKEControlPlane
+}
+
+func (f *fakeControlPlanes) UpdateStatus(controlPlane *rkev1.RKEControlPlane) (*rkev1.RKEControlPlane, error) {
+	f.updated = controlPlane.DeepCopy()
+	return controlPlane, nil
+}
+
+// apply copies the last status update onto controlPlane, as the next reconcile would see it.
+func (f *fakeControlPlanes) apply(controlPlane *rkev1.RKEControlPlane) bool {
+	if f.updated == nil {
+		return false
+	}
+	controlPlane.Status = f.updated.Status
+	f.updated = nil
+	return true
+}
+
+// fakePlanSecrets keeps the plans assigned through a PlanStore by machine name.
+type fakePlanSecrets struct {
+	corecontrollers.SecretClient
+	plans map[string]plan.NodePlan
+}
+
+func newTestStore() (*PlanStore, *fakePlanSecrets) {
+	secrets := &fakePlanSecrets{plans: map[string]plan.NodePlan{}}
+	return &PlanStore{secrets: secrets}, secrets
+}
+
+func (f *fakePlanSecrets) Get(namespace, name string, options metav1.GetOptions) (*corev1.Secret, error) {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}, nil
+}
+
+func (f *fakePlanSecrets) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	var nodePlan plan.NodePlan
+	if err := json.Unmarshal(secret.Data["plan"], &nodePlan); err != nil {
+		return nil, err
+	}
+	f.plans[secret.Name] = nodePlan
+	return secret, nil
+}
+
+// assigned returns the plan assigned to machine since the last call.
+func (f *fakePlanSecrets) assigned(machine string) (plan.NodePlan, bool) {
+	name := PlanSecretFromBootstrapName(machine)
+	nodePlan, ok := f.plans[name]
+	delete(f.plans, name)
+	return nodePlan, ok
+}
+
+// applyPlan records nodePlan as applied and healthy on machine, with output as the saved output of its instructions.
+func applyPlan(clusterPlan *plan.Plan, machine string, nodePlan plan.NodePlan, output map[string][]byte) {
+	clusterPlan.Nodes[machine] = &plan.Node{
+		Plan:        nodePlan,
+		AppliedPlan: &nodePlan,
+		Output:      output,
+		InSync:      true,
+		Healthy:     true,
+	}
+}
+
+// newTestMachine returns a machine that joined the cluster with the roles in labels, see EtcdRoleLabel,
+// ControlPlaneRoleLabel, WorkerRoleLabel and InitNodeLabel.
+func newTestMachine(name string, labels ...string) *capi.Machine {
+	machine := &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fleet-default",
+			Labels:    map[string]string{},
+		},
+		Spec: capi.MachineSpec{
+			Bootstrap: capi.Bootstrap{
+				ConfigRef: &corev1.ObjectReference{Kind: "RKEBootstrap", Name: name},
+			},
+		},
+		Status: capi.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: name},
+		},
+	}
+	for _, label := range labels {
+		machine.Labels[label] = "true"
+	}
+	return machine
+}
+
+// newTestPlan returns the plan of a cluster made of machines, each in sync with an empty plan.
+func newTestPlan(machines ...*capi.Machine) *plan.Plan {
+	clusterPlan := &plan.Plan{
+		Nodes:    map[string]*plan.Node{},
+		Machines: map[string]*capi.Machine{},
+	}
+	for _, machine := range machines {
+		clusterPlan.Machines[machine.Name] = machine
+		applyPlan(clusterPlan, machine.Name, plan.NodePlan{}, nil)
+	}
+	return clusterPlan
+}
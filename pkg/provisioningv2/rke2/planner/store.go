@@ -8,6 +8,7 @@
 	"io/ioutil"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
 	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
@@ -167,6 +168,10 @@ func SecretToNode(secret *corev1.Secret) (*plan.Node, error) {
 		return nil, nil
 	}
 
+	if updated, err := time.Parse(time.RFC3339, secret.Annotations[PlanUpdatedAnnotation]); err == nil {
+		result.PlanUpdated = updated
+	}
+
 	if len(appliedPlanData) > 0 {
 		newPlan := &plan.NodePlan{}
 		if err := json.Unmarshal(appliedPlanData, newPlan); err != nil {
@@ -233,8 +238,12 @@ func (p *PlanStore) UpdatePlan(machine *capi.Machine, plan plan.NodePlan) error
 	if secret.Data == nil {
 		secret.Data = map[string][]byte{}
 	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
 
 	secret.Data["plan"] = data
+	secret.Annotations[PlanUpdatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
 	_, err = p.secrets.Update(secret)
 	return err
 }
@@ -251,3 +260,57 @@ func assignAndCheckPlan(store *PlanStore, msg string, server planEntry, newPlan
 	}
 	return nil
 }
+
+// assignAndCheckPlans assigns a plan to every provisioned machine matching include, making at most maxUnavailable
+// machines unavailable at the same time, and waits for all of them to apply it.
+func assignAndCheckPlans(store *PlanStore, msg string, clusterPlan *plan.Plan, include roleFilter, maxUnavailable string,
+	nodePlan func(machine *capi.Machine) (plan.NodePlan, error)) error {
+	var (
+		waiting []string
+	)
+
+	entries := collect(clusterPlan, func(machine *capi.Machine) bool {
+		// machines that have not joined the cluster yet have nothing to act on
+		return include(machine) && machine.Status.NodeRef != nil
+	})
+
+	concurrency, unavailable, err := calculateConcurrency(maxUnavailable, entries, none)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Plan == nil {
+			continue
+		}
+
+		newPlan, err := nodePlan(entry.Machine)
+		if err != nil {
+			return err
+		}
+
+		if equality.Semantic.DeepEqual(entry.Plan.Plan, newPlan) {
+			if !entry.Plan.InSync {
+				waiting = append(waiting, entry.Machine.Status.NodeRef.Name)
+			}
+			continue
+		}
+
+		if !entry.Plan.InSync || concurrency == 0 || unavailable < concurrency {
+			if entry.Plan.InSync {
+				unavailable++
+			}
+			if err := store.UpdatePlan(entry.Machine, newPlan); err != nil {
+				return err
+			}
+		}
+		waiting = append(waiting, entry.Machine.Status.NodeRef.Name)
+	}
+
+	waiting = atMostThree(waiting)
+	if len(waiting) > 0 {
+		return ErrWaiting(msg + " node(s) " + strings.Join(waiting, ","))
+	}
+
+	return nil
+}
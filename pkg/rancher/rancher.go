@@ -252,7 +252,7 @@ func (r *Rancher) Start(ctx context.Context) error {
 	}
 
 	r.Wrangler.OnLeader(r.authServer.OnLeader)
-	r.auditLog.Start(ctx)
+	r.auditLog.Start(ctx, r.Wrangler.Core.Secret())
 
 	return r.Wrangler.Start(ctx)
 }
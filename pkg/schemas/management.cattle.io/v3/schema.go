@@ -472,6 +472,9 @@ func authnTypes(schemas *types.Schemas) *types.Schemas {
 		MustImport(&Version, v3.SearchPrincipalsInput{}).
 		MustImport(&Version, v3.ChangePasswordInput{}).
 		MustImport(&Version, v3.SetPasswordInput{}).
+		MustImport(&Version, v3.TOTPCodeInput{}).
+		MustImport(&Version, v3.TOTPEnrollOutput{}).
+		MustImport(&Version, v3.TOTPRecoveryCodesOutput{}).
 		MustImportAndCustomize(&Version, v3.User{}, func(schema *types.Schema) {
 			schema.ResourceActions = map[string]types.Action{
 				"setpassword": {
@@ -479,12 +482,24 @@ func authnTypes(schemas *types.Schemas) *types.Schemas {
 					Output: "user",
 				},
 				"refreshauthprovideraccess": {},
+				"unlock":                    {},
+				"resettotp":                 {},
 			}
 			schema.CollectionActions = map[string]types.Action{
 				"changepassword": {
 					Input: "changePasswordInput",
 				},
 				"refreshauthprovideraccess": {},
+				"enrolltotp": {
+					Output: "totpEnrollOutput",
+				},
+				"activatetotp": {
+					Input:  "totpCodeInput",
+					Output: "totpRecoveryCodesOutput",
+				},
+				"disabletotp": {
+					Input: "totpCodeInput",
+				},
 			}
 		}).
 		MustImportAndCustomize(&Version, v3.AuthConfig{}, func(schema *types.Schema) {
@@ -22,8 +22,20 @@
 	AgentImage                        = NewSetting("agent-image", "rancher/rancher-agent:master-head")
 	AgentRolloutTimeout               = NewSetting("agent-rollout-timeout", "300s")
 	AgentRolloutWait                  = NewSetting("agent-rollout-wait", "true")
+	AuditPolicy                       = NewSetting("audit-policy", "")        // JSON audit policy selecting the audit level per request
+	AuditLogSinks                     = NewSetting("audit-log-sinks", "file") // comma separated list of: file, stdout, webhook, syslog
+	AuditLogQueueSize                 = NewSetting("audit-log-queue-size", "10000")
+	AuditLogWebhookURL                = NewSetting("audit-log-webhook-url", "")
+	AuditLogWebhookBatchSize          = NewSetting("audit-log-webhook-batch-size", "100")
+	AuditLogWebhookMaxRetries         = NewSetting("audit-log-webhook-max-retries", "5")
+	AuditLogSyslogAddress             = NewSetting("audit-log-syslog-address", "")
+	AuditLogSyslogTLS                 = NewSetting("audit-log-syslog-tls", "false")
+	AuditLogSyslogCACerts             = NewSetting("audit-log-syslog-cacerts", "")
+	AuditLogHashChain                 = NewSetting("audit-log-hash-chain", "false")
+	AuditLogCheckpointInterval        = NewSetting("audit-log-checkpoint-interval", "1000") // number of records between signed checkpoints, 0 disables checkpoints
 	AuthImage                         = NewSetting("auth-image", v32.ToolsSystemImages.AuthSystemImages.KubeAPIAuth)
-	AuthTokenMaxTTLMinutes            = NewSetting("auth-token-max-ttl-minutes", "0") // never expire
+	AuthTokenMaxTTLMinutes            = NewSetting("auth-token-max-ttl-minutes", "0")      // never expire
+	AuthTokenIdleTimeoutMinutes       = NewSetting("auth-token-idle-timeout-minutes", "0") // never expire idle tokens
 	AuthorizationCacheTTLSeconds      = NewSetting("authorization-cache-ttl-seconds", "10")
 	AuthorizationDenyCacheTTLSeconds  = NewSetting("authorization-deny-cache-ttl-seconds", "10")
 	AzureGroupCacheSize               = NewSetting("azure-group-cache-size", "10000")
@@ -55,11 +67,20 @@
 	KubernetesVersionsDeprecated      = NewSetting("k8s-versions-deprecated", "")
 	KDMBranch                         = NewSetting("kdm-branch", "dev-v2.6")
 	MachineVersion                    = NewSetting("machine-version", "dev")
+	MFARequiredForAdmins              = NewSetting("mfa-required-for-admins", "false") // local admins without a TOTP enrollment only get a short-lived session to enroll
 	Namespace                         = NewSetting("namespace", os.Getenv("CATTLE_NAMESPACE"))
+	PasswordMinLength                 = NewSetting("password-min-length", "0")             // 0 disables the minimum length
+	PasswordRequireComplexity         = NewSetting("password-require-complexity", "false") // require upper and lower case letters, digits and symbols
+	PasswordDisallowUsername          = NewSetting("password-disallow-username", "false")  // reject passwords equal to the username
+	PasswordHistoryCount              = NewSetting("password-history-count", "0")          // number of previous passwords that cannot be reused
+	PasswordMaxAgeDays                = NewSetting("password-max-age-days", "0")           // 0 disables password expiry
+	PasswordMaxFailedAttempts         = NewSetting("password-max-failed-attempts", "0")    // 0 disables account lockout
+	PasswordLockoutMinutes            = NewSetting("password-lockout-minutes", "15")
 	PeerServices                      = NewSetting("peer-service", os.Getenv("CATTLE_PEER_SERVICE"))
 	RDNSServerBaseURL                 = NewSetting("rdns-base-url", "https://api.lb.rancher.cloud/v1")
 	RkeVersion                        = NewSetting("rke-version", "")
 	RkeMetadataConfig                 = NewSetting("rke-metadata-config", getMetadataConfig())
+	ScimAuthProvider                  = NewSetting("scim-auth-provider", "") // auth provider SCIM users and groups are principals of, empty disables SCIM provisioning
 	ServerImage                       = NewSetting("server-image", "rancher/rancher")
 	ServerURL                         = NewSetting("server-url", "")
 	ServerVersion                     = NewSetting("server-version", "dev")
@@ -113,6 +134,7 @@
 	GKEUpstreamRefresh                = NewSetting("gke-refresh", "300")
 	HideLocalCluster                  = NewSetting("hide-local-cluster", "false")
 	MachineProvisionImage             = NewSetting("machine-provision-image", "rancher/machine:v0.15.0-rancher67")
+	ClusterAutoscalerImage            = NewSetting("cluster-autoscaler-image", "rancher/mirrored-cluster-autoscaler:v1.22.2")
 	SystemFeatureChartRefreshSeconds  = NewSetting("system-feature-chart-refresh-seconds", "900")
 
 	FleetMinVersion          = NewSetting("fleet-min-version", "")